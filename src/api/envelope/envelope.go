@@ -0,0 +1,84 @@
+// Package envelope 定义了 API 响应信封的版本协商
+//
+// 默认(v1)沿用 xhttp.Response: {"trace_id", "code", "msg", "data"}, 成功时 data 一般为 {"result": ...}
+// 请求头 X-API-Version: 2 时使用 v2 信封: {"code", "message", "data", "request_id"}
+//
+// v1 到 v2 的字段对应关系:
+//   - code -> code, 业务状态码不变
+//   - msg -> message, 按 Accept-Language 翻译的规则不变
+//   - data.result -> data, 只有 result 一个字段的包装被去掉; 带有分页等其他字段的响应整体作为 data
+//   - trace_id -> request_id, 与响应头 X-Request-ID 和服务端日志中的 request_id 一致
+//
+// 错误响应在 v2 请求中一律使用 v2 信封, 成功响应只有已经迁移的接口使用 v2 信封, 其余接口保持 v1 形式
+package envelope
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joinmouse/EasySwapBase/errcode"
+	"github.com/joinmouse/EasySwapBase/logger/xzap"
+	"github.com/joinmouse/EasySwapBase/xhttp"
+	"go.uber.org/zap"
+)
+
+const (
+	// VersionHeader 客户端选择响应信封版本的请求头
+	VersionHeader = "X-API-Version"
+	// Version2 v2 信封的版本号
+	Version2 = "2"
+
+	// requestIDKey 请求ID在Gin上下文中的键名, 与 middleware.RequestIDKey 一致
+	// middleware 依赖本包(经由 i18n), 这里不直接引用以避免循环依赖
+	requestIDKey = "request_id"
+)
+
+// Response v2 响应信封
+type Response struct {
+	Code      uint32      `json:"code"`       // 业务状态码, 成功时为 200
+	Message   string      `json:"message"`    // 状态描述, 错误时为翻译后的错误信息
+	Data      interface{} `json:"data"`       // 响应数据, 错误时为 null
+	RequestID string      `json:"request_id"` // 请求ID
+}
+
+// IsV2 判断请求是否要求 v2 信封
+func IsV2(c *gin.Context) bool {
+	return c.GetHeader(VersionHeader) == Version2
+}
+
+// OK 以 v2 信封返回成功响应
+func OK(c *gin.Context, data interface{}) {
+	OKWithStatus(c, http.StatusOK, data)
+}
+
+// OKWithStatus 以 v2 信封和指定的 HTTP 状态码返回成功响应, 如已受理的异步任务返回 202
+func OKWithStatus(c *gin.Context, status int, data interface{}) {
+	xhttp.WriteHeader(c.Writer)
+	c.JSON(status, &Response{
+		Code:      errcode.CodeOK,
+		Message:   errcode.MsgOK,
+		Data:      data,
+		RequestID: c.GetString(requestIDKey),
+	})
+}
+
+// Error 以 v2 信封返回错误响应, err 需要已经完成翻译
+// 与 xhttp.Error 一致, 未预期的错误会记录错误日志
+func Error(c *gin.Context, err error) {
+	e := errcode.ParseErr(err)
+	if e == errcode.ErrUnexpected || e == errcode.ErrCustom {
+		xzap.WithContext(c.Request.Context()).Error("request handle err",
+			zap.Error(err),
+			zap.Uint32("code", e.Code()),
+			zap.String("path", c.Request.URL.Path),
+			zap.String("query", c.Request.URL.RawQuery))
+	}
+
+	xhttp.WriteHeader(c.Writer, e)
+	c.JSON(e.HTTPCode(), &Response{
+		Code:      e.Code(),
+		Message:   e.Error(),
+		Data:      nil,
+		RequestID: c.GetString(requestIDKey),
+	})
+}
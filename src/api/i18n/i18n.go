@@ -0,0 +1,138 @@
+// Package i18n 根据请求的 Accept-Language 头返回本地化的错误消息
+// 错误的业务状态码和HTTP状态码与语言无关, 只有消息文本会按语言翻译, 默认使用英文
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joinmouse/EasySwapBase/errcode"
+	"github.com/joinmouse/EasySwapBase/xhttp"
+
+	"github.com/joinmouse/EasySwapBackend/src/api/envelope"
+)
+
+const (
+	LangEN = "en"
+	LangZH = "zh"
+
+	// DefaultLang 请求未指定或不支持所请求的语言时使用的语言
+	DefaultLang = LangEN
+)
+
+// Message 带有消息模板的业务错误, 返回给客户端时按请求的语言渲染模板
+type Message struct {
+	code     uint32
+	httpCode int
+	format   string // 英文消息模板, 同时作为翻译表的键
+	args     []interface{}
+}
+
+// Errorf 创建自定义业务错误(业务状态码 7000), format 为英文消息模板
+func Errorf(httpCode int, format string, args ...interface{}) *Message {
+	return &Message{code: errcode.CodeCustom, httpCode: httpCode, format: format, args: args}
+}
+
+// Error 返回英文消息
+func (m *Message) Error() string {
+	return fmt.Sprintf(m.format, m.args...)
+}
+
+// Lang 从 Accept-Language 头中选择支持的语言
+// 按 q 值从高到低匹配, 只比较主语言标签(如 zh-CN 匹配 zh), 没有匹配时返回 DefaultLang
+func Lang(c *gin.Context) string {
+	header := c.GetHeader("Accept-Language")
+	if header == "" {
+		return DefaultLang
+	}
+
+	type candidate struct {
+		lang string
+		q    float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		lang := tag
+		if i := strings.IndexAny(tag, "-_"); i > 0 {
+			lang = tag[:i]
+		}
+		candidates = append(candidates, candidate{lang: lang, q: q})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+
+	for _, cand := range candidates {
+		if cand.lang == LangEN {
+			return LangEN
+		}
+		if _, ok := codeMessages[cand.lang]; ok {
+			return cand.lang
+		}
+	}
+
+	return DefaultLang
+}
+
+// Localize 将错误转换为指定语言的业务错误, 业务状态码和HTTP状态码保持不变
+// 1. Message 按语言渲染消息模板
+// 2. 业务错误优先按业务状态码翻译, 自定义错误(7000)按英文消息翻译
+// 3. 没有对应翻译时保持原消息
+func Localize(lang string, err error) error {
+	var m *Message
+	if errors.As(err, &m) {
+		format := m.format
+		if translated, ok := customMessages[lang][m.format]; ok {
+			format = translated
+		}
+		return errcode.NewErr(m.code, fmt.Sprintf(format, m.args...), m.httpCode)
+	}
+
+	var e *errcode.Err
+	if !errors.As(err, &e) {
+		return err
+	}
+	if e.Code() != errcode.CodeCustom {
+		if msg, ok := codeMessages[lang][e.Code()]; ok {
+			return errcode.NewErr(e.Code(), msg, e.HTTPCode())
+		}
+		return e
+	}
+	if msg, ok := customMessages[lang][e.Error()]; ok {
+		return errcode.NewErr(e.Code(), msg, e.HTTPCode())
+	}
+
+	return e
+}
+
+// Error 按请求的语言返回错误响应, 替代 xhttp.Error
+// 请求头 X-API-Version: 2 时使用 v2 信封
+func Error(c *gin.Context, err error) {
+	if envelope.IsV2(c) {
+		envelope.Error(c, Localize(Lang(c), err))
+		return
+	}
+
+	xhttp.Error(c, Localize(Lang(c), err))
+}
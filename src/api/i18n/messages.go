@@ -0,0 +1,73 @@
+package i18n
+
+// codeMessages 按业务状态码翻译的错误消息, 英文为 errcode 中定义的原始消息
+var codeMessages = map[string]map[uint32]string{
+	LangZH: {
+		7777:  "网络错误，请稍后重试",
+		9999:  "令牌不合法",
+		10002: "参数不合法",
+		10003: "令牌校验失败",
+		10004: "令牌已过期",
+		20001: "不支持的链 ID",
+		20002: "过滤参数不合法",
+		20003: "集合不存在",
+		20004: "区块链节点请求失败",
+		20005: "登录消息已过期，请重新获取",
+		20006: "登录消息已被使用，请重新获取",
+		20007: "登录消息不匹配",
+		20008: "关注列表已满",
+		20009: "NFT 不存在",
+		20010: "NFT 已存在于链上但尚未同步，请刷新元数据",
+		20011: "查询超时",
+		20012: "登录签名无效",
+		20013: "合约钱包拒绝了该签名",
+		20014: "任务不存在",
+		20015: "不支持的事件类型",
+		20016: "该地址不是合约",
+		20017: "该合约不是 ERC-721 或 ERC-1155 集合",
+		20018: "订单不存在",
+	},
+}
+
+// customMessages 自定义错误(业务状态码 7000)的翻译, 键为英文消息或消息模板
+var customMessages = map[string]map[string]string{
+	LangZH: {
+		"Invalid %s: %s": "%s 地址格式不合法: %s",
+		"Invalid %s, expected a non-negative integer: %s":                "%s 不合法，应为非负整数: %s",
+		"Invalid %s, expected a positive integer: %s":                    "%s 不合法，应为正整数: %s",
+		"%s must not be later than %s.":                                  "%s 不能晚于 %s",
+		"Time range must not exceed %d days.":                            "时间范围不能超过 %d 天",
+		"Invalid query params: %s.":                                      "查询参数不合法: %s",
+		"User address is required.":                                      "用户地址不能为空",
+		"Filter param is nil.":                                           "过滤参数不能为空",
+		"Invalid trait filter.":                                          "特征过滤参数不合法",
+		"Invalid min_price.":                                             "min_price 不合法",
+		"Invalid max_price.":                                             "max_price 不合法",
+		"Invalid listed_only.":                                           "listed_only 不合法",
+		"Price must not be negative.":                                    "价格不能为负数",
+		`Prices must be sent as strings, e.g. "0.3".`:                    `价格必须以字符串形式传入，例如 "0.3"`,
+		"Invalid token_ids.":                                             "token_ids 不合法",
+		"token_ids is empty.":                                            "token_ids 不能为空",
+		"order_ids is empty.":                                            "order_ids 不能为空",
+		"addresses is empty.":                                            "addresses 不能为空",
+		"addresses exceeds the limit of %d.":                             "addresses 数量不能超过 %d",
+		"Request body too large.":                                        "请求体过大",
+		"Too many requests.":                                             "请求过于频繁",
+		"Too many stream connections.":                                   "实时推送连接数过多",
+		"Idempotency-Key is too long.":                                   "Idempotency-Key 过长",
+		"user address mismatch with token.":                              "用户地址与令牌不匹配",
+		"min_price must not be greater than max_price.":                  "min_price 不能大于 max_price",
+		"A request with the same Idempotency-Key is in progress.":        "相同 Idempotency-Key 的请求正在处理中",
+		"Idempotency-Key is already used with a different request body.": "Idempotency-Key 已被用于不同的请求内容",
+		"Service is under maintenance, write operations are temporarily unavailable.": "系统维护中，暂时无法进行写操作",
+		"Server is busy, please try again later.":                                     "服务繁忙，请稍后重试",
+		"Request timed out.":                          "请求处理超时",
+		"Chain is temporarily unavailable.":           "该链暂时不可用",
+		"Admin API is disabled.":                      "管理接口未开放",
+		"Invalid admin secret.":                       "管理接口密钥错误",
+		"Access from this IP address is not allowed.": "当前 IP 不允许访问",
+		"API key is required.":                        "缺少 API Key",
+		"Invalid API key.":                            "API Key 无效",
+		"API key does not have write scope.":          "API Key 没有写权限",
+	},
+}
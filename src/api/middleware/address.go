@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/joinmouse/EasySwapBackend/src/api/i18n"
+	"github.com/joinmouse/EasySwapBackend/src/common"
+)
+
+// ValidateAddressParam 地址路径参数校验中间件
+// 1. 对指定名称的路径参数进行地址校验, 路由中不存在该参数时跳过
+// 2. 地址非法时直接返回400
+// 3. 地址合法时将参数改写为校验和格式, 后续处理函数可以直接使用
+func ValidateAddressParam(paramNames ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, name := range paramNames {
+			for i, param := range c.Params {
+				if param.Key != name {
+					continue
+				}
+
+				address, err := common.UnifyAddress(param.Value)
+				if err != nil {
+					i18n.Error(c, i18n.Errorf(http.StatusBadRequest, "Invalid %s: %s", name, param.Value))
+					c.Abort()
+					return
+				}
+				c.Params[i].Value = address
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// ValidateTokenIDParam token id 路径参数校验中间件
+// 1. 对指定名称的路径参数进行 token id 校验, 路由中不存在该参数时跳过
+// 2. token id 不是合法的 uint256 整数时直接返回400
+// 3. 合法时将参数改写为不带前导零的十进制格式, 后续处理函数和数据库查询使用统一的格式
+func ValidateTokenIDParam(paramNames ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, name := range paramNames {
+			for i, param := range c.Params {
+				if param.Key != name {
+					continue
+				}
+
+				tokenID, err := common.NormalizeTokenID(param.Value)
+				if err != nil {
+					i18n.Error(c, i18n.Errorf(http.StatusBadRequest, "Invalid %s, expected a non-negative integer: %s", name, param.Value))
+					c.Abort()
+					return
+				}
+				c.Params[i].Value = tokenID
+			}
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,192 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joinmouse/EasySwapBase/errcode"
+	"github.com/joinmouse/EasySwapBase/logger/xzap"
+	"go.uber.org/zap"
+
+	"github.com/joinmouse/EasySwapBackend/src/api/i18n"
+	"github.com/joinmouse/EasySwapBackend/src/config"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+)
+
+// ApiKeyHeader 合作方传入 API Key 的请求头
+const ApiKeyHeader = "X-API-Key"
+
+// ApiKeyIdentityKey 认证通过后 API Key 身份在gin上下文中的键名
+const ApiKeyIdentityKey string = "api_key_identity"
+
+const (
+	// ApiKeyNegativeCacheTTL 数据库中不存在的 Key 哈希的缓存时间, 缓存期内不再查询数据库
+	ApiKeyNegativeCacheTTL = 30 * time.Second
+	// apiKeyNegativeCacheSize 不存在的 Key 哈希缓存达到该数量时清理过期记录
+	apiKeyNegativeCacheSize = 10000
+)
+
+var (
+	ErrApiKeyRequired = errcode.NewCustomErr("API key is required.", http.StatusUnauthorized)
+	ErrApiKeyInvalid  = errcode.NewCustomErr("Invalid API key.", http.StatusUnauthorized)
+	ErrApiKeyScope    = errcode.NewCustomErr("API key does not have write scope.", http.StatusForbidden)
+)
+
+// ApiKeyIdentity 认证通过的 API Key 身份
+type ApiKeyIdentity struct {
+	Partner string // 合作方标识
+	Scope   string // 权限范围, read 或 write
+}
+
+// HashApiKey 计算 API Key 的 SHA-256 哈希(十六进制), 配置和数据库中只保存该哈希
+func HashApiKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// unknownKeyCache 记录数据库中不存在的 Key 哈希, 避免无效 Key 的每个请求都查询数据库
+type unknownKeyCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]time.Time // key hash -> 过期时间
+}
+
+func newUnknownKeyCache(ttl time.Duration) *unknownKeyCache {
+	return &unknownKeyCache{ttl: ttl, entries: make(map[string]time.Time)}
+}
+
+// contains Key 哈希是否在缓存期内被确认不存在
+func (u *unknownKeyCache) contains(keyHash string) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	expireAt, ok := u.entries[keyHash]
+	if !ok {
+		return false
+	}
+	if time.Now().After(expireAt) {
+		delete(u.entries, keyHash)
+		return false
+	}
+
+	return true
+}
+
+// add 缓存不存在的 Key 哈希
+// 缓存已满且没有过期记录时不再写入, 避免随机 Key 使缓存无限增长
+func (u *unknownKeyCache) add(keyHash string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	now := time.Now()
+	if len(u.entries) >= apiKeyNegativeCacheSize {
+		for key, expireAt := range u.entries {
+			if now.After(expireAt) {
+				delete(u.entries, key)
+			}
+		}
+		if len(u.entries) >= apiKeyNegativeCacheSize {
+			return
+		}
+	}
+	u.entries[keyHash] = now.Add(u.ttl)
+}
+
+// ApiKey 是基于 X-API-Key 请求头的认证中间件, 用于合作方的服务端集成, 按需挂载到路由组上
+// 主要功能包括:
+// 1. 计算请求头中 Key 的哈希, 先匹配配置中的 Key, 未命中时查询数据库 api_key 表,
+// 数据库中不存在的哈希缓存 ApiKeyNegativeCacheTTL, 期间直接返回401
+// 2. read 权限的 Key 只允许 GET/HEAD/OPTIONS 请求, 其他请求返回403
+// 3. 按 Key 单独限流, 超过限制时返回429
+// 4. 验证通过后将合作方身份写入gin上下文, 供后续处理器通过GetApiKeyIdentity读取
+func ApiKey(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	configKeys := make(map[string]config.ApiKey, len(svcCtx.C.Api.ApiKeys))
+	for _, key := range svcCtx.C.Api.ApiKeys {
+		configKeys[strings.ToLower(key.KeyHash)] = key
+	}
+	unknownKeys := newUnknownKeyCache(ApiKeyNegativeCacheTTL)
+
+	return func(c *gin.Context) {
+		rawKey := strings.TrimSpace(c.Request.Header.Get(ApiKeyHeader))
+		if rawKey == "" {
+			i18n.Error(c, ErrApiKeyRequired)
+			c.Abort()
+			return
+		}
+
+		keyHash := HashApiKey(rawKey)
+		apiKey, ok := configKeys[keyHash]
+		if !ok {
+			if unknownKeys.contains(keyHash) {
+				i18n.Error(c, ErrApiKeyInvalid)
+				c.Abort()
+				return
+			}
+			dbKey, err := svcCtx.Dao.QueryApiKeyByHash(c.Request.Context(), keyHash)
+			if err != nil {
+				xzap.WithContext(c.Request.Context()).Error("failed on verify api key", zap.Error(err))
+				i18n.Error(c, errcode.ErrUnexpected)
+				c.Abort()
+				return
+			}
+			if dbKey == nil {
+				unknownKeys.add(keyHash)
+				i18n.Error(c, ErrApiKeyInvalid)
+				c.Abort()
+				return
+			}
+			apiKey = config.ApiKey{
+				Partner:    dbKey.Partner,
+				KeyHash:    dbKey.KeyHash,
+				Scope:      dbKey.Scope,
+				RateLimit:  dbKey.RateLimit,
+				RateWindow: dbKey.RateWindow,
+			}
+		}
+
+		scope := apiKey.ScopeOrDefault()
+		if scope != config.ApiKeyScopeWrite {
+			switch c.Request.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+			default:
+				i18n.Error(c, ErrApiKeyScope)
+				c.Abort()
+				return
+			}
+		}
+
+		// 按 Key 限流, 与按IP的全局限流相互独立; Redis不可用时放行
+		if apiKey.RateLimit > 0 {
+			key := fmt.Sprintf("%s:apikey:%s", RateLimitPrefix, keyHash)
+			wait, err := slidingWindowWait(c.Request.Context(), svcCtx.KvStore, key, apiKey.RateLimit, apiKey.RateWindowOrDefault())
+			if err != nil {
+				xzap.WithContext(c.Request.Context()).Warn("api key rate limit unavailable, allow request",
+					zap.String("partner", apiKey.Partner), zap.Error(err))
+			} else if wait > 0 {
+				abortTooManyRequests(c, wait)
+				return
+			}
+		}
+
+		c.Set(ApiKeyIdentityKey, ApiKeyIdentity{Partner: apiKey.Partner, Scope: scope})
+		c.Next()
+	}
+}
+
+// GetApiKeyIdentity 获取ApiKey中间件注入的合作方身份
+func GetApiKeyIdentity(c *gin.Context) (ApiKeyIdentity, bool) {
+	value, ok := c.Get(ApiKeyIdentityKey)
+	if !ok {
+		return ApiKeyIdentity{}, false
+	}
+
+	identity, ok := value.(ApiKeyIdentity)
+	return identity, ok
+}
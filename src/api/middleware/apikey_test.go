@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestUnknownKeyCache(t *testing.T) {
+	cache := newUnknownKeyCache(50 * time.Millisecond)
+	hash := HashApiKey("unknown")
+
+	if cache.contains(hash) {
+		t.Fatal("contains() before add = true, want false")
+	}
+	cache.add(hash)
+	if !cache.contains(hash) {
+		t.Fatal("contains() after add = false, want true")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if cache.contains(hash) {
+		t.Error("contains() after ttl = true, want false")
+	}
+}
+
+func TestUnknownKeyCacheFull(t *testing.T) {
+	cache := newUnknownKeyCache(time.Minute)
+	for i := 0; i < apiKeyNegativeCacheSize; i++ {
+		cache.add(HashApiKey(fmt.Sprint(i)))
+	}
+
+	// 缓存已满且没有过期记录时不再写入
+	cache.add(HashApiKey("overflow"))
+	if cache.contains(HashApiKey("overflow")) {
+		t.Error("contains() on full cache = true, want false")
+	}
+	if got := len(cache.entries); got != apiKeyNegativeCacheSize {
+		t.Errorf("cache size = %d, want %d", got, apiKeyNegativeCacheSize)
+	}
+}
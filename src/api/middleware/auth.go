@@ -8,17 +8,24 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/joinmouse/EasySwapBase/xhttp"
 	"github.com/pkg/errors"
 
 	"github.com/joinmouse/EasySwapBase/errcode"
 	"github.com/joinmouse/EasySwapBase/stores/xkv"
+
+	"github.com/joinmouse/EasySwapBackend/src/api/i18n"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
 )
 
 const CR_LOGIN_MSG_KEY string = "cache:es:login:msg"
 const CR_LOGIN_KEY string = "cache:es:login:address:data"
 const CR_LOGIN_SALT string = "es_login_salt&$%"
 
+// AuthAddressKey 认证通过后用户地址在gin上下文中的键名
+const AuthAddressKey string = "auth_user_address"
+
+const bearerPrefix string = "Bearer "
+
 // 设置路由cookie
 // AuthMiddleWare 是一个认证中间件函数,用于验证请求中的会话令牌
 // 主要功能包括:
@@ -43,7 +50,7 @@ func AuthMiddleWare(ctx *xkv.Store) gin.HandlerFunc {
 		for _, sessionID := range sessionIDs {
 			encryptCode, err := hex.DecodeString(sessionID)
 			if err != nil {
-				xhttp.Error(c, errcode.ErrTokenVerify)
+				i18n.Error(c, errcode.ErrTokenVerify)
 				c.Abort()
 				return
 			}
@@ -51,14 +58,14 @@ func AuthMiddleWare(ctx *xkv.Store) gin.HandlerFunc {
 			//解密
 			decrptCode, err := AesDecryptOFB(encryptCode, []byte(CR_LOGIN_SALT))
 			if err != nil {
-				xhttp.Error(c, errcode.ErrTokenExpire)
+				i18n.Error(c, errcode.ErrTokenExpire)
 				c.Abort()
 				return
 			}
 			//从redis里取数据
 			result, err := ctx.Get(string(decrptCode))
 			if result == "" || err != nil {
-				xhttp.Error(c, errcode.ErrTokenExpire)
+				i18n.Error(c, errcode.ErrTokenExpire)
 				c.Abort()
 				return
 			}
@@ -106,6 +113,79 @@ func GetAuthUserAddress(c *gin.Context, ctx *xkv.Store) ([]string, error) {
 	return addrs, nil
 }
 
+// AuthMiddleware 是基于Bearer令牌的认证中间件
+// 主要功能包括:
+// 1. 从Authorization请求头中获取Bearer令牌,缺失时返回401
+// 2. 解密令牌得到登录缓存key,并校验会话在KvStore中是否仍然有效
+// 3. 验证通过后将用户地址写入gin上下文,供后续处理器通过GetAuthAddress读取
+func AuthMiddleware(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.Request.Header.Get("Authorization")
+		if !strings.HasPrefix(header, bearerPrefix) {
+			i18n.Error(c, errcode.ErrTokenVerify)
+			c.Abort()
+			return
+		}
+
+		token := strings.TrimSpace(strings.TrimPrefix(header, bearerPrefix))
+		address, err := parseLoginToken(svcCtx.KvStore, token)
+		if err != nil {
+			i18n.Error(c, errcode.ErrTokenExpire)
+			c.Abort()
+			return
+		}
+
+		c.Set(AuthAddressKey, address)
+		c.Next()
+	}
+}
+
+// GetAuthAddress 获取AuthMiddleware注入的已认证用户地址
+func GetAuthAddress(c *gin.Context) (string, bool) {
+	value, ok := c.Get(AuthAddressKey)
+	if !ok {
+		return "", false
+	}
+
+	address, ok := value.(string)
+	return address, ok && address != ""
+}
+
+// parseLoginToken 解析登录令牌并返回对应的用户地址
+// 令牌为登录缓存key(cache:es:login:address:data:<用户地址>)经AES-OFB加密后的十六进制编码
+func parseLoginToken(store *xkv.Store, token string) (string, error) {
+	encryptCode, err := hex.DecodeString(token)
+	if err != nil {
+		return "", errors.Wrap(err, "failed on decode token")
+	}
+	// 至少包含一个IV分组和一个数据分组
+	if len(encryptCode) < 2*aes.BlockSize {
+		return "", errors.New("invalid token length")
+	}
+
+	//解密
+	decrptCode, err := AesDecryptOFB(encryptCode, []byte(CR_LOGIN_SALT))
+	if err != nil {
+		return "", errors.Wrap(err, "invalid token")
+	}
+
+	//从redis里取数据
+	result, err := store.Get(string(decrptCode))
+	if err != nil {
+		return "", errors.Wrap(err, "failed on read token from cache")
+	}
+	if result == "" {
+		return "", errors.New("token expired")
+	}
+
+	arr := strings.Split(string(decrptCode), CR_LOGIN_KEY+":")
+	if len(arr) != 2 || arr[1] == "" {
+		return "", errors.New("user cache info format err")
+	}
+
+	return arr[1], nil
+}
+
 func AesDecryptOFB(data []byte, key []byte) ([]byte, error) {
 	block, _ := aes.NewCipher([]byte(key))
 	iv := data[:aes.BlockSize]
@@ -125,6 +205,12 @@ func AesDecryptOFB(data []byte, key []byte) ([]byte, error) {
 // 去码
 func PKCS7UnPadding(origData []byte) []byte {
 	length := len(origData)
+	if length == 0 {
+		return origData
+	}
 	unpadding := int(origData[length-1])
+	if unpadding > length {
+		return origData
+	}
 	return origData[:(length - unpadding)]
 }
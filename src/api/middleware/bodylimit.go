@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joinmouse/EasySwapBase/errcode"
+
+	"github.com/joinmouse/EasySwapBackend/src/api/i18n"
+)
+
+// ErrRequestBodyTooLarge 请求体超过大小限制
+var ErrRequestBodyTooLarge = errcode.NewCustomErr("Request body too large.", http.StatusRequestEntityTooLarge)
+
+// MaxBodyBytes 请求体大小限制中间件
+// 1. Content-Length 已知且超过限制时直接返回413, 不读取请求体
+// 2. 其余请求使用 http.MaxBytesReader 包装请求体, 读取超过限制时返回 *http.MaxBytesError
+// 需要放在日志中间件之前, 日志中间件读取请求体时最多缓冲 limit 字节, 超过限制时返回413
+func MaxBodyBytes(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > limit {
+			i18n.Error(c, ErrRequestBodyTooLarge)
+			c.Abort()
+			return
+		}
+
+		if c.Request.Body != nil {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		}
+
+		c.Next()
+	}
+}
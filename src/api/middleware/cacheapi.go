@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"crypto/sha512"
 	"encoding/gob"
 	"encoding/json"
@@ -9,20 +10,66 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/joinmouse/EasySwapBase/errcode"
 	"github.com/joinmouse/EasySwapBase/stores/xkv"
 	"github.com/joinmouse/EasySwapBase/xhttp"
+
+	"github.com/joinmouse/EasySwapBackend/src/api/envelope"
+	"github.com/joinmouse/EasySwapBackend/src/api/i18n"
+	"github.com/joinmouse/EasySwapBackend/src/dao"
 )
 
 const CacheApiPrefix = "apicache:"
 
+const (
+	CacheStatusHeader = "X-Cache"
+	CacheHit          = "HIT"
+	CacheMiss         = "MISS"
+)
+
 type responseCache struct {
 	Status int
 	Header http.Header
 	Data   []byte
+	ETag   string // 根据响应数据计算的ETag, 与响应一起缓存, 命中缓存时无需重新计算
+}
+
+// bufferedWriter 缓冲响应内容的写入器
+// 处理器写入的状态码和响应体先保存在内存中, 计算出ETag后再统一写回客户端
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body   *bytes.Buffer
+	status int
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	w.status = code
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.body.Len() > 0
 }
 
 // CacheApi 是一个缓存中间件函数,用于缓存API响应数据
@@ -30,69 +77,168 @@ type responseCache struct {
 // 1. 接收一个 xkv.Store 存储实例和过期时间作为参数
 // 2. 检查请求是否有缓存,如果有且状态码为200则直接返回缓存数据
 // 3. 如果没有缓存,则继续处理请求
-// 4. 请求处理完成后,如果响应状态码为200,则将响应数据缓存起来
+// 4. 请求处理完成后,如果HTTP状态码为2xx且业务状态码为200,则将响应数据和ETag缓存起来
+// 5. 响应带有ETag头,请求的If-None-Match与ETag一致时返回304,不再发送响应体
+// 6. 响应带有 X-Cache: HIT|MISS 头标识是否命中缓存, 可缓存的响应带有 Cache-Control 头
+// 7. 携带管理密钥的请求不读写缓存, 避免管理员才能看到的数据(如被隐藏的集合)被缓存后返回给其他用户
 func CacheApi(store *xkv.Store, expireSeconds int) gin.HandlerFunc {
+	return cacheApi(store, expireSeconds, "")
+}
+
+// VersionedCacheApi 与 CacheApi 相同, 缓存键中额外包含路由的缓存版本号
+// 数据变更时通过 Dao.BumpApiCacheVersion 递增版本号, 该路由已缓存的响应立即失效; 读取版本号失败时不使用缓存
+func VersionedCacheApi(store *xkv.Store, expireSeconds int, route string) gin.HandlerFunc {
+	return cacheApi(store, expireSeconds, route)
+}
+
+func cacheApi(store *xkv.Store, expireSeconds int, route string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var data xhttp.Response
-		// 创建响应体写入器用于获取响应内容
-		bodyLogWriter := &BodyLogWriter{body: bytes.NewBufferString(""), ResponseWriter: c.Writer}
-		c.Writer = bodyLogWriter
+		if c.GetHeader(AdminSecretHeader) != "" {
+			c.Next()
+			return
+		}
 
 		// 生成缓存key
 		cacheKey := CreateKey(c)
 		if cacheKey == "" {
-			xhttp.Error(c, errcode.NewCustomErr("cache error:no cache"))
+			i18n.Error(c, errcode.NewCustomErr("cache error:no cache"))
 			c.Abort()
+			return
+		}
+		if route != "" {
+			version, err := (*store).Get(dao.ApiCacheVersionKey(route))
+			if err != nil {
+				c.Next()
+				return
+			}
+			cacheKey += ":v" + version
 		}
 
 		// 尝试获取缓存数据
 		cacheData, err := (*store).Get(cacheKey)
 		if err == nil && cacheData != "" {
 			cache := unserialize(cacheData)
-			if cache != nil {
+			if cache != nil && isCacheableStatus(cache.Status) && isOkResponse(cache.Data) {
 				// 如果有缓存,则直接返回缓存的响应
-				bodyLogWriter.ResponseWriter.WriteHeader(cache.Status)
 				for k, vals := range cache.Header {
 					for _, v := range vals {
-						bodyLogWriter.ResponseWriter.Header().Set(k, v)
+						c.Writer.Header().Set(k, v)
 					}
 				}
+				c.Header(CacheStatusHeader, CacheHit)
+				c.Header("Cache-Control", cacheControl(expireSeconds))
 
-				if err := json.Unmarshal(cache.Data, &data); err == nil {
-					if data.Code == http.StatusOK {
-						bodyLogWriter.ResponseWriter.Write(cache.Data)
-						c.Abort()
-					}
+				// 兼容没有保存ETag的旧缓存
+				etag := cache.ETag
+				if etag == "" {
+					etag = computeETag(cache.Data)
 				}
+				writeWithETag(c, cache.Status, cache.Data, etag)
+				c.Abort()
+				return
 			}
 		}
 
+		// 缓冲处理器写入的响应
+		writer := &bufferedWriter{ResponseWriter: c.Writer, body: bytes.NewBufferString(""), status: http.StatusOK}
+		c.Writer = writer
+
 		// 继续处理请求
 		c.Next()
 
-		// 获取响应数据
-		responseBody := bodyLogWriter.body.Bytes()
+		c.Writer = writer.ResponseWriter
+		responseBody := writer.body.Bytes()
 
-		// 如果响应状态码为200,则缓存响应数据
-		if err := json.Unmarshal(responseBody, &data); err == nil {
-			if data.Code == http.StatusOK {
-				storeCache := responseCache{
-					Header: bodyLogWriter.Header().Clone(),
-					Status: bodyLogWriter.ResponseWriter.Status(),
-					Data:   responseBody,
-				}
-				store.SetnxEx(cacheKey, serialize(storeCache), expireSeconds)
+		// 如果HTTP状态码为2xx且业务状态码为200,则缓存响应数据
+		// 上游错误等异常响应不缓存, 避免在缓存有效期内持续返回错误
+		var etag string
+		c.Header(CacheStatusHeader, CacheMiss)
+		if isCacheableStatus(writer.status) && isOkResponse(responseBody) {
+			c.Header("Cache-Control", cacheControl(expireSeconds))
+			etag = computeETag(responseBody)
+			header := c.Writer.Header().Clone()
+			header.Del(CacheStatusHeader)
+			header.Del(RequestIDHeader)
+			storeCache := responseCache{
+				Header: header,
+				Status: writer.status,
+				Data:   responseBody,
+				ETag:   etag,
 			}
+			store.SetnxEx(cacheKey, serialize(storeCache), expireSeconds)
+		}
+
+		writeWithETag(c, writer.status, responseBody, etag)
+	}
+}
+
+// isCacheableStatus 判断HTTP状态码是否可以缓存, 只缓存2xx响应
+func isCacheableStatus(status int) bool {
+	return status >= http.StatusOK && status < http.StatusMultipleChoices
+}
+
+// cacheControl 生成 Cache-Control 响应头
+func cacheControl(expireSeconds int) string {
+	return fmt.Sprintf("public, max-age=%d", expireSeconds)
+}
+
+// isOkResponse 判断响应是否为业务成功的响应
+func isOkResponse(body []byte) bool {
+	var data xhttp.Response
+	if err := json.Unmarshal(body, &data); err != nil {
+		return false
+	}
+
+	return data.Code == http.StatusOK
+}
+
+// computeETag 根据响应中的data字段计算ETag
+// 响应中的trace_id每次请求都不同, 只对data计算哈希才能保证内容不变时ETag不变
+func computeETag(body []byte) string {
+	var data struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return ""
+	}
+
+	sum := sha256.Sum256(data.Data)
+	return fmt.Sprintf(`"%x"`, sum[:16])
+}
+
+// etagMatch 判断请求头If-None-Match中是否包含指定的ETag
+func etagMatch(ifNoneMatch, etag string) bool {
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
 		}
+	}
+
+	return false
+}
 
+// writeWithETag 写出响应, 请求的If-None-Match与ETag一致时返回304
+func writeWithETag(c *gin.Context, status int, body []byte, etag string) {
+	if etag != "" {
+		c.Header("ETag", etag)
+		if inm := c.GetHeader("If-None-Match"); inm != "" && etagMatch(inm, etag) {
+			c.Writer.WriteHeader(http.StatusNotModified)
+			c.Writer.WriteHeaderNow()
+			return
+		}
 	}
+
+	c.Writer.WriteHeader(status)
+	c.Writer.Write(body)
 }
 
 // CreateKey 生成缓存的key
 // 主要功能:
-// 1. 将路径、查询参数和请求体组合成缓存key
-// 2. 如果key长度超过128,使用SHA512进行哈希
-// 3. 添加缓存前缀并返回最终的key
+//  1. 将路径(包含路径参数)、排序后的查询参数和请求体组合成缓存key
+//     查询参数按key排序, 参数顺序不同的相同请求命中同一缓存, chain_id 等参数不同的请求互不影响
+//  2. 如果key长度超过128,使用SHA512进行哈希
+//  3. 添加缓存前缀并返回最终的key
 func CreateKey(c *gin.Context) string {
 	var buf bytes.Buffer
 	tee := io.TeeReader(c.Request.Body, &buf)
@@ -100,10 +246,14 @@ func CreateKey(c *gin.Context) string {
 	c.Request.Body = ioutil.NopCloser(&buf)
 
 	path := c.Request.URL.Path
-	query := c.Request.URL.RawQuery
+	// Encode 会按key排序, 同一个key的多个值保持原有顺序
+	query := c.Request.URL.Query().Encode()
 
-	// 组合缓存key
+	// 组合缓存key, v1 和 v2 信封的响应体不同, 分开缓存
 	cacheKey := path + "," + query + string(requestBody)
+	if envelope.IsV2(c) {
+		cacheKey += ",v" + envelope.Version2
+	}
 
 	// 如果key太长则进行哈希
 	if len(cacheKey) > 128 {
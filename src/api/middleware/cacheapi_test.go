@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/joinmouse/EasySwapBase/stores/xkv"
+	"github.com/joinmouse/EasySwapBase/xhttp"
+	"github.com/zeromicro/go-zero/core/stores/cache"
+	"github.com/zeromicro/go-zero/core/stores/kv"
+	"github.com/zeromicro/go-zero/core/stores/redis"
+
+	"github.com/joinmouse/EasySwapBackend/src/dao"
+)
+
+func newTestStore(t *testing.T) *xkv.Store {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	return xkv.NewStore(kv.KvConf{cache.NodeConf{
+		RedisConf: redis.RedisConf{Host: mr.Addr(), Type: redis.NodeType},
+		Weight:    1,
+	}})
+}
+
+func TestVersionedCacheApi(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := newTestStore(t)
+
+	calls := 0
+	r := gin.New()
+	r.GET("/ranking", VersionedCacheApi(store, 60, "ranking"), func(c *gin.Context) {
+		calls++
+		xhttp.OkJson(c, struct {
+			Result int `json:"result"`
+		}{Result: calls})
+	})
+
+	get := func() string {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ranking?chain_id=1", nil))
+		return w.Header().Get(CacheStatusHeader)
+	}
+
+	if got := get(); got != CacheMiss {
+		t.Fatalf("first request X-Cache = %q, want %q", got, CacheMiss)
+	}
+	if got := get(); got != CacheHit {
+		t.Fatalf("second request X-Cache = %q, want %q", got, CacheHit)
+	}
+
+	// 递增版本号后已缓存的响应不再命中
+	if err := dao.New(nil, store).BumpApiCacheVersion(context.Background(), "ranking"); err != nil {
+		t.Fatalf("BumpApiCacheVersion() error: %v", err)
+	}
+	if got := get(); got != CacheMiss {
+		t.Errorf("request after bump X-Cache = %q, want %q", got, CacheMiss)
+	}
+	if got := get(); got != CacheHit {
+		t.Errorf("second request after bump X-Cache = %q, want %q", got, CacheHit)
+	}
+	if calls != 2 {
+		t.Errorf("handler called %d times, want 2", calls)
+	}
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joinmouse/EasySwapBase/errcode"
+
+	"github.com/joinmouse/EasySwapBackend/src/api/i18n"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+)
+
+var ErrChainDegraded = errcode.NewCustomErr("Chain is temporarily unavailable.", http.StatusServiceUnavailable)
+
+// ChainAvailable 降级链拦截中间件
+// 请求的 chain_id(路径参数或 query 参数)对应的区块链启动时初始化失败时返回503, 其余链的请求不受影响
+// 没有降级链时直接放行
+func ChainAvailable(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if len(svcCtx.DegradedChains) == 0 {
+			c.Next()
+			return
+		}
+
+		id := c.Param("chain_id")
+		if id == "" {
+			id = c.Query("chain_id")
+		}
+		chainID, err := strconv.ParseInt(id, 10, 64)
+		if err != nil || !svcCtx.IsChainDegraded(chainID) {
+			c.Next()
+			return
+		}
+
+		c.Header("Retry-After", "60")
+		i18n.Error(c, ErrChainDegraded)
+		c.Abort()
+	}
+}
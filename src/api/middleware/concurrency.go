@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joinmouse/EasySwapBase/errcode"
+
+	"github.com/joinmouse/EasySwapBackend/src/api/i18n"
+)
+
+// DefaultConcurrencyWait 并发数达到上限时请求排队等待的最长时间
+const DefaultConcurrencyWait = 100 * time.Millisecond
+
+// ErrServerBusy 正在处理的请求数达到上限
+var ErrServerBusy = errcode.NewCustomErr("Server is busy, please try again later.", http.StatusServiceUnavailable)
+
+// ConcurrencyLimit 全局并发限制中间件
+// 1. 使用容量为 maxNum 的信号量限制同时处理的请求数
+// 2. 达到上限时最多排队等待 wait, 仍未获得信号量时返回503
+// 需要在健康检查路由注册之后使用, 保证高负载时探测请求不受限制
+// WebSocket 握手请求不占用信号量, 避免长连接持续占用并发名额
+func ConcurrencyLimit(maxNum int64, wait time.Duration) gin.HandlerFunc {
+	sem := make(chan struct{}, maxNum)
+
+	return func(c *gin.Context) {
+		if strings.EqualFold(c.GetHeader("Upgrade"), "websocket") {
+			c.Next()
+			return
+		}
+
+		select {
+		case sem <- struct{}{}:
+		default:
+			timer := time.NewTimer(wait)
+			select {
+			case sem <- struct{}{}:
+				timer.Stop()
+			case <-timer.C:
+				c.Header("Retry-After", "1")
+				i18n.Error(c, ErrServerBusy)
+				c.Abort()
+				return
+			case <-c.Request.Context().Done():
+				timer.Stop()
+				c.Abort()
+				return
+			}
+		}
+		defer func() { <-sem }()
+
+		c.Next()
+	}
+}
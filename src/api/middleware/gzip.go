@@ -0,0 +1,222 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 不压缩的内容类型前缀, 图片、音视频和压缩包本身已经是压缩格式, 再次压缩只会浪费CPU
+var incompressibleTypes = []string{
+	"image/",
+	"video/",
+	"audio/",
+	"application/zip",
+	"application/gzip",
+	"application/x-gzip",
+	"application/octet-stream",
+	"font/woff",
+}
+
+// gzipWriter 按需压缩响应的写入器
+// 响应体先缓冲到 minSize, 达到阈值时根据内容类型决定压缩后直接写出, 响应结束时仍未达到阈值则原样写出
+type gzipWriter struct {
+	gin.ResponseWriter
+	pool    *sync.Pool
+	minSize int
+	buf     bytes.Buffer
+	gz      *gzip.Writer
+	status  int
+	size    int
+	decided bool
+}
+
+func (w *gzipWriter) WriteHeader(code int) {
+	if !w.decided {
+		w.status = code
+	}
+}
+
+func (w *gzipWriter) WriteHeaderNow() {}
+
+func (w *gzipWriter) Write(b []byte) (int, error) {
+	w.size += len(b)
+	if !w.decided {
+		w.buf.Write(b)
+		if w.buf.Len() >= w.minSize {
+			if err := w.decide(false); err != nil {
+				return 0, err
+			}
+		}
+		return len(b), nil
+	}
+	if w.gz != nil {
+		return w.gz.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *gzipWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *gzipWriter) Status() int {
+	return w.status
+}
+
+func (w *gzipWriter) Size() int {
+	return w.size
+}
+
+func (w *gzipWriter) Written() bool {
+	return w.decided || w.size > 0
+}
+
+func (w *gzipWriter) Flush() {
+	if !w.decided {
+		w.decide(false)
+	}
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *gzipWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.ResponseWriter.Hijack()
+}
+
+// decide 根据已缓冲的内容决定是否压缩, 写出响应头和缓冲的数据
+// 压缩后响应长度未知, 删除 Content-Length 改用分块传输; 强ETag 改为弱ETag, 因为压缩后的字节与原始内容不同
+// final 表示响应已经结束, 此时不压缩的响应长度已知, 补充 Content-Length
+func (w *gzipWriter) decide(final bool) error {
+	w.decided = true
+	header := w.ResponseWriter.Header()
+
+	if w.buf.Len() >= w.minSize && bodyAllowed(w.status) &&
+		header.Get("Content-Encoding") == "" && isCompressible(header.Get("Content-Type")) {
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length")
+		if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			header.Set("ETag", "W/"+etag)
+		}
+		w.ResponseWriter.WriteHeader(w.status)
+
+		w.gz = w.pool.Get().(*gzip.Writer)
+		w.gz.Reset(w.ResponseWriter)
+		_, err := w.gz.Write(w.buf.Bytes())
+		w.buf.Reset()
+		return err
+	}
+
+	if final && w.buf.Len() > 0 && header.Get("Content-Length") == "" {
+		header.Set("Content-Length", strconv.Itoa(w.buf.Len()))
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.buf.Len() == 0 {
+		w.ResponseWriter.WriteHeaderNow()
+		return nil
+	}
+	_, err := w.ResponseWriter.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+// finish 写出剩余的缓冲数据并结束压缩流
+func (w *gzipWriter) finish() {
+	if !w.decided {
+		w.decide(true)
+	}
+	if w.gz != nil {
+		w.gz.Close()
+		w.gz.Reset(io.Discard)
+		w.pool.Put(w.gz)
+		w.gz = nil
+	}
+}
+
+// Gzip 响应压缩中间件
+// 1. 只在请求头 Accept-Encoding 接受 gzip 时压缩, WebSocket 升级请求和没有响应体的 HEAD 请求不处理
+// 2. 响应体小于 minSize 时不压缩, 避免小响应压缩后反而变大
+// 3. 图片等已压缩的内容类型和已设置 Content-Encoding 的响应不压缩
+// 4. 需要放在日志中间件之前, 使日志记录的是压缩前的响应体
+func Gzip(level, minSize int) gin.HandlerFunc {
+	pool := &sync.Pool{
+		New: func() interface{} {
+			gz, err := gzip.NewWriterLevel(io.Discard, level)
+			if err != nil {
+				gz, _ = gzip.NewWriterLevel(io.Discard, gzip.DefaultCompression)
+			}
+			return gz
+		},
+	}
+
+	return func(c *gin.Context) {
+		if !acceptsGzip(c.GetHeader("Accept-Encoding")) || c.GetHeader("Upgrade") != "" || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		writer := &gzipWriter{ResponseWriter: c.Writer, pool: pool, minSize: minSize, status: http.StatusOK}
+		c.Writer = writer
+		defer func() {
+			writer.finish()
+			c.Writer = writer.ResponseWriter
+		}()
+
+		c.Next()
+	}
+}
+
+// acceptsGzip 判断 Accept-Encoding 是否接受 gzip, q=0 表示明确拒绝
+func acceptsGzip(acceptEncoding string) bool {
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		params = strings.ReplaceAll(params, " ", "")
+		if q, ok := strings.CutPrefix(params, "q="); ok {
+			if weight, err := strconv.ParseFloat(q, 64); err == nil && weight == 0 {
+				return false
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+// isCompressible 判断内容类型是否值得压缩
+func isCompressible(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	for _, prefix := range incompressibleTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// bodyAllowed 判断状态码是否允许携带响应体
+func bodyAllowed(status int) bool {
+	switch {
+	case status >= 100 && status <= 199:
+		return false
+	case status == http.StatusNoContent, status == http.StatusNotModified:
+		return false
+	}
+
+	return true
+}
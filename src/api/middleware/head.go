@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// headWriter HEAD 请求的响应写入器
+// 丢弃处理器写入的响应体, 只统计长度, 响应结束时补充 Content-Length 后写出响应头
+type headWriter struct {
+	gin.ResponseWriter
+	status int
+	size   int
+}
+
+func (w *headWriter) WriteHeader(code int) {
+	w.status = code
+}
+
+func (w *headWriter) WriteHeaderNow() {}
+
+func (w *headWriter) Write(b []byte) (int, error) {
+	w.size += len(b)
+	return len(b), nil
+}
+
+func (w *headWriter) WriteString(s string) (int, error) {
+	w.size += len(s)
+	return len(s), nil
+}
+
+func (w *headWriter) Status() int {
+	return w.status
+}
+
+func (w *headWriter) Size() int {
+	return w.size
+}
+
+func (w *headWriter) Written() bool {
+	return w.size > 0
+}
+
+// HeadResponse 使 GET 处理器可以同时处理 HEAD 请求
+// 路由需要同时注册 GET 和 HEAD 方法, HEAD 请求按 GET 的逻辑处理(包括响应缓存),
+// 返回与 GET 相同的状态码和响应头(Content-Type、ETag、Content-Length 等), 不返回响应体
+func HeadResponse() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodHead {
+			c.Next()
+			return
+		}
+
+		writer := &headWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		if writer.size > 0 && bodyAllowed(writer.status) && c.Writer.Header().Get("Content-Length") == "" {
+			c.Header("Content-Length", strconv.Itoa(writer.size))
+		}
+		c.Writer.WriteHeader(writer.status)
+		c.Writer.WriteHeaderNow()
+	}
+}
@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/joinmouse/EasySwapBase/errcode"
+	"github.com/joinmouse/EasySwapBase/logger/xzap"
+	"github.com/joinmouse/EasySwapBase/stores/xkv"
+
+	"github.com/joinmouse/EasySwapBackend/src/api/i18n"
+)
+
+const (
+	IdempotencyPrefix = "cache:es:idempotency"
+	IdempotencyHeader = "Idempotency-Key"
+
+	// DefaultIdempotencyTTL 幂等记录默认保存时间(秒)
+	DefaultIdempotencyTTL = 24 * 60 * 60
+	// idempotencyLockTTL 请求处理中的占位记录保存时间(秒), 防止处理异常时key被永久占用
+	idempotencyLockTTL = 60
+	// maxIdempotencyKeyLen Idempotency-Key 的最大长度
+	maxIdempotencyKeyLen = 128
+)
+
+var (
+	ErrIdempotencyKeyReused  = errcode.NewCustomErr("Idempotency-Key is already used with a different request body.", http.StatusConflict)
+	ErrIdempotencyInProgress = errcode.NewCustomErr("A request with the same Idempotency-Key is in progress.", http.StatusConflict)
+	ErrIdempotencyKeyTooLong = errcode.NewCustomErr("Idempotency-Key is too long.", http.StatusBadRequest)
+)
+
+// idempotencyRecord 幂等记录, 保存首次请求的请求体哈希和响应
+// Done 为 false 表示首次请求仍在处理中
+type idempotencyRecord struct {
+	BodyHash string      `json:"body_hash"`
+	Done     bool        `json:"done"`
+	Status   int         `json:"status"`
+	Header   http.Header `json:"header"`
+	Data     []byte      `json:"data"`
+}
+
+// Idempotency 幂等中间件
+// 主要功能包括:
+// 1. 请求没有 Idempotency-Key 头时直接放行
+// 2. 首次请求时写入处理中的占位记录, 处理完成后将响应保存到Redis, 保存 ttl 秒
+// 3. 相同 key 和相同请求体的后续请求直接返回保存的响应
+// 4. 相同 key 但请求体不同, 或首次请求仍在处理中时返回409
+// 5. 处理结果为5xx时删除记录, 允许客户端使用同一个 key 重试
+func Idempotency(store *xkv.Store, ttl int) gin.HandlerFunc {
+	if ttl <= 0 {
+		ttl = DefaultIdempotencyTTL
+	}
+
+	return func(c *gin.Context) {
+		idempotencyKey := c.GetHeader(IdempotencyHeader)
+		if idempotencyKey == "" {
+			c.Next()
+			return
+		}
+		if len(idempotencyKey) > maxIdempotencyKeyLen {
+			i18n.Error(c, ErrIdempotencyKeyTooLong)
+			c.Abort()
+			return
+		}
+
+		var buf bytes.Buffer
+		requestBody, _ := ioutil.ReadAll(io.TeeReader(c.Request.Body, &buf))
+		c.Request.Body = ioutil.NopCloser(&buf)
+		bodyHash := fmt.Sprintf("%x", sha256.Sum256(requestBody))
+
+		// key 按请求方法和路由隔离, 不同接口使用相同的 Idempotency-Key 互不影响
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		key := fmt.Sprintf("%s:%s:%s:%s", IdempotencyPrefix, c.Request.Method, route, idempotencyKey)
+		logger := xzap.WithContext(c.Request.Context())
+
+		lock, _ := json.Marshal(idempotencyRecord{BodyHash: bodyHash})
+		acquired, err := store.SetnxEx(key, string(lock), idempotencyLockTTL)
+		if err != nil {
+			// Redis不可用时放行请求, 与限流中间件保持一致
+			logger.Warn("idempotency store unavailable, allow request", zap.String("key", key), zap.Error(err))
+			c.Next()
+			return
+		}
+
+		if !acquired {
+			replayIdempotentResponse(c, store, key, bodyHash)
+			c.Abort()
+			return
+		}
+
+		writer := &bufferedWriter{ResponseWriter: c.Writer, body: bytes.NewBufferString(""), status: http.StatusOK}
+		c.Writer = writer
+
+		c.Next()
+
+		c.Writer = writer.ResponseWriter
+		responseBody := writer.body.Bytes()
+
+		if writer.status >= http.StatusInternalServerError {
+			if _, err := store.Del(key); err != nil {
+				logger.Warn("failed on release idempotency key", zap.String("key", key), zap.Error(err))
+			}
+		} else {
+			record, _ := json.Marshal(idempotencyRecord{
+				BodyHash: bodyHash,
+				Done:     true,
+				Status:   writer.status,
+				Header:   c.Writer.Header().Clone(),
+				Data:     responseBody,
+			})
+			if err := store.Setex(key, string(record), ttl); err != nil {
+				logger.Warn("failed on save idempotency response", zap.String("key", key), zap.Error(err))
+			}
+		}
+
+		c.Writer.WriteHeader(writer.status)
+		c.Writer.Write(responseBody)
+	}
+}
+
+// replayIdempotentResponse 返回已保存的幂等响应
+func replayIdempotentResponse(c *gin.Context, store *xkv.Store, key, bodyHash string) {
+	data, err := store.Get(key)
+	if err != nil || data == "" {
+		// 记录在读取前过期或被删除, 视为首次请求仍在处理, 由客户端稍后重试
+		i18n.Error(c, ErrIdempotencyInProgress)
+		return
+	}
+
+	var record idempotencyRecord
+	if err := json.Unmarshal([]byte(data), &record); err != nil {
+		i18n.Error(c, ErrIdempotencyInProgress)
+		return
+	}
+
+	if record.BodyHash != bodyHash {
+		i18n.Error(c, ErrIdempotencyKeyReused)
+		return
+	}
+	if !record.Done {
+		i18n.Error(c, ErrIdempotencyInProgress)
+		return
+	}
+
+	for k, vals := range record.Header {
+		// 保留本次请求的请求ID, 不使用首次请求的
+		if http.CanonicalHeaderKey(k) == http.CanonicalHeaderKey(RequestIDHeader) {
+			continue
+		}
+		for _, v := range vals {
+			c.Writer.Header().Set(k, v)
+		}
+	}
+	c.Header("Idempotent-Replayed", "true")
+	c.Writer.WriteHeader(record.Status)
+	c.Writer.Write(record.Data)
+}
@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joinmouse/EasySwapBase/errcode"
+	"github.com/joinmouse/EasySwapBase/logger/xzap"
+	"go.uber.org/zap"
+
+	"github.com/joinmouse/EasySwapBackend/src/api/i18n"
+)
+
+// ErrIPNotAllowed 请求来源 IP 不在允许列表中
+var ErrIPNotAllowed = errcode.NewCustomErr("Access from this IP address is not allowed.", http.StatusForbidden)
+
+// defaultAllowlist 未配置允许列表时只允许本机访问
+var defaultAllowlist = []string{"127.0.0.0/8", "::1/128"}
+
+// parseIPNets 解析 CIDR 列表, 不带掩码的 IP 视为单个地址
+func parseIPNets(list []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(list))
+	for _, s := range list {
+		s = strings.TrimSpace(s)
+		if !strings.Contains(s, "/") {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return nil, &net.ParseError{Type: "IP address", Text: s}
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// sourceIP 返回请求的来源 IP
+// 只有直接连接的地址属于可信代理时才读取 X-Forwarded-For, 从右向左跳过可信代理, 取第一个不可信的地址;
+// 未配置可信代理时总是使用直接连接的地址, 避免客户端伪造请求头绕过限制
+func sourceIP(c *gin.Context, trusted []*net.IPNet) net.IP {
+	remote := net.ParseIP(c.RemoteIP())
+	if remote == nil || len(trusted) == 0 || !containsIP(trusted, remote) {
+		return remote
+	}
+
+	forwarded := strings.Split(c.GetHeader("X-Forwarded-For"), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if ip == nil {
+			// 请求头格式错误时不再继续解析, 使用最后一个可信的地址
+			return remote
+		}
+		if !containsIP(trusted, ip) {
+			return ip
+		}
+		remote = ip
+	}
+	return remote
+}
+
+// IPAllowlist 来源 IP 允许列表中间件, 用于管理接口
+// 1. cidrs 为允许访问的 CIDR 列表(也可以是单个 IP), 为空时只允许本机访问
+// 2. trustedProxies 为可信代理的 CIDR 列表, 只有配置了可信代理时才根据 X-Forwarded-For 获取来源 IP
+// 3. 来源 IP 不在允许列表中时返回403, 不再执行后续的鉴权和处理器
+// 配置在启动时已校验, 这里遇到无法解析的配置项时拒绝所有请求
+func IPAllowlist(cidrs []string, trustedProxies []string) gin.HandlerFunc {
+	if len(cidrs) == 0 {
+		cidrs = defaultAllowlist
+	}
+	allowed, err := parseIPNets(cidrs)
+	if err != nil {
+		xzap.WithContext(context.Background()).Error("invalid ip allowlist, all requests will be rejected", zap.Error(err))
+		allowed = nil
+	}
+	trusted, err := parseIPNets(trustedProxies)
+	if err != nil {
+		xzap.WithContext(context.Background()).Error("invalid trusted proxies, X-Forwarded-For will be ignored", zap.Error(err))
+		trusted = nil
+	}
+
+	return func(c *gin.Context) {
+		ip := sourceIP(c, trusted)
+		if ip == nil || !containsIP(allowed, ip) {
+			xzap.WithContext(c.Request.Context()).Warn("request rejected by ip allowlist",
+				zap.String("remote_ip", c.RemoteIP()), zap.String("path", c.Request.URL.Path))
+			i18n.Error(c, ErrIPNotAllowed)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -4,14 +4,20 @@ package middleware
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"io"
 	"io/ioutil"
+	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"                     // Gin Web框架
+	"github.com/joinmouse/EasySwapBase/errcode"     // 业务状态码
 	"github.com/joinmouse/EasySwapBase/logger/xzap" // 结构化日志库
 	"go.uber.org/zap"                              // Uber的高性能日志库
 	"go.uber.org/zap/zapcore"                      // Zap日志库核心组件
+
+	"github.com/joinmouse/EasySwapBackend/src/api/i18n"
 )
 
 // BodyLogWriter 是一个自定义的响应写入器
@@ -44,9 +50,22 @@ func (w BodyLogWriter) WriteString(s string) (int, error) {
 // 4. 客户端 IP、User-Agent 等元数据
 // 5. 错误信息（如果有）
 //
+// 请求体和响应体在记录前会进行脱敏和截断，非 JSON 内容只记录类型和长度
+// 启用采样后，每个路由的成功请求每 sampleRate 条只记录 1 条，
+// 出错、非 2xx 响应、业务状态码不为 200 的响应(包括 HTTP 200 的业务错误)以及耗时超过 slowThreshold 的请求总是记录
+//
+// 参数:
+//   - redactKeys: 需要脱敏的 JSON 字段名，为空时使用 DefaultRedactKeys
+//   - maxBodySize: 记录的 body 最大字节数，为 0 时使用 DefaultLogBodyMaxSize
+//   - sampleRate: 成功请求的采样率，小于等于 1 时记录全部请求
+//   - slowThreshold: 慢请求阈值，为 0 时不按耗时判断
+//
 // 返回值:
 //   - gin.HandlerFunc: Gin 中间件函数
-func RLog() gin.HandlerFunc {
+func RLog(redactKeys []string, maxBodySize int, sampleRate int, slowThreshold time.Duration) gin.HandlerFunc {
+	redactor := newBodyRedactor(redactKeys, maxBodySize)
+	sampler := newLogSampler(sampleRate)
+
 	return func(c *gin.Context) {
 		// 获取原始请求路径和查询参数（避免被其他中间件修改）
 		path := c.Request.URL.Path
@@ -54,9 +73,21 @@ func RLog() gin.HandlerFunc {
 
 		// 读取并保存请求体内容
 		// 使用 TeeReader 在读取的同时保存数据副本
+		// 请求体已被 MaxBodyBytes 限制大小, 超过限制时直接返回413, 不把超大的请求体交给后续处理器
 		var buf bytes.Buffer
 		tee := io.TeeReader(c.Request.Body, &buf)
-		requestBody, _ := ioutil.ReadAll(tee)
+		requestBody, err := ioutil.ReadAll(tee)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			xzap.GetZapLogger().Warn("request body too large",
+				zap.String(RequestIDKey, GetRequestID(c.Request.Context())),
+				zap.String("method", c.Request.Method),
+				zap.String("path", path),
+				zap.Int64("limit", maxBytesErr.Limit))
+			i18n.Error(c, ErrRequestBodyTooLarge)
+			c.Abort()
+			return
+		}
 		// 重新设置请求体，供后续处理器使用
 		c.Request.Body = ioutil.NopCloser(&buf)
 		
@@ -75,8 +106,9 @@ func RLog() gin.HandlerFunc {
 
 		// 获取响应体内容
 		responseBody := bodyLogWriter.body.Bytes()
-		// 获取上下文相关的日志记录器
-		logger := xzap.WithContext(c.Request.Context())
+		// 获取日志记录器并带上请求ID, 便于和服务层日志关联
+		// 请求ID已作为日志标签写入上下文, 这里直接使用基础日志记录器以免字段重复
+		logger := xzap.GetZapLogger().With(zap.String(RequestIDKey, GetRequestID(c.Request.Context())))
 		
 		if len(c.Errors) > 0 {
 			// 如果请求处理过程中出现错误，记录所有错误信息
@@ -85,25 +117,60 @@ func RLog() gin.HandlerFunc {
 			}
 		} else {
 			// 计算请求处理的延迟时间（毫秒）
-			latency := float64(time.Now().Sub(start).Nanoseconds() / 1000000.0)
+			elapsed := time.Since(start)
+			latency := float64(elapsed.Nanoseconds() / 1000000.0)
+
+			// 成功且不慢的请求按路由采样记录, HTTP 200 的业务错误总是记录
+			// 只在启用采样时解析响应体, 业务错误不占用采样计数
+			status := c.Writer.Status()
+			isSuccess := status >= http.StatusOK && status < http.StatusMultipleChoices
+			isSlow := slowThreshold > 0 && elapsed >= slowThreshold
+			route := c.FullPath()
+			if route == "" {
+				route = path
+			}
+			if isSuccess && !isSlow && sampler != nil && !isBusinessError(responseBody) && !sampler.sample(route) {
+				return
+			}
 			
 			// 构建日志字段，记录请求和响应的详细信息
 			fields := []zapcore.Field{
-				zap.Int("status", c.Writer.Status()),                         // HTTP 状态码
+				zap.Int("status", status),                                    // HTTP 状态码
 				zap.String("method", c.Request.Method),                       // HTTP 请求方法
 				zap.String("function", c.HandlerName()),                     // 处理函数名
 				zap.String("path", path),                                    // 请求路径
 				zap.String("query", query),                                  // 查询参数
 				zap.String("ip", c.ClientIP()),                              // 客户端 IP 地址
 				zap.String("user-agent", c.Request.UserAgent()),             // 客户端 User-Agent
-				zap.String("token", c.Request.Header.Get("session_id")),     // 会话 ID
+				zap.String("token", redactHeader(c.Request.Header.Get("session_id"))), // 会话 ID, 只记录是否携带
 				zap.String("content-type", c.Request.Header.Get("Content-Type")), // 请求内容类型
 				zap.Float64("latency", latency),                             // 请求处理延迟
-				zap.String("request", string(requestBody)),                  // 请求体内容
-				zap.String("response", string(responseBody)),                // 响应体内容
+				zap.String("request", redactor.format(requestBody, c.Request.Header.Get("Content-Type"))),    // 脱敏后的请求体内容
+				zap.String("response", redactor.format(responseBody, c.Writer.Header().Get("Content-Type"))), // 脱敏后的响应体内容
 			}
 			// 记录成功的请求处理日志
 			logger.Info("EasySwap API 请求处理完成", fields...)
 		}
 	}
 }
+
+// redactHeader 脱敏请求头中的凭证, 携带时记录为 ***, 未携带时为空
+func redactHeader(value string) string {
+	if value == "" {
+		return ""
+	}
+	return redactedValue
+}
+
+// isBusinessError 判断响应体是否为业务错误, 即 JSON 响应中的 code 不为 200
+// 非 JSON 响应(如图片)和没有 code 字段的响应不视为业务错误
+func isBusinessError(body []byte) bool {
+	var resp struct {
+		Code *int64 `json:"code"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil || resp.Code == nil {
+		return false
+	}
+
+	return *resp.Code != errcode.CodeOK
+}
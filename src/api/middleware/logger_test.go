@@ -0,0 +1,33 @@
+package middleware
+
+import "testing"
+
+func TestIsBusinessError(t *testing.T) {
+	tests := []struct {
+		body string
+		want bool
+	}{
+		{`{"trace_id":"","code":200,"msg":"Successful","data":{"result":[]}}`, false},
+		{`{"code":200,"message":"Successful","data":null,"request_id":"r"}`, false},
+		// HTTP 200 的业务错误
+		{`{"trace_id":"","code":7000,"msg":"API key is required.","data":null}`, true},
+		{`{"code":10001,"message":"参数错误","data":null,"request_id":"r"}`, true},
+		{`{"result":[]}`, false},
+		{"\x89PNG\r\n", false},
+		{``, false},
+	}
+	for _, tt := range tests {
+		if got := isBusinessError([]byte(tt.body)); got != tt.want {
+			t.Errorf("isBusinessError(%q) = %v, want %v", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestRedactHeader(t *testing.T) {
+	if got := redactHeader("0xsession"); got != "***" {
+		t.Errorf("redactHeader() = %q, want ***", got)
+	}
+	if got := redactHeader(""); got != "" {
+		t.Errorf("redactHeader(\"\") = %q, want empty", got)
+	}
+}
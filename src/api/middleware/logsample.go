@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// logSampler 按路由对成功请求的日志进行采样
+// 每个路由独立计数, 每 rate 个请求记录 1 个, 第一个请求总是记录
+type logSampler struct {
+	rate     uint64
+	counters sync.Map // route -> *uint64
+}
+
+func newLogSampler(rate int) *logSampler {
+	if rate <= 1 {
+		return nil
+	}
+
+	return &logSampler{rate: uint64(rate)}
+}
+
+// sample 判断该路由本次请求是否需要记录日志
+// 未启用采样时总是返回 true
+func (s *logSampler) sample(route string) bool {
+	if s == nil {
+		return true
+	}
+
+	v, _ := s.counters.LoadOrStore(route, new(uint64))
+	n := atomic.AddUint64(v.(*uint64), 1)
+	return (n-1)%s.rate == 0
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/joinmouse/EasySwapBackend/src/common/metrics"
+)
+
+// inFlight 正在处理中的请求数, 不依赖指标是否注册, 用于就绪检查和优雅关闭时观察请求排空进度
+var inFlight atomic.Int64
+
+// InFlightRequests 返回正在处理中的请求数
+func InFlightRequests() int64 {
+	return inFlight.Load()
+}
+
+// Metrics 是请求监控中间件
+// 按路由模板和状态码记录请求数、请求耗时和并发请求数
+// 使用前需要先调用metrics.Init完成指标注册, 未注册时只统计正在处理中的请求数
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		inFlight.Add(1)
+		defer inFlight.Add(-1)
+
+		if metrics.RequestTotal == nil {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		metrics.RequestsInFlight.Inc()
+		defer metrics.RequestsInFlight.Dec()
+
+		c.Next()
+
+		// 使用路由模板而不是实际路径，避免地址、token_id等参数导致标签基数膨胀
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+
+		metrics.RequestTotal.WithLabelValues(route, c.Request.Method, status).Inc()
+		metrics.RequestDuration.WithLabelValues(route, c.Request.Method, status).Observe(time.Since(start).Seconds())
+	}
+}
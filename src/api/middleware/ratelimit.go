@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/zeromicro/go-zero/core/stores/redis"
+	"go.uber.org/zap"
+
+	"github.com/joinmouse/EasySwapBase/errcode"
+	"github.com/joinmouse/EasySwapBase/logger/xzap"
+	"github.com/joinmouse/EasySwapBase/stores/xkv"
+
+	"github.com/joinmouse/EasySwapBackend/src/api/i18n"
+)
+
+const RateLimitPrefix = "cache:es:ratelimit"
+
+// slidingWindowScript 滑动窗口限流lua脚本
+// KEYS[1]: 限流key
+// ARGV[1]: 当前时间(毫秒) ARGV[2]: 窗口大小(毫秒) ARGV[3]: 窗口内允许的请求数 ARGV[4]: 本次请求的唯一标识
+// 返回0表示放行, 大于0表示需要等待的毫秒数
+var slidingWindowScript = redis.NewScript(`local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+redis.call('ZREMRANGEBYSCORE', key, 0, now - window)
+if redis.call('ZCARD', key) < limit then
+    redis.call('ZADD', key, now, ARGV[4])
+    redis.call('PEXPIRE', key, window)
+    return 0
+end
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+local wait = tonumber(oldest[2]) + window - now
+if wait < 1 then
+    wait = 1
+end
+return wait`)
+
+// RateLimit 是基于Redis滑动窗口的限流中间件
+// 主要功能包括:
+// 1. 以客户端IP和路由模板作为限流key,统计window时间窗口内的请求数
+// 2. 超过limit时返回429,并通过Retry-After头告知客户端需要等待的秒数
+// 3. Redis不可用时放行请求(fail open),避免缓存故障导致整个API不可用
+func RateLimit(store *xkv.Store, limit int, window time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limit <= 0 || window <= 0 {
+			c.Next()
+			return
+		}
+
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		key := fmt.Sprintf("%s:%s:%s", RateLimitPrefix, c.ClientIP(), route)
+
+		wait, err := slidingWindowWait(c.Request.Context(), store, key, limit, window)
+		if err != nil {
+			xzap.WithContext(c.Request.Context()).Warn("rate limit unavailable, allow request",
+				zap.String("key", key), zap.Error(err))
+			c.Next()
+			return
+		}
+		if wait <= 0 {
+			c.Next()
+			return
+		}
+
+		abortTooManyRequests(c, wait)
+	}
+}
+
+// slidingWindowWait 在滑动窗口中记录一次请求
+// 返回0表示放行, 大于0表示超过限制需要等待的毫秒数
+func slidingWindowWait(ctx context.Context, store *xkv.Store, key string, limit int, window time.Duration) (int64, error) {
+	now := time.Now().UnixMilli()
+	val, err := store.Redis.ScriptRunCtx(ctx, slidingWindowScript, []string{key},
+		now, window.Milliseconds(), limit, fmt.Sprintf("%d-%s", now, uuid.NewString()))
+	if err != nil {
+		return 0, err
+	}
+
+	wait, _ := val.(int64)
+	return wait, nil
+}
+
+// abortTooManyRequests 返回429, 并通过Retry-After头告知客户端需要等待的秒数
+func abortTooManyRequests(c *gin.Context, wait int64) {
+	// 向上取整到秒
+	retryAfter := (wait + 999) / 1000
+	c.Header("Retry-After", strconv.FormatInt(retryAfter, 10))
+	i18n.Error(c, errcode.NewCustomErr("Too many requests.", http.StatusTooManyRequests))
+	c.Abort()
+}
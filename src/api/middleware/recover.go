@@ -2,17 +2,23 @@ package middleware
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httputil"
 	"runtime"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joinmouse/EasySwapBase/errcode"
 	"github.com/joinmouse/EasySwapBase/logger/xzap"
 	"github.com/joinmouse/EasySwapBase/xhttp"
+	"go.uber.org/zap"
+
+	"github.com/joinmouse/EasySwapBackend/src/api/i18n"
 )
 
 var (
@@ -22,20 +28,122 @@ var (
 	slash     = []byte("/")
 )
 
+// PanicEvent 请求处理发生 panic 时传给告警回调的信息
+type PanicEvent struct {
+	RequestID string `json:"request_id"`
+	Method    string `json:"method"`
+	Path      string `json:"path"`
+	Cause     string `json:"cause"`
+	Stack     string `json:"stack"`
+	Time      int64  `json:"time"`
+}
+
+// PanicHook panic 告警回调, 在独立的协程中调用, 不会阻塞错误响应
+type PanicHook func(ctx context.Context, event PanicEvent)
+
+// panicErrorData panic 错误响应中的 data 字段
+// 客户端可以用 request_id 反馈问题, 与服务端日志和告警关联
+type panicErrorData struct {
+	RequestID string `json:"request_id"`
+	Stack     string `json:"stack,omitempty"` // 只在 gin 调试模式下返回
+}
+
 // RecoverMiddleware 恐慌捕获恢复处理
-func RecoverMiddleware() gin.HandlerFunc {
+// 1. 记录带有请求ID、请求内容和调用栈的结构化错误日志, 请求ID由 RequestID 中间件写入日志上下文
+// 2. hook 不为空时异步调用告警回调
+// 3. 返回固定格式的500错误响应, data 中带有请求ID; 调用栈只在 gin 调试模式下返回给客户端
+func RecoverMiddleware(hook PanicHook) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		defer func() {
-			if cause := recover(); cause != nil {
-				xzap.WithContext(c.Request.Context()).Errorf("[Recovery] panic recovered, request:%s%v [## stack:]:\n%s", dumpRequest(c.Request), cause, dumpStack(3))
-				xhttp.Error(c, errcode.ErrUnexpected)
+			cause := recover()
+			if cause == nil {
+				return
+			}
+
+			ctx := c.Request.Context()
+			requestID := GetRequestID(ctx)
+			stack := string(dumpStack(3))
+			// 日志上下文中已经带有请求ID
+			xzap.WithContext(ctx).Error("[Recovery] panic recovered",
+				zap.String("cause", fmt.Sprint(cause)),
+				zap.String("request", dumpRequest(c.Request)),
+				zap.String("stack", stack),
+			)
+
+			if hook != nil {
+				event := PanicEvent{
+					RequestID: requestID,
+					Method:    c.Request.Method,
+					Path:      c.Request.URL.Path,
+					Cause:     fmt.Sprint(cause),
+					Stack:     stack,
+					Time:      time.Now().Unix(),
+				}
+				go func() {
+					defer func() {
+						if r := recover(); r != nil {
+							xzap.WithContext(ctx).Error("panic alert hook panicked", zap.String("cause", fmt.Sprint(r)))
+						}
+					}()
+					hook(context.WithoutCancel(ctx), event)
+				}()
+			}
+
+			// 处理器已经写出响应时无法再返回错误响应
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+
+			data := panicErrorData{RequestID: requestID}
+			if gin.IsDebugging() {
+				data.Stack = stack
 			}
+			e := errcode.ParseErr(i18n.Localize(i18n.Lang(c), errcode.ErrUnexpected))
+			xhttp.WriteHeader(c.Writer, e)
+			c.AbortWithStatusJSON(e.HTTPCode(), &xhttp.Response{
+				TraceId: xhttp.GetTraceId(ctx),
+				Code:    e.Code(),
+				Msg:     e.Error(),
+				Data:    data,
+			})
 		}()
 
 		c.Next()
 	}
 }
 
+// WebhookPanicHook 返回将 panic 信息以 JSON 格式 POST 到 Webhook 的告警回调
+// 发送失败只记录日志
+func WebhookPanicHook(webhook string, timeout time.Duration) PanicHook {
+	client := &http.Client{Timeout: timeout}
+
+	return func(ctx context.Context, event PanicEvent) {
+		body, err := json.Marshal(event)
+		if err != nil {
+			return
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook, bytes.NewReader(body))
+		if err != nil {
+			xzap.WithContext(ctx).Error("failed on create panic alert request", zap.Error(err))
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			xzap.WithContext(ctx).Error("failed on send panic alert", zap.Error(err))
+			return
+		}
+		defer resp.Body.Close()
+		_, _ = io.Copy(io.Discard, resp.Body)
+		if resp.StatusCode >= http.StatusMultipleChoices {
+			xzap.WithContext(ctx).Error("panic alert webhook returned error", zap.Int("status", resp.StatusCode))
+		}
+	}
+}
+
 // dumpRequest 格式化请求样式
 func dumpRequest(req *http.Request) string {
 	var dup io.ReadCloser
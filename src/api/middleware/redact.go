@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultLogBodyMaxSize 日志中记录的请求体/响应体默认最大字节数
+	DefaultLogBodyMaxSize = 4 * 1024
+	// redactedValue 敏感字段脱敏后的值
+	redactedValue = "***"
+)
+
+// DefaultRedactKeys 默认需要脱敏的 JSON 字段名
+var DefaultRedactKeys = []string{"signature", "token", "pass", "password"}
+
+// bodyRedactor 负责在记录日志前对请求体/响应体进行脱敏和截断
+type bodyRedactor struct {
+	keys    map[string]struct{}
+	maxSize int
+}
+
+func newBodyRedactor(redactKeys []string, maxSize int) *bodyRedactor {
+	if len(redactKeys) == 0 {
+		redactKeys = DefaultRedactKeys
+	}
+	if maxSize <= 0 {
+		maxSize = DefaultLogBodyMaxSize
+	}
+
+	keys := make(map[string]struct{}, len(redactKeys))
+	for _, key := range redactKeys {
+		keys[strings.ToLower(key)] = struct{}{}
+	}
+
+	return &bodyRedactor{keys: keys, maxSize: maxSize}
+}
+
+// format 返回可以写入日志的 body 内容
+// 1. 非 JSON 内容(如图片)不记录原文, 只记录类型和长度
+// 2. JSON 内容中命中脱敏字段的值替换为 ***
+// 3. 超过最大长度时截断并注明原始长度
+func (r *bodyRedactor) format(body []byte, contentType string) string {
+	if len(body) == 0 {
+		return ""
+	}
+	if contentType != "" && !strings.Contains(strings.ToLower(contentType), "json") {
+		return fmt.Sprintf("[skipped: %s, %d bytes]", contentType, len(body))
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var data interface{}
+	if err := decoder.Decode(&data); err != nil {
+		return fmt.Sprintf("[skipped: invalid json, %d bytes]", len(body))
+	}
+
+	redacted, err := json.Marshal(r.redact(data))
+	if err != nil {
+		return fmt.Sprintf("[skipped: invalid json, %d bytes]", len(body))
+	}
+
+	return r.truncate(string(redacted))
+}
+
+// redact 递归替换 JSON 对象中敏感字段的值, 字段名不区分大小写
+func (r *bodyRedactor) redact(data interface{}) interface{} {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if _, ok := r.keys[strings.ToLower(key)]; ok {
+				v[key] = redactedValue
+				continue
+			}
+			v[key] = r.redact(value)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = r.redact(value)
+		}
+	}
+
+	return data
+}
+
+// truncate 按最大长度截断, 保证不截断在多字节字符中间
+func (r *bodyRedactor) truncate(s string) string {
+	if len(s) <= r.maxSize {
+		return s
+	}
+
+	cut := r.maxSize
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+
+	return fmt.Sprintf("%s...[truncated, %d bytes total]", s[:cut], len(s))
+}
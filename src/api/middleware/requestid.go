@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	logging "github.com/joinmouse/EasySwapBase/logger"
+)
+
+const (
+	// RequestIDHeader 请求ID的请求头/响应头名称
+	RequestIDHeader = "X-Request-ID"
+	// RequestIDKey 请求ID在Gin上下文和日志字段中的键名
+	RequestIDKey = "request_id"
+
+	// maxRequestIDLen 客户端传入的请求ID最大长度,超出时重新生成
+	maxRequestIDLen = 128
+)
+
+type requestIDCtxKey struct{}
+
+// RequestID 请求ID中间件
+// 优先使用客户端传入的 X-Request-ID, 没有时生成UUID
+// 请求ID会写入Gin上下文、请求的 context.Context 和响应头,
+// 并作为日志标签注入上下文, 使服务层通过 xzap.WithContext 打印的日志带上相同的 request_id
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLen {
+			requestID = uuid.NewString()
+		}
+
+		c.Set(RequestIDKey, requestID)
+		c.Header(RequestIDHeader, requestID)
+		c.Request = c.Request.WithContext(WithRequestID(c.Request.Context(), requestID))
+
+		c.Next()
+	}
+}
+
+// WithRequestID 将请求ID写入上下文, 同时添加到日志标签中
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	tags := logging.Extract(ctx)
+	if tags == logging.NoopTags {
+		tags = logging.NewTags()
+	}
+	tags.Set(RequestIDKey, requestID)
+	ctx = logging.SetInContext(ctx, tags)
+
+	return context.WithValue(ctx, requestIDCtxKey{}, requestID)
+}
+
+// GetRequestID 从上下文中获取请求ID, 不存在时返回空字符串
+func GetRequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDCtxKey{}).(string)
+	return requestID
+}
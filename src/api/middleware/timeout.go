@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joinmouse/EasySwapBase/errcode"
+
+	"github.com/joinmouse/EasySwapBackend/src/api/i18n"
+)
+
+// RequestTimeoutHeader 客户端指定请求处理超时时间(毫秒)的请求头
+const RequestTimeoutHeader = "X-Request-Timeout-Ms"
+
+// ErrRequestTimeout 请求处理超过了截止时间
+var ErrRequestTimeout = errcode.NewCustomErr("Request timed out.", http.StatusGatewayTimeout)
+
+// RequestTimeout 请求截止时间中间件
+// 1. 请求携带 X-Request-Timeout-Ms 时使用其值作为超时时间, 不是正整数或超过 maxTimeout 时返回400
+// 2. 未携带时使用 defaultTimeout, 为 0 时不设置截止时间
+// 3. 截止时间设置在请求的 context 上, 数据库查询和链上调用随之取消;
+// 处理器通过 handleServiceError 返回的超时错误为504, 处理器没有写入响应时由中间件返回504
+// WebSocket 握手请求不设置截止时间, 避免长连接被中断
+func RequestTimeout(defaultTimeout, maxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if strings.EqualFold(c.GetHeader("Upgrade"), "websocket") {
+			c.Next()
+			return
+		}
+
+		timeout := defaultTimeout
+		if v := c.GetHeader(RequestTimeoutHeader); v != "" {
+			ms, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || ms <= 0 || time.Duration(ms)*time.Millisecond > maxTimeout {
+				i18n.Error(c, i18n.Errorf(http.StatusBadRequest, "Invalid %s: %s", RequestTimeoutHeader, v))
+				c.Abort()
+				return
+			}
+			timeout = time.Duration(ms) * time.Millisecond
+		}
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			i18n.Error(c, ErrRequestTimeout)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/semconv/v1.17.0/httpconv"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/joinmouse/EasySwapBackend/src/common/tracing"
+)
+
+// Tracing 链路追踪中间件
+// 1. 从请求头的 traceparent 中恢复上游的链路信息, 没有时开启新的链路
+// 2. 为每个请求创建一个 server span, 以"方法 路由模板"命名, 并写入请求的 context.Context,
+// 服务层和数据访问层基于该 context 创建子 span
+// 3. 在响应头中写回 traceparent, 方便客户端关联同一条链路
+// 未配置导出端点时 span 为 no-op, 只透传 traceparent
+func Tracing() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		propagator := otel.GetTextMapPropagator()
+		ctx := propagator.Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		// 使用路由模板而不是实际路径，避免地址、token_id等参数导致 span 名称基数膨胀
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		ctx, span := tracing.Tracer().Start(ctx, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(httpconv.ServerRequest("", c.Request)...),
+			trace.WithAttributes(semconv.HTTPRoute(route)),
+		)
+		defer span.End()
+
+		if requestID := GetRequestID(ctx); requestID != "" {
+			span.SetAttributes(attribute.String(RequestIDKey, requestID))
+		}
+
+		propagator.Inject(ctx, propagation.HeaderCarrier(c.Writer.Header()))
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		if len(c.Errors) > 0 {
+			span.RecordError(c.Errors.Last())
+		}
+	}
+}
@@ -9,14 +9,17 @@ import (
 	"github.com/gin-gonic/gin"                                // Gin Web 框架
 
 	"github.com/joinmouse/EasySwapBackend/src/api/middleware" // 自定义中间件
+	v1 "github.com/joinmouse/EasySwapBackend/src/api/v1"      // API v1 版本处理器
+	"github.com/joinmouse/EasySwapBackend/src/common/metrics" // 监控指标
 	"github.com/joinmouse/EasySwapBackend/src/service/svc"    // 服务上下文
 )
 
 // NewRouter 创建并配置一个新的 Gin HTTP 路由器
 // 该函数负责:
-// 1. 初始化 Gin 引擎并设置运行模式
-// 2. 配置全局中间件（错误恢复、日志记录、CORS）
-// 3. 加载所有API版本的路由配置
+// 1. 按配置设置 Gin 运行模式（默认 release）并初始化 Gin 引擎
+// 2. 配置全局中间件（请求ID、链路追踪、监控、响应压缩、错误恢复、请求体大小限制、日志记录、CORS、并发限制、限流）
+// 3. 注册监控指标端点 /metrics 和健康检查端点 /health、/ready
+// 4. 加载所有API版本的路由配置
 //
 // 参数:
 //   - svcCtx: 服务上下文，包含数据库、缓存等服务实例
@@ -24,23 +27,57 @@ import (
 // 返回值:
 //   - *gin.Engine: 配置完成的 Gin 路由器实例
 func NewRouter(svcCtx *svc.ServerCtx) *gin.Engine {
-	// 强制使用彩色输出，提高日志可读性
-	gin.ForceConsoleColor()
-	// 设置为发布模式，减少调试信息的输出
-	gin.SetMode(gin.ReleaseMode)
+	// 按配置设置运行模式，未配置时使用发布模式，减少调试信息的输出
+	// 调试模式下输出路由注册等调试日志，并强制使用彩色输出，提高本地调试时日志的可读性
+	gin.SetMode(svcCtx.C.Api.ModeOrDefault())
+	if gin.Mode() == gin.DebugMode {
+		gin.ForceConsoleColor()
+	}
 	
 	// 创建新的 Gin 引擎实例
 	r := gin.New()
 	
+	// 初始化监控指标，使用项目名称作为命名空间，避免多个部署的指标冲突
+	namespace := ""
+	if svcCtx.C.ProjectCfg != nil {
+		namespace = svcCtx.C.ProjectCfg.Name
+	}
+	metrics.Init(namespace)
+
 	// 注册全局中间件
-	r.Use(middleware.RecoverMiddleware()) // 恢复中间件，捕获panic并返回错误响应
-	r.Use(middleware.RLog())              // 日志中间件，记录请求和响应信息
+	r.Use(middleware.RequestID())         // 请求ID中间件，生成或透传 X-Request-ID
+	r.Use(middleware.Tracing())           // 链路追踪中间件，透传 traceparent 并为每个请求创建 span
+	r.Use(middleware.Metrics())           // 监控中间件，记录请求数、耗时和并发数
+	if compression := svcCtx.C.Api.Compression; compression.Enabled() {
+		// 压缩中间件，放在恢复和日志中间件之前，使panic的错误响应也被压缩、日志记录压缩前的响应体
+		r.Use(middleware.Gzip(compression.LevelOrDefault(), compression.MinSizeOrDefault()))
+	}
+	// 恢复中间件，捕获panic并返回带请求ID的错误响应，配置了告警 Webhook 时发送告警
+	var panicHook middleware.PanicHook
+	if panicAlert := svcCtx.C.Api.PanicAlert; panicAlert.Webhook != "" {
+		panicHook = middleware.WebhookPanicHook(panicAlert.Webhook, panicAlert.TimeoutOrDefault())
+	}
+	r.Use(middleware.RecoverMiddleware(panicHook))
+	if maxBodyBytes := svcCtx.C.Api.MaxBodyBytesOrDefault(); maxBodyBytes > 0 {
+		// 请求体大小限制中间件，放在日志中间件之前，避免超大的请求体被完整缓冲
+		r.Use(middleware.MaxBodyBytes(maxBodyBytes))
+	}
+	r.Use(middleware.RLog( // 日志中间件，记录脱敏后的请求和响应信息，成功请求按配置采样
+		svcCtx.C.Api.LogBody.RedactKeys,
+		svcCtx.C.Api.LogBody.MaxSize,
+		svcCtx.C.Api.LogSampling.Rate,
+		time.Duration(svcCtx.C.Api.LogSampling.SlowThreshold)*time.Millisecond,
+	))
+	r.Use(middleware.RequestTimeout( // 截止时间中间件，按 X-Request-Timeout-Ms 或默认值设置请求 context 的截止时间
+		svcCtx.C.Api.RequestTimeout.DefaultOrDefault(),
+		svcCtx.C.Api.RequestTimeout.MaxOrDefault(),
+	))
 
 	// 配置 CORS（跨域资源共享）中间件
 	r.Use(cors.New(cors.Config{
 		AllowAllOrigins: true, // 允许所有来源的跨域请求
 		// 允许的 HTTP 方法
-		AllowMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
+		AllowMethods: []string{"GET", "HEAD", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
 		// 允许的请求头
 		AllowHeaders: []string{
 			"Origin",
@@ -50,6 +87,13 @@ func NewRouter(svcCtx *svc.ServerCtx) *gin.Engine {
 			"Authorization",
 			"AccessToken",
 			"Token",
+			"If-None-Match",
+			"X-Request-ID",
+			"Idempotency-Key",
+			"X-API-Version",
+			"X-Request-Timeout-Ms",
+			"traceparent",
+			"tracestate",
 		},
 		// 向客户端暴露的响应头
 		ExposeHeaders: []string{
@@ -59,10 +103,35 @@ func NewRouter(svcCtx *svc.ServerCtx) *gin.Engine {
 			"Access-Control-Allow-Headers",
 			"X-GW-Error-Code",
 			"X-GW-Error-Message",
+			"Retry-After",
+			"ETag",
+			"X-Request-ID",
+			"Idempotent-Replayed",
+			"X-Cache",
+			"traceparent",
+			"Content-Encoding",
 		},
 		AllowCredentials: true,          // 允许发送身份凭证（如 Cookies）
 		MaxAge:           1 * time.Hour, // 预检请求的缓存时间
 	}))
+
+	// 监控指标端点，独立于 /api/v1 路由组，且不受限流影响
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	// 健康检查端点，供负载均衡和容器编排探测使用，不受限流影响
+	r.GET("/health", v1.HealthHandler())     // 存活检查，进程存活即返回200
+	r.GET("/ready", v1.ReadyHandler(svcCtx)) // 就绪检查，检查数据库、Redis和链上节点
+
+	// 并发限制中间件，同时处理的请求数达到 max_num 时短暂排队，仍无空闲时返回503
+	// 注册在健康检查端点之后，高负载时探测请求不受限制
+	r.Use(middleware.ConcurrencyLimit(svcCtx.C.Api.MaxNum, middleware.DefaultConcurrencyWait))
+
+	// 限流中间件，按客户端IP和路由在时间窗口内限制请求次数
+	// 放在CORS之后，保证429响应也带有跨域头
+	rateLimit := svcCtx.C.Api.RateLimit
+	if rateLimit.Limit > 0 && rateLimit.Window > 0 {
+		r.Use(middleware.RateLimit(svcCtx.KvStore, rateLimit.Limit, time.Duration(rateLimit.Window)*time.Second))
+	}
 	
 	// 加载 API v1 版本路由
 	loadV1(r, svcCtx)
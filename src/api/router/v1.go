@@ -1,13 +1,19 @@
 package router
 
 import (
+	"net/http" // HTTP 方法常量
+
 	"github.com/gin-gonic/gin"                                 // Gin Web框架
 
 	"github.com/joinmouse/EasySwapBackend/src/api/middleware"   // 中间件包
 	v1 "github.com/joinmouse/EasySwapBackend/src/api/v1"        // API v1 版本处理器
+	"github.com/joinmouse/EasySwapBackend/src/config"           // 配置
 	"github.com/joinmouse/EasySwapBackend/src/service/svc"      // 服务上下文
 )
 
+// getAndHead 同时支持 GET 和 HEAD 的路由方法, CDN 和链接预览会先发送 HEAD 请求获取响应头
+var getAndHead = []string{http.MethodGet, http.MethodHead}
+
 // loadV1 加载 API v1 版本的所有路由配置
 // 该函数定义了 EasySwap NFT 交易所的所有 API 端点，包括:
 // - 用户认证相关 API
@@ -23,46 +29,113 @@ func loadV1(r *gin.Engine, svcCtx *svc.ServerCtx) {
 	// 创建 API v1 版本的路由组
 	apiV1 := r.Group("/api/v1")
 
+	// 只读模式下拒绝写请求, 只读的批量查询接口和管理接口不受影响
+	middleware.SetReadOnly(svcCtx.C.Api.ReadOnly)
+	apiV1.Use(middleware.ReadOnly(
+		"/api/v1/collections/:address/items/batch",
+		"/api/v1/orders/batch",
+		"/api/v1/orders/validate",
+		"/api/v1/admin/read-only",
+	))
+
+	// 启动时初始化失败的降级链, 其接口返回503
+	apiV1.Use(middleware.ChainAvailable(svcCtx))
+
+	// 合作方服务端集成接口和写接口需要携带 X-API-Key 请求头访问, 所有路由共用无效 Key 的缓存
+	apiKey := middleware.ApiKey(svcCtx)
+
+	// 管理接口, 来源 IP 需要在允许列表中(未配置时只允许本机), 并携带 X-Admin-Secret 请求头访问
+	admin := apiV1.Group("/admin")
+	admin.Use(middleware.IPAllowlist(svcCtx.C.Api.AdminAllowlist, svcCtx.C.Api.TrustedProxies))
+	admin.Use(middleware.AdminAuth(svcCtx.C.Api.AdminSecret))
+	{
+		admin.GET("/read-only", v1.ReadOnlyStatusHandler()) // 获取只读模式状态
+		admin.PUT("/read-only", v1.SetReadOnlyHandler())    // 运行时切换只读模式
+		// 隐藏或恢复被举报的集合
+		admin.PUT("/collections/:address/hidden", v1.SetCollectionHiddenHandler(svcCtx))
+		// 查看脱敏后的运行配置和构建信息, 未配置管理密钥时不注册
+		if svcCtx.C.Api.AdminSecret != "" {
+			admin.GET("/config", v1.AdminConfigHandler(svcCtx))
+		}
+	}
+
+	// 支持的区块链列表，供前端渲染链选择器
+	apiV1.GET("/chains", v1.SupportedChainsHandler(svcCtx))
+	// 链的市场手续费、默认版税以及集合单独配置的版税
+	apiV1.GET("/chains/:chain_id/fees", v1.ChainFeesHandler(svcCtx))
+	// 索引服务在链上的同步状态，供集成方判断持有人等链上数据是否可信
+	apiV1.GET("/chains/:chain_id/sync-status", apiKey, v1.ChainSyncStatusHandler(svcCtx))
+
 	// 用户认证相关路由组
 	// 处理用户登录、签名验证等功能
 	user := apiV1.Group("/user")
+	user.Use(middleware.ValidateAddressParam("address")) // 校验路径中的地址参数并统一为校验和格式
 	{
 		user.GET("/:address/login-message", v1.GetLoginMessageHandler(svcCtx)) // 获取登录签名消息，用于用户签名认证
 		user.POST("/login", v1.UserLoginHandler(svcCtx))                       // 用户登录接口，验证签名并返回令牌
 		user.GET("/:address/sig-status", v1.GetSigStatusHandler(svcCtx))       // 获取用户签名状态
+		user.GET("/:address/login-history", middleware.AuthMiddleware(svcCtx),
+			v1.GetLoginHistoryHandler(svcCtx)) // 获取用户自己最近的登录记录, 需要携带 Authorization: Bearer <token> 访问
 	}
 
 	// NFT 集合和物品相关路由组
 	// 处理 NFT 集合信息、物品详情、交易信息等
 	collections := apiV1.Group("/collections")
+	collections.Use(middleware.ValidateAddressParam("address", "owner")) // 校验路径中的集合地址和持有人地址参数并统一为校验和格式
+	collections.Use(middleware.ValidateTokenIDParam("token_id")) // 校验路径中的 token id 并统一为十进制格式
 	{
 		// NFT 集合管理 API
-		collections.GET("/:address", v1.CollectionDetailHandler(svcCtx))                  // 获取指定 NFT 集合的详细信息
+		collections.POST("", apiKey, v1.CollectionIndexHandler(svcCtx))                         // 提交集合收录请求，已收录返回 200，否则返回 202 和收录任务ID
+		collections.GET("/search", v1.CollectionSearchHandler(svcCtx))                    // 按名称或符号搜索 NFT 集合
+		collections.GET("/compare", v1.CollectionCompareHandler(svcCtx))                  // 对比多个 NFT 集合的统计信息
+		collections.Match(getAndHead, "/:address", middleware.HeadResponse(), v1.CollectionDetailHandler(svcCtx)) // 获取指定 NFT 集合的详细信息，支持 HEAD
 		collections.GET("/:address/bids", v1.CollectionBidsHandler(svcCtx))               // 获取指定集合的所有出价信息
+		collections.GET("/:address/bids/aggregated", v1.CollectionAggregatedBidsHandler(svcCtx)) // 按价格档位聚合集合出价，用于绘制出价深度图
+		collections.GET("/:address/listings/aggregated", v1.CollectionAggregatedListingsHandler(svcCtx)) // 按价格档位聚合集合挂单，用于绘制深度图的卖方
+		collections.GET("/:address/trait-bids", v1.CollectionItemBidsByTraitHandler(svcCtx)) // 按特征值分组获取集合中 Item 出价的最高价格和剩余数量（订单簿没有特征出价）
 		collections.GET("/:address/:token_id/bids", v1.CollectionItemBidsHandler(svcCtx)) // 获取指定 NFT 物品的出价信息
+		collections.GET("/:address/:token_id/offers", v1.ItemOffersHandler(svcCtx))     // 获取适用于指定 NFT 物品的所有有效出价（Item出价和集合出价），按实际到手金额排序
+		collections.GET("/:address/:token_id/accept-offer-preview", v1.AcceptOfferPreviewHandler(svcCtx)) // 预估接受当前最优出价后扣除手续费和版税的实际到手金额
 		collections.GET("/:address/items", v1.CollectionItemsHandler(svcCtx))             // 获取指定集合下的所有 NFT 物品
+		collections.POST("/:address/items/batch", v1.ItemDetailBatchHandler(svcCtx))      // 批量获取指定集合下 NFT 物品的详细信息
 
 		// NFT 物品详情 API
-		collections.GET("/:address/:token_id", v1.ItemDetailHandler(svcCtx))     // 获取 NFT 物品的详细信息（包括价格、所有者等）
+		collections.Match(getAndHead, "/:address/:token_id", middleware.HeadResponse(), v1.ItemDetailHandler(svcCtx)) // 获取 NFT 物品的详细信息（包括价格、所有者等），支持 HEAD
 		collections.GET("/:address/:token_id/traits", v1.ItemTraitsHandler(svcCtx)) // 获取 NFT 物品的属性特征信息
+		collections.GET("/:address/:token_id/rarity", v1.ItemRarityHandler(svcCtx)) // 获取 NFT 物品各特征的稀有度及稀有度排名
 		collections.GET("/:address/top-trait", v1.ItemTopTraitPriceHandler(svcCtx)) // 获取集合中最高价的特征信息
 		
 		// NFT 媒体和元数据 API
-		collections.GET("/:address/:token_id/image", 
-			middleware.CacheApi(svcCtx.KvStore, 60), // 缓存 60 秒
-			v1.GetItemImageHandler(svcCtx))          // 获取 NFT 物品的图片信息
-		collections.POST("/:address/:token_id/metadata", v1.ItemMetadataRefreshHandler(svcCtx)) // 刷新 NFT 物品的元数据
+		collections.Match(getAndHead, "/:address/:token_id/image",
+			middleware.HeadResponse(), // HEAD 请求与 GET 共用响应缓存，只返回响应头
+			middleware.CacheApi(svcCtx.KvStore, svcCtx.C.Api.Cache.TTL(config.CacheRouteItemImage)), // 缓存时间由 api.cache 配置
+			v1.GetItemImageHandler(svcCtx))          // 获取 NFT 物品的图片信息，支持 HEAD
+		collections.POST("/:address/:token_id/metadata",
+			apiKey, // 需要 write 权限的 API Key
+			middleware.Idempotency(svcCtx.KvStore, middleware.DefaultIdempotencyTTL), // 携带 Idempotency-Key 时重放首次响应
+			v1.ItemMetadataRefreshHandler(svcCtx))                                   // 刷新 NFT 物品的元数据
+		collections.POST("/:address/metadata/refresh", apiKey, v1.CollectionMetadataRefreshHandler(svcCtx)) // 后台批量刷新集合中 NFT 物品的元数据，返回任务ID
 		
 		// NFT 交易历史和所有权 API
 		collections.GET("/:address/history-sales", v1.HistorySalesHandler(svcCtx))       // 获取 NFT 集合的销售历史信息
+		collections.GET("/:address/floor-history", v1.FloorPriceHistoryHandler(svcCtx))  // 获取 NFT 集合按时间分桶的地板价历史
+		collections.GET("/:address/stats", v1.CollectionStatsHandler(svcCtx))            // 获取 NFT 集合的供应量、持有人、上架比例和成交统计
+		collections.GET("/:address/owners", v1.CollectionOwnersHandler(svcCtx))          // 分页获取 NFT 集合的持有人及持有分布
+		collections.GET("/:address/owners/:owner/items", v1.OwnerItemsHandler(svcCtx))   // 分页获取指定地址在 NFT 集合中持有的物品详情
+		collections.GET("/:address/traits", v1.CollectionTraitsHandler(svcCtx))          // 分页获取 NFT 集合的 Trait类别、可选值及数量
+		collections.GET("/:address/activities/stream", v1.ActivityStreamHandler(svcCtx)) // WebSocket 实时推送集合的交易活动
 		collections.GET("/:address/:token_id/owner", v1.ItemOwnerHandler(svcCtx))       // 获取 NFT 物品的当前持有者信息
+		collections.GET("/:address/:token_id/price-history", v1.ItemPriceHistoryHandler(svcCtx)) // 分页获取 NFT 物品的历史成交记录
 
 		// NFT 排行榜 API
 		collections.GET("/ranking", 
-			middleware.CacheApi(svcCtx.KvStore, 60), // 缓存 60 秒
+			middleware.VersionedCacheApi(svcCtx.KvStore, svcCtx.C.Api.Cache.TTL(config.CacheRouteRanking), config.CacheRouteRanking), // 缓存时间由 api.cache 配置, 隐藏集合后缓存立即失效
 			v1.TopRankingHandler(svcCtx))            // 获取 NFT 集合排行榜信息
 	}
 
+	// 后台任务相关路由
+	apiV1.GET("/jobs/:id", apiKey, v1.JobStatusHandler(svcCtx)) // 查询后台任务的状态和进度
+
 	// 交易活动相关路由组
 	// 处理交易历史、交易事件等信息
 	activities := apiV1.Group("/activities")
@@ -73,11 +146,16 @@ func loadV1(r *gin.Engine, svcCtx *svc.ServerCtx) {
 	// 用户投资组合相关路由组
 	// 处理用户持有的 NFT、挂单、出价等信息
 	portfolio := apiV1.Group("/portfolio")
+	portfolio.Use(middleware.AuthMiddleware(svcCtx)) // 需要携带 Authorization: Bearer <token> 访问
 	{
 		portfolio.GET("/collections", v1.UserMultiChainCollectionsHandler(svcCtx)) // 获取用户在多链上持有的 NFT 集合信息
 		portfolio.GET("/items", v1.UserMultiChainItemsHandler(svcCtx))             // 获取用户在多链上持有的 NFT 物品信息
 		portfolio.GET("/listings", v1.UserMultiChainListingsHandler(svcCtx))       // 获取用户在多链上的挂单信息
 		portfolio.GET("/bids", v1.UserMultiChainBidsHandler(svcCtx))               // 获取用户在多链上的出价信息
+		portfolio.GET("/value", v1.PortfolioValueHandler(svcCtx))                  // 估算用户在指定链上持有的 NFT 的总价值及按集合的明细
+		portfolio.GET("/watchlist", v1.WatchlistHandler(svcCtx))                   // 获取用户关注的集合列表
+		portfolio.POST("/watchlist/:address", middleware.ValidateAddressParam("address"), v1.AddWatchlistHandler(svcCtx))      // 关注集合, 校验集合地址并统一为校验和格式
+		portfolio.DELETE("/watchlist/:address", middleware.ValidateAddressParam("address"), v1.RemoveWatchlistHandler(svcCtx)) // 取消关注集合, 校验集合地址并统一为校验和格式
 	}
 
 	// 订单管理相关路由组
@@ -86,4 +164,11 @@ func loadV1(r *gin.Engine, svcCtx *svc.ServerCtx) {
 	{
 		orders.GET("", v1.OrderInfosHandler(svcCtx)) // 批量查询出价订单信息
 	}
+
+	// 订单详情查询路由，支持跨链按订单ID批量查询
+	apiV1.POST("/orders/batch", v1.OrderDetailsHandler(svcCtx))
+	// 订单当前状态及创建、成交、取消、过期的时间线
+	apiV1.GET("/orders/:order_id/status", v1.OrderStatusHandler(svcCtx))
+	// 签名前校验订单, 只返回校验结果, 不写入任何数据
+	apiV1.POST("/orders/validate", v1.OrderValidateHandler(svcCtx))
 }
@@ -2,59 +2,139 @@ package v1
 
 import (
 	"encoding/json"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joinmouse/EasySwapBase/errcode"
 
+	"github.com/joinmouse/EasySwapBackend/src/api/i18n"
+	"github.com/joinmouse/EasySwapBackend/src/common"
 	"github.com/joinmouse/EasySwapBackend/src/service/svc"
 	"github.com/joinmouse/EasySwapBackend/src/service/v1"
 	"github.com/joinmouse/EasySwapBackend/src/types/v1"
 	"github.com/joinmouse/EasySwapBase/xhttp"
 )
 
+// activitiesQuery 多链活动查询的 query 参数
+// page、page_size、cursor 与 filters 中的同名字段合并, query 中的值优先
+// chain_id、collection、event_types 为逗号分隔的列表, 追加到 filters 中的同名条件
+// since、until 为事件时间区间(Unix 秒), 由 parseTimeRange 解析
+type activitiesQuery struct {
+	pageQuery
+	Filters    string `form:"filters"`
+	Cursor     string `form:"cursor"`
+	ChainID    string `form:"chain_id"`
+	Collection string `form:"collection"`
+	EventTypes string `form:"event_types"`
+	Maker      string `form:"maker"`
+	Taker      string `form:"taker"`
+}
+
 // ActivityMultiChainHandler 处理多链活动查询请求
 // 主要功能:
-// 1. 解析过滤参数
+// 1. 解析过滤参数, 合并 filters 与 query 中的过滤条件
 // 2. 根据是否指定链ID执行不同的查询逻辑:
 //   - 未指定链ID: 查询所有链上的活动
 //   - 指定链ID: 只查询指定链上的活动
 func ActivityMultiChainHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 获取过滤参数
-		filterParam := c.Query("filters")
-		if filterParam == "" {
-			xhttp.Error(c, errcode.NewCustomErr("Filter param is nil."))
+		var query activitiesQuery
+		if !bindQuery(c, &query) {
 			return
 		}
 
 		// 解析过滤参数
 		var filter types.ActivityMultiChainFilterParams
-		err := json.Unmarshal([]byte(filterParam), &filter)
+		if query.Filters != "" {
+			if err := json.Unmarshal([]byte(query.Filters), &filter); err != nil {
+				i18n.Error(c, service.ErrInvalidFilter)
+				return
+			}
+		}
+		if query.Page > 0 {
+			filter.Page = query.Page
+		}
+		if query.PageSize > 0 {
+			filter.PageSize = query.PageSize
+		}
+		if query.Cursor != "" {
+			filter.Cursor = query.Cursor
+		}
+		filter.Page, filter.PageSize = normalizePageParams(filter.Page, filter.PageSize)
+		for _, v := range splitList(query.ChainID) {
+			id, err := strconv.Atoi(v)
+			if err != nil {
+				i18n.Error(c, service.ErrInvalidChainID)
+				return
+			}
+			filter.ChainID = append(filter.ChainID, id)
+		}
+		filter.CollectionAddresses = append(filter.CollectionAddresses, splitList(query.Collection)...)
+		filter.EventTypes = append(filter.EventTypes, splitList(query.EventTypes)...)
+		if query.Maker != "" {
+			filter.Maker = query.Maker
+		}
+		if query.Taker != "" {
+			filter.Taker = query.Taker
+		}
+		timeRange, err := parseTimeRange(c)
 		if err != nil {
-			xhttp.Error(c, errcode.NewCustomErr("Filter param is nil."))
+			i18n.Error(c, err)
 			return
 		}
+		filter.TimeRange = timeRange
+
+		// 校验地址格式
+		for i, addr := range filter.CollectionAddresses {
+			unified, err := common.UnifyAddress(addr)
+			if err != nil {
+				i18n.Error(c, i18n.Errorf(http.StatusBadRequest, "Invalid %s: %s", "collection", addr))
+				return
+			}
+			filter.CollectionAddresses[i] = unified
+		}
+		for name, addr := range map[string]*string{"maker": &filter.Maker, "taker": &filter.Taker} {
+			if *addr == "" {
+				continue
+			}
+			unified, err := common.UnifyAddress(*addr)
+			if err != nil {
+				i18n.Error(c, i18n.Errorf(http.StatusBadRequest, "Invalid %s: %s", name, *addr))
+				return
+			}
+			*addr = unified
+		}
 
-		// 指定链ID,只查询指定链上的活动
+		// 指定链ID,只查询指定链上的活动; 未指定时查询所有链
+		if len(filter.ChainID) == 0 {
+			for id := range chainIDToChain {
+				filter.ChainID = append(filter.ChainID, id)
+			}
+			sort.Ints(filter.ChainID)
+		}
+		var chainIDs []int
 		var chainName []string
 		for _, id := range filter.ChainID {
-			chainName = append(chainName, chainIDToChain[id])
-		}
-
-		res, err := service.GetMultiChainActivities(
-			c.Request.Context(),
-			svcCtx,
-			filter.ChainID,
-			chainName,
-			filter.CollectionAddresses,
-			filter.TokenID,
-			filter.UserAddresses,
-			filter.EventTypes,
-			filter.Page,
-			filter.PageSize,
-		)
+			chain, ok := chainIDToChain[id]
+			if !ok {
+				i18n.Error(c, service.ErrInvalidChainID)
+				return
+			}
+			if slices.Contains(chainIDs, id) {
+				continue
+			}
+			chainIDs = append(chainIDs, id)
+			chainName = append(chainName, chain)
+		}
+		filter.ChainID = chainIDs
+
+		res, err := service.GetMultiChainActivities(c.Request.Context(), svcCtx, chainName, filter)
 		if err != nil {
-			xhttp.Error(c, errcode.NewCustomErr("Get multi-chain activities failed."))
+			handleServiceError(c, err, errcode.NewCustomErr("Get multi-chain activities failed."))
 			return
 		}
 		xhttp.OkJson(c, res)
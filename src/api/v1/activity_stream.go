@@ -0,0 +1,143 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+
+	"github.com/joinmouse/EasySwapBase/errcode"
+	"github.com/joinmouse/EasySwapBase/logger/xzap"
+
+	"github.com/joinmouse/EasySwapBackend/src/api/i18n"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+	"github.com/joinmouse/EasySwapBackend/src/service/v1"
+	"github.com/joinmouse/EasySwapBackend/src/types/v1"
+)
+
+const (
+	wsWriteWait  = 10 * time.Second    // 单次写入超时时间
+	wsPongWait   = 60 * time.Second    // 等待客户端pong的超时时间
+	wsPingPeriod = wsPongWait * 9 / 10 // 发送ping的间隔, 必须小于wsPongWait
+)
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// 与CORS配置保持一致, 允许所有来源
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// ActivityStreamHandler 通过WebSocket实时推送集合交易活动
+// 主要功能:
+// 1. 校验参数并占用集合的连接名额, 超过上限返回错误
+// 2. 订阅集合的Redis发布订阅频道后升级为WebSocket连接
+// 3. 推送最近活动快照, 之后转发频道中的新活动
+// 4. 定时发送ping保活, 客户端断开时释放订阅和连接名额
+func ActivityStreamHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		collectionAddr := c.Params.ByName("address")
+		if collectionAddr == "" {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		chainID, err := strconv.Atoi(c.Query("chain_id"))
+		if err != nil {
+			i18n.Error(c, service.ErrInvalidChainID)
+			return
+		}
+		chain, ok := chainIDToChain[chainID]
+		if !ok {
+			i18n.Error(c, service.ErrInvalidChainID)
+			return
+		}
+
+		release, err := service.AcquireActivityStream(chain, collectionAddr)
+		if err != nil {
+			i18n.Error(c, errcode.NewCustomErr("Too many stream connections.", http.StatusTooManyRequests))
+			return
+		}
+		defer release()
+
+		ctx := c.Request.Context()
+		pubSub, err := service.SubscribeActivities(ctx, svcCtx, chain, collectionAddr)
+		if err != nil {
+			xzap.WithContext(ctx).Error("failed on subscribe activities", zap.Error(err))
+			i18n.Error(c, errcode.ErrUnexpected)
+			return
+		}
+		defer pubSub.Close()
+
+		snapshot, err := service.GetActivitySnapshot(ctx, svcCtx, chainID, chain, collectionAddr)
+		if err != nil {
+			xzap.WithContext(ctx).Error("failed on get activity snapshot", zap.Error(err))
+			i18n.Error(c, errcode.ErrUnexpected)
+			return
+		}
+
+		conn, err := wsUpgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			// Upgrade失败时已向客户端返回错误响应
+			xzap.WithContext(ctx).Warn("failed on upgrade websocket", zap.Error(err))
+			return
+		}
+		defer conn.Close()
+
+		// 读协程: 处理pong和客户端关闭, 客户端不需要发送业务消息
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			conn.SetReadLimit(512)
+			conn.SetReadDeadline(time.Now().Add(wsPongWait))
+			conn.SetPongHandler(func(string) error {
+				return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+			})
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		if err := writeStreamMsg(conn, types.ActivityStreamMsg{Type: types.ActivityStreamSnapshot, Data: snapshot}); err != nil {
+			return
+		}
+
+		ticker := time.NewTicker(wsPingPeriod)
+		defer ticker.Stop()
+		messages := pubSub.Channel()
+		for {
+			select {
+			case <-closed:
+				return
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				// 频道中的消息为单条活动的JSON, 原样转发
+				streamMsg := types.ActivityStreamMsg{Type: types.ActivityStreamEvent, Data: json.RawMessage(msg.Payload)}
+				if err := writeStreamMsg(conn, streamMsg); err != nil {
+					return
+				}
+			case <-ticker.C:
+				conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+				if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+// writeStreamMsg 向WebSocket连接写入一条JSON消息
+func writeStreamMsg(conn *websocket.Conn, msg types.ActivityStreamMsg) error {
+	conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+	return conn.WriteJSON(msg)
+}
@@ -0,0 +1,101 @@
+package v1
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joinmouse/EasySwapBase/errcode"
+	"github.com/joinmouse/EasySwapBase/logger/xzap"
+	"github.com/joinmouse/EasySwapBase/xhttp"
+	"go.uber.org/zap"
+
+	"github.com/joinmouse/EasySwapBackend/src/api/i18n"
+	"github.com/joinmouse/EasySwapBackend/src/api/middleware"
+	"github.com/joinmouse/EasySwapBackend/src/common/buildinfo"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+	"github.com/joinmouse/EasySwapBackend/src/service/v1"
+	"github.com/joinmouse/EasySwapBackend/src/types/v1"
+)
+
+// ReadOnlyStatusHandler 获取当前是否处于只读模式
+func ReadOnlyStatusHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		xhttp.OkJson(c, struct {
+			Result interface{} `json:"result"`
+		}{
+			Result: types.ReadOnlyResp{ReadOnly: middleware.IsReadOnly()},
+		})
+	}
+}
+
+// SetReadOnlyHandler 运行时切换只读模式
+// 只读模式下写接口返回503, 读接口不受影响; 切换只在当前进程内生效, 重启后恢复为配置的值
+func SetReadOnlyHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req types.ReadOnlyReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		middleware.SetReadOnly(*req.ReadOnly)
+		xzap.WithContext(c.Request.Context()).Info("read-only mode switched",
+			zap.Bool("read_only", *req.ReadOnly), zap.String("client_ip", c.ClientIP()))
+
+		xhttp.OkJson(c, struct {
+			Result interface{} `json:"result"`
+		}{
+			Result: types.ReadOnlyResp{ReadOnly: middleware.IsReadOnly()},
+		})
+	}
+}
+
+// SetCollectionHiddenHandler 设置集合的隐藏标记
+// 被隐藏的集合不出现在搜索和排名中, 按地址直接查询时仍然返回并带有 flagged 标记
+func SetCollectionHiddenHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		collectionAddr := c.Params.ByName("address")
+		if collectionAddr == "" {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		var req types.CollectionHiddenReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		reason := strings.TrimSpace(req.Reason)
+		res, err := service.SetCollectionHidden(c.Request.Context(), svcCtx, req.ChainID, collectionAddr, *req.Hidden, reason)
+		if err != nil {
+			handleServiceError(c, err, errcode.ErrUnexpected)
+			return
+		}
+
+		xzap.WithContext(c.Request.Context()).Info("collection hidden flag switched",
+			zap.Int("chain_id", req.ChainID), zap.String("collection", res.CollectionAddress),
+			zap.Bool("hidden", res.Hidden), zap.String("reason", reason), zap.String("client_ip", c.ClientIP()))
+
+		xhttp.OkJson(c, struct {
+			Result interface{} `json:"result"`
+		}{
+			Result: res,
+		})
+	}
+}
+
+// AdminConfigHandler 获取当前生效的配置和构建信息, 用于线上排查问题
+// 配置使用与启动日志相同的脱敏规则, 不会返回密码、密钥和 RPC 端点中的 API Key
+func AdminConfigHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		masked, err := svcCtx.C.Masked()
+		if err != nil {
+			xzap.WithContext(c.Request.Context()).Error("failed on mask config", zap.Error(err))
+			i18n.Error(c, errcode.ErrUnexpected)
+			return
+		}
+
+		okResult(c, types.AdminConfigResp{Config: masked, Build: buildinfo.Get()})
+	}
+}
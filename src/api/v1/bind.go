@@ -0,0 +1,107 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/joinmouse/EasySwapBase/errcode"
+
+	"github.com/joinmouse/EasySwapBackend/src/api/i18n"
+	"github.com/joinmouse/EasySwapBackend/src/types/v1"
+)
+
+func init() {
+	// 校验错误中使用 form/json 标签名作为字段名, 与客户端传入的参数名保持一致
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			for _, tag := range []string{"form", "json"} {
+				name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]
+				if name != "" && name != "-" {
+					return name
+				}
+			}
+			return field.Name
+		})
+	}
+}
+
+// chainQuery 链ID query 参数
+type chainQuery struct {
+	ChainID int `form:"chain_id" binding:"required"`
+}
+
+// pageQuery 分页 query 参数, 未传入时为 0
+type pageQuery struct {
+	Page     int `form:"page" binding:"omitempty,min=1"`
+	PageSize int `form:"page_size" binding:"omitempty,min=1"`
+}
+
+// bindQuery 将 query 参数绑定到 obj, 并按 binding 标签校验
+// 绑定或校验失败时返回400, 消息中逐个列出不合法的参数; 返回 false 时处理器应直接返回
+func bindQuery(c *gin.Context, obj interface{}) bool {
+	if err := c.ShouldBindQuery(obj); err != nil {
+		i18n.Error(c, i18n.Errorf(http.StatusBadRequest, "Invalid query params: %s.", queryErrorDetail(err)))
+		return false
+	}
+
+	return true
+}
+
+// queryErrorDetail 将绑定错误转换为面向客户端的参数错误描述
+func queryErrorDetail(err error) string {
+	var fieldErrs validator.ValidationErrors
+	if errors.As(err, &fieldErrs) {
+		details := make([]string, 0, len(fieldErrs))
+		for _, fe := range fieldErrs {
+			details = append(details, fieldErrorDetail(fe))
+		}
+		return strings.Join(details, "; ")
+	}
+
+	// 类型转换失败时 gin 不返回参数名, 只能给出不合法的值
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		if numErr.Func == "ParseBool" {
+			return fmt.Sprintf("%q is not a valid boolean", numErr.Num)
+		}
+		return fmt.Sprintf("%q is not a valid number", numErr.Num)
+	}
+
+	return err.Error()
+}
+
+// fieldErrorDetail 描述单个参数的校验错误
+func fieldErrorDetail(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", fe.Field())
+	case "min", "gte":
+		return fmt.Sprintf("%s must be at least %s", fe.Field(), fe.Param())
+	case "max", "lte":
+		return fmt.Sprintf("%s must be at most %s", fe.Field(), fe.Param())
+	case "gt":
+		return fmt.Sprintf("%s must be greater than %s", fe.Field(), fe.Param())
+	case "oneof":
+		return fmt.Sprintf("%s must be one of [%s]", fe.Field(), fe.Param())
+	case "numeric", "number":
+		return fmt.Sprintf("%s must be a number", fe.Field())
+	default:
+		return fmt.Sprintf("%s is invalid", fe.Field())
+	}
+}
+
+// priceParseError 价格以数字而不是字符串传入时返回明确的400错误, 否则返回 fallback
+func priceParseError(err error, fallback error) error {
+	if errors.Is(err, types.ErrPriceNotString) {
+		return errcode.NewCustomErr(`Prices must be sent as strings, e.g. "0.3".`, http.StatusBadRequest)
+	}
+
+	return fallback
+}
@@ -0,0 +1,72 @@
+package v1
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joinmouse/EasySwapBase/errcode"
+	"github.com/joinmouse/EasySwapBase/xhttp"
+
+	"github.com/joinmouse/EasySwapBackend/src/api/i18n"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+	"github.com/joinmouse/EasySwapBackend/src/service/v1"
+)
+
+// SupportedChainsHandler 获取后端支持的区块链列表
+// 返回链名称、链 ID、原生代币符号和订单簿合约地址，不包含 RPC 端点
+func SupportedChainsHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		xhttp.OkJson(c, struct {
+			Result interface{} `json:"result"`
+		}{
+			Result: service.GetSupportedChains(svcCtx),
+		})
+	}
+}
+
+// ChainFeesHandler 获取指定链的市场手续费、默认版税以及集合单独配置的版税
+func ChainFeesHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		chainID, err := strconv.Atoi(c.Params.ByName("chain_id"))
+		if err != nil {
+			i18n.Error(c, service.ErrInvalidChainID)
+			return
+		}
+
+		res, err := service.GetChainFees(c.Request.Context(), svcCtx, chainID)
+		if err != nil {
+			handleServiceError(c, err, errcode.ErrUnexpected)
+			return
+		}
+
+		xhttp.OkJson(c, struct {
+			Result interface{} `json:"result"`
+		}{
+			Result: res,
+		})
+	}
+}
+
+// ChainSyncStatusHandler 获取索引服务在指定链上的同步状态
+// 返回数据已同步到的区块、链上最新区块以及落后的区块数和估算秒数
+func ChainSyncStatusHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		chainID, err := strconv.Atoi(c.Params.ByName("chain_id"))
+		if err != nil {
+			i18n.Error(c, service.ErrInvalidChainID)
+			return
+		}
+
+		res, err := service.GetChainSyncStatus(c.Request.Context(), svcCtx, chainID)
+		if err != nil {
+			handleServiceError(c, err, errcode.ErrUnexpected)
+			return
+		}
+
+		xhttp.OkJson(c, struct {
+			Result interface{} `json:"result"`
+		}{
+			Result: res,
+		})
+	}
+}
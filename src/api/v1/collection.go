@@ -2,244 +2,536 @@ package v1
 
 import (
 	"encoding/json"
+	"fmt"
+	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 
 	"github.com/joinmouse/EasySwapBase/errcode"
 	"github.com/joinmouse/EasySwapBase/logger/xzap"
 
+	"github.com/joinmouse/EasySwapBackend/src/api/i18n"
+	"github.com/joinmouse/EasySwapBackend/src/common"
 	"github.com/joinmouse/EasySwapBackend/src/service/svc"
 	"github.com/joinmouse/EasySwapBackend/src/service/v1"
 	"github.com/joinmouse/EasySwapBackend/src/types/v1"
 	"github.com/joinmouse/EasySwapBase/xhttp"
 )
 
+// collectionItemsQuery 集合Item列表的 query 参数
+// page、page_size、min_price、max_price、listed_only 与 filters 中的同名字段合并, query 中的值优先
+type collectionItemsQuery struct {
+	pageQuery
+	Filters    string `form:"filters" binding:"required"`
+	MinPrice   string `form:"min_price"`
+	MaxPrice   string `form:"max_price"`
+	ListedOnly *bool  `form:"listed_only"`
+}
+
 func CollectionItemsHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		filterParam := c.Query("filters")
-		if filterParam == "" {
-			xhttp.Error(c, errcode.NewCustomErr("Filter param is nil."))
+		var query collectionItemsQuery
+		if !bindQuery(c, &query) {
 			return
 		}
 
 		var filter types.CollectionItemFilterParams
-		err := json.Unmarshal([]byte(filterParam), &filter)
+		err := json.Unmarshal([]byte(query.Filters), &filter)
 		if err != nil {
-			xhttp.Error(c, errcode.NewCustomErr("Filter param is nil."))
+			i18n.Error(c, priceParseError(err, errcode.NewCustomErr("Filter param is nil.")))
 			return
 		}
 
 		collectionAddr := c.Params.ByName("address")
 		if collectionAddr == "" {
-			xhttp.Error(c, errcode.ErrInvalidParams)
+			i18n.Error(c, errcode.ErrInvalidParams)
 			return
 		}
 
 		chain, ok := chainIDToChain[filter.ChainID]
 		if !ok {
-			xhttp.Error(c, errcode.ErrInvalidParams)
+			i18n.Error(c, service.ErrInvalidChainID)
+			return
+		}
+
+		if err := mergeItemFilterQuery(c, query, &filter); err != nil {
+			i18n.Error(c, err)
 			return
 		}
+
 		res, err := service.GetItems(c.Request.Context(), svcCtx, chain, filter, collectionAddr)
 		if err != nil {
-			xhttp.Error(c, errcode.ErrUnexpected)
+			i18n.Error(c, errcode.ErrUnexpected)
 			return
 		}
 		xhttp.OkJson(c, res)
 	}
 }
 
+// parsePriceRangeQuery 解析 query 中的 min_price、max_price 参数, 未传入时返回 nil
+func parsePriceRangeQuery(c *gin.Context) (*decimal.Decimal, *decimal.Decimal, error) {
+	return parsePriceRange(c.Query("min_price"), c.Query("max_price"))
+}
+
+// parsePriceRange 解析价格区间, 为空的一端返回 nil
+// 价格不能为负数, 且 min_price 不能大于 max_price
+func parsePriceRange(minValue, maxValue string) (*decimal.Decimal, *decimal.Decimal, error) {
+	var minPrice, maxPrice *decimal.Decimal
+	if minValue != "" {
+		price, err := decimal.NewFromString(minValue)
+		if err != nil {
+			return nil, nil, errcode.NewCustomErr("Invalid min_price.", http.StatusBadRequest)
+		}
+		minPrice = &price
+	}
+	if maxValue != "" {
+		price, err := decimal.NewFromString(maxValue)
+		if err != nil {
+			return nil, nil, errcode.NewCustomErr("Invalid max_price.", http.StatusBadRequest)
+		}
+		maxPrice = &price
+	}
+	if err := validatePriceRange(minPrice, maxPrice); err != nil {
+		return nil, nil, err
+	}
+
+	return minPrice, maxPrice, nil
+}
+
+// validatePriceRange 校验价格区间: 价格不能为负数, 且 min_price 不能大于 max_price
+func validatePriceRange(minPrice, maxPrice *decimal.Decimal) error {
+	if (minPrice != nil && minPrice.IsNegative()) || (maxPrice != nil && maxPrice.IsNegative()) {
+		return errcode.NewCustomErr("Price must not be negative.", http.StatusBadRequest)
+	}
+	if minPrice != nil && maxPrice != nil && minPrice.GreaterThan(*maxPrice) {
+		return errcode.NewCustomErr("min_price must not be greater than max_price.", http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+// mergeItemFilterQuery 将 query 参数合并到集合Item列表的过滤条件中
+// 特征过滤格式为 trait[Background]=Blue&trait[Eyes]=Laser, 同一特征可以重复传入多个值
+func mergeItemFilterQuery(c *gin.Context, query collectionItemsQuery, filter *types.CollectionItemFilterParams) error {
+	if query.Page > 0 {
+		filter.Page = query.Page
+	}
+	if query.PageSize > 0 {
+		filter.PageSize = query.PageSize
+	}
+	filter.Page, filter.PageSize = normalizePageParams(filter.Page, filter.PageSize)
+
+	for key, values := range c.Request.URL.Query() {
+		if !strings.HasPrefix(key, "trait[") || !strings.HasSuffix(key, "]") {
+			continue
+		}
+		name := strings.TrimSpace(key[len("trait[") : len(key)-1])
+		if name == "" {
+			return errcode.NewCustomErr("Invalid trait filter.", http.StatusBadRequest)
+		}
+		if filter.Traits == nil {
+			filter.Traits = make(map[string][]string)
+		}
+		for _, value := range values {
+			if value = strings.TrimSpace(value); value != "" {
+				filter.Traits[name] = append(filter.Traits[name], value)
+			}
+		}
+	}
+	if len(filter.Traits) > service.MaxItemTraitFilters {
+		return errcode.NewCustomErr(fmt.Sprintf("Too many trait filters, the limit is %d.", service.MaxItemTraitFilters), http.StatusBadRequest)
+	}
+
+	minPrice, maxPrice, err := parsePriceRange(query.MinPrice, query.MaxPrice)
+	if err != nil {
+		return err
+	}
+	if minPrice != nil {
+		filter.MinPrice = &types.Price{Decimal: *minPrice}
+	}
+	if maxPrice != nil {
+		filter.MaxPrice = &types.Price{Decimal: *maxPrice}
+	}
+	if err := validatePriceRange(filter.MinPrice.DecimalPtr(), filter.MaxPrice.DecimalPtr()); err != nil {
+		return err
+	}
+
+	if query.ListedOnly != nil {
+		filter.ListedOnly = *query.ListedOnly
+	}
+
+	return nil
+}
+
 func CollectionBidsHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		filterParam := c.Query("filters")
 		if filterParam == "" {
-			xhttp.Error(c, errcode.NewCustomErr("Filter param is nil."))
+			i18n.Error(c, errcode.NewCustomErr("Filter param is nil."))
 			return
 		}
 
 		var filter types.CollectionBidFilterParams
 		err := json.Unmarshal([]byte(filterParam), &filter)
 		if err != nil {
-			xhttp.Error(c, errcode.NewCustomErr("Filter param is nil."))
+			i18n.Error(c, errcode.NewCustomErr("Filter param is nil."))
 			return
 		}
 
 		collectionAddr := c.Params.ByName("address")
 		if collectionAddr == "" {
-			xhttp.Error(c, errcode.ErrInvalidParams)
+			i18n.Error(c, errcode.ErrInvalidParams)
 			return
 		}
 
 		chain, ok := chainIDToChain[int(filter.ChainID)]
 		if !ok {
-			xhttp.Error(c, errcode.ErrInvalidParams)
+			i18n.Error(c, service.ErrInvalidChainID)
 			return
 		}
 
-		res, err := service.GetBids(c.Request.Context(), svcCtx, chain, collectionAddr, filter.Page, filter.PageSize)
+		page, pageSize := normalizePageParams(filter.Page, filter.PageSize)
+		res, err := service.GetBids(c.Request.Context(), svcCtx, chain, collectionAddr, page, pageSize, filter.ResolveEns, filter.RefreshEns)
 		if err != nil {
-			xhttp.Error(c, errcode.ErrUnexpected)
+			i18n.Error(c, errcode.ErrUnexpected)
 			return
 		}
 		xhttp.OkJson(c, res)
 	}
 }
 
+func CollectionAggregatedBidsHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		collectionAddr := c.Params.ByName("address")
+		if collectionAddr == "" {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		chainID, err := strconv.ParseInt(c.Query("chain_id"), 10, 64)
+		if err != nil {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		chain, ok := chainIDToChain[int(chainID)]
+		if !ok {
+			i18n.Error(c, service.ErrInvalidChainID)
+			return
+		}
+
+		limit := service.DefaultBidLevels
+		if l := c.Query("limit"); l != "" {
+			limit, err = strconv.Atoi(l)
+			if err != nil || limit <= 0 {
+				i18n.Error(c, errcode.ErrInvalidParams)
+				return
+			}
+		}
+
+		res, err := service.GetAggregatedBids(c.Request.Context(), svcCtx, chain, collectionAddr, limit)
+		if err != nil {
+			i18n.Error(c, errcode.ErrUnexpected)
+			return
+		}
+
+		xhttp.OkJson(c, struct {
+			Result interface{} `json:"result"`
+		}{
+			Result: res,
+		})
+	}
+}
+
+// CollectionAggregatedListingsHandler 获取按价格档位聚合的集合挂单，用于绘制深度图的卖方
+func CollectionAggregatedListingsHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		collectionAddr := c.Params.ByName("address")
+		if collectionAddr == "" {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		var query struct {
+			chainQuery
+			Limit int `form:"limit" binding:"omitempty,min=1"`
+		}
+		if !bindQuery(c, &query) {
+			return
+		}
+
+		chain, ok := chainIDToChain[query.ChainID]
+		if !ok {
+			i18n.Error(c, service.ErrInvalidChainID)
+			return
+		}
+
+		res, err := service.GetAggregatedListings(c.Request.Context(), svcCtx, chain, collectionAddr, query.Limit)
+		if err != nil {
+			handleServiceError(c, err, errcode.ErrUnexpected)
+			return
+		}
+
+		xhttp.OkJson(c, struct {
+			Result interface{} `json:"result"`
+		}{
+			Result: res,
+		})
+	}
+}
+
 func CollectionItemBidsHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		filterParam := c.Query("filters")
 		if filterParam == "" {
-			xhttp.Error(c, errcode.NewCustomErr("Filter param is nil."))
+			i18n.Error(c, errcode.NewCustomErr("Filter param is nil."))
 			return
 		}
 
 		var filter types.CollectionBidFilterParams
 		err := json.Unmarshal([]byte(filterParam), &filter)
 		if err != nil {
-			xhttp.Error(c, errcode.NewCustomErr("Filter param is nil."))
+			i18n.Error(c, errcode.NewCustomErr("Filter param is nil."))
 			return
 		}
 
 		collectionAddr := c.Params.ByName("address")
 		if collectionAddr == "" {
-			xhttp.Error(c, errcode.ErrInvalidParams)
+			i18n.Error(c, errcode.ErrInvalidParams)
 			return
 		}
 
 		tokenID := c.Params.ByName("token_id")
 		if tokenID == "" {
-			xhttp.Error(c, errcode.ErrInvalidParams)
+			i18n.Error(c, errcode.ErrInvalidParams)
 			return
 		}
 
 		chain, ok := chainIDToChain[int(filter.ChainID)]
 		if !ok {
-			xhttp.Error(c, errcode.ErrInvalidParams)
+			i18n.Error(c, service.ErrInvalidChainID)
 			return
 		}
 
 		res, err := service.GetItemBidsInfo(c.Request.Context(), svcCtx, chain, collectionAddr, tokenID, filter.Page, filter.PageSize)
 		if err != nil {
-			xhttp.Error(c, errcode.ErrUnexpected)
+			i18n.Error(c, errcode.ErrUnexpected)
 			return
 		}
-		xhttp.OkJson(c, res)
+		okData(c, res)
 	}
 }
 
-func ItemDetailHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+// ItemOffersHandler 获取适用于指定 NFT 物品的所有有效出价
+// 合并Item出价和集合出价, 按扣除费用后卖家实际到手的金额降序排列
+func ItemOffersHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		collectionAddr := c.Params.ByName("address")
 		if collectionAddr == "" {
-			xhttp.Error(c, errcode.ErrInvalidParams)
+			i18n.Error(c, errcode.ErrInvalidParams)
 			return
 		}
 
 		tokenID := c.Params.ByName("token_id")
 		if tokenID == "" {
-			xhttp.Error(c, errcode.ErrInvalidParams)
+			i18n.Error(c, errcode.ErrInvalidParams)
 			return
 		}
 
-		chainID, err := strconv.ParseInt(c.Query("chain_id"), 10, 64)
-		if err != nil {
-			xhttp.Error(c, errcode.ErrInvalidParams)
+		var query struct {
+			chainQuery
+			Limit int `form:"limit" binding:"omitempty,min=1"`
+		}
+		if !bindQuery(c, &query) {
 			return
 		}
 
-		chain, ok := chainIDToChain[int(chainID)]
+		chain, ok := chainIDToChain[query.ChainID]
 		if !ok {
-			xhttp.Error(c, errcode.ErrInvalidParams)
+			i18n.Error(c, service.ErrInvalidChainID)
 			return
 		}
 
-		res, err := service.GetItem(c.Request.Context(), svcCtx, chain, int(chainID), collectionAddr, tokenID)
+		res, err := service.GetItemOffers(c.Request.Context(), svcCtx, chain, query.ChainID, collectionAddr, tokenID, query.Limit)
 		if err != nil {
-			xhttp.Error(c, errcode.NewCustomErr("get item error"))
+			handleServiceError(c, err, errcode.ErrUnexpected)
 			return
-
 		}
-		xhttp.OkJson(c, res)
+
+		okResult(c, res)
 	}
 }
 
-func ItemTopTraitPriceHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+// AcceptOfferPreviewHandler 预估持有人接受指定 NFT 当前最优出价后的到手金额
+// 返回最优出价、市场手续费和版税明细以及实际到手金额, 没有可接受的出价时 has_offer 为 false
+func AcceptOfferPreviewHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		collectionAddr := c.Params.ByName("address")
 		if collectionAddr == "" {
-			xhttp.Error(c, errcode.ErrInvalidParams)
+			i18n.Error(c, errcode.ErrInvalidParams)
 			return
 		}
 
-		filterParam := c.Query("filters")
-		if filterParam == "" {
-			xhttp.Error(c, errcode.NewCustomErr("Filter param is nil."))
+		tokenID := c.Params.ByName("token_id")
+		if tokenID == "" {
+			i18n.Error(c, errcode.ErrInvalidParams)
 			return
 		}
 
-		var filter types.TopTraitFilterParams
-		err := json.Unmarshal([]byte(filterParam), &filter)
+		var query chainQuery
+		if !bindQuery(c, &query) {
+			return
+		}
+
+		chain, ok := chainIDToChain[query.ChainID]
+		if !ok {
+			i18n.Error(c, service.ErrInvalidChainID)
+			return
+		}
+
+		res, err := service.PreviewAcceptOffer(c.Request.Context(), svcCtx, chain, query.ChainID, collectionAddr, tokenID)
 		if err != nil {
-			xhttp.Error(c, errcode.NewCustomErr("Filter param is nil."))
+			handleServiceError(c, err, errcode.ErrUnexpected)
 			return
 		}
 
-		chain, ok := chainIDToChain[filter.ChainID]
+		okResult(c, res)
+	}
+}
+
+func ItemDetailHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		collectionAddr := c.Params.ByName("address")
+		if collectionAddr == "" {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		tokenID := c.Params.ByName("token_id")
+		if tokenID == "" {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		var query struct {
+			chainQuery
+			Refresh bool `form:"refresh"` // 忽略链上查询的负缓存, 重新确认NFT是否存在
+		}
+		if !bindQuery(c, &query) {
+			return
+		}
+
+		chain, ok := chainIDToChain[query.ChainID]
 		if !ok {
-			xhttp.Error(c, errcode.ErrInvalidParams)
+			i18n.Error(c, service.ErrInvalidChainID)
 			return
 		}
 
-		res, err := service.GetItemTopTraitPrice(c.Request.Context(), svcCtx, chain, collectionAddr, filter.TokenIds)
+		res, err := service.GetItem(c.Request.Context(), svcCtx, chain, query.ChainID, collectionAddr, tokenID, query.Refresh)
 		if err != nil {
-			xhttp.Error(c, errcode.NewCustomErr("get item error"))
+			handleServiceError(c, err, errcode.NewCustomErr("get item error"))
 			return
+
 		}
-		xhttp.OkJson(c, res)
+		okResult(c, res.Result)
 	}
 }
 
-func HistorySalesHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+// ItemDetailBatchHandler 批量查询NFT Item详情
+// 请求体为 {"token_ids": ["1","2",...]},最多50个,返回token_id到详情的映射
+func ItemDetailBatchHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		collectionAddr := c.Params.ByName("address")
 		if collectionAddr == "" {
-			xhttp.Error(c, errcode.ErrInvalidParams)
+			i18n.Error(c, errcode.ErrInvalidParams)
 			return
 		}
 
 		chainID, err := strconv.ParseInt(c.Query("chain_id"), 10, 64)
 		if err != nil {
-			xhttp.Error(c, errcode.ErrInvalidParams)
+			i18n.Error(c, errcode.ErrInvalidParams)
 			return
 		}
 
 		chain, ok := chainIDToChain[int(chainID)]
 		if !ok {
-			xhttp.Error(c, errcode.ErrInvalidParams)
+			i18n.Error(c, service.ErrInvalidChainID)
+			return
+		}
+
+		var req types.ItemDetailBatchReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			i18n.Error(c, errcode.ErrInvalidParams)
 			return
 		}
 
-		duration := c.Query("duration")
-		if duration != "" {
-			validParams := map[string]bool{
-				"24h": true,
-				"7d":  true,
-				"30d": true,
+		// 去除空值和重复的token id, 统一为十进制格式后再去重
+		var tokenIDs []string
+		seen := make(map[string]bool)
+		for _, tokenID := range req.TokenIDs {
+			if tokenID == "" {
+				continue
 			}
-			if ok := validParams[duration]; !ok {
-				xzap.WithContext(c).Error("duration parse error: ", zap.String("duration", duration))
-				xhttp.Error(c, errcode.ErrInvalidParams)
+			tokenID, err := common.NormalizeTokenID(tokenID)
+			if err != nil {
+				i18n.Error(c, errcode.NewCustomErr("Invalid token_ids."))
 				return
 			}
-		} else {
-			duration = "7d"
+			if seen[tokenID] {
+				continue
+			}
+			seen[tokenID] = true
+			tokenIDs = append(tokenIDs, tokenID)
+		}
+		if len(tokenIDs) == 0 {
+			i18n.Error(c, errcode.NewCustomErr("token_ids is empty."))
+			return
+		}
+		if len(tokenIDs) > service.MaxBatchItemDetail {
+			i18n.Error(c, errcode.NewCustomErr(fmt.Sprintf("token_ids exceeds the limit of %d.", service.MaxBatchItemDetail)))
+			return
+		}
+
+		res, err := service.GetItemsDetail(c.Request.Context(), svcCtx, chain, int(chainID), collectionAddr, tokenIDs)
+		if err != nil {
+			i18n.Error(c, errcode.NewCustomErr("get items detail error"))
+			return
+		}
+		okResult(c, res.Result)
+	}
+}
+
+// CollectionTraitsHandler 分页获取集合的 Trait目录
+// 返回每个 Trait类别、该类别所有可能的值以及拥有每个值的Item数量, 供前端渲染 Trait筛选器
+// 按类别分页, page_size 为每页的类别数量
+func CollectionTraitsHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		collectionAddr := c.Params.ByName("address")
+		if collectionAddr == "" {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		var query chainQuery
+		if !bindQuery(c, &query) {
+			return
+		}
+
+		chain, ok := chainIDToChain[query.ChainID]
+		if !ok {
+			i18n.Error(c, service.ErrInvalidChainID)
+			return
 		}
 
-		res, err := service.GetHistorySalesPrice(c.Request.Context(), svcCtx, chain, collectionAddr, duration)
+		page, pageSize := parsePageParams(c, DefaultPage, DefaultPageSize)
+		res, err := service.GetCollectionTraits(c.Request.Context(), svcCtx, chain, collectionAddr, page, pageSize)
 		if err != nil {
-			xhttp.Error(c, errcode.NewCustomErr("get history sales price error"))
+			handleServiceError(c, err, errcode.ErrUnexpected)
 			return
 		}
 
@@ -251,182 +543,748 @@ func HistorySalesHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 	}
 }
 
-func ItemTraitsHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+// CollectionOwnersHandler 分页获取集合的持有人及持有数量, 并返回持有人分布和头部持有人集中度
+func CollectionOwnersHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		collectionAddr := c.Params.ByName("address")
 		if collectionAddr == "" {
-			xhttp.Error(c, errcode.ErrInvalidParams)
-			return
-		}
-
-		tokenID := c.Params.ByName("token_id")
-		if tokenID == "" {
-			xhttp.Error(c, errcode.ErrInvalidParams)
+			i18n.Error(c, errcode.ErrInvalidParams)
 			return
 		}
 
 		chainID, err := strconv.ParseInt(c.Query("chain_id"), 10, 64)
 		if err != nil {
-			xhttp.Error(c, errcode.ErrInvalidParams)
+			i18n.Error(c, errcode.ErrInvalidParams)
 			return
 		}
 
 		chain, ok := chainIDToChain[int(chainID)]
 		if !ok {
-			xhttp.Error(c, errcode.ErrInvalidParams)
+			i18n.Error(c, service.ErrInvalidChainID)
 			return
 		}
 
-		itemTraits, err := service.GetItemTraits(c.Request.Context(), svcCtx, chain, collectionAddr, tokenID)
+		page, pageSize := parsePageParams(c, DefaultPage, DefaultPageSize)
+		res, err := service.GetCollectionOwners(c.Request.Context(), svcCtx, chain, collectionAddr, page, pageSize)
 		if err != nil {
-			xhttp.Error(c, errcode.NewCustomErr("get item traits error"))
+			handleServiceError(c, err, errcode.NewCustomErr("get collection owners error"))
 			return
 		}
 
-		xhttp.OkJson(c, types.ItemTraitsResp{Result: itemTraits})
+		xhttp.OkJson(c, struct {
+			Result interface{} `json:"result"`
+		}{
+			Result: res,
+		})
 	}
 }
 
-func ItemOwnerHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+// OwnerItemsHandler 分页获取指定地址在集合中持有的 Item 详情
+// owner 路径参数由路由中间件校验并统一为校验和格式, ERC-1155 持有数量为0的 Item 不返回
+func OwnerItemsHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		collectionAddr := c.Params.ByName("address")
-		if collectionAddr == "" {
-			xhttp.Error(c, errcode.ErrInvalidParams)
-			return
-		}
-
-		tokenID := c.Params.ByName("token_id")
-		if tokenID == "" {
-			xhttp.Error(c, errcode.ErrInvalidParams)
+		owner := c.Params.ByName("owner")
+		if collectionAddr == "" || owner == "" {
+			i18n.Error(c, errcode.ErrInvalidParams)
 			return
 		}
 
 		chainID, err := strconv.ParseInt(c.Query("chain_id"), 10, 64)
 		if err != nil {
-			xhttp.Error(c, errcode.ErrInvalidParams)
+			i18n.Error(c, errcode.ErrInvalidParams)
 			return
 		}
 
 		chain, ok := chainIDToChain[int(chainID)]
 		if !ok {
-			xhttp.Error(c, errcode.ErrInvalidParams)
+			i18n.Error(c, service.ErrInvalidChainID)
 			return
 		}
 
-		owner, err := service.GetItemOwner(c.Request.Context(), svcCtx, chainID, chain, collectionAddr, tokenID)
+		page, pageSize := parsePageParams(c, DefaultPage, DefaultPageSize)
+		res, err := service.GetOwnerItems(c.Request.Context(), svcCtx, chain, int(chainID), collectionAddr, owner, page, pageSize)
 		if err != nil {
-			xhttp.Error(c, errcode.NewCustomErr("get item owner error"))
+			handleServiceError(c, err, errcode.NewCustomErr("get owner items error"))
 			return
 		}
 
-		xhttp.OkJson(c, struct {
-			Result interface{} `json:"result"`
-		}{
-			Result: owner,
-		})
+		okResult(c, res)
 	}
 }
 
-func GetItemImageHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+// CollectionItemBidsByTraitHandler 按 Trait值分组获取集合内Item出价的最高价格和剩余数量
+// 订单簿中没有 Trait出价, 返回的是拥有该 Trait值的 Item 上的Item出价
+func CollectionItemBidsByTraitHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		collectionAddr := c.Params.ByName("address")
 		if collectionAddr == "" {
-			xhttp.Error(c, errcode.ErrInvalidParams)
-			return
-		}
-
-		tokenID := c.Params.ByName("token_id")
-		if tokenID == "" {
-			xhttp.Error(c, errcode.ErrInvalidParams)
+			i18n.Error(c, errcode.ErrInvalidParams)
 			return
 		}
 
 		chainID, err := strconv.ParseInt(c.Query("chain_id"), 10, 64)
 		if err != nil {
-			xhttp.Error(c, errcode.ErrInvalidParams)
+			i18n.Error(c, errcode.ErrInvalidParams)
 			return
 		}
 
 		chain, ok := chainIDToChain[int(chainID)]
 		if !ok {
-			xhttp.Error(c, errcode.ErrInvalidParams)
+			i18n.Error(c, service.ErrInvalidChainID)
 			return
 		}
 
-		result, err := service.GetItemImage(c.Request.Context(), svcCtx, chain, collectionAddr, tokenID)
+		res, err := service.GetCollectionItemBidsByTrait(c.Request.Context(), svcCtx, chain, collectionAddr)
 		if err != nil {
-			xhttp.Error(c, errcode.NewCustomErr("failed on get item image"))
+			handleServiceError(c, err, errcode.ErrUnexpected)
 			return
 		}
 
 		xhttp.OkJson(c, struct {
 			Result interface{} `json:"result"`
-		}{Result: result})
+		}{
+			Result: res,
+		})
 	}
 }
 
-func ItemMetadataRefreshHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+func ItemTopTraitPriceHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		chainId, err := strconv.ParseInt(c.Query("chain_id"), 10, 32)
-		if err != nil {
-			xhttp.Error(c, errcode.ErrInvalidParams)
+		collectionAddr := c.Params.ByName("address")
+		if collectionAddr == "" {
+			i18n.Error(c, errcode.ErrInvalidParams)
 			return
 		}
 
-		chain, ok := chainIDToChain[int(chainId)]
-		if !ok {
-			xhttp.Error(c, errcode.ErrInvalidParams)
-			return
+		// filters 可选, 未指定 token_ids 时返回合集维度的 Trait估值排行
+		var filter types.TopTraitFilterParams
+		if filterParam := c.Query("filters"); filterParam != "" {
+			if err := json.Unmarshal([]byte(filterParam), &filter); err != nil {
+				i18n.Error(c, errcode.NewCustomErr("Filter param is nil."))
+				return
+			}
 		}
 
-		collectionAddr := c.Params.ByName("address")
-		if collectionAddr == "" {
-			xhttp.Error(c, errcode.ErrInvalidParams)
-			return
+		// chain_id 查询参数优先, 兼容旧版放在 filters 中的 chain_id
+		chainID := filter.ChainID
+		if id := c.Query("chain_id"); id != "" {
+			parsed, err := strconv.Atoi(id)
+			if err != nil {
+				i18n.Error(c, errcode.ErrInvalidParams)
+				return
+			}
+			chainID = parsed
 		}
 
-		tokenId := c.Params.ByName("token_id")
-		if collectionAddr == "" {
-			xhttp.Error(c, errcode.ErrInvalidParams)
+		chain, ok := chainIDToChain[chainID]
+		if !ok {
+			i18n.Error(c, service.ErrInvalidChainID)
 			return
 		}
 
-		err = service.RefreshItemMetadata(c.Request.Context(), svcCtx, chain, chainId, collectionAddr, tokenId)
+		limit := service.DefaultTopTraitLimit
+		if l := c.Query("limit"); l != "" {
+			var err error
+			limit, err = strconv.Atoi(l)
+			if err != nil || limit <= 0 {
+				i18n.Error(c, errcode.ErrInvalidParams)
+				return
+			}
+		}
+
+		res, err := service.GetItemTopTraitPrice(c.Request.Context(), svcCtx, chain, collectionAddr, filter.TokenIds, limit)
 		if err != nil {
-			xhttp.Error(c, err)
+			handleServiceError(c, err, errcode.NewCustomErr("get item error"))
 			return
 		}
-
-		successStr := "Success to joined the refresh queue and waiting for refresh."
-		xhttp.OkJson(c, types.CommonResp{Result: successStr})
+		okResult(c, res.Result)
 	}
 }
 
-func CollectionDetailHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+func HistorySalesHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		chainID, err := strconv.ParseInt(c.Query("chain_id"), 10, 32)
+		collectionAddr := c.Params.ByName("address")
+		if collectionAddr == "" {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		chainID, err := strconv.ParseInt(c.Query("chain_id"), 10, 64)
 		if err != nil {
-			xhttp.Error(c, errcode.ErrInvalidParams)
+			i18n.Error(c, errcode.ErrInvalidParams)
 			return
 		}
 
 		chain, ok := chainIDToChain[int(chainID)]
 		if !ok {
-			xhttp.Error(c, errcode.ErrInvalidParams)
+			i18n.Error(c, service.ErrInvalidChainID)
 			return
 		}
 
-		collectionAddr := c.Params.ByName("address")
-		if collectionAddr == "" {
-			xhttp.Error(c, errcode.ErrInvalidParams)
+		// since/until 指定时间区间时忽略 time_range
+		between, err := parseTimeRange(c)
+		if err != nil {
+			i18n.Error(c, err)
 			return
 		}
-		res, err := service.GetCollectionDetail(c.Request.Context(), svcCtx, chain, collectionAddr)
+
+		// time_range 为空时兼容旧参数 duration
+		timeRange := c.Query("time_range")
+		if timeRange == "" {
+			timeRange = c.DefaultQuery("duration", service.DefaultHistorySalesTimeRange)
+		}
+		if _, ok := service.HistorySalesTimeRanges[timeRange]; !ok {
+			xzap.WithContext(c).Error("time range parse error: ", zap.String("time_range", timeRange))
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		minPrice, maxPrice, err := parsePriceRangeQuery(c)
 		if err != nil {
-			xhttp.Error(c, errcode.ErrUnexpected)
+			i18n.Error(c, err)
 			return
 		}
 
-		xhttp.OkJson(c, res)
+		page, pageSize := parsePageParams(c, DefaultPage, DefaultPageSize)
+		res, err := service.GetHistorySalesPrice(c.Request.Context(), svcCtx, chain, collectionAddr, timeRange, between, minPrice, maxPrice, page, pageSize)
+		if err != nil {
+			handleServiceError(c, err, errcode.NewCustomErr("get history sales price error"))
+			return
+		}
+
+		xhttp.OkJson(c, struct {
+			Result interface{} `json:"result"`
+		}{
+			Result: res,
+		})
+	}
+}
+
+// ItemPriceHistoryHandler 分页获取单个NFT Item的历史成交记录, 按成交时间倒序排列
+func ItemPriceHistoryHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		collectionAddr := c.Params.ByName("address")
+		if collectionAddr == "" {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		tokenID := c.Params.ByName("token_id")
+		if tokenID == "" {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		var query struct {
+			chainQuery
+			pageQuery
+		}
+		if !bindQuery(c, &query) {
+			return
+		}
+
+		chain, ok := chainIDToChain[query.ChainID]
+		if !ok {
+			i18n.Error(c, service.ErrInvalidChainID)
+			return
+		}
+
+		page, pageSize := normalizePageParams(query.Page, query.PageSize)
+		res, err := service.GetItemPriceHistory(c.Request.Context(), svcCtx, chain, collectionAddr, tokenID, page, pageSize)
+		if err != nil {
+			i18n.Error(c, errcode.NewCustomErr("get item price history error"))
+			return
+		}
+
+		okResult(c, res)
+	}
+}
+
+func FloorPriceHistoryHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		collectionAddr := c.Params.ByName("address")
+		if collectionAddr == "" {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		chainID, err := strconv.ParseInt(c.Query("chain_id"), 10, 64)
+		if err != nil {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		chain, ok := chainIDToChain[int(chainID)]
+		if !ok {
+			i18n.Error(c, service.ErrInvalidChainID)
+			return
+		}
+
+		interval, ok := service.FloorHistoryIntervals[c.DefaultQuery("interval", "1h")]
+		if !ok {
+			xzap.WithContext(c).Error("interval parse error: ", zap.String("interval", c.Query("interval")))
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		// since/until 指定时间区间时忽略 range, 区间同样不能超过地板价历史的最大范围
+		between, err := parseTimeRange(c)
+		if err != nil {
+			i18n.Error(c, err)
+			return
+		}
+		now := time.Now().Unix()
+		if between == nil {
+			rangeSec, err := service.ParseFloorHistoryRange(c.DefaultQuery("range", "7d"))
+			if err != nil || rangeSec < interval {
+				xzap.WithContext(c).Error("range parse error: ", zap.String("range", c.Query("range")))
+				i18n.Error(c, errcode.ErrInvalidParams)
+				return
+			}
+			between = &types.TimeRange{Since: now - rangeSec, Until: now}
+		} else {
+			// 未传入 until 时截止到当前时间
+			if between.Until == 0 {
+				between.Until = now
+			}
+			if rangeSec := between.Until - between.Since; rangeSec < interval || rangeSec > service.MaxFloorHistoryRange {
+				i18n.Error(c, errcode.ErrInvalidParams)
+				return
+			}
+		}
+
+		res, err := service.GetFloorPriceHistory(c.Request.Context(), svcCtx, chain, collectionAddr, interval, *between)
+		if err != nil {
+			i18n.Error(c, errcode.NewCustomErr("get floor price history error"))
+			return
+		}
+
+		xhttp.OkJson(c, struct {
+			Result interface{} `json:"result"`
+		}{
+			Result: res,
+		})
+	}
+}
+
+func CollectionSearchHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		chainID, err := strconv.ParseInt(c.Query("chain_id"), 10, 64)
+		if err != nil {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		chain, ok := chainIDToChain[int(chainID)]
+		if !ok {
+			i18n.Error(c, service.ErrInvalidChainID)
+			return
+		}
+
+		keyword := strings.TrimSpace(c.Query("q"))
+		if len([]rune(keyword)) < service.MinSearchKeywordLen {
+			i18n.Error(c, errcode.NewCustomErr(fmt.Sprintf("query must be at least %d characters.", service.MinSearchKeywordLen)))
+			return
+		}
+
+		limit := service.DefaultSearchLimit
+		if l := c.Query("limit"); l != "" {
+			limit, err = strconv.Atoi(l)
+			if err != nil || limit <= 0 {
+				i18n.Error(c, errcode.ErrInvalidParams)
+				return
+			}
+		}
+
+		includeHidden, err := parseIncludeHidden(c, svcCtx)
+		if err != nil {
+			i18n.Error(c, err)
+			return
+		}
+
+		res, err := service.SearchCollections(c.Request.Context(), svcCtx, chain, keyword, limit, includeHidden)
+		if err != nil {
+			i18n.Error(c, errcode.NewCustomErr("search collections error"))
+			return
+		}
+
+		xhttp.OkJson(c, struct {
+			Result interface{} `json:"result"`
+		}{
+			Result: res,
+		})
+	}
+}
+
+func ItemTraitsHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		collectionAddr := c.Params.ByName("address")
+		if collectionAddr == "" {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		tokenID := c.Params.ByName("token_id")
+		if tokenID == "" {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		chainID, err := strconv.ParseInt(c.Query("chain_id"), 10, 64)
+		if err != nil {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		chain, ok := chainIDToChain[int(chainID)]
+		if !ok {
+			i18n.Error(c, service.ErrInvalidChainID)
+			return
+		}
+
+		itemTraits, err := service.GetItemTraits(c.Request.Context(), svcCtx, chain, collectionAddr, tokenID)
+		if err != nil {
+			i18n.Error(c, errcode.NewCustomErr("get item traits error"))
+			return
+		}
+
+		okResult(c, itemTraits)
+	}
+}
+
+func ItemRarityHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		collectionAddr := c.Params.ByName("address")
+		if collectionAddr == "" {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		tokenID := c.Params.ByName("token_id")
+		if tokenID == "" {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		chainID, err := strconv.ParseInt(c.Query("chain_id"), 10, 64)
+		if err != nil {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		chain, ok := chainIDToChain[int(chainID)]
+		if !ok {
+			i18n.Error(c, service.ErrInvalidChainID)
+			return
+		}
+
+		rarity, err := service.GetItemRarity(c.Request.Context(), svcCtx, chain, collectionAddr, tokenID)
+		if err != nil {
+			i18n.Error(c, errcode.NewCustomErr("get item rarity error"))
+			return
+		}
+
+		okResult(c, rarity)
+	}
+}
+
+func ItemOwnerHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		collectionAddr := c.Params.ByName("address")
+		if collectionAddr == "" {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		tokenID := c.Params.ByName("token_id")
+		if tokenID == "" {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		chainID, err := strconv.ParseInt(c.Query("chain_id"), 10, 64)
+		if err != nil {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		chain, ok := chainIDToChain[int(chainID)]
+		if !ok {
+			i18n.Error(c, service.ErrInvalidChainID)
+			return
+		}
+
+		owner, err := service.GetItemOwner(c.Request.Context(), svcCtx, chainID, chain, collectionAddr, tokenID)
+		if err != nil {
+			handleServiceError(c, err, errcode.NewCustomErr("get item owner error"))
+			return
+		}
+
+		okResult(c, owner)
+	}
+}
+
+func GetItemImageHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		collectionAddr := c.Params.ByName("address")
+		if collectionAddr == "" {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		tokenID := c.Params.ByName("token_id")
+		if tokenID == "" {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		chainID, err := strconv.ParseInt(c.Query("chain_id"), 10, 64)
+		if err != nil {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		chain, ok := chainIDToChain[int(chainID)]
+		if !ok {
+			i18n.Error(c, service.ErrInvalidChainID)
+			return
+		}
+
+		result, err := service.GetItemImage(c.Request.Context(), svcCtx, chain, collectionAddr, tokenID)
+		if err != nil {
+			handleServiceError(c, err, errcode.NewCustomErr("failed on get item image"))
+			return
+		}
+
+		okResult(c, result)
+	}
+}
+
+func ItemMetadataRefreshHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		chainId, err := strconv.ParseInt(c.Query("chain_id"), 10, 32)
+		if err != nil {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		chain, ok := chainIDToChain[int(chainId)]
+		if !ok {
+			i18n.Error(c, service.ErrInvalidChainID)
+			return
+		}
+
+		collectionAddr := c.Params.ByName("address")
+		if collectionAddr == "" {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		tokenId := c.Params.ByName("token_id")
+		if tokenId == "" {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		res, err := service.RefreshItemMetadata(c.Request.Context(), svcCtx, chain, chainId, collectionAddr, tokenId)
+		if err != nil {
+			i18n.Error(c, err)
+			return
+		}
+
+		okData(c, res)
+	}
+}
+
+// CollectionIndexHandler 提交集合收录请求
+// 请求体: chain_id 和集合合约地址, 合约需要通过 ERC-165 声明支持 ERC-721 或 ERC-1155
+// 集合已收录时返回 200, 否则创建收录任务并返回 202, 任务进度通过 GET /jobs/:id 查询
+// 同一集合的并发请求共享同一个任务
+func CollectionIndexHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req types.CollectionIndexReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		chain, ok := chainIDToChain[req.ChainID]
+		if !ok {
+			i18n.Error(c, service.ErrInvalidChainID)
+			return
+		}
+
+		collectionAddr, err := common.UnifyAddress(req.Address)
+		if err != nil {
+			i18n.Error(c, i18n.Errorf(http.StatusBadRequest, "Invalid %s: %s", "address", req.Address))
+			return
+		}
+
+		res, err := service.RequestCollectionIndex(c.Request.Context(), svcCtx, chain, req.ChainID, collectionAddr)
+		if err != nil {
+			handleServiceError(c, err, errcode.NewCustomErr("index collection error"))
+			return
+		}
+
+		if res.Indexed {
+			okResult(c, res)
+			return
+		}
+		acceptedResult(c, res)
+	}
+}
+
+// CollectionMetadataRefreshHandler 创建集合元数据批量刷新任务, 立即返回任务ID, 进度通过 GET /jobs/:id 查询
+// query 参数: chain_id 必填, limit 为最多刷新的item数量, 不传时使用默认上限
+func CollectionMetadataRefreshHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var query struct {
+			chainQuery
+			Limit int `form:"limit" binding:"omitempty,min=1"`
+		}
+		if !bindQuery(c, &query) {
+			return
+		}
+
+		chain, ok := chainIDToChain[query.ChainID]
+		if !ok {
+			i18n.Error(c, service.ErrInvalidChainID)
+			return
+		}
+
+		collectionAddr := c.Params.ByName("address")
+		if collectionAddr == "" {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		res, err := service.RefreshCollectionMetadata(c.Request.Context(), svcCtx, chain, int64(query.ChainID), collectionAddr, query.Limit)
+		if err != nil {
+			handleServiceError(c, err, errcode.NewCustomErr("refresh collection metadata error"))
+			return
+		}
+
+		xhttp.OkJson(c, struct {
+			Result interface{} `json:"result"`
+		}{
+			Result: res,
+		})
+	}
+}
+
+func CollectionDetailHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		chainID, err := strconv.ParseInt(c.Query("chain_id"), 10, 32)
+		if err != nil {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		chain, ok := chainIDToChain[int(chainID)]
+		if !ok {
+			i18n.Error(c, service.ErrInvalidChainID)
+			return
+		}
+
+		collectionAddr := c.Params.ByName("address")
+		if collectionAddr == "" {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+		res, err := service.GetCollectionDetail(c.Request.Context(), svcCtx, chain, collectionAddr)
+		if err != nil {
+			handleServiceError(c, err, errcode.ErrUnexpected)
+			return
+		}
+
+		xhttp.OkJson(c, res)
+	}
+}
+
+// CollectionCompareHandler 对比多个集合的地板价、24小时成交额、持有人数量、总供应量和上架比例
+// query 参数: chain_id 必填, addresses 为逗号分隔的集合地址, 最多5个; 未收录的集合在 skipped 中返回
+func CollectionCompareHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var query struct {
+			chainQuery
+			Addresses string `form:"addresses"`
+		}
+		if !bindQuery(c, &query) {
+			return
+		}
+
+		chain, ok := chainIDToChain[query.ChainID]
+		if !ok {
+			i18n.Error(c, service.ErrInvalidChainID)
+			return
+		}
+
+		var collectionAddrs []string
+		seen := make(map[string]bool)
+		for _, addr := range splitList(query.Addresses) {
+			collectionAddr, err := common.UnifyAddress(addr)
+			if err != nil {
+				i18n.Error(c, i18n.Errorf(http.StatusBadRequest, "Invalid %s: %s", "addresses", addr))
+				return
+			}
+			if seen[collectionAddr] {
+				continue
+			}
+			seen[collectionAddr] = true
+			collectionAddrs = append(collectionAddrs, collectionAddr)
+		}
+		if len(collectionAddrs) == 0 {
+			i18n.Error(c, errcode.NewCustomErr("addresses is empty."))
+			return
+		}
+		if len(collectionAddrs) > service.MaxCompareCollections {
+			i18n.Error(c, i18n.Errorf(http.StatusBadRequest, "addresses exceeds the limit of %d.", service.MaxCompareCollections))
+			return
+		}
+
+		res, err := service.CompareCollections(c.Request.Context(), svcCtx, chain, collectionAddrs)
+		if err != nil {
+			handleServiceError(c, err, errcode.NewCustomErr("compare collections error"))
+			return
+		}
+
+		okResult(c, res)
+	}
+}
+
+// CollectionStatsHandler 获取集合的聚合统计信息
+// 包括总供应量、持有人数量、上架数量和比例、地板价以及24小时/7天/30天的成交额和成交笔数
+func CollectionStatsHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		collectionAddr := c.Params.ByName("address")
+		if collectionAddr == "" {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		chainID, err := strconv.ParseInt(c.Query("chain_id"), 10, 64)
+		if err != nil {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		chain, ok := chainIDToChain[int(chainID)]
+		if !ok {
+			i18n.Error(c, service.ErrInvalidChainID)
+			return
+		}
+
+		res, err := service.GetCollectionStats(c.Request.Context(), svcCtx, chain, collectionAddr)
+		if err != nil {
+			handleServiceError(c, err, errcode.NewCustomErr("get collection stats error"))
+			return
+		}
+
+		xhttp.OkJson(c, struct {
+			Result interface{} `json:"result"`
+		}{
+			Result: res,
+		})
 	}
 }
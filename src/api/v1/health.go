@@ -0,0 +1,44 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/joinmouse/EasySwapBackend/src/api/middleware"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+	"github.com/joinmouse/EasySwapBackend/src/service/v1"
+	"github.com/joinmouse/EasySwapBackend/src/types/v1"
+)
+
+// HealthHandler 存活检查,进程正常运行即返回200
+func HealthHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": types.HealthStatusOK})
+	}
+}
+
+// ReadyHandler 就绪检查,检查数据库、Redis和各链RPC节点
+// 任一依赖不可用时返回503,响应体中列出每个依赖的检查结果
+// 开始优雅关闭后不再检查依赖,立即返回503,使负载均衡停止转发新请求; 存活检查不受影响
+func ReadyHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if svcCtx.IsShuttingDown() {
+			c.JSON(http.StatusServiceUnavailable, types.ReadinessResp{
+				Status:       types.HealthStatusShuttingDown,
+				Dependencies: []types.DependencyStatus{},
+				InFlight:     middleware.InFlightRequests(),
+			})
+			return
+		}
+
+		res, ready := service.CheckReadiness(c.Request.Context(), svcCtx)
+		res.InFlight = middleware.InFlightRequests()
+		if !ready {
+			c.JSON(http.StatusServiceUnavailable, res)
+			return
+		}
+
+		c.JSON(http.StatusOK, res)
+	}
+}
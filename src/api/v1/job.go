@@ -0,0 +1,34 @@
+package v1
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/joinmouse/EasySwapBase/errcode"
+	"github.com/joinmouse/EasySwapBase/xhttp"
+
+	"github.com/joinmouse/EasySwapBackend/src/api/i18n"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+	"github.com/joinmouse/EasySwapBackend/src/service/v1"
+)
+
+// JobStatusHandler 查询后台任务的状态和进度
+func JobStatusHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		jobID := c.Params.ByName("id")
+		if jobID == "" {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		res, err := service.GetJob(c.Request.Context(), svcCtx, jobID)
+		if err != nil {
+			handleServiceError(c, err, errcode.NewCustomErr("get job error"))
+			return
+		}
+
+		xhttp.OkJson(c, struct {
+			Result interface{} `json:"result"`
+		}{
+			Result: res,
+		})
+	}
+}
@@ -2,10 +2,13 @@ package v1
 
 import (
 	"encoding/json"
+	"fmt"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joinmouse/EasySwapBase/errcode"
 
+	"github.com/joinmouse/EasySwapBackend/src/api/i18n"
 	"github.com/joinmouse/EasySwapBackend/src/service/svc"
 	"github.com/joinmouse/EasySwapBackend/src/service/v1"
 	"github.com/joinmouse/EasySwapBackend/src/types/v1"
@@ -16,26 +19,132 @@ func OrderInfosHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		filterParam := c.Query("filters")
 		if filterParam == "" {
-			xhttp.Error(c, errcode.NewCustomErr("Filter param is nil."))
+			i18n.Error(c, errcode.NewCustomErr("Filter param is nil."))
 			return
 		}
 
 		var filter types.OrderInfosParam
 		err := json.Unmarshal([]byte(filterParam), &filter)
 		if err != nil {
-			xhttp.Error(c, errcode.NewCustomErr("Filter param is nil."))
+			i18n.Error(c, errcode.NewCustomErr("Filter param is nil."))
 			return
 		}
 
 		chain, ok := chainIDToChain[filter.ChainID]
 		if !ok {
-			xhttp.Error(c, errcode.ErrInvalidParams)
+			i18n.Error(c, service.ErrInvalidChainID)
 			return
 		}
 
 		res, err := service.GetOrderInfos(c.Request.Context(), svcCtx, filter.ChainID, chain, filter.UserAddress, filter.CollectionAddress, filter.TokenIds)
 		if err != nil {
-			xhttp.Error(c, errcode.NewCustomErr(err.Error()))
+			i18n.Error(c, errcode.NewCustomErr(err.Error()))
+			return
+		}
+		xhttp.OkJson(c, struct {
+			Result interface{} `json:"result"`
+		}{Result: res})
+	}
+}
+
+// OrderDetailsHandler 根据订单ID批量查询订单详情
+// chain_id 不传时在所有支持的链上查询
+func OrderDetailsHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req types.OrderDetailsReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		chains := make(map[int]string)
+		if req.ChainID != 0 {
+			chain, ok := chainIDToChain[req.ChainID]
+			if !ok {
+				i18n.Error(c, service.ErrInvalidChainID)
+				return
+			}
+			chains[req.ChainID] = chain
+		} else {
+			for chainID, chain := range chainIDToChain {
+				chains[chainID] = chain
+			}
+		}
+
+		// 去除空值和重复的订单ID, 订单ID不区分大小写
+		var orderIDs []string
+		seen := make(map[string]bool)
+		for _, orderID := range req.OrderIDs {
+			orderID = strings.TrimSpace(orderID)
+			if orderID == "" || seen[strings.ToLower(orderID)] {
+				continue
+			}
+			seen[strings.ToLower(orderID)] = true
+			orderIDs = append(orderIDs, orderID)
+		}
+		if len(orderIDs) == 0 {
+			i18n.Error(c, errcode.NewCustomErr("order_ids is empty."))
+			return
+		}
+		if len(orderIDs) > service.MaxBatchOrderDetails {
+			i18n.Error(c, errcode.NewCustomErr(fmt.Sprintf("order_ids exceeds the limit of %d.", service.MaxBatchOrderDetails)))
+			return
+		}
+
+		res, err := service.GetOrderDetails(c.Request.Context(), svcCtx, chains, orderIDs)
+		if err != nil {
+			handleServiceError(c, err, errcode.NewCustomErr("get order details error"))
+			return
+		}
+		xhttp.OkJson(c, struct {
+			Result interface{} `json:"result"`
+		}{Result: res})
+	}
+}
+
+// OrderStatusHandler 查询订单的当前状态和状态变化时间线
+// query 参数: chain_id 必填
+func OrderStatusHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var query chainQuery
+		if !bindQuery(c, &query) {
+			return
+		}
+		chain, ok := chainIDToChain[query.ChainID]
+		if !ok {
+			i18n.Error(c, service.ErrInvalidChainID)
+			return
+		}
+
+		orderID := strings.TrimSpace(c.Params.ByName("order_id"))
+		if orderID == "" {
+			i18n.Error(c, errcode.ErrInvalidParams)
+			return
+		}
+
+		res, err := service.GetOrderStatus(c.Request.Context(), svcCtx, query.ChainID, chain, orderID)
+		if err != nil {
+			handleServiceError(c, err, errcode.NewCustomErr("get order status error"))
+			return
+		}
+
+		okResult(c, res)
+	}
+}
+
+// OrderValidateHandler 在用户签名前校验订单
+// 请求体为待签名的订单, 返回每个字段的校验结果(ok/warning/error), 不写入任何数据
+func OrderValidateHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req types.OrderValidateReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			i18n.Error(c, priceParseError(err, errcode.ErrInvalidParams))
+			return
+		}
+
+		res, err := service.ValidateOrder(c.Request.Context(), svcCtx, req)
+		if err != nil {
+			handleServiceError(c, err, errcode.NewCustomErr("validate order error"))
 			return
 		}
 		xhttp.OkJson(c, struct {
@@ -2,28 +2,51 @@ package v1
 
 import (
 	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joinmouse/EasySwapBase/errcode"
 
+	"github.com/joinmouse/EasySwapBackend/src/api/i18n"
+	"github.com/joinmouse/EasySwapBackend/src/api/middleware"
+	"github.com/joinmouse/EasySwapBackend/src/common"
 	"github.com/joinmouse/EasySwapBackend/src/service/svc"
 	"github.com/joinmouse/EasySwapBackend/src/service/v1"
 	"github.com/joinmouse/EasySwapBackend/src/types/v1"
 	"github.com/joinmouse/EasySwapBase/xhttp"
 )
 
+// UserMultiChainCollectionsHandler 获取用户在多链上持有的集合, 包括持有数量、上架数量、地板价和持仓估值
+// filters 中 sort_by 可选 value(默认)、count、name, min_items 过滤持有数量较少的集合
 func UserMultiChainCollectionsHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		filterParam := c.Query("filters")
 		if filterParam == "" {
-			xhttp.Error(c, errcode.NewCustomErr("Filter param is nil."))
+			i18n.Error(c, errcode.NewCustomErr("Filter param is nil."))
 			return
 		}
 
 		var filter types.UserCollectionsParams
 		err := json.Unmarshal([]byte(filterParam), &filter)
 		if err != nil {
-			xhttp.Error(c, errcode.NewCustomErr("Filter param is nil."))
+			i18n.Error(c, errcode.NewCustomErr("Filter param is nil."))
+			return
+		}
+
+		filter.UserAddresses, err = authorizedUserAddresses(c, filter.UserAddresses)
+		if err != nil {
+			i18n.Error(c, err)
+			return
+		}
+
+		if !service.IsPortfolioCollectionSort(filter.SortBy) {
+			i18n.Error(c, i18n.Errorf(http.StatusBadRequest, "Invalid %s: %s", "sort_by", filter.SortBy))
+			return
+		}
+		if filter.MinItems < 0 {
+			i18n.Error(c, i18n.Errorf(http.StatusBadRequest, "Invalid %s: %s", "min_items", strconv.FormatInt(filter.MinItems, 10)))
 			return
 		}
 
@@ -34,9 +57,9 @@ func UserMultiChainCollectionsHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 			chainNames = append(chainNames, chain.Name)
 		}
 
-		res, err := service.GetMultiChainUserCollections(c.Request.Context(), svcCtx, chainIDs, chainNames, filter.UserAddresses)
+		res, err := service.GetMultiChainUserCollections(c.Request.Context(), svcCtx, chainIDs, chainNames, filter.UserAddresses, filter.SortBy, filter.MinItems)
 		if err != nil {
-			xhttp.Error(c, errcode.NewCustomErr("query user multi chain collections err."))
+			i18n.Error(c, errcode.NewCustomErr("query user multi chain collections err."))
 			return
 		}
 
@@ -48,14 +71,26 @@ func UserMultiChainItemsHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		filterParam := c.Query("filters")
 		if filterParam == "" {
-			xhttp.Error(c, errcode.NewCustomErr("Filter param is nil."))
+			i18n.Error(c, errcode.NewCustomErr("Filter param is nil."))
 			return
 		}
 
 		var filter types.PortfolioMultiChainItemFilterParams
 		err := json.Unmarshal([]byte(filterParam), &filter)
 		if err != nil {
-			xhttp.Error(c, errcode.NewCustomErr("Filter param is nil."))
+			i18n.Error(c, errcode.NewCustomErr("Filter param is nil."))
+			return
+		}
+
+		filter.UserAddresses, err = authorizedUserAddresses(c, filter.UserAddresses)
+		if err != nil {
+			i18n.Error(c, err)
+			return
+		}
+
+		filter.ChainID, filter.CollectionAddresses, err = portfolioScope(c, filter.ChainID, filter.CollectionAddresses)
+		if err != nil {
+			i18n.Error(c, err)
 			return
 		}
 
@@ -70,7 +105,7 @@ func UserMultiChainItemsHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 		for _, chainID := range filter.ChainID {
 			chain, ok := chainIDToChain[chainID]
 			if !ok {
-				xhttp.Error(c, errcode.ErrInvalidParams)
+				i18n.Error(c, service.ErrInvalidChainID)
 				return
 			}
 			chainNames = append(chainNames, chain)
@@ -78,7 +113,7 @@ func UserMultiChainItemsHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 
 		res, err := service.GetMultiChainUserItems(c.Request.Context(), svcCtx, filter.ChainID, chainNames, filter.UserAddresses, filter.CollectionAddresses, filter.Page, filter.PageSize)
 		if err != nil {
-			xhttp.Error(c, errcode.NewCustomErr("query user multi chain items err."))
+			i18n.Error(c, errcode.NewCustomErr("query user multi chain items err."))
 			return
 		}
 
@@ -90,14 +125,26 @@ func UserMultiChainListingsHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		filterParam := c.Query("filters")
 		if filterParam == "" {
-			xhttp.Error(c, errcode.NewCustomErr("Filter param is nil."))
+			i18n.Error(c, errcode.NewCustomErr("Filter param is nil."))
 			return
 		}
 
 		var filter types.PortfolioMultiChainListingFilterParams
 		err := json.Unmarshal([]byte(filterParam), &filter)
 		if err != nil {
-			xhttp.Error(c, errcode.NewCustomErr("Filter param is nil."))
+			i18n.Error(c, errcode.NewCustomErr("Filter param is nil."))
+			return
+		}
+
+		filter.UserAddresses, err = authorizedUserAddresses(c, filter.UserAddresses)
+		if err != nil {
+			i18n.Error(c, err)
+			return
+		}
+
+		filter.ChainID, filter.CollectionAddresses, err = portfolioScope(c, filter.ChainID, filter.CollectionAddresses)
+		if err != nil {
+			i18n.Error(c, err)
 			return
 		}
 
@@ -112,7 +159,7 @@ func UserMultiChainListingsHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 		for _, chainID := range filter.ChainID {
 			chain, ok := chainIDToChain[chainID]
 			if !ok {
-				xhttp.Error(c, errcode.ErrInvalidParams)
+				i18n.Error(c, service.ErrInvalidChainID)
 				return
 			}
 			chainNames = append(chainNames, chain)
@@ -120,7 +167,7 @@ func UserMultiChainListingsHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 
 		res, err := service.GetMultiChainUserListings(c.Request.Context(), svcCtx, filter.ChainID, chainNames, filter.UserAddresses, filter.CollectionAddresses, filter.Page, filter.PageSize)
 		if err != nil {
-			xhttp.Error(c, errcode.NewCustomErr("query user multi chain items err."))
+			i18n.Error(c, errcode.NewCustomErr("query user multi chain items err."))
 			return
 		}
 
@@ -132,14 +179,26 @@ func UserMultiChainBidsHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		filterParam := c.Query("filters")
 		if filterParam == "" {
-			xhttp.Error(c, errcode.NewCustomErr("Filter param is nil."))
+			i18n.Error(c, errcode.NewCustomErr("Filter param is nil."))
 			return
 		}
 
 		var filter types.PortfolioMultiChainBidFilterParams
 		err := json.Unmarshal([]byte(filterParam), &filter)
 		if err != nil {
-			xhttp.Error(c, errcode.NewCustomErr("Filter param is nil."))
+			i18n.Error(c, errcode.NewCustomErr("Filter param is nil."))
+			return
+		}
+
+		filter.UserAddresses, err = authorizedUserAddresses(c, filter.UserAddresses)
+		if err != nil {
+			i18n.Error(c, err)
+			return
+		}
+
+		filter.ChainID, filter.CollectionAddresses, err = portfolioScope(c, filter.ChainID, filter.CollectionAddresses)
+		if err != nil {
+			i18n.Error(c, err)
 			return
 		}
 
@@ -154,7 +213,7 @@ func UserMultiChainBidsHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 		for _, chainID := range filter.ChainID {
 			chain, ok := chainIDToChain[chainID]
 			if !ok {
-				xhttp.Error(c, errcode.ErrInvalidParams)
+				i18n.Error(c, service.ErrInvalidChainID)
 				return
 			}
 			chainNames = append(chainNames, chain)
@@ -162,10 +221,87 @@ func UserMultiChainBidsHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 
 		res, err := service.GetMultiChainUserBids(c.Request.Context(), svcCtx, filter.ChainID, chainNames, filter.UserAddresses, filter.CollectionAddresses, filter.Page, filter.PageSize)
 		if err != nil {
-			xhttp.Error(c, errcode.NewCustomErr("query user multi chain items err."))
+			i18n.Error(c, errcode.NewCustomErr("query user multi chain items err."))
 			return
 		}
 
 		xhttp.OkJson(c, res)
 	}
 }
+
+// PortfolioValueHandler 估算已认证用户在指定链上持有的 NFT 的总价值
+// 每个Item按最高出价估值, 没有出价时使用地板价, 返回总价值和按集合的明细, 结果短暂缓存
+func PortfolioValueHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var query chainQuery
+		if !bindQuery(c, &query) {
+			return
+		}
+		if _, ok := chainIDToChain[query.ChainID]; !ok {
+			i18n.Error(c, service.ErrInvalidChainID)
+			return
+		}
+
+		userAddr, ok := middleware.GetAuthAddress(c)
+		if !ok {
+			i18n.Error(c, errcode.ErrTokenVerify)
+			return
+		}
+
+		res, err := service.GetPortfolioValue(c.Request.Context(), svcCtx, query.ChainID, userAddr)
+		if err != nil {
+			handleServiceError(c, err, errcode.NewCustomErr("query portfolio value err."))
+			return
+		}
+
+		okResult(c, res)
+	}
+}
+
+// portfolioScope 解析 collection_address 和 chain_id query 参数, 将查询限定在单个集合(和单条链)上,
+// 避免为了一个集合查询用户的全部资产; query 参数优先于 filters 中的 chain_id 和 collection_addresses
+// 所有集合地址都会校验并统一为校验和格式
+func portfolioScope(c *gin.Context, chainIDs []int, collectionAddrs []string) ([]int, []string, error) {
+	if v := c.Query("chain_id"); v != "" {
+		chainID, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, nil, i18n.Errorf(http.StatusBadRequest, "Invalid %s: %s", "chain_id", v)
+		}
+		chainIDs = []int{chainID}
+	}
+	if v := c.Query("collection_address"); v != "" {
+		collectionAddrs = []string{v}
+	}
+
+	addrs := make([]string, 0, len(collectionAddrs))
+	for _, addr := range collectionAddrs {
+		unified, err := common.UnifyAddress(addr)
+		if err != nil {
+			return nil, nil, i18n.Errorf(http.StatusBadRequest, "Invalid %s: %s", "collection_address", addr)
+		}
+		addrs = append(addrs, unified)
+	}
+
+	return chainIDs, addrs, nil
+}
+
+// authorizedUserAddresses 校验查询的用户地址是否与令牌中的地址一致
+// 未指定用户地址时默认查询已认证用户
+func authorizedUserAddresses(c *gin.Context, userAddrs []string) ([]string, error) {
+	authAddr, ok := middleware.GetAuthAddress(c)
+	if !ok {
+		return nil, errcode.ErrTokenVerify
+	}
+
+	if len(userAddrs) == 0 {
+		return []string{authAddr}, nil
+	}
+
+	for _, addr := range userAddrs {
+		if !strings.EqualFold(addr, authAddr) {
+			return nil, errcode.NewCustomErr("user address mismatch with token.", http.StatusForbidden)
+		}
+	}
+
+	return userAddrs, nil
+}
@@ -1,7 +1,6 @@
 package v1
 
 import (
-	"sort"
 	"strconv"
 	"sync"
 
@@ -11,79 +10,82 @@ import (
 	"github.com/joinmouse/EasySwapBase/xhttp"
 	"go.uber.org/zap"
 
+	"github.com/joinmouse/EasySwapBackend/src/api/i18n"
 	"github.com/joinmouse/EasySwapBackend/src/service/svc"
 	"github.com/joinmouse/EasySwapBackend/src/service/v1"
 	"github.com/joinmouse/EasySwapBackend/src/types/v1"
 )
 
 // TopRankingHandler 处理获取排名前列的NFT集合的请求
+// 查询参数:
+//   - limit: 返回数量
+//   - sort_by: 排序指标 volume/sales/floor_change/avg_price, 默认 volume
+//   - window: 时间窗口 1h/6h/24h/7d, 默认 24h; 兼容旧的 range 参数(15m/1h/6h/1d/7d/30d)
+//   - include_hidden: 是否包含被隐藏的集合, 仅管理员可用
 func TopRankingHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 解析limit参数,获取需要返回的数量
 		limit, err := strconv.ParseInt(c.Query("limit"), 10, 64)
 		if err != nil {
-			xhttp.Error(c, errcode.ErrInvalidParams)
+			i18n.Error(c, errcode.ErrInvalidParams)
 			return
 		}
 
-		// 获取时间范围参数
-		period := c.Query("range")
-		if period != "" {
-			// 验证时间范围参数是否有效
-			validParams := map[string]bool{
-				"15m": true, // 15分钟
-				"1h":  true, // 1小时
-				"6h":  true, // 6小时
-				"1d":  true, // 1天
-				"7d":  true, // 7天
-				"30d": true, // 30天
-			}
-			if ok := validParams[period]; !ok {
-				xzap.WithContext(c).Error("range parse error: ", zap.String("range", period))
-				xhttp.Error(c, errcode.ErrInvalidParams)
-				return
-			}
-		} else {
-			// 默认使用1天的时间范围
-			period = "1d"
+		// 获取排序指标和时间窗口, 未传 window 时使用旧的 range 参数
+		window := c.Query("window")
+		if window == "" {
+			window = c.Query("range")
+		}
+		sortBy, window, err := service.ParseRankingParams(c.Query("sort_by"), window)
+		if err != nil {
+			xzap.WithContext(c.Request.Context()).Error("ranking params parse error: ",
+				zap.String("sort_by", c.Query("sort_by")), zap.String("window", window))
+			i18n.Error(c, err)
+			return
 		}
 
-		// 存储所有链的排名结果
-		var allResult []*types.CollectionRankingInfo
+		includeHidden, err := parseIncludeHidden(c, svcCtx)
+		if err != nil {
+			i18n.Error(c, err)
+			return
+		}
+
+		// 存储每条链的排名结果
+		rankings := make([][]*types.CollectionRankingInfo, len(svcCtx.C.ChainSupported))
 
 		// 使用WaitGroup和Mutex来保证并发安全
 		var wg sync.WaitGroup
 		var mu sync.Mutex
+		var rankingErr error
 
 		// 并发获取每条链的排名数据
-		for _, chain := range svcCtx.C.ChainSupported {
+		for i, chain := range svcCtx.C.ChainSupported {
 			wg.Add(1)
-			go func(chain string) {
+			go func(i int, chain string) {
 				defer wg.Done()
 
 				// 获取该链的排名数据
-				result, err := service.GetTopRanking(c.Copy(), svcCtx, chain, period, limit)
+				result, err := service.GetTopRanking(c.Request.Context(), svcCtx, chain, sortBy, window, limit, includeHidden)
 				if err != nil {
-					xhttp.Error(c, err)
+					mu.Lock()
+					if rankingErr == nil {
+						rankingErr = err
+					}
+					mu.Unlock()
 					return
 				}
-
-				// 将结果追加到总结果中
-				mu.Lock()
-				allResult = append(allResult, result...)
-				mu.Unlock()
-			}(chain.Name)
+				rankings[i] = result
+			}(i, chain.Name)
 		}
 
 		// 等待所有goroutine完成
 		wg.Wait()
+		if rankingErr != nil {
+			i18n.Error(c, rankingErr)
+			return
+		}
 
-		// 根据交易量对集合进行降序排序
-		sort.SliceStable(allResult, func(i, j int) bool {
-			return allResult[i].Volume.GreaterThan(allResult[j].Volume)
-		})
-
-		// 返回排序后的结果
-		xhttp.OkJson(c, types.CollectionRankingResp{Result: allResult})
+		// 合并各链排名并按排序指标重新计算排名位置
+		xhttp.OkJson(c, types.CollectionRankingResp{Result: service.MergeRankings(rankings, sortBy, limit)})
 	}
 }
@@ -3,11 +3,16 @@
 package v1
 
 import (
+	"net/http"
+	"strconv"
+	"strings"
+
 	"github.com/gin-gonic/gin"                              // Gin Web框架
 	"github.com/joinmouse/EasySwapBase/errcode"              // 错误码定义
 	"github.com/joinmouse/EasySwapBase/kit/validator"        // 数据验证工具
-	"github.com/joinmouse/EasySwapBase/xhttp"                // HTTP 响应封装工具
 
+	"github.com/joinmouse/EasySwapBackend/src/api/i18n"
+	"github.com/joinmouse/EasySwapBackend/src/api/middleware"
 	"github.com/joinmouse/EasySwapBackend/src/service/svc"   // 服务上下文
 	service "github.com/joinmouse/EasySwapBackend/src/service/v1" // 业务逻辑服务层
 	"github.com/joinmouse/EasySwapBackend/src/types/v1"      // 数据结构定义
@@ -32,30 +37,29 @@ func UserLoginHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 		req := types.LoginReq{}
 		if err := c.BindJSON(&req); err != nil {
 			// 请求数据解析失败，返回错误响应
-			xhttp.Error(c, err)
+			i18n.Error(c, err)
 			return
 		}
 
 		// 验证请求参数的完整性和合法性
 		// 检查必填字段和数据格式
 		if err := validator.Verify(&req); err != nil {
-			xhttp.Error(c, errcode.NewCustomErr(err.Error()))
+			i18n.Error(c, errcode.NewCustomErr(err.Error()))
 			return
 		}
 
 		// 调用业务逻辑层处理登录逻辑
 		// 包括签名验证、用户信息查询、令牌生成等
-		res, err := service.UserLogin(c.Request.Context(), svcCtx, req)
+		// 客户端 IP 和 User-Agent 仅用于登录审计记录
+		res, err := service.UserLogin(c.Request.Context(), svcCtx, req, c.ClientIP(), c.Request.UserAgent())
 		if err != nil {
-			// 登录失败，返回错误信息
-			xhttp.Error(c, errcode.NewCustomErr(err.Error()))
+			// 登录失败，nonce 过期、已使用等业务错误返回对应的错误码
+			handleServiceError(c, err, errcode.NewCustomErr(err.Error()))
 			return
 		}
 
 		// 登录成功，返回用户信息和访问令牌
-		xhttp.OkJson(c, types.UserLoginResp{
-			Result: res,
-		})
+		okResult(c, res)
 	}
 }
 
@@ -69,6 +73,9 @@ func UserLoginHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 // 路由参数:
 //   - address: 用户的区块链地址
 //
+// 查询参数:
+//   - chain_id: 用户登录的链 ID，不传时使用配置中的第一条链
+//
 // 返回值:
 //   - gin.HandlerFunc: Gin 框架的处理函数
 func GetLoginMessageHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
@@ -77,21 +84,38 @@ func GetLoginMessageHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 		address := c.Params.ByName("address")
 		if address == "" {
 			// 地址参数为空，返回错误响应
-			xhttp.Error(c, errcode.NewCustomErr("用户地址不能为空"))
+			i18n.Error(c, errcode.NewCustomErr("User address is required."))
+			return
+		}
+
+		// 解析链 ID，消息中会包含链 ID 以防止跨链重放
+		var chainID int
+		if id := c.Query("chain_id"); id != "" {
+			var err error
+			chainID, err = strconv.Atoi(id)
+			if err != nil {
+				i18n.Error(c, errcode.ErrInvalidParams)
+				return
+			}
+		} else if len(svcCtx.C.ChainSupported) > 0 {
+			chainID = svcCtx.C.ChainSupported[0].ChainID
+		}
+		if _, ok := chainIDToChain[chainID]; !ok {
+			i18n.Error(c, service.ErrInvalidChainID)
 			return
 		}
 
 		// 调用业务逻辑层生成登录消息
-		// 服务层会验证地址格式并生成安全的消息
-		res, err := service.GetUserLoginMsg(c.Request.Context(), svcCtx, address)
+		// 服务层会生成带有效期的一次性 nonce
+		res, err := service.GetUserLoginMsg(c.Request.Context(), svcCtx, chainID, address)
 		if err != nil {
 			// 消息生成失败，返回错误信息
-			xhttp.Error(c, errcode.NewCustomErr(err.Error()))
+			i18n.Error(c, errcode.NewCustomErr(err.Error()))
 			return
 		}
 
 		// 成功返回登录消息
-		xhttp.OkJson(c, res)
+		okData(c, res)
 	}
 }
 
@@ -113,7 +137,7 @@ func GetSigStatusHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 		userAddr := c.Params.ByName("address")
 		if userAddr == "" {
 			// 地址参数为空，返回错误响应
-			xhttp.Error(c, errcode.NewCustomErr("用户地址不能为空"))
+			i18n.Error(c, errcode.NewCustomErr("User address is required."))
 			return
 		}
 
@@ -122,11 +146,60 @@ func GetSigStatusHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
 		res, err := service.GetSigStatusMsg(c.Request.Context(), svcCtx, userAddr)
 		if err != nil {
 			// 查询失败，返回错误信息
-			xhttp.Error(c, errcode.NewCustomErr(err.Error()))
+			i18n.Error(c, errcode.NewCustomErr(err.Error()))
 			return
 		}
 
 		// 成功返回签名状态
-		xhttp.OkJson(c, res)
+		okData(c, res)
+	}
+}
+
+// GetLoginHistoryHandler 处理获取用户登录记录请求的 HTTP 处理器
+// 需要登录认证，且只能查询当前登录地址自己的登录记录
+//
+// 参数:
+//   - svcCtx: 服务上下文
+//
+// 路由参数:
+//   - address: 用户的区块链地址，必须与登录令牌对应的地址一致
+//
+// 查询参数:
+//   - limit: 返回的记录数量，默认 20，最多 100
+//
+// 返回值:
+//   - gin.HandlerFunc: Gin 框架的处理函数
+func GetLoginHistoryHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authAddr, ok := middleware.GetAuthAddress(c)
+		if !ok {
+			i18n.Error(c, errcode.ErrTokenVerify)
+			return
+		}
+
+		// 只允许查询自己的登录记录
+		userAddr := c.Params.ByName("address")
+		if !strings.EqualFold(userAddr, authAddr) {
+			i18n.Error(c, errcode.NewCustomErr("user address mismatch with token.", http.StatusForbidden))
+			return
+		}
+
+		limit := service.DefaultLoginHistoryLimit
+		if l := c.Query("limit"); l != "" {
+			var err error
+			limit, err = strconv.Atoi(l)
+			if err != nil || limit <= 0 {
+				i18n.Error(c, errcode.ErrInvalidParams)
+				return
+			}
+		}
+
+		res, err := service.GetLoginHistory(c.Request.Context(), svcCtx, userAddr, limit)
+		if err != nil {
+			handleServiceError(c, err, errcode.NewCustomErr("get login history error"))
+			return
+		}
+
+		okResult(c, res)
 	}
 }
@@ -1,9 +1,35 @@
 package v1
 
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joinmouse/EasySwapBase/errcode"
+	"github.com/joinmouse/EasySwapBase/xhttp"
+
+	"github.com/joinmouse/EasySwapBackend/src/api/envelope"
+	"github.com/joinmouse/EasySwapBackend/src/api/i18n"
+	"github.com/joinmouse/EasySwapBackend/src/api/middleware"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+	"github.com/joinmouse/EasySwapBackend/src/service/v1"
+	"github.com/joinmouse/EasySwapBackend/src/types/v1"
+)
+
 const (
 	CursorDelimiter = "_"
 )
 
+const (
+	DefaultPage     = 1   // 默认页码
+	DefaultPageSize = 20  // 默认每页数量
+	MaxPageSize     = 100 // 每页数量上限
+)
+
 type chainIDMap map[int]string
 
 var chainIDToChain = chainIDMap{
@@ -11,3 +37,180 @@ var chainIDToChain = chainIDMap{
 	10:       "optimism",
 	11155111: "sepolia",
 }
+
+// parsePageParams 解析分页参数
+// 优先使用query中的page和page_size, 未传入时使用fallback中的值
+func parsePageParams(c *gin.Context, fallbackPage, fallbackPageSize int) (int, int) {
+	page, pageSize := fallbackPage, fallbackPageSize
+	if v, ok := c.GetQuery("page"); ok {
+		page, _ = strconv.Atoi(v)
+	}
+	if v, ok := c.GetQuery("page_size"); ok {
+		pageSize, _ = strconv.Atoi(v)
+	}
+
+	return normalizePageParams(page, pageSize)
+}
+
+// normalizePageParams 修正分页参数
+// 非法或负数的页码修正为默认值, page_size为0时使用默认值, 超过上限时截断为上限
+func normalizePageParams(page, pageSize int) (int, int) {
+	if page < 1 {
+		page = DefaultPage
+	}
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+
+	return page, pageSize
+}
+
+// splitList 解析逗号分隔的 query 参数, 忽略空白项
+func splitList(v string) []string {
+	var list []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
+
+// parseIncludeHidden 解析 include_hidden 参数, 为 true 时返回被隐藏的集合
+// 该参数只对管理员开放, 需要同时携带正确的 X-Admin-Secret 请求头
+func parseIncludeHidden(c *gin.Context, svcCtx *svc.ServerCtx) (bool, error) {
+	v, ok := c.GetQuery("include_hidden")
+	if !ok {
+		return false, nil
+	}
+	includeHidden, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, errcode.ErrInvalidParams
+	}
+	if includeHidden && !middleware.IsAdminRequest(c, svcCtx.C.Api.AdminSecret) {
+		return false, middleware.ErrAdminUnauthorized
+	}
+
+	return includeHidden, nil
+}
+
+// handleServiceError 将service层返回的错误转换为HTTP响应
+// 错误链中包含业务错误(*errcode.Err)时使用其业务状态码和HTTP状态码,
+// 查询超时或请求超过截止时间(X-Request-Timeout-Ms)时返回504, 否则返回fallback
+func handleServiceError(c *gin.Context, err error, fallback error) {
+	var e *errcode.Err
+	if errors.As(err, &e) {
+		i18n.Error(c, e)
+		return
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(c.Request.Context().Err(), context.DeadlineExceeded) {
+		i18n.Error(c, service.ErrQueryTimeout)
+		return
+	}
+
+	i18n.Error(c, fallback)
+}
+
+// okResult 返回成功响应
+// v1 信封中 data 为 {"result": res}, v2 信封中 data 直接为 res
+func okResult(c *gin.Context, res interface{}) {
+	c.Writer.Header().Add("Vary", envelope.VersionHeader)
+	if envelope.IsV2(c) {
+		envelope.OK(c, res)
+		return
+	}
+
+	xhttp.OkJson(c, struct {
+		Result interface{} `json:"result"`
+	}{
+		Result: res,
+	})
+}
+
+// okData 返回成功响应, 用于没有 result 包装或除 result 外还带有分页等字段的响应
+// v1 和 v2 信封中 data 都为 data 本身
+func okData(c *gin.Context, data interface{}) {
+	c.Writer.Header().Add("Vary", envelope.VersionHeader)
+	if envelope.IsV2(c) {
+		envelope.OK(c, data)
+		return
+	}
+
+	xhttp.OkJson(c, data)
+}
+
+// acceptedResult 以 202 返回成功响应, 用于已受理、在后台处理的请求
+// 响应体与 okResult 一致
+func acceptedResult(c *gin.Context, res interface{}) {
+	c.Writer.Header().Add("Vary", envelope.VersionHeader)
+	if envelope.IsV2(c) {
+		envelope.OKWithStatus(c, http.StatusAccepted, res)
+		return
+	}
+
+	xhttp.WriteHeader(c.Writer)
+	c.JSON(http.StatusAccepted, &xhttp.Response{
+		TraceId: xhttp.GetTraceId(c.Request.Context()),
+		Code:    errcode.CodeOK,
+		Msg:     errcode.MsgOK,
+		Data: struct {
+			Result interface{} `json:"result"`
+		}{
+			Result: res,
+		},
+	})
+}
+
+// parseTimeRange 解析 since/until query 参数(Unix 秒), 两个参数都未传入时返回 nil
+// 1. 不是非负整数时返回400, until 为 0 时同样返回400
+// 2. 未传入 until 时 Until 为 0, 表示截止到当前时间, 保证相同参数的缓存键不随时间变化
+// 3. 未传入 since 时为 until 之前的最大跨度
+// 4. since 不能晚于 until, 跨度不能超过 service.MaxTimeRangeDays 天, 未传入 until 时按当前时间计算
+func parseTimeRange(c *gin.Context) (*types.TimeRange, error) {
+	sinceStr, untilStr := c.Query("since"), c.Query("until")
+	if sinceStr == "" && untilStr == "" {
+		return nil, nil
+	}
+
+	parse := func(name, v string) (int64, error) {
+		ts, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || ts < 0 {
+			return 0, i18n.Errorf(http.StatusBadRequest, "Invalid %s, expected a non-negative integer: %s", name, v)
+		}
+		return ts, nil
+	}
+
+	maxRange := int64(service.MaxTimeRangeDays) * 24 * 60 * 60
+	tr := &types.TimeRange{}
+	until := time.Now().Unix()
+	var err error
+	if untilStr != "" {
+		if tr.Until, err = parse("until", untilStr); err != nil {
+			return nil, err
+		}
+		if tr.Until == 0 {
+			return nil, i18n.Errorf(http.StatusBadRequest, "Invalid %s, expected a positive integer: %s", "until", untilStr)
+		}
+		until = tr.Until
+	}
+	if sinceStr != "" {
+		if tr.Since, err = parse("since", sinceStr); err != nil {
+			return nil, err
+		}
+	} else if tr.Since = until - maxRange; tr.Since < 0 {
+		tr.Since = 0
+	}
+
+	if tr.Since > until {
+		return nil, i18n.Errorf(http.StatusBadRequest, "%s must not be later than %s.", "since", "until")
+	}
+	if until-tr.Since > maxRange {
+		return nil, i18n.Errorf(http.StatusBadRequest, "Time range must not exceed %d days.", service.MaxTimeRangeDays)
+	}
+
+	return tr, nil
+}
@@ -0,0 +1,190 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joinmouse/EasySwapBase/errcode"
+
+	"github.com/joinmouse/EasySwapBackend/src/api/envelope"
+	"github.com/joinmouse/EasySwapBackend/src/api/i18n"
+	"github.com/joinmouse/EasySwapBackend/src/api/middleware"
+	"github.com/joinmouse/EasySwapBackend/src/service/v1"
+	"github.com/joinmouse/EasySwapBackend/src/types/v1"
+)
+
+type envelopePayload struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func newEnvelopeRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set(middleware.RequestIDKey, "req-1")
+		c.Next()
+	})
+	payload := envelopePayload{Name: "azuki", Count: 2}
+	r.GET("/result", func(c *gin.Context) { okResult(c, payload) })
+	r.GET("/data", func(c *gin.Context) { okData(c, payload) })
+	r.GET("/error", func(c *gin.Context) { i18n.Error(c, errcode.ErrInvalidParams) })
+	return r
+}
+
+// doEnvelope 请求测试路由, 返回 HTTP 状态码、响应头和顶层字段
+func doEnvelope(t *testing.T, r *gin.Engine, path string, v2 bool) (int, http.Header, map[string]json.RawMessage) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if v2 {
+		req.Header.Set(envelope.VersionHeader, envelope.Version2)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode %s response: %v, body: %s", path, err, w.Body.String())
+	}
+	return w.Code, w.Header(), body
+}
+
+func assertKeys(t *testing.T, body map[string]json.RawMessage, want ...string) {
+	t.Helper()
+
+	got := make([]string, 0, len(body))
+	for k := range body {
+		got = append(got, k)
+	}
+	sort.Strings(got)
+	sort.Strings(want)
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("response keys = %v, want %v", got, want)
+	}
+}
+
+func assertJSON(t *testing.T, raw json.RawMessage, want string) {
+	t.Helper()
+
+	var got, expected interface{}
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatalf("decode %s: %v", raw, err)
+	}
+	if err := json.Unmarshal([]byte(want), &expected); err != nil {
+		t.Fatalf("decode %s: %v", want, err)
+	}
+	gotRaw, _ := json.Marshal(got)
+	expectedRaw, _ := json.Marshal(expected)
+	if string(gotRaw) != string(expectedRaw) {
+		t.Errorf("json = %s, want %s", gotRaw, expectedRaw)
+	}
+}
+
+func TestOkResultEnvelope(t *testing.T) {
+	r := newEnvelopeRouter()
+
+	// v1: data 为 {"result": ...}
+	status, header, body := doEnvelope(t, r, "/result", false)
+	if status != http.StatusOK {
+		t.Errorf("v1 status = %d, want %d", status, http.StatusOK)
+	}
+	assertKeys(t, body, "trace_id", "code", "msg", "data")
+	assertJSON(t, body["code"], "200")
+	assertJSON(t, body["data"], `{"result":{"name":"azuki","count":2}}`)
+	if header.Get("Vary") != envelope.VersionHeader {
+		t.Errorf("v1 Vary = %q, want %q", header.Get("Vary"), envelope.VersionHeader)
+	}
+
+	// v2: 去掉 result 包装, trace_id 改为 request_id
+	status, header, body = doEnvelope(t, r, "/result", true)
+	if status != http.StatusOK {
+		t.Errorf("v2 status = %d, want %d", status, http.StatusOK)
+	}
+	assertKeys(t, body, "code", "message", "data", "request_id")
+	assertJSON(t, body["code"], "200")
+	assertJSON(t, body["data"], `{"name":"azuki","count":2}`)
+	assertJSON(t, body["request_id"], `"req-1"`)
+	if header.Get("Vary") != envelope.VersionHeader {
+		t.Errorf("v2 Vary = %q, want %q", header.Get("Vary"), envelope.VersionHeader)
+	}
+}
+
+func TestOkDataEnvelope(t *testing.T) {
+	r := newEnvelopeRouter()
+
+	_, _, body := doEnvelope(t, r, "/data", false)
+	assertKeys(t, body, "trace_id", "code", "msg", "data")
+	assertJSON(t, body["data"], `{"name":"azuki","count":2}`)
+
+	_, _, body = doEnvelope(t, r, "/data", true)
+	assertKeys(t, body, "code", "message", "data", "request_id")
+	assertJSON(t, body["data"], `{"name":"azuki","count":2}`)
+}
+
+func TestErrorEnvelope(t *testing.T) {
+	r := newEnvelopeRouter()
+
+	_, _, v1Body := doEnvelope(t, r, "/error", false)
+	assertKeys(t, v1Body, "trace_id", "code", "msg", "data")
+	assertJSON(t, v1Body["data"], "null")
+
+	_, _, v2Body := doEnvelope(t, r, "/error", true)
+	assertKeys(t, v2Body, "code", "message", "data", "request_id")
+	assertJSON(t, v2Body["data"], "null")
+
+	// 两种信封的业务状态码和错误信息一致
+	assertJSON(t, v2Body["code"], string(v1Body["code"]))
+	assertJSON(t, v2Body["message"], string(v1Body["msg"]))
+}
+
+func TestParseTimeRange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	maxRange := int64(service.MaxTimeRangeDays) * 24 * 60 * 60
+	now := time.Now().Unix()
+
+	tests := []struct {
+		query   string
+		want    *types.TimeRange
+		wantErr bool
+	}{
+		{query: "", want: nil},
+		// 未传入 until 时保持为 0, 相同参数得到相同的区间
+		{query: "since=" + strconv.FormatInt(now-3600, 10), want: &types.TimeRange{Since: now - 3600}},
+		{query: "until=" + strconv.FormatInt(maxRange+100, 10), want: &types.TimeRange{Since: 100, Until: maxRange + 100}},
+		{query: "until=0", wantErr: true},
+		{query: "since=0&until=0", wantErr: true},
+		{query: "since=-1", wantErr: true},
+		{query: "since=200&until=100", wantErr: true},
+		{query: "since=" + strconv.FormatInt(now+3600, 10), wantErr: true},
+		// 未传入 until 时按当前时间计算跨度
+		{query: "since=" + strconv.FormatInt(now-maxRange-3600, 10), wantErr: true},
+		{query: "since=0&until=" + strconv.FormatInt(maxRange+1, 10), wantErr: true},
+	}
+	for _, tt := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil)
+
+		got, err := parseTimeRange(c)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseTimeRange(%q) = %+v, want error", tt.query, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseTimeRange(%q) error: %v", tt.query, err)
+			continue
+		}
+		if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+			t.Errorf("parseTimeRange(%q) = %+v, want %+v", tt.query, got, tt.want)
+		}
+	}
+}
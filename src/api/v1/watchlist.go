@@ -0,0 +1,106 @@
+package v1
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joinmouse/EasySwapBase/errcode"
+	"github.com/joinmouse/EasySwapBase/xhttp"
+
+	"github.com/joinmouse/EasySwapBackend/src/api/i18n"
+	"github.com/joinmouse/EasySwapBackend/src/api/middleware"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+	"github.com/joinmouse/EasySwapBackend/src/service/v1"
+)
+
+// parseWatchlistParams 解析关注列表接口的集合地址和链ID参数
+func parseWatchlistParams(c *gin.Context) (string, int, string, bool) {
+	collectionAddr := c.Params.ByName("address")
+	if collectionAddr == "" {
+		i18n.Error(c, errcode.ErrInvalidParams)
+		return "", 0, "", false
+	}
+
+	chainID, err := strconv.Atoi(c.Query("chain_id"))
+	if err != nil {
+		i18n.Error(c, errcode.ErrInvalidParams)
+		return "", 0, "", false
+	}
+
+	chain, ok := chainIDToChain[chainID]
+	if !ok {
+		i18n.Error(c, service.ErrInvalidChainID)
+		return "", 0, "", false
+	}
+
+	return collectionAddr, chainID, chain, true
+}
+
+// WatchlistHandler 获取当前用户关注的集合列表, 包含当前地板价和24小时地板价变化
+func WatchlistHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userAddr, ok := middleware.GetAuthAddress(c)
+		if !ok {
+			i18n.Error(c, errcode.ErrTokenVerify)
+			return
+		}
+
+		res, err := service.GetWatchlist(c.Request.Context(), svcCtx, userAddr)
+		if err != nil {
+			i18n.Error(c, errcode.NewCustomErr("get watchlist error"))
+			return
+		}
+
+		xhttp.OkJson(c, struct {
+			Result interface{} `json:"result"`
+		}{
+			Result: res,
+		})
+	}
+}
+
+// AddWatchlistHandler 关注集合, 重复关注同一集合不会报错
+func AddWatchlistHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userAddr, ok := middleware.GetAuthAddress(c)
+		if !ok {
+			i18n.Error(c, errcode.ErrTokenVerify)
+			return
+		}
+
+		collectionAddr, chainID, chain, ok := parseWatchlistParams(c)
+		if !ok {
+			return
+		}
+
+		if err := service.AddToWatchlist(c.Request.Context(), svcCtx, userAddr, chainID, chain, collectionAddr); err != nil {
+			handleServiceError(c, err, errcode.NewCustomErr("add watchlist error"))
+			return
+		}
+
+		xhttp.OkJson(c, nil)
+	}
+}
+
+// RemoveWatchlistHandler 取消关注集合
+func RemoveWatchlistHandler(svcCtx *svc.ServerCtx) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userAddr, ok := middleware.GetAuthAddress(c)
+		if !ok {
+			i18n.Error(c, errcode.ErrTokenVerify)
+			return
+		}
+
+		collectionAddr, chainID, _, ok := parseWatchlistParams(c)
+		if !ok {
+			return
+		}
+
+		if err := service.RemoveFromWatchlist(c.Request.Context(), svcCtx, userAddr, chainID, collectionAddr); err != nil {
+			i18n.Error(c, errcode.NewCustomErr("remove watchlist error"))
+			return
+		}
+
+		xhttp.OkJson(c, nil)
+	}
+}
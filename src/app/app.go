@@ -4,13 +4,21 @@ package app
 
 import (
 	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"                              // Gin Web框架，用于构建REST API
 	"github.com/joinmouse/EasySwapBase/logger/xzap"         // 日志库，基于zap的结构化日志
+	"github.com/pkg/errors"                                 // 错误处理库
 	"go.uber.org/zap"                                       // Uber的高性能日志库
 
+	"github.com/joinmouse/EasySwapBackend/src/api/middleware" // 中间件，提供正在处理中的请求数
 	"github.com/joinmouse/EasySwapBackend/src/config"       // 配置管理模块
 	"github.com/joinmouse/EasySwapBackend/src/service/svc"  // 服务上下文模块
+	"github.com/joinmouse/EasySwapBackend/src/service/worker" // 后台任务模块
 )
 
 // Platform 表示EasySwap NFT交易所的主应用程序平台
@@ -19,6 +27,10 @@ type Platform struct {
 	config    *config.Config    // 应用程序配置，包含数据库、API、区块链等配置信息
 	router    *gin.Engine       // Gin HTTP路由器，处理所有的API请求
 	serverCtx *svc.ServerCtx    // 服务上下文，包含数据库连接、缓存、区块链服务等
+	server    *http.Server      // HTTP服务器，包装Gin路由器以支持优雅关闭
+	marketWorker *worker.MarketWorker // 集合地板价和成交数据的后台刷新任务，未启用时为 nil
+	poolStatsWorker *worker.PoolStatsWorker // 数据库连接池监控指标的采集任务，未启用时为 nil
+	activityStreamWorker *worker.ActivityStreamWorker // 新交易活动的实时推送任务，未启用时为 nil
 }
 
 // NewPlatform 创建一个新的应用程序平台实例
@@ -35,24 +47,122 @@ func NewPlatform(config *config.Config, router *gin.Engine, serverCtx *svc.Serve
 		config:    config,     // 保存应用程序配置
 		router:    router,     // 保存HTTP路由器
 		serverCtx: serverCtx,  // 保存服务上下文
+		marketWorker: worker.NewMarketWorker(serverCtx), // 创建集合行情后台刷新任务
+		poolStatsWorker: worker.NewPoolStatsWorker(serverCtx), // 创建连接池监控指标采集任务
+		activityStreamWorker: worker.NewActivityStreamWorker(serverCtx), // 创建交易活动实时推送任务
+		server: &http.Server{
+			Addr:    config.Api.Port, // 监听地址
+			Handler: router,          // 使用Gin路由器处理请求
+		},
 	}, nil
 }
 
 // Start 启动应用程序平台
 // 该方法会记录启动信息并开始HTTP服务器的监听
 // 服务器将在配置指定的端口上接收和处理HTTP请求
-// 此方法会阻塞运行，直到服务器关闭或发生错误
-func (p *Platform) Start() {
+// 此方法会阻塞运行，直到收到 SIGINT/SIGTERM 信号或服务器发生错误
+// 收到退出信号后会在超时时间内等待正在处理的请求完成，然后释放服务上下文资源
+func (p *Platform) Start() error {
 	// 记录服务器启动日志，包含监听端口信息
 	xzap.WithContext(context.Background()).Info(
-		"EasySwap NFT交易所后端服务器已启动", 
+		"EasySwap NFT交易所后端服务器已启动",
 		zap.String("port", p.config.Api.Port),  // 记录监听端口
+		zap.Int64("max_num", p.config.Api.MaxNum), // 记录生效的最大并发请求数
 	)
-	
-	// 启动Gin HTTP服务器
-	// 在指定端口上开始监听并处理HTTP请求
-	if err := p.router.Run(p.config.Api.Port); err != nil {
-		// 如果服务器启动失败，直接崩溃程序
-		panic(err)
+
+	// 启动集合行情后台刷新任务
+	if p.marketWorker != nil {
+		if err := p.marketWorker.Start(); err != nil {
+			xzap.WithContext(context.Background()).Warn("启动集合行情刷新任务失败", zap.Error(err))
+		}
+	}
+
+	// 启动连接池监控指标采集任务
+	if p.poolStatsWorker != nil {
+		if err := p.poolStatsWorker.Start(); err != nil {
+			xzap.WithContext(context.Background()).Warn("启动连接池监控任务失败", zap.Error(err))
+		}
+	}
+
+	// 启动交易活动实时推送任务
+	if p.activityStreamWorker != nil {
+		if err := p.activityStreamWorker.Start(); err != nil {
+			xzap.WithContext(context.Background()).Warn("启动交易活动推送任务失败", zap.Error(err))
+		}
+	}
+
+	// 在独立的协程中启动HTTP服务器
+	// 正常关闭时 ListenAndServe 返回 http.ErrServerClosed，不视为错误
+	serveErr := make(chan error, 1)
+	go func() {
+		if err := p.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- err
+		}
+		close(serveErr)
+	}()
+
+	// 监听系统退出信号
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(quit)
+
+	select {
+	case err, ok := <-serveErr:
+		if ok {
+			// 服务器启动或运行失败，释放资源后返回错误
+			p.Close()
+			return errors.Wrap(err, "failed on run http server")
+		}
+		return p.Close()
+	case sig := <-quit:
+		// 先标记为正在关闭，就绪检查立即失败，负载均衡不再转发新请求，存活检查保持200直到进程退出
+		if p.serverCtx != nil {
+			p.serverCtx.SetShuttingDown()
+		}
+		xzap.WithContext(context.Background()).Info("收到退出信号，开始优雅关闭服务器",
+			zap.String("signal", sig.String()), zap.Int64("in_flight", middleware.InFlightRequests()))
+	}
+
+	// 在超时时间内等待正在处理的请求完成
+	timeout := p.config.Api.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = config.DefaultShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	if err := p.server.Shutdown(ctx); err != nil {
+		p.Close()
+		return errors.Wrap(err, "failed on shutdown http server")
+	}
+
+	xzap.WithContext(context.Background()).Info("HTTP服务器已关闭",
+		zap.Int64("in_flight", middleware.InFlightRequests()))
+	return p.Close()
+}
+
+// Close 释放平台持有的服务上下文资源（数据库、缓存等）
+// 在HTTP服务器关闭之后调用
+func (p *Platform) Close() error {
+	// 先停止后台任务，避免任务继续使用即将关闭的数据库连接
+	if p.marketWorker != nil {
+		p.marketWorker.Stop()
+	}
+	if p.poolStatsWorker != nil {
+		p.poolStatsWorker.Stop()
 	}
+	if p.activityStreamWorker != nil {
+		p.activityStreamWorker.Stop()
+	}
+
+	if p.serverCtx == nil {
+		return nil
+	}
+
+	if err := p.serverCtx.Close(); err != nil {
+		xzap.WithContext(context.Background()).Error("释放服务上下文资源失败", zap.Error(err))
+		return err
+	}
+
+	return nil
 }
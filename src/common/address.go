@@ -10,6 +10,9 @@ import (
 	"github.com/joinmouse/EasySwapBackend/src/common/utils" // 内部工具函数
 )
 
+// ErrInvalidAddress 地址格式不合法
+var ErrInvalidAddress = errors.New("invalid address format")
+
 // UnifyAddress 统一化区块链地址格式
 // 该函数将输入的地址转换为标准的 EIP-55 校验和地址格式
 // 确保所有地址在系统中都使用统一的格式，避免因大小写不同导致的问题
@@ -24,20 +27,20 @@ func UnifyAddress(address string) (string, error) {
 	// 验证地址的基本格式
 	// 地址必须大于 2 个字符（包含 0x 前缀）且符合十六进制地址格式
 	if len(address) <= 2 || !common.IsHexAddress(address) {
-		return "", errors.New("用户地址格式不合法")
+		return "", ErrInvalidAddress
 	}
 
 	// 使用 EIP-55 标准转换为校验和地址
 	// EIP-55 通过大小写混合的方式提供地址校验功能
 	addr, err := eip.ToCheckSumAddress(address)
 	if err != nil {
-		return "", errors.Wrap(err, "无效的地址格式")
+		return "", errors.Wrap(ErrInvalidAddress, err.Error())
 	}
 
 	// 再次验证转换后的地址是否有效
 	// 这是一个额外的安全检查，确保地址的一致性
 	if addr != utils.ToValidateAddress(addr) {
-		return "", errors.Wrap(err, "地址统一化失败")
+		return "", ErrInvalidAddress
 	}
 
 	return addr, nil
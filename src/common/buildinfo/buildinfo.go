@@ -0,0 +1,54 @@
+// Package buildinfo 记录服务的构建信息和启动时间
+// Version 和 Commit 在构建时通过 -ldflags 注入, 例如:
+//
+//	go build -ldflags "-X github.com/joinmouse/EasySwapBackend/src/common/buildinfo.Version=v1.2.0 \
+//		-X github.com/joinmouse/EasySwapBackend/src/common/buildinfo.Commit=$(git rev-parse HEAD)"
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+var (
+	// Version 服务版本, 构建时注入, 未注入时为 dev
+	Version = "dev"
+	// Commit 构建时的 git commit, 未注入时尝试读取 go 构建时记录的 vcs 信息
+	Commit = ""
+)
+
+// startTime 进程启动时间
+var startTime = time.Now()
+
+// Info 服务的构建信息
+type Info struct {
+	Version       string `json:"version"`
+	Commit        string `json:"commit"`
+	GoVersion     string `json:"go_version"`
+	StartTime     int64  `json:"start_time"`     // 进程启动时间(秒)
+	UptimeSeconds int64  `json:"uptime_seconds"` // 已运行时间(秒)
+}
+
+// Get 返回当前进程的构建信息
+func Get() Info {
+	commit := Commit
+	if commit == "" {
+		if bi, ok := debug.ReadBuildInfo(); ok {
+			for _, s := range bi.Settings {
+				if s.Key == "vcs.revision" {
+					commit = s.Value
+					break
+				}
+			}
+		}
+	}
+
+	return Info{
+		Version:       Version,
+		Commit:        commit,
+		GoVersion:     runtime.Version(),
+		StartTime:     startTime.Unix(),
+		UptimeSeconds: int64(time.Since(startTime).Seconds()),
+	}
+}
@@ -0,0 +1,182 @@
+// Package metrics 定义了EasySwap NFT交易所后端服务的Prometheus监控指标
+// 包括HTTP请求数、请求耗时、并发请求数、各链RPC调用次数、缓存命中情况以及数据库连接池状态
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const defaultNamespace = "easyswap"
+
+var (
+	// RequestTotal HTTP请求总数，按路由模板、请求方法和状态码区分
+	RequestTotal *prometheus.CounterVec
+	// RequestDuration HTTP请求耗时分布（秒），按路由模板、请求方法和状态码区分
+	RequestDuration *prometheus.HistogramVec
+	// RequestsInFlight 正在处理中的HTTP请求数
+	RequestsInFlight prometheus.Gauge
+	// RPCCallTotal 通过NodeSrvs发起的链上RPC调用次数，按链、方法和结果区分
+	RPCCallTotal *prometheus.CounterVec
+	// CacheLookupTotal 预计算缓存的读取次数，按缓存名称和是否命中区分，用于计算命中率
+	CacheLookupTotal *prometheus.CounterVec
+	// DBPoolConnections 数据库连接池的连接数，按状态区分: max_open / open / in_use / idle
+	DBPoolConnections *prometheus.GaugeVec
+	// DBPoolWaitCount 等待获取数据库连接的累计次数
+	DBPoolWaitCount prometheus.Gauge
+	// DBPoolWaitDuration 等待获取数据库连接的累计时长（秒）
+	DBPoolWaitDuration prometheus.Gauge
+
+	registry = prometheus.NewRegistry()
+	initOnce sync.Once
+
+	invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+)
+
+// Init 使用给定的命名空间初始化并注册所有指标
+// 命名空间一般取ProjectCfg.Name，避免多个部署之间的指标名冲突; 只有第一次调用生效
+func Init(namespace string) {
+	initOnce.Do(func() {
+		ns := sanitizeNamespace(namespace)
+
+		RequestTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Total number of HTTP requests.",
+		}, []string{"route", "method", "status"})
+
+		RequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: ns,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency in seconds.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"})
+
+		RequestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: ns,
+			Subsystem: "http",
+			Name:      "requests_in_flight",
+			Help:      "Number of HTTP requests currently being served.",
+		})
+
+		RPCCallTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns,
+			Subsystem: "rpc",
+			Name:      "calls_total",
+			Help:      "Total number of chain RPC calls made through node services.",
+		}, []string{"chain", "method", "result"})
+
+		CacheLookupTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns,
+			Subsystem: "cache",
+			Name:      "lookups_total",
+			Help:      "Total number of precomputed cache lookups.",
+		}, []string{"cache", "result"})
+
+		DBPoolConnections = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: ns,
+			Subsystem: "db_pool",
+			Name:      "connections",
+			Help:      "Number of database pool connections by state.",
+		}, []string{"state"})
+
+		DBPoolWaitCount = prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: ns,
+			Subsystem: "db_pool",
+			Name:      "wait_count",
+			Help:      "Total number of connections waited for.",
+		})
+
+		DBPoolWaitDuration = prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: ns,
+			Subsystem: "db_pool",
+			Name:      "wait_duration_seconds",
+			Help:      "Total time blocked waiting for a new connection in seconds.",
+		})
+
+		registry.MustRegister(
+			RequestTotal,
+			RequestDuration,
+			RequestsInFlight,
+			RPCCallTotal,
+			CacheLookupTotal,
+			DBPoolConnections,
+			DBPoolWaitCount,
+			DBPoolWaitDuration,
+			prometheus.NewGoCollector(),
+			prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{Namespace: ns}),
+		)
+	})
+}
+
+// Handler 返回暴露指标数据的HTTP处理器
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// Registry 返回指标注册器，便于其他模块注册自定义指标
+func Registry() *prometheus.Registry {
+	return registry
+}
+
+// ObserveRPC 记录一次链上RPC调用
+func ObserveRPC(chain, method string, err error) {
+	if RPCCallTotal == nil {
+		return
+	}
+
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	RPCCallTotal.WithLabelValues(chain, method, result).Inc()
+}
+
+// ObserveCache 记录一次预计算缓存的读取
+func ObserveCache(cache string, hit bool) {
+	if CacheLookupTotal == nil {
+		return
+	}
+
+	result := "hit"
+	if !hit {
+		result = "miss"
+	}
+	CacheLookupTotal.WithLabelValues(cache, result).Inc()
+}
+
+// ObserveDBPool 记录数据库连接池的状态
+// 等待次数和等待时长是 sql.DB 的累计值, 告警时使用 rate() 计算单位时间内的增量
+func ObserveDBPool(stats sql.DBStats) {
+	if DBPoolConnections == nil {
+		return
+	}
+
+	DBPoolConnections.WithLabelValues("max_open").Set(float64(stats.MaxOpenConnections))
+	DBPoolConnections.WithLabelValues("open").Set(float64(stats.OpenConnections))
+	DBPoolConnections.WithLabelValues("in_use").Set(float64(stats.InUse))
+	DBPoolConnections.WithLabelValues("idle").Set(float64(stats.Idle))
+	DBPoolWaitCount.Set(float64(stats.WaitCount))
+	DBPoolWaitDuration.Set(stats.WaitDuration.Seconds())
+}
+
+// sanitizeNamespace 将项目名称转换为合法的Prometheus命名空间
+func sanitizeNamespace(namespace string) string {
+	ns := invalidNameChars.ReplaceAllString(strings.ToLower(strings.TrimSpace(namespace)), "_")
+	if ns == "" {
+		return defaultNamespace
+	}
+	if ns[0] >= '0' && ns[0] <= '9' {
+		ns = "_" + ns
+	}
+
+	return ns
+}
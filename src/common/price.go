@@ -0,0 +1,54 @@
+package common
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+// 价格聚合辅助函数
+// 约定: 价格为 0 表示没有价格(如没有挂单时的地板价), 负数价格是异常数据, 两者都不参与最小值/最大值的比较;
+// decimal.Decimal 的零值可以直接使用, 等同于 decimal.Zero
+
+// IsZeroOrNeg 判断价格是否为 0 或负数, 即不是有效的价格
+func IsZeroOrNeg(price decimal.Decimal) bool {
+	return price.Sign() <= 0
+}
+
+// MinPrice 返回有效价格中的最小值, 没有有效价格时返回 0
+func MinPrice(prices ...decimal.Decimal) decimal.Decimal {
+	min := decimal.Zero
+	for _, price := range prices {
+		if IsZeroOrNeg(price) {
+			continue
+		}
+		if IsZeroOrNeg(min) || price.LessThan(min) {
+			min = price
+		}
+	}
+
+	return min
+}
+
+// MaxPrice 返回有效价格中的最大值, 没有有效价格时返回 0
+func MaxPrice(prices ...decimal.Decimal) decimal.Decimal {
+	max := decimal.Zero
+	for _, price := range prices {
+		if price.GreaterThan(max) {
+			max = price
+		}
+	}
+
+	return max
+}
+
+// SumPrices 返回价格之和, 负数价格视为异常数据不计入
+func SumPrices(prices ...decimal.Decimal) decimal.Decimal {
+	sum := decimal.Zero
+	for _, price := range prices {
+		if IsZeroOrNeg(price) {
+			continue
+		}
+		sum = sum.Add(price)
+	}
+
+	return sum
+}
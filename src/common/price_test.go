@@ -0,0 +1,70 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func prices(values ...string) []decimal.Decimal {
+	res := make([]decimal.Decimal, 0, len(values))
+	for _, v := range values {
+		res = append(res, decimal.RequireFromString(v))
+	}
+	return res
+}
+
+func TestIsZeroOrNeg(t *testing.T) {
+	tests := []struct {
+		price string
+		want  bool
+	}{
+		{"0", true},
+		{"-0.000000000000000001", true},
+		{"-1", true},
+		{"0.000000000000000001", false},
+		{"1.5", false},
+	}
+	for _, tt := range tests {
+		if got := IsZeroOrNeg(decimal.RequireFromString(tt.price)); got != tt.want {
+			t.Errorf("IsZeroOrNeg(%s) = %v, want %v", tt.price, got, tt.want)
+		}
+	}
+
+	var zero decimal.Decimal
+	if !IsZeroOrNeg(zero) {
+		t.Error("IsZeroOrNeg(zero value) = false, want true")
+	}
+}
+
+func TestPriceAggregates(t *testing.T) {
+	tests := []struct {
+		name            string
+		prices          []decimal.Decimal
+		min, max, total string
+	}{
+		{"empty", nil, "0", "0", "0"},
+		{"single", prices("1.5"), "1.5", "1.5", "1.5"},
+		{"single zero", prices("0"), "0", "0", "0"},
+		{"single negative", prices("-2"), "0", "0", "0"},
+		{"all invalid", prices("0", "-1", "-0.5"), "0", "0", "0"},
+		{"zero ignored", prices("0", "2", "0.5"), "0.5", "2", "2.5"},
+		{"negative ignored", prices("-3", "1", "4"), "1", "4", "5"},
+		{"decimal precision", prices("0.1", "0.2"), "0.1", "0.2", "0.3"},
+		{"duplicates", prices("1", "1", "1"), "1", "1", "3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MinPrice(tt.prices...); !got.Equal(decimal.RequireFromString(tt.min)) {
+				t.Errorf("MinPrice = %s, want %s", got, tt.min)
+			}
+			if got := MaxPrice(tt.prices...); !got.Equal(decimal.RequireFromString(tt.max)) {
+				t.Errorf("MaxPrice = %s, want %s", got, tt.max)
+			}
+			if got := SumPrices(tt.prices...); !got.Equal(decimal.RequireFromString(tt.total)) {
+				t.Errorf("SumPrices = %s, want %s", got, tt.total)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package common
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidTokenID token id 不是合法的 uint256 整数
+var ErrInvalidTokenID = errors.New("invalid token id format")
+
+// maxTokenID token id 的最大值 2^256-1
+var maxTokenID = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+// NormalizeTokenID 统一化 token id 格式
+// 支持十进制和 0x 前缀的十六进制, 校验为不超过 uint256 的非负整数后转换为不带前导零的十进制字符串
+// 保证 "007"、"7" 和 "0x7" 在数据库中对应同一条记录
+//
+// 参数:
+//   - tokenID: 原始 token id 字符串
+//
+// 返回值:
+//   - string: 标准化后的十进制 token id
+//   - error: token id 不合法时返回 ErrInvalidTokenID
+func NormalizeTokenID(tokenID string) (string, error) {
+	tokenID = strings.TrimSpace(tokenID)
+
+	base := 10
+	digits := tokenID
+	if strings.HasPrefix(tokenID, "0x") || strings.HasPrefix(tokenID, "0X") {
+		base = 16
+		digits = tokenID[2:]
+	}
+	if digits == "" {
+		return "", ErrInvalidTokenID
+	}
+
+	// big.Int 的 SetString 接受正负号和下划线, 这里只允许数字
+	for _, ch := range digits {
+		isDigit := ch >= '0' && ch <= '9'
+		isHex := base == 16 && ((ch >= 'a' && ch <= 'f') || (ch >= 'A' && ch <= 'F'))
+		if !isDigit && !isHex {
+			return "", ErrInvalidTokenID
+		}
+	}
+
+	value, ok := new(big.Int).SetString(digits, base)
+	if !ok || value.Cmp(maxTokenID) > 0 {
+		return "", ErrInvalidTokenID
+	}
+
+	return value.String(), nil
+}
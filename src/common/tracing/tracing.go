@@ -0,0 +1,129 @@
+// Package tracing 封装了EasySwap NFT交易所后端服务的 OpenTelemetry 链路追踪
+// 负责初始化 OTLP 导出器、W3C traceparent 传播器，并提供创建 span 的辅助函数
+// 未配置导出端点时全局 TracerProvider 保持为 otel 默认的 no-op 实现，创建 span 几乎没有开销
+package tracing
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/joinmouse/EasySwapBackend/src/config"
+)
+
+// TracerName 本服务创建 span 时使用的 tracer 名称
+const TracerName = "github.com/joinmouse/EasySwapBackend"
+
+// defaultServiceName 未配置服务名且没有项目名时上报的服务名
+const defaultServiceName = "easyswap-backend"
+
+// Init 根据配置初始化全局的 TracerProvider 和传播器
+// 1. 无论是否导出都注册 W3C traceparent 传播器, 保证上游的链路信息可以继续透传
+// 2. 未配置或 Endpoint 为空时不创建导出器, 返回的关闭函数什么也不做
+// 3. serviceName 为 conf.ServiceName 为空时使用的服务名, 一般取 ProjectCfg.Name
+//
+// 返回值:
+//   - func(context.Context) error: 关闭函数, 进程退出前调用以导出剩余的 span
+//   - error: 创建导出器失败时的错误
+func Init(conf *config.Trace, serviceName string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	noop := func(context.Context) error { return nil }
+	if conf == nil || conf.Endpoint == "" {
+		return noop, nil
+	}
+
+	exporter, err := newExporter(conf)
+	if err != nil {
+		return noop, err
+	}
+
+	if conf.ServiceName != "" {
+		serviceName = conf.ServiceName
+	}
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+
+	ratio := conf.SampleRatio
+	if ratio <= 0 {
+		ratio = 1
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceName(serviceName),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		if err := provider.Shutdown(ctx); err != nil {
+			return errors.Wrap(err, "failed on shutdown tracer provider")
+		}
+		return nil
+	}, nil
+}
+
+// newExporter 按配置的协议创建 OTLP 导出器, 创建时不会阻塞等待连接建立
+func newExporter(conf *config.Trace) (*otlptrace.Exporter, error) {
+	var client otlptrace.Client
+	switch conf.Protocol {
+	case config.TraceProtocolHTTP:
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(conf.Endpoint)}
+		if conf.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		client = otlptracehttp.NewClient(opts...)
+	default:
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(conf.Endpoint)}
+		if conf.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		client = otlptracegrpc.NewClient(opts...)
+	}
+
+	exporter, err := otlptrace.New(context.Background(), client)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on create otlp trace exporter")
+	}
+
+	return exporter, nil
+}
+
+// Tracer 返回本服务使用的 tracer
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// Start 以 ctx 中的 span 为父节点创建一个内部 span
+// 调用方需要在操作结束时调用 End
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End 结束 span, err 不为空时记录错误并将 span 标记为失败
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -4,10 +4,12 @@ package config
 
 import (
 	"strings"
+	"time"
 
 	"github.com/joinmouse/EasySwapBase/evm/erc"        // ERC标准实现，用于处理NFT相关操作
 	logging "github.com/joinmouse/EasySwapBase/logger" // 日志配置结构
 	"github.com/joinmouse/EasySwapBase/stores/gdb"     // 数据库配置结构
+	"github.com/mitchellh/mapstructure"               // 配置解析时的类型转换钩子
 	"github.com/spf13/viper"                          // 配置文件解析库
 )
 
@@ -17,11 +19,16 @@ type Config struct {
 	Api            `toml:"api" json:"api"`                                                               // API 服务器配置，包括端口和请求限制
 	ProjectCfg     *ProjectCfg     `toml:"project_cfg" mapstructure:"project_cfg" json:"project_cfg"`         // 项目基本信息配置
 	Log            logging.LogConf `toml:"log" json:"log"`                                                   // 日志系统配置
-	DB             gdb.Config      `toml:"db" json:"db"`                                                     // 数据库连接配置
+	DB             DBConf          `toml:"db" json:"db"`                                                     // 数据库连接配置
 	Kv             *KvConf         `toml:"kv" json:"kv"`                                                     // 键值存储（Redis）配置
 	Evm            *erc.NftErc     `toml:"evm" json:"evm"`                                                   // EVM 区块链相关配置
 	MetadataParse  *MetadataParse  `toml:"metadata_parse" mapstructure:"metadata_parse" json:"metadata_parse"` // NFT 元数据解析配置
 	ChainSupported []*ChainSupported `toml:"chain_supported" mapstructure:"chain_supported" json:"chain_supported"` // 支持的区块链列表配置
+	Worker         Worker          `toml:"worker" mapstructure:"worker" json:"worker"`                         // 后台任务配置
+	IpfsGateways   []string        `toml:"ipfs_gateways" mapstructure:"ipfs_gateways" json:"ipfs_gateways"`     // IPFS 网关列表，按优先级排列，请求失败或超时时依次切换，为空时使用内置的公共网关
+	MaxPrice       float64         `toml:"max_price" mapstructure:"max_price" json:"max_price"`                 // 订单/成交的合理最大价格（代币数量），超过的视为异常数据，不参与统计，默认 1e12
+	Trace          *Trace          `toml:"trace" mapstructure:"trace" json:"trace"`                           // 链路追踪配置，不配置或 Endpoint 为空时不导出
+	AllowDegradedChains bool       `toml:"allow_degraded_chains" mapstructure:"allow_degraded_chains" json:"allow_degraded_chains"` // 某条链初始化失败时是否继续启动，失败的链标记为降级，其接口返回503；默认 false，任一链失败即启动失败
 }
 
 // ProjectCfg 定义了项目的基本信息配置
@@ -31,13 +38,374 @@ type ProjectCfg struct {
 
 // Api 定义了 HTTP API 服务器的配置参数
 type Api struct {
-	Port   string `toml:"port" json:"port"`     // HTTP 服务器监听端口，格式为 ":8080"
-	MaxNum int64  `toml:"max_num" json:"max_num"` // 最大并发请求数量限制
+	Port            string `toml:"port" mapstructure:"port" json:"port"`                               // HTTP 服务器监听端口，格式为 ":8080"
+	Mode            string `toml:"mode" mapstructure:"mode" json:"mode"`                               // Gin 运行模式: debug、release 或 test，为空时使用 release
+	MaxNum          int64  `toml:"max_num" mapstructure:"max_num" json:"max_num"`                      // 最大并发请求数量限制
+	ShutdownTimeout int    `toml:"shutdown_timeout" mapstructure:"shutdown_timeout" json:"shutdown_timeout"` // 优雅关闭等待时间（秒），默认 10 秒
+	RateLimit       RateLimit `toml:"rate_limit" mapstructure:"rate_limit" json:"rate_limit"`                // 接口限流配置
+	LogBody         LogBody   `toml:"log_body" mapstructure:"log_body" json:"log_body"`                      // 请求日志中请求体/响应体的脱敏与截断配置
+	LogSampling     LogSampling `toml:"log_sampling" mapstructure:"log_sampling" json:"log_sampling"`        // 请求日志采样配置
+	Compression     Compression `toml:"compression" mapstructure:"compression" json:"compression"`          // 响应 gzip 压缩配置
+	ReadOnly        bool        `toml:"read_only" mapstructure:"read_only" json:"read_only"`                // 启动时是否处于只读模式，只读模式下写接口返回503，运行时可通过管理接口切换
+	AdminSecret     string      `toml:"admin_secret" mapstructure:"admin_secret" json:"-"`                  // 管理接口密钥，请求头 X-Admin-Secret 需与之一致，为空时不开放管理接口
+	AdminAllowlist  []string    `toml:"admin_allowlist" mapstructure:"admin_allowlist" json:"admin_allowlist"` // 允许访问管理接口的来源 IP 或 CIDR，为空时只允许本机访问
+	TrustedProxies  []string    `toml:"trusted_proxies" mapstructure:"trusted_proxies" json:"trusted_proxies"` // 可信代理的 IP 或 CIDR，配置后管理接口根据 X-Forwarded-For 判断来源 IP
+	MaxBodyBytes    int64       `toml:"max_body_bytes" mapstructure:"max_body_bytes" json:"max_body_bytes"` // 请求体最大字节数，超过时返回413，0 使用默认的 1MB，负数表示不限制
+	ApiKeys         []ApiKey    `toml:"api_keys" mapstructure:"api_keys" json:"-"`                          // 服务端集成使用的 API Key，也可以存放在数据库 api_key 表中
+	Cache           Cache       `toml:"cache" mapstructure:"cache" json:"cache"`                            // 接口响应缓存时间配置
+	PanicAlert      PanicAlert  `toml:"panic_alert" mapstructure:"panic_alert" json:"panic_alert"`          // 请求处理发生 panic 时的告警配置
+	RequestTimeout  RequestTimeout `toml:"request_timeout" mapstructure:"request_timeout" json:"request_timeout"` // 请求处理超时配置，客户端可通过 X-Request-Timeout-Ms 请求头指定
+}
+
+// RequestTimeout 定义了请求处理的超时配置
+// 请求携带 X-Request-Timeout-Ms 时使用请求头中的值, 不能超过 Max; 未携带时使用 Default
+type RequestTimeout struct {
+	Default int `toml:"default" mapstructure:"default" json:"default"` // 未携带请求头时的超时时间（毫秒），0 使用默认的 15000，负数表示不限制
+	Max     int `toml:"max" mapstructure:"max" json:"max"`             // 请求头允许的最大超时时间（毫秒），0 使用默认的 30000
+}
+
+// 默认的请求处理超时时间（毫秒）
+const (
+	DefaultRequestTimeout    = 15000
+	DefaultMaxRequestTimeout = 30000
+)
+
+// DefaultOrDefault 返回未携带请求头时生效的超时时间，为 0 表示不限制
+func (r RequestTimeout) DefaultOrDefault() time.Duration {
+	if r.Default < 0 {
+		return 0
+	}
+	if r.Default == 0 {
+		return DefaultRequestTimeout * time.Millisecond
+	}
+	return time.Duration(r.Default) * time.Millisecond
+}
+
+// MaxOrDefault 返回请求头允许的最大超时时间
+func (r RequestTimeout) MaxOrDefault() time.Duration {
+	if r.Max <= 0 {
+		return DefaultMaxRequestTimeout * time.Millisecond
+	}
+	return time.Duration(r.Max) * time.Millisecond
+}
+
+// PanicAlert 定义了请求处理发生 panic 时的告警配置
+// Webhook 为空时只记录错误日志，不发送告警
+type PanicAlert struct {
+	Webhook string `toml:"webhook" mapstructure:"webhook" json:"-"`            // 告警 Webhook 地址，panic 时以 JSON 格式 POST 告警内容，地址中可能包含密钥，不对外展示
+	Timeout int    `toml:"timeout" mapstructure:"timeout" json:"timeout"`     // 发送告警的超时时间（秒），0 使用默认的 5 秒
+}
+
+// DefaultPanicAlertTimeout 默认的告警发送超时时间（秒）
+const DefaultPanicAlertTimeout = 5
+
+// TimeoutOrDefault 返回生效的告警发送超时时间
+func (p PanicAlert) TimeoutOrDefault() time.Duration {
+	if p.Timeout <= 0 {
+		return DefaultPanicAlertTimeout * time.Second
+	}
+	return time.Duration(p.Timeout) * time.Second
+}
+
+// Cache 定义了各接口响应的缓存时间
+// TTLs 按路由名配置缓存秒数，未配置的路由使用 DefaultTTL
+type Cache struct {
+	DefaultTTL int            `toml:"default_ttl" mapstructure:"default_ttl" json:"default_ttl"` // 默认缓存时间（秒），0 使用默认的 60 秒
+	TTLs       map[string]int `toml:"ttls" mapstructure:"ttls" json:"ttls"`                      // 路由名到缓存时间（秒）的映射
+}
+
+// 可以配置缓存时间的路由名
+const (
+	CacheRouteItemImage = "item_image" // NFT 物品图片
+	CacheRouteRanking   = "ranking"    // 集合排行榜
+)
+
+// CacheRoutes 所有可以配置缓存时间的路由名
+var CacheRoutes = []string{CacheRouteItemImage, CacheRouteRanking}
+
+// DefaultCacheTTL 默认的接口响应缓存时间（秒）
+const DefaultCacheTTL = 60
+
+// TTL 返回路由生效的缓存时间（秒）
+func (c Cache) TTL(route string) int {
+	if ttl, ok := c.TTLs[route]; ok && ttl > 0 {
+		return ttl
+	}
+	if c.DefaultTTL > 0 {
+		return c.DefaultTTL
+	}
+	return DefaultCacheTTL
+}
+
+// ApiKey 定义了合作方服务端集成使用的 API Key
+// 只保存 Key 的 SHA-256 哈希（十六进制），明文 Key 只在发放时交给合作方
+type ApiKey struct {
+	Partner    string `toml:"partner" mapstructure:"partner" json:"partner"`             // 合作方标识
+	KeyHash    string `toml:"key_hash" mapstructure:"key_hash" json:"key_hash"`          // API Key 的 SHA-256 哈希（十六进制）
+	Scope      string `toml:"scope" mapstructure:"scope" json:"scope"`                   // 权限范围，read 只允许读请求，write 允许所有请求，默认 read
+	RateLimit  int    `toml:"rate_limit" mapstructure:"rate_limit" json:"rate_limit"`    // 时间窗口内允许的最大请求数，0 表示不限制
+	RateWindow int    `toml:"rate_window" mapstructure:"rate_window" json:"rate_window"` // 限流时间窗口（秒），0 使用默认的 60 秒
+}
+
+// API Key 权限范围
+const (
+	ApiKeyScopeRead  = "read"
+	ApiKeyScopeWrite = "write"
+)
+
+// DefaultApiKeyRateWindow 默认的 API Key 限流时间窗口（秒）
+const DefaultApiKeyRateWindow = 60
+
+// ScopeOrDefault 返回生效的权限范围
+func (k ApiKey) ScopeOrDefault() string {
+	if k.Scope == "" {
+		return ApiKeyScopeRead
+	}
+	return k.Scope
+}
+
+// RateWindowOrDefault 返回生效的限流时间窗口
+func (k ApiKey) RateWindowOrDefault() time.Duration {
+	if k.RateWindow <= 0 {
+		return DefaultApiKeyRateWindow * time.Second
+	}
+	return time.Duration(k.RateWindow) * time.Second
+}
+
+// DefaultMaxBodyBytes 默认的请求体最大字节数
+const DefaultMaxBodyBytes = 1 << 20
+
+// MaxBodyBytesOrDefault 返回生效的请求体最大字节数，为 0 表示不限制
+func (a Api) MaxBodyBytesOrDefault() int64 {
+	if a.MaxBodyBytes < 0 {
+		return 0
+	}
+	if a.MaxBodyBytes == 0 {
+		return DefaultMaxBodyBytes
+	}
+	return a.MaxBodyBytes
+}
+
+// Gin 运行模式
+const (
+	ApiModeDebug   = "debug"
+	ApiModeRelease = "release"
+	ApiModeTest    = "test"
+)
+
+// ModeOrDefault 返回生效的 Gin 运行模式，未配置时使用 release
+func (a Api) ModeOrDefault() string {
+	if a.Mode == "" {
+		return ApiModeRelease
+	}
+	return a.Mode
+}
+
+// Compression 定义了响应 gzip 压缩的配置
+// 客户端 Accept-Encoding 接受 gzip 且响应体达到 MinSize 时压缩
+type Compression struct {
+	Level   int `toml:"level" mapstructure:"level" json:"level"`          // 压缩级别 1-9，0 使用默认的 5，负数表示不启用压缩
+	MinSize int `toml:"min_size" mapstructure:"min_size" json:"min_size"` // 最小压缩字节数，0 使用默认的 1024
+}
+
+// 响应压缩默认配置
+const (
+	DefaultCompressionLevel   = 5
+	DefaultCompressionMinSize = 1024
+)
+
+// Enabled 判断是否启用响应压缩
+func (c Compression) Enabled() bool {
+	return c.Level >= 0
+}
+
+// LevelOrDefault 返回生效的压缩级别
+func (c Compression) LevelOrDefault() int {
+	if c.Level == 0 {
+		return DefaultCompressionLevel
+	}
+	return c.Level
+}
+
+// MinSizeOrDefault 返回生效的最小压缩字节数
+func (c Compression) MinSizeOrDefault() int {
+	if c.MinSize <= 0 {
+		return DefaultCompressionMinSize
+	}
+	return c.MinSize
+}
+
+// LogSampling 定义了请求日志的采样规则
+// 成功请求按路由每 Rate 条记录 1 条，出错、非 2xx 和慢请求总是记录
+type LogSampling struct {
+	Rate          int `toml:"rate" mapstructure:"rate" json:"rate"`                               // 成功请求采样率，0 或 1 表示记录全部请求
+	SlowThreshold int `toml:"slow_threshold" mapstructure:"slow_threshold" json:"slow_threshold"` // 慢请求阈值（毫秒），超过时总是记录，0 表示不启用
+}
+
+// LogBody 定义了请求日志记录请求体和响应体时的脱敏与截断规则
+// RedactKeys 为空时使用默认的敏感字段列表，MaxSize 为 0 时使用默认的 4KB
+type LogBody struct {
+	RedactKeys []string `toml:"redact_keys" mapstructure:"redact_keys" json:"redact_keys"` // 需要脱敏的 JSON 字段名（不区分大小写），值会被替换为 ***
+	MaxSize    int      `toml:"max_size" mapstructure:"max_size" json:"max_size"`          // 日志中记录的请求体/响应体最大字节数
+}
+
+// RateLimit 定义了基于 Redis 滑动窗口的接口限流配置
+// 按客户端 IP + 路由统计请求数，Limit 为 0 时不启用限流
+type RateLimit struct {
+	Limit  int `toml:"limit" json:"limit"`   // 时间窗口内允许的最大请求数
+	Window int `toml:"window" json:"window"` // 时间窗口大小（秒）
+}
+
+// Worker 定义了进程内后台任务的配置
+type Worker struct {
+	MarketRefreshInterval  int `toml:"market_refresh_interval" mapstructure:"market_refresh_interval" json:"market_refresh_interval"`    // 集合地板价和24小时成交数据的刷新间隔（秒），0 使用默认的 60 秒，负数表示不启用
+	PoolStatsInterval      int `toml:"pool_stats_interval" mapstructure:"pool_stats_interval" json:"pool_stats_interval"`                // 数据库连接池监控指标的采集间隔（秒），0 使用默认的 15 秒，负数表示不启用
+	ActivityStreamInterval int `toml:"activity_stream_interval" mapstructure:"activity_stream_interval" json:"activity_stream_interval"` // 轮询新交易活动并发布到实时推送频道的间隔（秒），0 使用默认的 2 秒，负数表示不启用
+}
+
+// Trace 定义了 OpenTelemetry 链路追踪的导出配置
+// Endpoint 为空时只透传 traceparent，不采集也不导出 span
+type Trace struct {
+	Endpoint    string  `toml:"endpoint" mapstructure:"endpoint" json:"endpoint"`             // OTLP 采集端地址，例如 "localhost:4317"
+	Protocol    string  `toml:"protocol" mapstructure:"protocol" json:"protocol"`             // 导出协议，grpc 或 http，默认 grpc
+	Insecure    bool    `toml:"insecure" mapstructure:"insecure" json:"insecure"`             // 是否使用明文连接（不启用 TLS）
+	SampleRatio float64 `toml:"sample_ratio" mapstructure:"sample_ratio" json:"sample_ratio"` // 根 span 的采样比例，0 使用默认的 1（全部采样）
+	ServiceName string  `toml:"service_name" mapstructure:"service_name" json:"service_name"` // 上报的服务名，为空时使用 ProjectCfg.Name
+}
+
+// 链路追踪导出协议
+const (
+	TraceProtocolGRPC = "grpc"
+	TraceProtocolHTTP = "http"
+)
+
+// DefaultShutdownTimeout 默认的优雅关闭等待时间（秒）
+const DefaultShutdownTimeout = 10
+
+// DBConf 定义了数据库配置
+// 在 gdb.Config 的基础上增加连接池配置
+type DBConf struct {
+	gdb.Config   `mapstructure:",squash"`
+	Pool         *DBPool `toml:"pool" mapstructure:"pool" json:"pool"`                            // 连接池配置，不配置时使用默认值
+	QueryTimeout int     `toml:"query_timeout" mapstructure:"query_timeout" json:"query_timeout"` // 单条 SQL 的默认超时时间（秒），0 使用默认的 10 秒，负数表示不限制
+}
+
+// DBPool 定义了 SQL 连接池的配置参数
+type DBPool struct {
+	MaxOpenConns    int `toml:"max_open_conns" mapstructure:"max_open_conns" json:"max_open_conns"`          // 最大打开连接数
+	MaxIdleConns    int `toml:"max_idle_conns" mapstructure:"max_idle_conns" json:"max_idle_conns"`          // 最大空闲连接数
+	ConnMaxLifetime int `toml:"conn_max_lifetime" mapstructure:"conn_max_lifetime" json:"conn_max_lifetime"` // 连接最大复用时间（秒）
+	ConnMaxIdleTime int `toml:"conn_max_idle_time" mapstructure:"conn_max_idle_time" json:"conn_max_idle_time"` // 连接最大空闲时间（秒）
+}
+
+// 连接池默认配置
+const (
+	DefaultDBMaxOpenConns    = 100
+	DefaultDBMaxIdleConns    = 10
+	DefaultDBConnMaxLifetime = 300 // 秒
+	DefaultDBConnMaxIdleTime = 60  // 秒
+)
+
+// 缓存重试和熔断默认配置
+const (
+	DefaultKvRetryAttempts    = 2
+	DefaultKvRetryBackoffMs   = 20
+	DefaultKvBreakerThreshold = 5
+	DefaultKvBreakerCooldown  = 10 // 秒
+)
+
+// DefaultDBQueryTimeout 默认的单条 SQL 超时时间（秒）
+const DefaultDBQueryTimeout = 10
+
+// QueryTimeoutDuration 返回生效的单条 SQL 超时时间，为 0 表示不限制
+func (c *DBConf) QueryTimeoutDuration() time.Duration {
+	if c.QueryTimeout < 0 {
+		return 0
+	}
+	if c.QueryTimeout == 0 {
+		return DefaultDBQueryTimeout * time.Second
+	}
+
+	return time.Duration(c.QueryTimeout) * time.Second
+}
+
+// RetryConfig 返回生效的缓存重试和熔断配置，未配置或非法的项使用默认值
+func (c *KvConf) RetryConfig() KvRetry {
+	retry := KvRetry{
+		Attempts:         DefaultKvRetryAttempts,
+		BackoffMs:        DefaultKvRetryBackoffMs,
+		BreakerThreshold: DefaultKvBreakerThreshold,
+		BreakerCooldown:  DefaultKvBreakerCooldown,
+	}
+	if c == nil || c.Retry == nil {
+		return retry
+	}
+
+	if c.Retry.Attempts > 0 {
+		retry.Attempts = c.Retry.Attempts
+	}
+	if c.Retry.BackoffMs > 0 {
+		retry.BackoffMs = c.Retry.BackoffMs
+	}
+	if c.Retry.BreakerThreshold > 0 {
+		retry.BreakerThreshold = c.Retry.BreakerThreshold
+	}
+	if c.Retry.BreakerCooldown > 0 {
+		retry.BreakerCooldown = c.Retry.BreakerCooldown
+	}
+
+	return retry
+}
+
+// PoolConfig 返回生效的连接池配置
+// 优先使用 db.pool 中的配置，未配置的项使用 db 中的旧配置项，仍未配置时使用默认值
+func (c *DBConf) PoolConfig() DBPool {
+	var pool DBPool
+	if c.Pool != nil {
+		pool = *c.Pool
+	}
+
+	if pool.MaxOpenConns <= 0 {
+		pool.MaxOpenConns = c.MaxOpenConns
+	}
+	if pool.MaxOpenConns <= 0 {
+		pool.MaxOpenConns = DefaultDBMaxOpenConns
+	}
+	if pool.MaxIdleConns <= 0 {
+		pool.MaxIdleConns = c.MaxIdleConns
+	}
+	if pool.MaxIdleConns <= 0 {
+		pool.MaxIdleConns = DefaultDBMaxIdleConns
+	}
+	// 空闲连接数不能超过最大连接数
+	if pool.MaxIdleConns > pool.MaxOpenConns {
+		pool.MaxIdleConns = pool.MaxOpenConns
+	}
+	if pool.ConnMaxLifetime <= 0 {
+		pool.ConnMaxLifetime = int(c.MaxConnMaxLifetime)
+	}
+	if pool.ConnMaxLifetime <= 0 {
+		pool.ConnMaxLifetime = DefaultDBConnMaxLifetime
+	}
+	if pool.ConnMaxIdleTime <= 0 {
+		pool.ConnMaxIdleTime = DefaultDBConnMaxIdleTime
+	}
+
+	return pool
 }
 
 // KvConf 定义了键值存储（主要是 Redis）的配置
 type KvConf struct {
-	Redis []*Redis `toml:"redis" mapstructure:"redis" json:"redis"` // Redis 服务器配置列表，支持多实例配置
+	Redis []*Redis  `toml:"redis" mapstructure:"redis" json:"redis"` // Redis 服务器配置列表，支持多实例配置
+	Retry *KvRetry `toml:"retry" mapstructure:"retry" json:"retry"` // 缓存读写的重试和熔断配置，不配置时使用默认值
+}
+
+// KvRetry 定义了数据访问层缓存读写的重试和熔断参数
+type KvRetry struct {
+	Attempts         int `toml:"attempts" mapstructure:"attempts" json:"attempts"`                            // 单次缓存操作的最大尝试次数，包括第一次
+	BackoffMs        int `toml:"backoff_ms" mapstructure:"backoff_ms" json:"backoff_ms"`                      // 首次重试前的等待时间（毫秒），之后每次翻倍
+	BreakerThreshold int `toml:"breaker_threshold" mapstructure:"breaker_threshold" json:"breaker_threshold"` // 连续失败多少次后熔断，熔断期间缓存操作直接跳过
+	BreakerCooldown  int `toml:"breaker_cooldown" mapstructure:"breaker_cooldown" json:"breaker_cooldown"`    // 熔断持续时间（秒），到期后重新尝试访问缓存
 }
 
 // Redis 定义了单个 Redis 实例的连接配置
@@ -63,7 +431,82 @@ type MetadataParse struct {
 type ChainSupported struct {
 	Name     string `toml:"name" mapstructure:"name" json:"name"`         // 区块链名称（如 "Ethereum", "Polygon", "BSC"）
 	ChainID  int    `toml:"chain_id" mapstructure:"chain_id" json:"chain_id"` // 区块链 ID（如 Ethereum 主网是 1）
-	Endpoint string `toml:"endpoint" mapstructure:"endpoint" json:"endpoint"` // 区块链 RPC 连接端点 URL
+	Endpoints []string `toml:"endpoints" mapstructure:"endpoints" json:"endpoints"` // 区块链 RPC 连接端点 URL 列表，按优先级排列，故障时依次切换
+	Endpoint  string   `toml:"endpoint" mapstructure:"endpoint" json:"endpoint,omitempty"` // 兼容旧配置的单个 RPC 端点，解析后会合并到 Endpoints
+	NativeSymbol string      `toml:"native_symbol" mapstructure:"native_symbol" json:"native_symbol"` // 链原生代币符号（如 "ETH"），为空时默认 ETH
+	Currencies   []*Currency `toml:"currencies" mapstructure:"currencies" json:"currencies"`         // 订单支持的 ERC-20 支付代币列表
+	MarketplaceContract string `toml:"marketplace_contract" mapstructure:"marketplace_contract" json:"marketplace_contract"` // 该链上 EasySwap 订单簿合约地址
+	Fees                *Fees  `toml:"fees" mapstructure:"fees" json:"fees"`                                               // 市场手续费和默认版税配置，不配置时均为 0
+	BlockTime           int    `toml:"block_time" mapstructure:"block_time" json:"block_time"`                               // 平均出块时间（秒），用于估算同步延迟，0 使用默认的 12 秒
+	MaxSyncLag          int64  `toml:"max_sync_lag" mapstructure:"max_sync_lag" json:"max_sync_lag"`                         // 索引落后链上最新区块的最大区块数，超过时同步状态视为不健康，0 使用默认的 50
+}
+
+// 链同步状态默认配置
+const (
+	DefaultBlockTime  = 12
+	DefaultMaxSyncLag = 50
+)
+
+// BlockTimeOrDefault 返回生效的平均出块时间（秒）
+func (c *ChainSupported) BlockTimeOrDefault() int {
+	if c.BlockTime <= 0 {
+		return DefaultBlockTime
+	}
+	return c.BlockTime
+}
+
+// MaxSyncLagOrDefault 返回生效的最大同步延迟区块数
+func (c *ChainSupported) MaxSyncLagOrDefault() int64 {
+	if c.MaxSyncLag <= 0 {
+		return DefaultMaxSyncLag
+	}
+	return c.MaxSyncLag
+}
+
+// MaxFeeBps 手续费基点上限，10000 基点即 100%
+const MaxFeeBps = 10000
+
+// Fees 定义了链上交易的手续费配置，费率单位为基点（1 基点 = 0.01%）
+type Fees struct {
+	MarketplaceBps    int    `toml:"marketplace_bps" mapstructure:"marketplace_bps" json:"marketplace_bps"`             // 市场手续费基点
+	FeeRecipient      string `toml:"fee_recipient" mapstructure:"fee_recipient" json:"fee_recipient"`                   // 市场手续费接收地址
+	DefaultRoyaltyBps int    `toml:"default_royalty_bps" mapstructure:"default_royalty_bps" json:"default_royalty_bps"` // 集合未单独配置版税时使用的默认版税基点
+}
+
+// Currency 定义了订单中使用的 ERC-20 支付代币
+type Currency struct {
+	Address string `toml:"address" mapstructure:"address" json:"address"` // 代币合约地址
+	Symbol  string `toml:"symbol" mapstructure:"symbol" json:"symbol"`    // 代币符号（如 "WETH"）
+	Decimals int32 `toml:"decimals" mapstructure:"decimals" json:"decimals"` // 代币精度，用于计算费用时取整，0 使用默认的 18
+}
+
+// DefaultCurrencyDecimals 原生代币和未配置精度的 ERC-20 代币使用的精度
+const DefaultCurrencyDecimals = 18
+
+// DecimalsOrDefault 返回生效的代币精度
+func (c *Currency) DecimalsOrDefault() int32 {
+	if c.Decimals <= 0 {
+		return DefaultCurrencyDecimals
+	}
+	return c.Decimals
+}
+
+// normalizeEndpoints 将旧配置中的单个 endpoint 合并到 Endpoints 列表
+// endpoint 排在列表最前，与 endpoints 中重复的地址会被去除
+func normalizeEndpoints(c *Config) {
+	for _, chain := range c.ChainSupported {
+		if chain == nil || chain.Endpoint == "" {
+			continue
+		}
+		endpoints := []string{chain.Endpoint}
+		for _, e := range chain.Endpoints {
+			if e != chain.Endpoint {
+				endpoints = append(endpoints, e)
+			}
+		}
+		chain.Endpoints = endpoints
+		chain.Endpoint = ""
+	}
 }
 
 // UnmarshalConfig 从指定的配置文件中解析配置信息
@@ -74,7 +517,7 @@ type ChainSupported struct {
 //
 // 返回值:
 //   - *Config: 解析完成的配置对象
-//   - error: 解析过程中的错误，如文件不存在、格式错误等
+//   - error: 解析过程中的错误，如文件不存在、格式错误、配置校验失败等
 func UnmarshalConfig(configFilePath string) (*Config, error) {
 	// 设置配置文件路径
 	viper.SetConfigFile(configFilePath)
@@ -101,7 +544,26 @@ func UnmarshalConfig(configFilePath string) (*Config, error) {
 	}
 
 	// 将读取的配置数据解析到配置对象中
-	if err := viper.Unmarshal(config); err != nil {
+	// 与 viper 默认的钩子相同: 字符串可解析为时间间隔, 字符串按逗号拆分为切片
+	// 单个字符串会被解析为只有一个元素的切片, 环境变量也可以用逗号分隔的字符串覆盖列表配置
+	if err := viper.Unmarshal(config, viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+	))); err != nil {
+		return nil, err
+	}
+
+	// 将旧配置中的单个 endpoint 合并到 endpoints 列表
+	normalizeEndpoints(config)
+
+	// 展开 Endpoint、Redis 密码中的 ${VAR} 环境变量占位符
+	// 与 CNFT_ 前缀的环境变量覆盖机制互为补充
+	if err := expandConfigEnv(config); err != nil {
+		return nil, err
+	}
+
+	// 校验配置项，一次性返回所有不合法的配置
+	if err := config.Validate(); err != nil {
 		return nil, err
 	}
 	
@@ -109,11 +571,15 @@ func UnmarshalConfig(configFilePath string) (*Config, error) {
 }
 
 // DefaultConfig 创建一个默认的配置对象
-// 返回一个空的 Config 结构体，所有字段都使用默认值
+// 返回一个 Config 结构体，未在配置文件中出现的字段使用这里的默认值
 //
 // 返回值:
 //   - *Config: 默认配置对象
 //   - error: 创建过程中的错误（当前始终返回 nil）
 func DefaultConfig() (*Config, error) {
-	return &Config{}, nil
+	return &Config{
+		Api: Api{
+			ShutdownTimeout: DefaultShutdownTimeout, // 优雅关闭默认等待 10 秒
+		},
+	}, nil
 }
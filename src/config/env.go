@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// envPlaceholder 匹配 ${VAR} 形式的环境变量占位符
+// 只识别带花括号的写法，避免误替换密码中出现的 "$" 字符
+var envPlaceholder = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandEnv 使用进程环境变量替换字符串中的 ${VAR} 占位符
+// 存在未设置的环境变量时返回错误，并指明缺失的变量名
+func expandEnv(value string) (string, error) {
+	var missing string
+	expanded := envPlaceholder.ReplaceAllStringFunc(value, func(placeholder string) string {
+		name := envPlaceholder.FindStringSubmatch(placeholder)[1]
+		v, ok := os.LookupEnv(name)
+		if !ok && missing == "" {
+			missing = name
+		}
+		return v
+	})
+
+	if missing != "" {
+		return "", fmt.Errorf("environment variable %q is not set", missing)
+	}
+
+	return expanded, nil
+}
+
+// expandConfigEnv 展开配置中允许引用环境变量的字段
+// 目前支持 ChainSupported.Endpoints 和 Redis.Pass，便于将 RPC 密钥、密码等敏感信息放在环境变量中
+func expandConfigEnv(c *Config) error {
+	for i, chain := range c.ChainSupported {
+		if chain == nil {
+			continue
+		}
+		for j, e := range chain.Endpoints {
+			endpoint, err := expandEnv(e)
+			if err != nil {
+				return fmt.Errorf("chain_supported[%d].endpoints[%d]: %w", i, j, err)
+			}
+			chain.Endpoints[j] = endpoint
+		}
+	}
+
+	if c.Kv != nil {
+		for i, r := range c.Kv.Redis {
+			if r == nil {
+				continue
+			}
+			pass, err := expandEnv(r.Pass)
+			if err != nil {
+				return fmt.Errorf("kv.redis[%d].pass: %w", i, err)
+			}
+			r.Pass = pass
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+
+	"github.com/joinmouse/EasySwapBase/logger/xzap"
+	"go.uber.org/zap"
+)
+
+// maskedValue 敏感字段脱敏后的值
+const maskedValue = "***"
+
+// maskSecret 脱敏密码、密钥等字段, 未配置时保持为空, 便于区分是否已配置
+func maskSecret(s string) string {
+	if s == "" {
+		return ""
+	}
+	return maskedValue
+}
+
+// maskURL 脱敏 URL 中可能包含密钥的部分
+// RPC 服务商通常把 API Key 放在路径、查询参数或用户信息中, 只保留协议和主机
+func maskURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return maskedValue
+	}
+
+	masked := u.Scheme + "://" + u.Host
+	if u.User != nil || (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" {
+		masked += "/" + maskedValue
+	}
+	return masked
+}
+
+// Masked 返回脱敏后的配置副本, 可以安全地写入日志或对外展示
+// 数据库和 Redis 密码替换为 ***, RPC 端点只保留协议和主机;
+// json 标签为 "-" 的字段(管理密钥、API Key、告警 Webhook)不会出现在副本中
+func (c *Config) Masked() (*Config, error) {
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	var masked Config
+	if err := json.Unmarshal(raw, &masked); err != nil {
+		return nil, err
+	}
+
+	masked.DB.Password = maskSecret(masked.DB.Password)
+	if masked.Kv != nil {
+		for _, r := range masked.Kv.Redis {
+			if r != nil {
+				r.Pass = maskSecret(r.Pass)
+			}
+		}
+	}
+	if masked.Evm != nil {
+		masked.Evm.Endpoint = maskURL(masked.Evm.Endpoint)
+	}
+	for _, chain := range masked.ChainSupported {
+		if chain == nil {
+			continue
+		}
+		for i, e := range chain.Endpoints {
+			chain.Endpoints[i] = maskURL(e)
+		}
+		chain.Endpoint = maskURL(chain.Endpoint)
+	}
+	if masked.Trace != nil {
+		masked.Trace.Endpoint = maskURL(masked.Trace.Endpoint)
+	}
+
+	return &masked, nil
+}
+
+// LogSafe 在启动时记录生效的配置, 密码、密钥等敏感字段已脱敏
+// 除原始配置外, 单独记录链列表、端口、连接池和缓存时间等使用默认值补全后的配置
+func (c *Config) LogSafe() {
+	logger := xzap.WithContext(context.Background())
+	fields, err := c.logFields()
+	if err != nil {
+		logger.Warn("failed on mask config", zap.Error(err))
+		return
+	}
+
+	logger.Info("loaded config", fields...)
+}
+
+// logFields 返回启动日志中记录的配置字段, 所有字段都基于脱敏后的配置生成
+func (c *Config) logFields() ([]zap.Field, error) {
+	masked, err := c.Masked()
+	if err != nil {
+		return nil, err
+	}
+
+	type chainInfo struct {
+		Name      string   `json:"name"`
+		ChainID   int      `json:"chain_id"`
+		Endpoints []string `json:"endpoints"`
+	}
+	chains := make([]chainInfo, 0, len(masked.ChainSupported))
+	for _, chain := range masked.ChainSupported {
+		if chain != nil {
+			chains = append(chains, chainInfo{Name: chain.Name, ChainID: chain.ChainID, Endpoints: chain.Endpoints})
+		}
+	}
+
+	cacheTTLs := make(map[string]int, len(CacheRoutes))
+	for _, route := range CacheRoutes {
+		cacheTTLs[route] = c.Api.Cache.TTL(route)
+	}
+
+	return []zap.Field{
+		zap.Any("chains", chains),
+		zap.String("api_port", c.Api.Port),
+		zap.String("api_mode", c.Api.ModeOrDefault()),
+		zap.Bool("read_only", c.Api.ReadOnly),
+		zap.Bool("admin_enabled", c.Api.AdminSecret != ""),
+		zap.Int("api_keys", len(c.Api.ApiKeys)),
+		zap.Any("db_pool", c.DB.PoolConfig()),
+		zap.Duration("db_query_timeout", c.DB.QueryTimeoutDuration()),
+		zap.Any("kv_retry", c.Kv.RetryConfig()),
+		zap.Any("cache_ttls", cacheTTLs),
+		zap.Any("config", masked),
+	}, nil
+}
@@ -0,0 +1,224 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Validate 校验配置项是否合法
+// 会检查所有配置项并汇总全部问题一次性返回，而不是遇到第一个错误就退出
+//
+// 返回值:
+//   - error: 汇总后的校验错误，配置合法时返回 nil
+func (c *Config) Validate() error {
+	var errs []error
+
+	// 校验 API 监听端口，格式为 ":port" 或 "host:port"
+	if err := validatePort(c.Api.Port); err != nil {
+		errs = append(errs, fmt.Errorf("api.port: %w", err))
+	}
+
+	// 校验 Gin 运行模式
+	switch c.Api.ModeOrDefault() {
+	case ApiModeDebug, ApiModeRelease, ApiModeTest:
+	default:
+		errs = append(errs, fmt.Errorf("api.mode: %q must be one of debug, release, test", c.Api.Mode))
+	}
+
+	// 校验最大并发请求数, 用于全局并发限制中间件的信号量容量
+	if c.Api.MaxNum <= 0 {
+		errs = append(errs, fmt.Errorf("api.max_num: %d must be positive", c.Api.MaxNum))
+	}
+
+	// 校验 Redis 配置，至少需要一个带有地址的节点
+	if c.Kv == nil || len(c.Kv.Redis) == 0 {
+		errs = append(errs, errors.New("kv.redis: at least one redis node is required"))
+	} else {
+		for i, r := range c.Kv.Redis {
+			if r == nil || r.Host == "" {
+				errs = append(errs, fmt.Errorf("kv.redis[%d].host: must not be empty", i))
+			}
+		}
+	}
+
+	// 校验数据库必填项
+	if c.DB.Host == "" {
+		errs = append(errs, errors.New("db.host: must not be empty"))
+	}
+	if c.DB.Port <= 0 || c.DB.Port > 65535 {
+		errs = append(errs, fmt.Errorf("db.port: %d is out of range", c.DB.Port))
+	}
+	if c.DB.User == "" {
+		errs = append(errs, errors.New("db.user: must not be empty"))
+	}
+	if c.DB.Database == "" {
+		errs = append(errs, errors.New("db.database: must not be empty"))
+	}
+
+	// 校验支持的区块链配置
+	if len(c.ChainSupported) == 0 {
+		errs = append(errs, errors.New("chain_supported: at least one chain is required"))
+	}
+	for i, chain := range c.ChainSupported {
+		if chain == nil {
+			errs = append(errs, fmt.Errorf("chain_supported[%d]: must not be empty", i))
+			continue
+		}
+		if chain.ChainID == 0 {
+			errs = append(errs, fmt.Errorf("chain_supported[%d].chain_id: must not be 0", i))
+		}
+		if chain.Name == "" {
+			errs = append(errs, fmt.Errorf("chain_supported[%d].name: must not be empty", i))
+		}
+		if len(chain.Endpoints) == 0 {
+			errs = append(errs, fmt.Errorf("chain_supported[%d].endpoints: at least one endpoint is required", i))
+		}
+		for j, endpoint := range chain.Endpoints {
+			if err := validateEndpoint(endpoint); err != nil {
+				errs = append(errs, fmt.Errorf("chain_supported[%d].endpoints[%d]: %w", i, j, err))
+			}
+		}
+		if chain.BlockTime < 0 {
+			errs = append(errs, fmt.Errorf("chain_supported[%d].block_time: must not be negative", i))
+		}
+		if chain.MaxSyncLag < 0 {
+			errs = append(errs, fmt.Errorf("chain_supported[%d].max_sync_lag: must not be negative", i))
+		}
+		if chain.Fees != nil {
+			if err := validateBps(chain.Fees.MarketplaceBps); err != nil {
+				errs = append(errs, fmt.Errorf("chain_supported[%d].fees.marketplace_bps: %w", i, err))
+			}
+			if err := validateBps(chain.Fees.DefaultRoyaltyBps); err != nil {
+				errs = append(errs, fmt.Errorf("chain_supported[%d].fees.default_royalty_bps: %w", i, err))
+			}
+		}
+	}
+
+	// 校验响应压缩级别, 负数表示不启用
+	if c.Api.Compression.Level > 9 {
+		errs = append(errs, fmt.Errorf("api.compression.level: %d is out of range [1, 9]", c.Api.Compression.Level))
+	}
+
+	// 校验接口缓存时间, 只接受已知的路由名, 避免路由名拼写错误时配置静默失效
+	if c.Api.Cache.DefaultTTL < 0 {
+		errs = append(errs, fmt.Errorf("api.cache.default_ttl: %d must not be negative", c.Api.Cache.DefaultTTL))
+	}
+	for route, ttl := range c.Api.Cache.TTLs {
+		if !slices.Contains(CacheRoutes, route) {
+			errs = append(errs, fmt.Errorf("api.cache.ttls.%s: unknown route, must be one of %v", route, CacheRoutes))
+			continue
+		}
+		if ttl <= 0 {
+			errs = append(errs, fmt.Errorf("api.cache.ttls.%s: %d must be positive", route, ttl))
+		}
+	}
+
+	// 校验 panic 告警 Webhook 地址
+	if c.Api.PanicAlert.Webhook != "" {
+		if err := validateEndpoint(c.Api.PanicAlert.Webhook); err != nil {
+			errs = append(errs, fmt.Errorf("api.panic_alert.webhook: %w", err))
+		}
+	}
+
+	// 校验管理接口的来源 IP 允许列表和可信代理
+	for i, cidr := range c.Api.AdminAllowlist {
+		if err := validateCIDR(cidr); err != nil {
+			errs = append(errs, fmt.Errorf("api.admin_allowlist[%d]: %w", i, err))
+		}
+	}
+	for i, cidr := range c.Api.TrustedProxies {
+		if err := validateCIDR(cidr); err != nil {
+			errs = append(errs, fmt.Errorf("api.trusted_proxies[%d]: %w", i, err))
+		}
+	}
+
+	// 校验 API Key 配置, 只接受哈希后的 Key, 避免误把明文 Key 写进配置
+	for i, key := range c.Api.ApiKeys {
+		if key.Partner == "" {
+			errs = append(errs, fmt.Errorf("api.api_keys[%d].partner: must not be empty", i))
+		}
+		if b, err := hex.DecodeString(key.KeyHash); err != nil || len(b) != sha256.Size {
+			errs = append(errs, fmt.Errorf("api.api_keys[%d].key_hash: must be a hex encoded sha256 hash", i))
+		}
+		if key.Scope != "" && key.Scope != ApiKeyScopeRead && key.Scope != ApiKeyScopeWrite {
+			errs = append(errs, fmt.Errorf("api.api_keys[%d].scope: %q must be %s or %s", i, key.Scope, ApiKeyScopeRead, ApiKeyScopeWrite))
+		}
+		if key.RateLimit < 0 {
+			errs = append(errs, fmt.Errorf("api.api_keys[%d].rate_limit: must not be negative", i))
+		}
+	}
+
+	// 校验链路追踪配置
+	if c.Trace != nil {
+		if c.Trace.Protocol != "" && c.Trace.Protocol != TraceProtocolGRPC && c.Trace.Protocol != TraceProtocolHTTP {
+			errs = append(errs, fmt.Errorf("trace.protocol: %q must be %s or %s", c.Trace.Protocol, TraceProtocolGRPC, TraceProtocolHTTP))
+		}
+		if c.Trace.SampleRatio < 0 || c.Trace.SampleRatio > 1 {
+			errs = append(errs, fmt.Errorf("trace.sample_ratio: %v is out of range [0, 1]", c.Trace.SampleRatio))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateCIDR 校验是否为合法的 CIDR 或 IP 地址
+func validateCIDR(s string) error {
+	if strings.Contains(s, "/") {
+		if _, _, err := net.ParseCIDR(s); err != nil {
+			return fmt.Errorf("%q is not a valid CIDR", s)
+		}
+		return nil
+	}
+	if net.ParseIP(s) == nil {
+		return fmt.Errorf("%q is not a valid IP address", s)
+	}
+	return nil
+}
+
+// validatePort 校验监听地址是否为合法的 ":port" 形式
+func validatePort(addr string) error {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("%q is not a valid :port address", addr)
+	}
+
+	p, err := strconv.Atoi(port)
+	if err != nil || p <= 0 || p > 65535 {
+		return fmt.Errorf("%q has an invalid port", addr)
+	}
+
+	return nil
+}
+
+// validateEndpoint 校验区块链 RPC 端点是否为合法的 URL
+func validateEndpoint(endpoint string) error {
+	if endpoint == "" {
+		return errors.New("must not be empty")
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("%q is not a valid url", endpoint)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("%q must contain scheme and host", endpoint)
+	}
+
+	return nil
+}
+
+// validateBps 校验手续费基点是否在 0-10000 之间
+func validateBps(bps int) error {
+	if bps < 0 || bps > MaxFeeBps {
+		return fmt.Errorf("%d is out of range [0, %d]", bps, MaxFeeBps)
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package dao
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"strconv"
@@ -18,6 +19,14 @@ import (
 
 const CacheActivityNumPrefix = "cache:es:activity:count:"
 
+// ActivityChannelPrefix 集合交易活动实时推送的Redis发布订阅频道前缀
+const ActivityChannelPrefix = "es:activity:stream:"
+
+// ActivityChannel 生成指定链上集合交易活动的发布订阅频道名称
+func ActivityChannel(chain, collectionAddr string) string {
+	return ActivityChannelPrefix + chain + ":" + strings.ToLower(collectionAddr)
+}
+
 var eventTypesToID = map[string]int{
 	"sale":                  multi.Sale,
 	"transfer":              multi.Transfer,
@@ -48,12 +57,103 @@ var idToEventTypes = map[int]string{
 	multi.CancelItemBid:       "cancel_item_bid",
 }
 
+// ActivityEventType 返回活动类型ID对应的事件类型名称
+func ActivityEventType(activityType int) (string, bool) {
+	eventType, ok := idToEventTypes[activityType]
+	return eventType, ok
+}
+
+// activityEventAliases 事件类型的别名, 一个别名对应一组事件类型
+var activityEventAliases = map[string][]string{
+	"listing": {"list"},
+	"cancel":  {"cancel_list", "cancel_offer", "cancel_collection_bid", "cancel_item_bid"},
+}
+
+// ErrUnknownEventType 不支持的活动事件类型
+var ErrUnknownEventType = errors.New("unknown activity event type")
+
+// ExpandActivityEventTypes 校验活动事件类型名称并展开别名, 返回去重后的事件类型
+// 名称不区分大小写, 存在不支持的名称时返回 ErrUnknownEventType
+func ExpandActivityEventTypes(names []string) ([]string, error) {
+	var eventTypes []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			eventTypes = append(eventTypes, name)
+		}
+	}
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if aliases, ok := activityEventAliases[name]; ok {
+			for _, alias := range aliases {
+				add(alias)
+			}
+			continue
+		}
+		if _, ok := eventTypesToID[name]; !ok {
+			return nil, errors.Wrap(ErrUnknownEventType, name)
+		}
+		add(name)
+	}
+
+	return eventTypes, nil
+}
+
+// ActivityFilter 活动查询的过滤条件, 为空的条件不过滤
+type ActivityFilter struct {
+	ChainNames      []string // 链名称列表
+	CollectionAddrs []string // NFT合约地址列表
+	TokenID         string   // NFT的tokenID
+	UserAddrs       []string // 用户地址列表, maker 或 taker 为其中之一
+	Maker           string   // 挂单/出价/卖出方地址
+	Taker           string   // 成交/买入方地址
+	EventTypes      []string // 事件类型列表, 需先经过 ExpandActivityEventTypes 校验
+	Since           int64    // 事件时间下限(Unix 秒), 为 0 时不限制
+	Until           int64    // 事件时间上限(Unix 秒), 为 0 时不限制
+}
+
 type ActivityCountCache struct {
 	Chain             string   `json:"chain"`
 	ContractAddresses []string `json:"contract_addresses"`
 	TokenId           string   `json:"token_id"`
 	UserAddress       string   `json:"user_address"`
+	Maker             string   `json:"maker,omitempty"`
+	Taker             string   `json:"taker,omitempty"`
 	EventTypes        []string `json:"event_types"`
+	Since             int64    `json:"since,omitempty"`
+	Until             int64    `json:"until,omitempty"`
+}
+
+// ActivityCursor 活动列表游标分页的位置, 指向上一页最后一条活动
+// 下一页返回严格早于该位置的活动, 新事件写入不会导致翻页时出现重复或遗漏
+type ActivityCursor struct {
+	EventTime int64 `json:"t"`
+	ID        int64 `json:"i"`
+}
+
+// EncodeActivityCursor 将游标编码为对客户端不透明的字符串
+func EncodeActivityCursor(cursor ActivityCursor) string {
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeActivityCursor 解析客户端传入的游标字符串
+func DecodeActivityCursor(s string) (*ActivityCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on decode activity cursor")
+	}
+
+	var cursor ActivityCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil {
+		return nil, errors.Wrap(err, "failed on unmarshal activity cursor")
+	}
+
+	return &cursor, nil
 }
 
 type ActivityMultiChainInfo struct {
@@ -72,27 +172,24 @@ func getActivityCountCacheKey(activity *ActivityCountCache) (string, error) {
 // QueryMultiChainActivities 查询多链上的活动信息
 // 参数:
 // - ctx: 上下文
-// - chainName: 链名称列表
-// - collectionAddrs: NFT合约地址列表
-// - tokenID: NFT的tokenID
-// - userAddrs: 用户地址列表
-// - eventTypes: 事件类型列表
+// - filter: 过滤条件, 在每条链的子查询中作为参数化的 WHERE 条件, 可以使用活动表上的索引
+// - cursor: 游标位置, 不为空时按 (event_time, id) 查询更早的活动并忽略 page
 // - page: 页码
 // - pageSize: 每页大小
 // 返回:
 // - []ActivityMultiChainInfo: 活动信息列表
 // - int64: 总记录数
 // - error: 错误信息
-func (d *Dao) QueryMultiChainActivities(ctx context.Context, chainName []string, collectionAddrs []string, tokenID string, userAddrs []string, eventTypes []string, page, pageSize int) ([]ActivityMultiChainInfo, int64, error) {
-	//查询缓存中的总数
-	var strNums []string
-
+func (d *Dao) QueryMultiChainActivities(ctx context.Context, filter ActivityFilter, cursor *ActivityCursor, page, pageSize int) ([]ActivityMultiChainInfo, int64, error) {
 	var total int64
 	var activities []ActivityMultiChainInfo
+	if len(filter.ChainNames) == 0 {
+		return activities, 0, nil
+	}
 
 	//将事件类型转换为对应的ID
 	var events []int
-	for _, v := range eventTypes {
+	for _, v := range filter.EventTypes {
 		id, ok := eventTypesToID[v]
 		if !ok {
 			continue
@@ -100,112 +197,104 @@ func (d *Dao) QueryMultiChainActivities(ctx context.Context, chainName []string,
 		events = append(events, id)
 	}
 
-	//构建SQL查询
-	//1. 构建SQL头部
-	sqlHead := "SELECT * FROM ("
+	//1. 构建每条链子查询的过滤条件
+	var conds []string
+	var condArgs []interface{}
+	if len(filter.UserAddrs) > 0 {
+		userAddrs := make([]string, 0, len(filter.UserAddrs))
+		for _, addr := range filter.UserAddrs {
+			userAddrs = append(userAddrs, strings.ToLower(addr))
+		}
+		conds = append(conds, "(maker in (?) or taker in (?))")
+		condArgs = append(condArgs, userAddrs, userAddrs)
+	}
+	if filter.Maker != "" {
+		conds = append(conds, "maker = ?")
+		condArgs = append(condArgs, strings.ToLower(filter.Maker))
+	}
+	if filter.Taker != "" {
+		conds = append(conds, "taker = ?")
+		condArgs = append(condArgs, strings.ToLower(filter.Taker))
+	}
+	if len(filter.CollectionAddrs) > 0 {
+		conds = append(conds, "collection_address in (?)")
+		condArgs = append(condArgs, filter.CollectionAddrs)
+	}
+	if filter.TokenID != "" {
+		conds = append(conds, "token_id = ?")
+		condArgs = append(condArgs, filter.TokenID)
+	}
+	if len(events) > 0 {
+		conds = append(conds, "activity_type in (?)")
+		condArgs = append(condArgs, events)
+	}
+	// 时间区间作为范围条件, 可以与 (event_time, id) 索引配合使用
+	if filter.Since > 0 {
+		conds = append(conds, "event_time >= ?")
+		condArgs = append(condArgs, filter.Since)
+	}
+	if filter.Until > 0 {
+		conds = append(conds, "event_time <= ?")
+		condArgs = append(condArgs, filter.Until)
+	}
+	where := ""
+	if len(conds) > 0 {
+		where = "where " + strings.Join(conds, " and ") + " "
+	}
 
-	//2. 构建SQL中间部分 - 使用UNION ALL合并多个链的查询
+	//2. 使用UNION ALL合并多个链的查询, 每条链的子查询使用相同的过滤条件
 	sqlMid := ""
-	for _, chain := range chainName {
+	var args []interface{}
+	for _, chain := range filter.ChainNames {
 		if sqlMid != "" {
 			sqlMid += "UNION ALL "
 		}
-		//为每个链构建子查询
 		sqlMid += fmt.Sprintf("(select '%s' as chain_name,id,collection_address,token_id,currency_address,activity_type,maker,taker,price,tx_hash,event_time,marketplace_id ", chain)
-		sqlMid += fmt.Sprintf("from %s ", multi.ActivityTableName(chain))
-
-		//添加用户地址过滤条件
-		if len(userAddrs) == 1 {
-			sqlMid += fmt.Sprintf("where maker = '%s' or taker = '%s'", strings.ToLower(userAddrs[0]), strings.ToLower(userAddrs[0]))
-		} else if len(userAddrs) > 1 {
-			var userAddrsParam string
-			for i, addr := range userAddrs {
-				userAddrsParam += fmt.Sprintf(`'%s'`, addr)
-				if i < len(userAddrs)-1 {
-					userAddrsParam += ","
-				}
-			}
-			sqlMid += fmt.Sprintf("where maker in (%s) or taker in (%s)", userAddrsParam, userAddrsParam)
-		}
-		sqlMid += ") "
-	}
-
-	//3. 构建SQL尾部 - 添加过滤条件
-	sqlTail := ") as combined "
-	firstFlag := true
-
-	//添加合约地址过滤
-	if len(collectionAddrs) == 1 {
-		sqlTail += fmt.Sprintf("WHERE collection_address = '%s' ", collectionAddrs[0])
-		firstFlag = false
-	} else if len(collectionAddrs) > 1 {
-		sqlTail += fmt.Sprintf("WHERE collection_address in ('%s'", collectionAddrs[0])
-		for i := 1; i < len(collectionAddrs); i++ {
-			sqlTail += fmt.Sprintf(",'%s'", collectionAddrs[i])
-		}
-		sqlTail += ") "
-		firstFlag = false
+		sqlMid += fmt.Sprintf("from %s %s) ", multi.ActivityTableName(chain), where)
+		args = append(args, condArgs...)
 	}
 
-	//添加tokenID过滤
-	if tokenID != "" {
-		if firstFlag {
-			sqlTail += fmt.Sprintf("WHERE token_id = '%s' ", tokenID)
-			firstFlag = false
-		} else {
-			sqlTail += fmt.Sprintf("and token_id = '%s' ", tokenID)
-		}
-	}
+	//构建计数SQL, 总数不受分页和游标影响
+	sqlCnt := "SELECT COUNT(*) FROM (" + sqlMid + ") as combined"
 
-	//添加事件类型过滤
-	if len(events) > 0 {
-		if firstFlag {
-			sqlTail += fmt.Sprintf("WHERE activity_type in (%d", events[0])
-			for i := 1; i < len(events); i++ {
-				sqlTail += fmt.Sprintf(",%d", events[i])
-			}
-			sqlTail += ") "
-			firstFlag = false
-		} else {
-			sqlTail += fmt.Sprintf("and activity_type in (%d", events[0])
-			for i := 1; i < len(events); i++ {
-				sqlTail += fmt.Sprintf(",%d", events[i])
-			}
-			sqlTail += ") "
-		}
+	//3. 添加游标条件, 依赖 (event_time, id) 联合索引
+	sql := "SELECT * FROM (" + sqlMid + ") as combined "
+	queryArgs := args
+	if cursor != nil {
+		sql += "WHERE (combined.event_time, combined.id) < (?, ?) "
+		queryArgs = append(append([]interface{}{}, args...), cursor.EventTime, cursor.ID)
 	}
 
 	//添加分页
-	sqlTail += fmt.Sprintf("ORDER BY combined.event_time DESC, combined.id DESC limit %d offset %d", pageSize, pageSize*(page-1))
-
-	//组合完整SQL
-	sql := sqlHead + sqlMid + sqlTail
+	offset := pageSize * (page - 1)
+	if cursor != nil || offset < 0 {
+		offset = 0
+	}
+	sql += fmt.Sprintf("ORDER BY combined.event_time DESC, combined.id DESC limit %d offset %d", pageSize, offset)
 
 	//执行查询
-	if err := d.DB.Raw(sql).Scan(&activities).Error; err != nil {
+	if err := d.DB.WithContext(ctx).Raw(sql, queryArgs...).Scan(&activities).Error; err != nil {
 		return nil, 0, errors.Wrap(err, "failed on query activity")
 	}
 
-	//构建计数SQL
-	sqlCnt := "SELECT COUNT(*) FROM (" + sqlMid + sqlTail
-
 	//从Redis缓存获取总数
 	cacheKey, err := getActivityCountCacheKey(&ActivityCountCache{
-		Chain:             "MultiChain",
-		ContractAddresses: collectionAddrs,
-		TokenId:           tokenID,
-		UserAddress:       strings.ToLower(strings.Join(userAddrs, ",")),
-		EventTypes:        eventTypes,
+		Chain:             strings.Join(filter.ChainNames, ","),
+		ContractAddresses: filter.CollectionAddrs,
+		TokenId:           filter.TokenID,
+		UserAddress:       strings.ToLower(strings.Join(filter.UserAddrs, ",")),
+		Maker:             strings.ToLower(filter.Maker),
+		Taker:             strings.ToLower(filter.Taker),
+		EventTypes:        filter.EventTypes,
+		Since:             filter.Since,
+		Until:             filter.Until,
 	})
 	if err != nil {
 		return nil, 0, errors.Wrap(err, "failed on get activity number cache key")
 	}
 
-	strNum, err := d.KvStore.Get(cacheKey)
-	if err != nil {
-		return nil, 0, errors.Wrap(err, "failed on get activity number from cache")
-	}
-	strNums = append(strNums, strNum)
+	// 缓存读取失败时按未命中处理, 从数据库查询
+	strNum, _ := d.cacheGet(ctx, cacheKey)
 
 	//获取总数
 	if strNum != "" {
@@ -213,14 +302,12 @@ func (d *Dao) QueryMultiChainActivities(ctx context.Context, chainName []string,
 		total, _ = strconv.ParseInt(strNum, 10, 64)
 	} else {
 		//从数据库查询
-		if err := d.DB.Raw(sqlCnt).Scan(&total).Error; err != nil {
+		if err := d.DB.WithContext(ctx).Raw(sqlCnt, args...).Scan(&total).Error; err != nil {
 			return nil, 0, errors.Wrap(err, "failed on count activity")
 		}
 
-		//更新缓存
-		if err := d.KvStore.Setex(cacheKey, strconv.FormatInt(total, 10), 30); err != nil {
-			return nil, 0, errors.Wrap(err, "failed on cache activities number")
-		}
+		//更新缓存, 写入失败不影响请求
+		d.cacheSet(ctx, cacheKey, strconv.FormatInt(total, 10), 30)
 	}
 
 	return activities, total, nil
@@ -0,0 +1,47 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/joinmouse/EasySwapBase/stores/gdb/orderbookmodel/multi"
+	"github.com/pkg/errors"
+)
+
+// ActivityPublishedKeyPrefix 已发布到实时推送频道的活动标记, 多个实例同时轮询时同一条活动只发布一次
+const ActivityPublishedKeyPrefix = "es:activity:stream:published:"
+
+// ActivityPublishedKey 生成指定链上活动的发布标记键名
+func ActivityPublishedKey(chain string, activityID int64) string {
+	return fmt.Sprintf("%s%s:%d", ActivityPublishedKeyPrefix, chain, activityID)
+}
+
+// QueryLatestActivityID 查询链上活动表当前最大的活动ID, 表为空时返回0
+func (d *Dao) QueryLatestActivityID(ctx context.Context, chain string) (int64, error) {
+	var id int64
+	if err := d.DB.WithContext(ctx).
+		Table(multi.ActivityTableName(chain)).
+		Select("coalesce(max(id), 0)").
+		Scan(&id).Error; err != nil {
+		return 0, errors.Wrap(err, "failed on query latest activity id")
+	}
+
+	return id, nil
+}
+
+// QueryActivitiesAfterID 按ID升序查询指定ID之后写入的活动, 最多返回 limit 条
+// 活动由同步服务写入活动表, 后端通过该查询发现新写入的活动
+func (d *Dao) QueryActivitiesAfterID(ctx context.Context, chain string, afterID int64, limit int) ([]ActivityMultiChainInfo, error) {
+	var activities []ActivityMultiChainInfo
+	if err := d.DB.WithContext(ctx).
+		Table(multi.ActivityTableName(chain)).
+		Select("? as chain_name, id, collection_address, token_id, currency_address, activity_type, maker, taker, price, tx_hash, event_time, marketplace_id", chain).
+		Where("id > ?", afterID).
+		Order("id asc").
+		Limit(limit).
+		Scan(&activities).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query activities after id")
+	}
+
+	return activities, nil
+}
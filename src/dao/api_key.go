@@ -0,0 +1,53 @@
+package dao
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// ApiKeyTableName 合作方 API Key 表, 只保存 Key 的 SHA-256 哈希
+// 建表语句:
+//
+//	CREATE TABLE `api_key` (
+//	  `id` bigint NOT NULL AUTO_INCREMENT,
+//	  `partner` varchar(64) NOT NULL,
+//	  `key_hash` char(64) NOT NULL,
+//	  `scope` varchar(16) NOT NULL DEFAULT 'read',
+//	  `rate_limit` int NOT NULL DEFAULT 0,
+//	  `rate_window` int NOT NULL DEFAULT 0,
+//	  `enabled` tinyint(1) NOT NULL DEFAULT 1,
+//	  `create_time` bigint NOT NULL DEFAULT 0,
+//	  PRIMARY KEY (`id`),
+//	  UNIQUE KEY `uk_key_hash` (`key_hash`)
+//	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+const ApiKeyTableName = "api_key"
+
+// ApiKey 合作方 API Key
+type ApiKey struct {
+	Id         int64  `gorm:"column:id" json:"id"`
+	Partner    string `gorm:"column:partner" json:"partner"`
+	KeyHash    string `gorm:"column:key_hash" json:"key_hash"`
+	Scope      string `gorm:"column:scope" json:"scope"`             // read 或 write
+	RateLimit  int    `gorm:"column:rate_limit" json:"rate_limit"`   // 时间窗口内允许的最大请求数, 0 表示不限制
+	RateWindow int    `gorm:"column:rate_window" json:"rate_window"` // 限流时间窗口(秒)
+	Enabled    bool   `gorm:"column:enabled" json:"enabled"`
+	CreateTime int64  `gorm:"column:create_time" json:"create_time"`
+}
+
+// QueryApiKeyByHash 根据 Key 哈希查询启用中的 API Key, 不存在时返回 nil
+func (d *Dao) QueryApiKeyByHash(ctx context.Context, keyHash string) (*ApiKey, error) {
+	var apiKeys []ApiKey
+	if err := d.DB.WithContext(ctx).
+		Table(ApiKeyTableName).
+		Where("key_hash = ? and enabled = ?", keyHash, true).
+		Limit(1).
+		Find(&apiKeys).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query api key")
+	}
+	if len(apiKeys) == 0 {
+		return nil, nil
+	}
+
+	return &apiKeys[0], nil
+}
@@ -0,0 +1,161 @@
+package dao
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/joinmouse/EasySwapBase/logger/xzap"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.uber.org/zap"
+
+	"github.com/joinmouse/EasySwapBackend/src/common/tracing"
+)
+
+// ApiCacheVersionKeyPrefix 接口响应缓存版本号的键名前缀
+// 接口缓存键中包含版本号, 数据变更时递增版本号, 旧版本的缓存不再命中, 到期后自动清理
+const ApiCacheVersionKeyPrefix = "cache:es:apicache:version:"
+
+// ApiCacheVersionKey 生成指定路由的接口响应缓存版本号键名
+func ApiCacheVersionKey(route string) string {
+	return ApiCacheVersionKeyPrefix + route
+}
+
+// errCacheUnavailable 缓存熔断期间直接返回的错误
+var errCacheUnavailable = errors.New("cache unavailable")
+
+// CacheRetryPolicy 缓存读写的重试和熔断策略
+type CacheRetryPolicy struct {
+	Attempts         int           // 单次缓存操作的最大尝试次数, 包括第一次
+	Backoff          time.Duration // 首次重试前的等待时间, 之后每次翻倍
+	BreakerThreshold int           // 连续失败多少次后熔断, 0 表示不熔断
+	BreakerCooldown  time.Duration // 熔断持续时间, 到期后重新尝试访问缓存
+}
+
+// DefaultCacheRetryPolicy 默认的缓存重试和熔断策略
+var DefaultCacheRetryPolicy = CacheRetryPolicy{
+	Attempts:         2,
+	Backoff:          20 * time.Millisecond,
+	BreakerThreshold: 5,
+	BreakerCooldown:  10 * time.Second,
+}
+
+// cacheBreaker 记录缓存的连续失败次数, 连续失败达到阈值后在冷却期内跳过缓存操作
+// 避免 Redis 持续不可用时每个请求都等待重试, 冷却期结束后的第一次操作用于探测 Redis 是否恢复
+type cacheBreaker struct {
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+// allow 判断当前是否允许访问缓存
+func (b *cacheBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return time.Now().After(b.openUntil)
+}
+
+// record 记录一次缓存操作的结果, 连续失败达到阈值时熔断
+func (b *cacheBreaker) record(err error, policy CacheRetryPolicy) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil {
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if policy.BreakerThreshold > 0 && b.failures >= policy.BreakerThreshold {
+		b.openUntil = time.Now().Add(policy.BreakerCooldown)
+		b.failures = 0
+	}
+}
+
+// cacheDo 执行缓存操作, 失败时按策略退避重试
+// 熔断期间直接返回 errCacheUnavailable, 请求取消时停止重试
+// 每次调用创建一个 redis span, 记录操作名、键名和重试次数
+func (d *Dao) cacheDo(ctx context.Context, operation, key string, fn func() error) (err error) {
+	ctx, span := tracing.Start(ctx, "redis."+operation,
+		semconv.DBSystemRedis,
+		semconv.DBOperation(operation),
+		attribute.String("db.redis.key", key),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	if !d.cacheBreaker.allow() {
+		span.SetAttributes(attribute.Bool("cache.breaker_open", true))
+		return errCacheUnavailable
+	}
+
+	policy := d.CacheRetry
+	attempts := policy.Attempts
+	if attempts <= 0 {
+		attempts = 1
+	}
+
+	backoff := policy.Backoff
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				d.cacheBreaker.record(err, policy)
+				return err
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		span.SetAttributes(attribute.Int("cache.attempts", i+1))
+		if err = fn(); err == nil {
+			break
+		}
+	}
+	d.cacheBreaker.record(err, policy)
+
+	return err
+}
+
+// cacheGet 读取缓存, 读取失败时按未命中处理并返回 false, 由调用方回源数据库
+func (d *Dao) cacheGet(ctx context.Context, key string) (string, bool) {
+	var value string
+	err := d.cacheDo(ctx, "get", key, func() error {
+		var err error
+		value, err = d.KvStore.Get(key)
+		return err
+	})
+	if err != nil {
+		if err != errCacheUnavailable {
+			xzap.WithContext(ctx).Warn("failed on read cache, fall back to db", zap.String("key", key), zap.Error(err))
+		}
+		return "", false
+	}
+
+	return value, true
+}
+
+// cacheSet 写入缓存, seconds 为过期时间, 为 0 时不过期
+// 写入失败只记录日志, 不影响请求结果
+func (d *Dao) cacheSet(ctx context.Context, key string, value string, seconds int) {
+	err := d.cacheDo(ctx, "set", key, func() error {
+		if seconds > 0 {
+			return d.KvStore.Setex(key, value, seconds)
+		}
+		return d.KvStore.Set(key, value)
+	})
+	if err != nil && err != errCacheUnavailable {
+		xzap.WithContext(ctx).Warn("failed on write cache", zap.String("key", key), zap.Error(err))
+	}
+}
+
+// BumpApiCacheVersion 递增路由的接口响应缓存版本号, 使该路由已缓存的响应立即失效
+func (d *Dao) BumpApiCacheVersion(ctx context.Context, route string) error {
+	key := ApiCacheVersionKey(route)
+	return d.cacheDo(ctx, "incr", key, func() error {
+		_, err := d.KvStore.Incr(key)
+		return err
+	})
+}
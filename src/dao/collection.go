@@ -3,6 +3,7 @@ package dao
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -10,7 +11,9 @@ import (
 	"github.com/joinmouse/EasySwapBase/stores/gdb/orderbookmodel/multi"
 	"github.com/pkg/errors"
 	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
 
+	"github.com/joinmouse/EasySwapBackend/src/common"
 	"github.com/joinmouse/EasySwapBackend/src/types/v1"
 )
 
@@ -20,30 +23,91 @@ const QueryTimeout = time.Second * 30
 
 var collectionFields = []string{"id", "chain_id", "token_standard", "name", "address", "image_uri", "floor_price", "sale_price", "item_amount", "owner_amount"}
 
-// QueryHistorySalesPriceInfo 查询指定时间段内的NFT销售历史价格信息
-func (d *Dao) QueryHistorySalesPriceInfo(ctx context.Context, chain string, collectionAddr string, durationTimeStamp int64) ([]multi.Activity, error) {
+// QueryHistorySalesPriceInfo 分页查询集合的NFT销售历史价格信息
+// SQL语句解释:
+// 1. 从activity表中查询指定字段(id,price,token_id,event_time)
+// 2. 条件:
+//   - 活动类型为Sale(销售)
+//   - 集合地址匹配
+//   - 事件时间在指定范围内(since到until), since 为 0 时不限制下限, until 为 0 时为当前时间
+//   - 价格在指定区间内
+//
+// 3. 按成交时间倒序、id倒序排列, 保证有新成交写入时翻页结果不重叠,
+// 过滤和排序均可以使用 (collection_address, activity_type, event_time) 索引
+func (d *Dao) QueryHistorySalesPriceInfo(ctx context.Context, chain string, collectionAddr string, filter types.HistorySalesFilter, page, pageSize int) ([]multi.Activity, int64, error) {
+	until := filter.Until
+	if until <= 0 {
+		until = time.Now().Unix()
+	}
+	query := func() *gorm.DB {
+		db := d.DB.WithContext(ctx).
+			Table(multi.ActivityTableName(chain)).
+			Where("activity_type = ? and collection_address = ? and event_time <= ?",
+				multi.Sale,
+				collectionAddr,
+				until)
+		if filter.Since > 0 {
+			db = db.Where("event_time >= ?", filter.Since)
+		}
+		if filter.MinPrice != nil {
+			db = db.Where("price >= ?", *filter.MinPrice)
+		}
+		if filter.MaxPrice != nil {
+			db = db.Where("price <= ?", *filter.MaxPrice)
+		}
+		return db
+	}
+
+	var total int64
+	if err := query().Count(&total).Error; err != nil {
+		return nil, 0, errors.Wrap(err, "failed on count history sales")
+	}
+	if total == 0 {
+		return nil, 0, nil
+	}
+
 	var historySalesInfo []multi.Activity
-	now := time.Now().Unix()
-
-	// SQL语句解释:
-	// 1. 从activity表中查询指定字段(price,token_id,event_time)
-	// 2. 条件:
-	//   - 活动类型为Sale(销售)
-	//   - 集合地址匹配
-	//   - 事件时间在指定范围内(now-duration到now)
-	if err := d.DB.WithContext(ctx).
-		Table(multi.ActivityTableName(chain)).
-		Select("price", "token_id", "event_time").
-		Where("activity_type = ? and collection_address = ? and event_time >= ? and event_time <= ?",
-			multi.Sale,
-			collectionAddr,
-			now-durationTimeStamp,
-			now).
+	if err := query().Select("id", "price", "token_id", "event_time").
+		Order("event_time desc, id desc").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
 		Find(&historySalesInfo).Error; err != nil {
-		return nil, errors.Wrap(err, "failed on get history sales info")
+		return nil, 0, errors.Wrap(err, "failed on get history sales info")
 	}
 
-	return historySalesInfo, nil
+	return historySalesInfo, total, nil
+}
+
+// QueryItemSalesHistory 分页查询单个NFT Item的历史成交记录, 按成交时间倒序排列
+// 与 QueryHistorySalesPriceInfo 使用相同的成交活动过滤条件, 限定到指定的 token_id
+func (d *Dao) QueryItemSalesHistory(ctx context.Context, chain, collectionAddr, tokenID string, page, pageSize int) ([]multi.Activity, int64, error) {
+	query := func() *gorm.DB {
+		return d.DB.WithContext(ctx).
+			Table(multi.ActivityTableName(chain)).
+			Where("activity_type = ? and collection_address = ? and token_id = ?",
+				multi.Sale,
+				collectionAddr,
+				tokenID)
+	}
+
+	var total int64
+	if err := query().Count(&total).Error; err != nil {
+		return nil, 0, errors.Wrap(err, "failed on count item sales history")
+	}
+	if total == 0 {
+		return nil, 0, nil
+	}
+
+	var sales []multi.Activity
+	if err := query().Select("id", "maker", "taker", "marketplace_id", "currency_address", "price", "tx_hash", "event_time").
+		Order("event_time desc, id desc").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&sales).Error; err != nil {
+		return nil, 0, errors.Wrap(err, "failed on get item sales history")
+	}
+
+	return sales, total, nil
 }
 
 // QueryAllCollectionInfo 查询指定链上的所有NFT集合信息
@@ -240,8 +304,10 @@ func (d *Dao) QueryMultiChainUserItemInfos(ctx context.Context, chain []string,
 	// SQL语句组成部分
 	sqlCntHead := "SELECT COUNT(*) FROM ("
 	sqlHead := "SELECT * FROM ("
-	sqlTail := fmt.Sprintf(") as combined ORDER BY combined.owned_time DESC LIMIT %d OFFSET %d",
-		pageSize, page-1)
+	// 以链ID、合约地址、token_id 作为排序的补充条件, 保证分页结果稳定
+	sqlTail := fmt.Sprintf(") as combined ORDER BY combined.owned_time DESC, combined.chain_id ASC, "+
+		"combined.collection_address ASC, combined.token_id ASC LIMIT %d OFFSET %d",
+		pageSize, (page-1)*pageSize)
 	var sqlMids []string
 
 	// 遍历每条链,构建子查询
@@ -338,8 +404,10 @@ func (d *Dao) QueryMultiChainUserListingItemInfos(ctx context.Context, chain []s
 	sqlCntHead := "SELECT COUNT(*) FROM ("
 	sqlHead := "SELECT * FROM ("
 	// 分页SQL
-	sqlTail := fmt.Sprintf(") as combined ORDER BY combined.owned_time DESC LIMIT %d OFFSET %d",
-		pageSize, page-1)
+	// 以链ID、合约地址、token_id 作为排序的补充条件, 保证分页结果稳定
+	sqlTail := fmt.Sprintf(") as combined ORDER BY combined.owned_time DESC, combined.chain_id ASC, "+
+		"combined.collection_address ASC, combined.token_id ASC LIMIT %d OFFSET %d",
+		pageSize, (page-1)*pageSize)
 	var sqlMids []string
 
 	// 遍历每条链构建SQL
@@ -421,9 +489,15 @@ func (d *Dao) QueryCollectionsListed(ctx context.Context, chain string, collecti
 	}
 
 	for _, address := range collectionAddrs {
-		count, err := d.KvStore.GetInt(ordermanager.GenCollectionListedKey(chain, address))
-		if err != nil {
-			return nil, errors.Wrap(err, "failed on set collection listed count")
+		value, ok := d.cacheGet(ctx, ordermanager.GenCollectionListedKey(chain, address))
+		count, _ := strconv.Atoi(value)
+		// 缓存不可用时从数据库统计上架数量
+		if !ok {
+			listed, err := d.QueryListedAmount(ctx, chain, address)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed on get collection listed count")
+			}
+			count = int(listed)
 		}
 		collectionsListed = append(collectionsListed, types.CollectionListed{
 			CollectionAddr: address,
@@ -435,11 +509,9 @@ func (d *Dao) QueryCollectionsListed(ctx context.Context, chain string, collecti
 }
 
 // CacheCollectionsListed 缓存集合的上架数量
+// 写入失败只记录日志, 不影响请求结果
 func (d *Dao) CacheCollectionsListed(ctx context.Context, chain string, collectionAddr string, listedCount int) error {
-	err := d.KvStore.SetInt(ordermanager.GenCollectionListedKey(chain, collectionAddr), listedCount)
-	if err != nil {
-		return errors.Wrap(err, "failed on set collection listed count")
-	}
+	d.cacheSet(ctx, ordermanager.GenCollectionListedKey(chain, collectionAddr), strconv.Itoa(listedCount), 0)
 
 	return nil
 }
@@ -455,7 +527,7 @@ func (d *Dao) QueryFloorPrice(ctx context.Context, chain string, collectionAddr
 	// 4. WHERE条件:
 	//    - 指定集合地址
 	//    - 订单类型为listing(OrderType=1)
-	//    - 订单状态为active(OrderStatus=0)
+	//    - 订单状态为active(OrderStatusActive)
 	//    - 卖家是NFT当前所有者
 	//    - 排除marketplace_id=1的订单
 	// 5. 按价格升序排序,取第一条记录(即最低价)
@@ -471,7 +543,7 @@ func (d *Dao) QueryFloorPrice(ctx context.Context, chain string, collectionAddr
 		sql,
 		collectionAddr,
 		OrderType,
-		OrderStatus,
+		types.OrderStatusActive,
 		1,
 	).Scan(&order).Error; err != nil {
 		return decimal.Zero, errors.Wrap(err, "failed on get collection floor price")
@@ -480,6 +552,55 @@ func (d *Dao) QueryFloorPrice(ctx context.Context, chain string, collectionAddr
 	return order.Price, nil
 }
 
+// CollectionFloor 集合地板价
+type CollectionFloor struct {
+	CollectionAddress string          `gorm:"column:collection_address"`
+	FloorPrice        decimal.Decimal `gorm:"column:floor_price"`
+}
+
+// QueryCollectionsFloorPrice 批量查询链上所有集合的地板价
+// 条件与 QueryFloorPrice 相同, 按集合分组取最低的有效挂单价格
+func (d *Dao) QueryCollectionsFloorPrice(ctx context.Context, chain string) ([]CollectionFloor, error) {
+	var floors []CollectionFloor
+	if err := d.DB.WithContext(ctx).
+		Table(fmt.Sprintf("%s as ci", multi.ItemTableName(chain))).
+		Select("co.collection_address as collection_address, min(co.price) as floor_price").
+		Joins(fmt.Sprintf("join %s co on co.collection_address = ci.collection_address and co.token_id = ci.token_id",
+			multi.OrderTableName(chain))).
+		Where("co.order_type = ? and co.order_status = ? and co.maker = ci.owner and co.marketplace_id != ?",
+			OrderType, types.OrderStatusActive, 1).
+		Scopes(d.sanePrice("co.price")).
+		Group("co.collection_address").
+		Scan(&floors).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query collections floor price")
+	}
+
+	return floors, nil
+}
+
+// CollectionVolumeStat 集合在一段时间内的成交额和成交笔数
+type CollectionVolumeStat struct {
+	CollectionAddress string          `gorm:"column:collection_address"`
+	Volume            decimal.Decimal `gorm:"column:volume"`
+	Sales             int64           `gorm:"column:sales"`
+}
+
+// QueryCollectionsVolume 批量查询链上所有集合从 since 开始的成交额和成交笔数
+func (d *Dao) QueryCollectionsVolume(ctx context.Context, chain string, since int64) ([]CollectionVolumeStat, error) {
+	var stats []CollectionVolumeStat
+	if err := d.DB.WithContext(ctx).
+		Table(multi.ActivityTableName(chain)).
+		Select("collection_address, COALESCE(SUM(price), 0) as volume, count(*) as sales").
+		Where("activity_type = ? and event_time >= ?", multi.Sale, since).
+		Scopes(d.sanePrice("price")).
+		Group("collection_address").
+		Scan(&stats).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query collections volume")
+	}
+
+	return stats, nil
+}
+
 func GetCollectionTradeInfoKey(project, chain string, collectionAddr string) string {
 	return fmt.Sprintf("cache:%s:%s:collection:%s:trade", strings.ToLower(project), strings.ToLower(chain), strings.ToLower(collectionAddr))
 }
@@ -497,7 +618,7 @@ func GetHoldersCountKey(chain string) string {
 // @param timeDiff int64 时间差(秒)
 // @return map[string]float64 返回集合地址到地板价变化率的映射
 // @return error 错误信息
-func (d *Dao) QueryCollectionFloorChange(chain string, timeDiff int64) (map[string]float64, error) {
+func (d *Dao) QueryCollectionFloorChange(ctx context.Context, chain string, timeDiff int64) (map[string]float64, error) {
 	collectionFloorChange := make(map[string]float64)
 
 	var collectionPrices []multi.CollectionFloorPrice
@@ -523,7 +644,7 @@ func (d *Dao) QueryCollectionFloorChange(chain string, timeDiff int64) (map[stri
 		multi.CollectionFloorPriceTableName(chain),
 		multi.CollectionFloorPriceTableName(chain),
 		multi.CollectionFloorPriceTableName(chain))
-	if err := d.DB.Raw(rawSql, timeDiff).Scan(&collectionPrices).Error; err != nil {
+	if err := d.DB.WithContext(ctx).Raw(rawSql, timeDiff).Scan(&collectionPrices).Error; err != nil {
 		return nil, errors.Wrap(err, "failed on get collection floor change")
 	}
 
@@ -531,7 +652,7 @@ func (d *Dao) QueryCollectionFloorChange(chain string, timeDiff int64) (map[stri
 	// 1. 遍历collectionPrices数组,每个元素包含集合地址和对应时间点的地板价
 	// 2. 对于每个集合:
 	//    - 如果当前元素和下一个元素是同一个集合的记录(CollectionAddress相同)
-	//    - 且下一个元素的价格大于0
+	//    - 且下一个元素的价格是有效价格(大于0)
 	//    则:
 	//    - 计算价格变化率 = (当前价格 - 历史价格) / 历史价格
 	//    - 使用Price.Sub()计算价格差
@@ -543,7 +664,7 @@ func (d *Dao) QueryCollectionFloorChange(chain string, timeDiff int64) (map[stri
 	for i := 0; i < len(collectionPrices); i++ {
 		if i < len(collectionPrices)-1 &&
 			collectionPrices[i].CollectionAddress == collectionPrices[i+1].CollectionAddress &&
-			collectionPrices[i+1].Price.GreaterThan(decimal.Zero) {
+			!common.IsZeroOrNeg(collectionPrices[i+1].Price) {
 			collectionFloorChange[collectionPrices[i].CollectionAddress] = collectionPrices[i].Price.
 				Sub(collectionPrices[i+1].Price).Div(collectionPrices[i+1].Price).InexactFloat64()
 			i++
@@ -601,3 +722,251 @@ FROM %s as co where collection_address = ? and order_status = ? and order_type =
 
 	return &collection, nil
 }
+
+// FloorPriceBucket 按时间分桶统计的地板价
+type FloorPriceBucket struct {
+	Bucket int64           `json:"bucket"` // 分桶起始时间(Unix 秒)
+	Price  decimal.Decimal `json:"price"`  // 分桶内的最低地板价
+}
+
+// QueryCollectionFloorPriceHistory 按时间间隔分桶查询集合的地板价历史
+// 地板价表记录的是集合所有item的最低listing价格, 每个分桶取其中的最低值
+// 没有地板价记录的分桶不会返回, 由调用方补齐
+func (d *Dao) QueryCollectionFloorPriceHistory(ctx context.Context, chain, collectionAddr string, start, end, interval int64) ([]FloorPriceBucket, error) {
+	var buckets []FloorPriceBucket
+	if err := d.DB.WithContext(ctx).
+		Table(multi.CollectionFloorPriceTableName(chain)).
+		Select("(event_time - ?) DIV ? * ? + ? as bucket, MIN(price) as price", start, interval, interval, start).
+		Where("collection_address = ? and event_time >= ? and event_time < ? and price > 0",
+			collectionAddr, start, end).
+		Group("bucket").
+		Order("bucket asc").
+		Scan(&buckets).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query collection floor price history")
+	}
+
+	return buckets, nil
+}
+
+// QueryCollectionFloorPriceBefore 查询指定时间之前集合最后一次记录的地板价
+// 不存在记录时返回0
+func (d *Dao) QueryCollectionFloorPriceBefore(ctx context.Context, chain, collectionAddr string, before int64) (decimal.Decimal, error) {
+	var floorPrice multi.CollectionFloorPrice
+	if err := d.DB.WithContext(ctx).
+		Table(multi.CollectionFloorPriceTableName(chain)).
+		Select("price").
+		Where("collection_address = ? and event_time < ? and price > 0", collectionAddr, before).
+		Order("event_time desc").
+		Limit(1).
+		Scan(&floorPrice).Error; err != nil {
+		return decimal.Zero, errors.Wrap(err, "failed on query last floor price")
+	}
+
+	return floorPrice.Price, nil
+}
+
+// SearchPrefixMaxLen 关键字长度不超过该值时只做前缀匹配, 以便命中 name/symbol 上的索引
+const SearchPrefixMaxLen = 3
+
+// likeEscaper 转义 LIKE 通配符, 避免用户输入的 % 和 _ 被当作通配符
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SearchCollections 按名称或符号搜索集合, 结果按24小时交易额降序排列
+// 1. 短关键字使用 LIKE 'q%' 前缀匹配, 长关键字使用 LIKE '%q%' 子串匹配
+// 2. 关联活动表统计24小时内的成交额用于排序
+// 3. excludeAddrs 中的集合不出现在结果中, 用于过滤被隐藏的集合
+func (d *Dao) SearchCollections(ctx context.Context, chain, keyword string, limit int, excludeAddrs []string) ([]types.CollectionSearchInfo, error) {
+	var collections []types.CollectionSearchInfo
+
+	pattern := likeEscaper.Replace(keyword) + "%"
+	if len([]rune(keyword)) > SearchPrefixMaxLen {
+		pattern = "%" + pattern
+	}
+
+	volumeSubQuery := d.DB.WithContext(ctx).
+		Table(multi.ActivityTableName(chain)).
+		Select("collection_address, COALESCE(SUM(price), 0) as volume").
+		Where("activity_type = ? and event_time >= ?", multi.Sale, time.Now().Add(-24*time.Hour).Unix()).
+		Group("collection_address")
+
+	db := d.DB.WithContext(ctx).
+		Table(fmt.Sprintf("%s as ci", multi.CollectionTableName(chain))).
+		Select("ci.address as address, ci.name as name, ci.symbol as symbol, ci.image_uri as image_uri, "+
+			"ci.floor_price as floor_price, COALESCE(v.volume, 0) as volume_24h").
+		Joins("left join (?) as v on v.collection_address = ci.address", volumeSubQuery).
+		Where("ci.name like ? or ci.symbol like ?", pattern, pattern)
+	if len(excludeAddrs) > 0 {
+		db = db.Where("ci.address not in (?)", excludeAddrs)
+	}
+
+	if err := db.Order("volume_24h desc, ci.id asc").
+		Limit(limit).
+		Scan(&collections).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on search collections")
+	}
+
+	return collections, nil
+}
+
+// CollectionSupplyStats 集合的供应量和持有人数量
+type CollectionSupplyStats struct {
+	TotalSupply int64 `gorm:"column:total_supply"`
+	OwnerCount  int64 `gorm:"column:owner_count"`
+}
+
+// QueryCollectionSupplyStats 一次聚合查询集合的Item总数和去重后的持有人数量
+func (d *Dao) QueryCollectionSupplyStats(ctx context.Context, chain, collectionAddr string) (*CollectionSupplyStats, error) {
+	var stats CollectionSupplyStats
+	if err := d.DB.WithContext(ctx).
+		Table(multi.ItemTableName(chain)).
+		Select("count(*) as total_supply, count(distinct owner) as owner_count").
+		Where("collection_address = ?", collectionAddr).
+		Scan(&stats).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query collection supply stats")
+	}
+
+	return &stats, nil
+}
+
+// collectionHoldingsQuery 按持有人分组统计集合内每个持有人持有的 Item 记录数
+// Item 表没有按持有者记录的余额(supply 是 Item 最多可以有多少份), ERC-1155 的每条持有记录也按1个统计
+func (d *Dao) collectionHoldingsQuery(ctx context.Context, chain, collectionAddr string) *gorm.DB {
+	return d.DB.WithContext(ctx).
+		Table(multi.ItemTableName(chain)).
+		Select("owner, count(*) as balance").
+		Where("collection_address = ? and owner != ''", collectionAddr).
+		Group("owner")
+}
+
+// QueryCollectionOwners 分页查询集合的持有人及持有数量, 按持有数量降序、地址升序排列
+func (d *Dao) QueryCollectionOwners(ctx context.Context, chain, collectionAddr string, page, pageSize int) ([]types.ItemOwnerBalance, int64, error) {
+	var total int64
+	if err := d.DB.WithContext(ctx).
+		Table(multi.ItemTableName(chain)).
+		Select("count(distinct owner)").
+		Where("collection_address = ? and owner != ''", collectionAddr).
+		Scan(&total).Error; err != nil {
+		return nil, 0, errors.Wrap(err, "failed on count collection owners")
+	}
+	if total == 0 {
+		return nil, 0, nil
+	}
+
+	var owners []types.ItemOwnerBalance
+	if err := d.collectionHoldingsQuery(ctx, chain, collectionAddr).
+		Order("balance desc, owner asc").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Scan(&owners).Error; err != nil {
+		return nil, 0, errors.Wrap(err, "failed on query collection owners")
+	}
+
+	return owners, total, nil
+}
+
+// QueryOwnerTokenIDs 分页查询持有人在集合中持有的 token id, 按 token id 数值升序排列
+// Item 记录的 owner 即当前持有人; supply 是 Item 最多可以有多少份, 与是否持有无关, 不作为过滤条件
+func (d *Dao) QueryOwnerTokenIDs(ctx context.Context, chain, collectionAddr, owner string, page, pageSize int) ([]string, int64, error) {
+	db := d.DB.WithContext(ctx).
+		Table(multi.ItemTableName(chain)).
+		Where("collection_address = ? and owner = ?", collectionAddr, owner)
+
+	var total int64
+	if err := db.Session(&gorm.Session{}).
+		Select("count(distinct token_id)").
+		Scan(&total).Error; err != nil {
+		return nil, 0, errors.Wrap(err, "failed on count owner items")
+	}
+	if total == 0 {
+		return nil, 0, nil
+	}
+
+	var tokenIDs []string
+	if err := db.Select("token_id").
+		Group("token_id").
+		Order("length(token_id), token_id").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Scan(&tokenIDs).Error; err != nil {
+		return nil, 0, errors.Wrap(err, "failed on query owner items")
+	}
+
+	return tokenIDs, total, nil
+}
+
+// CollectionOwnerDistributionStats 集合持有人按持有数量分桶的统计
+type CollectionOwnerDistributionStats struct {
+	Holders      int64 `gorm:"column:holders"`      // 持有人数量
+	TotalHeld    int64 `gorm:"column:total_held"`   // 所有持有人的持有数量之和
+	Holding1     int64 `gorm:"column:holding_1"`    // 持有1个的持有人数量
+	Holding2To5  int64 `gorm:"column:holding_2_5"`  // 持有2-5个的持有人数量
+	Holding6To20 int64 `gorm:"column:holding_6_20"` // 持有6-20个的持有人数量
+	HoldingOver  int64 `gorm:"column:holding_20"`   // 持有超过20个的持有人数量
+	TopHeld      int64 `gorm:"column:top_held"`     // 持有数量最多的前N个持有人的持有数量之和
+}
+
+// QueryCollectionOwnerDistribution 聚合查询集合持有人的分布
+// 先按持有人分组统计持有数量, 再对分组结果按持有数量分桶计数, topN 个持有人的持有数量之和单独查询
+func (d *Dao) QueryCollectionOwnerDistribution(ctx context.Context, chain, collectionAddr string, topN int) (*CollectionOwnerDistributionStats, error) {
+	var stats CollectionOwnerDistributionStats
+	if err := d.DB.WithContext(ctx).
+		Table("(?) as holdings", d.collectionHoldingsQuery(ctx, chain, collectionAddr)).
+		Select(`count(*) as holders,
+			coalesce(sum(balance), 0) as total_held,
+			coalesce(sum(case when balance <= 1 then 1 else 0 end), 0) as holding_1,
+			coalesce(sum(case when balance between 2 and 5 then 1 else 0 end), 0) as holding_2_5,
+			coalesce(sum(case when balance between 6 and 20 then 1 else 0 end), 0) as holding_6_20,
+			coalesce(sum(case when balance > 20 then 1 else 0 end), 0) as holding_20`).
+		Scan(&stats).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query collection owner distribution")
+	}
+	if stats.Holders == 0 {
+		return &stats, nil
+	}
+
+	if err := d.DB.WithContext(ctx).
+		Table("(?) as top_holdings", d.collectionHoldingsQuery(ctx, chain, collectionAddr).
+			Order("balance desc").
+			Limit(topN)).
+		Select("coalesce(sum(balance), 0)").
+		Scan(&stats.TopHeld).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query collection top holders")
+	}
+
+	return &stats, nil
+}
+
+// CollectionSaleStats 集合在不同时间窗口内的成交额和成交笔数
+type CollectionSaleStats struct {
+	Volume24h decimal.Decimal `gorm:"column:volume_24h"`
+	Volume7d  decimal.Decimal `gorm:"column:volume_7d"`
+	Volume30d decimal.Decimal `gorm:"column:volume_30d"`
+	Sales24h  int64           `gorm:"column:sales_24h"`
+	Sales7d   int64           `gorm:"column:sales_7d"`
+	Sales30d  int64           `gorm:"column:sales_30d"`
+}
+
+// QueryCollectionSaleStats 查询集合24小时、7天、30天内的成交额和成交笔数
+// 只扫描30天内的成交记录, 通过 CASE WHEN 在一次查询中完成三个时间窗口的统计
+func (d *Dao) QueryCollectionSaleStats(ctx context.Context, chain, collectionAddr string, now time.Time) (*CollectionSaleStats, error) {
+	day := now.Add(-24 * time.Hour).Unix()
+	week := now.Add(-7 * 24 * time.Hour).Unix()
+	month := now.Add(-30 * 24 * time.Hour).Unix()
+
+	var stats CollectionSaleStats
+	if err := d.DB.WithContext(ctx).
+		Table(multi.ActivityTableName(chain)).
+		Select("COALESCE(SUM(CASE WHEN event_time >= ? THEN price ELSE 0 END), 0) as volume_24h, "+
+			"COALESCE(SUM(CASE WHEN event_time >= ? THEN price ELSE 0 END), 0) as volume_7d, "+
+			"COALESCE(SUM(price), 0) as volume_30d, "+
+			"COALESCE(SUM(CASE WHEN event_time >= ? THEN 1 ELSE 0 END), 0) as sales_24h, "+
+			"COALESCE(SUM(CASE WHEN event_time >= ? THEN 1 ELSE 0 END), 0) as sales_7d, "+
+			"count(*) as sales_30d", day, week, day, week).
+		Where("collection_address = ? and activity_type = ? and event_time >= ?", collectionAddr, multi.Sale, month).
+		Scopes(d.sanePrice("price")).
+		Scan(&stats).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query collection sale stats")
+	}
+
+	return &stats, nil
+}
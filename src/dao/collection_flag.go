@@ -0,0 +1,86 @@
+package dao
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm/clause"
+)
+
+// CollectionFlagTableName 集合隐藏标记表, 用于屏蔽被举报的诈骗集合
+// 集合表由同步服务维护, 隐藏标记单独存放, 不修改原始数据
+// 建表语句:
+//
+//	CREATE TABLE `collection_flag` (
+//	  `id` bigint NOT NULL AUTO_INCREMENT,
+//	  `chain_id` int NOT NULL,
+//	  `collection_address` varchar(64) NOT NULL,
+//	  `hidden` tinyint(1) NOT NULL DEFAULT 0,
+//	  `reason` varchar(255) NOT NULL DEFAULT '',
+//	  `update_time` bigint NOT NULL DEFAULT 0,
+//	  PRIMARY KEY (`id`),
+//	  UNIQUE KEY `uk_chain_collection` (`chain_id`, `collection_address`),
+//	  KEY `idx_chain_hidden` (`chain_id`, `hidden`)
+//	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+const CollectionFlagTableName = "collection_flag"
+
+// CollectionFlag 集合的隐藏标记
+type CollectionFlag struct {
+	Id                int64  `gorm:"column:id" json:"id"`
+	ChainId           int    `gorm:"column:chain_id" json:"chain_id"`
+	CollectionAddress string `gorm:"column:collection_address" json:"collection_address"`
+	Hidden            bool   `gorm:"column:hidden" json:"hidden"`
+	Reason            string `gorm:"column:reason" json:"reason"`
+	UpdateTime        int64  `gorm:"column:update_time" json:"update_time"`
+}
+
+// QueryHiddenCollections 查询链上所有被隐藏的集合地址(小写)
+func (d *Dao) QueryHiddenCollections(ctx context.Context, chainID int) ([]string, error) {
+	var addrs []string
+	if err := d.DB.WithContext(ctx).
+		Table(CollectionFlagTableName).
+		Where("chain_id = ? and hidden = ?", chainID, true).
+		Pluck("collection_address", &addrs).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query hidden collections")
+	}
+
+	return addrs, nil
+}
+
+// IsCollectionHidden 判断集合是否被隐藏
+func (d *Dao) IsCollectionHidden(ctx context.Context, chainID int, collectionAddr string) (bool, error) {
+	var count int64
+	if err := d.DB.WithContext(ctx).
+		Table(CollectionFlagTableName).
+		Where("chain_id = ? and collection_address = ? and hidden = ?",
+			chainID, strings.ToLower(collectionAddr), true).
+		Count(&count).Error; err != nil {
+		return false, errors.Wrap(err, "failed on query collection flag")
+	}
+
+	return count > 0, nil
+}
+
+// SetCollectionHidden 设置集合的隐藏标记, 记录不存在时新建
+func (d *Dao) SetCollectionHidden(ctx context.Context, chainID int, collectionAddr string, hidden bool, reason string) error {
+	flag := CollectionFlag{
+		ChainId:           chainID,
+		CollectionAddress: strings.ToLower(collectionAddr),
+		Hidden:            hidden,
+		Reason:            reason,
+		UpdateTime:        time.Now().UnixMilli(),
+	}
+	if err := d.DB.WithContext(ctx).
+		Table(CollectionFlagTableName).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "chain_id"}, {Name: "collection_address"}},
+			DoUpdates: clause.AssignmentColumns([]string{"hidden", "reason", "update_time"}),
+		}).
+		Create(&flag).Error; err != nil {
+		return errors.Wrap(err, "failed on set collection hidden")
+	}
+
+	return nil
+}
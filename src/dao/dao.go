@@ -4,35 +4,53 @@
 package dao
 
 import (
-	"context"
-
 	"github.com/joinmouse/EasySwapBase/stores/xkv"  // 键值存储操作封装
+	"github.com/shopspring/decimal"                // 精度十进制运算库
 	"gorm.io/gorm"                                 // GORM ORM 框架
 )
 
+// DefaultMaxPrice 默认的合理最大价格, 超过该价格的订单和成交记录不参与统计
+var DefaultMaxPrice = decimal.New(1, 12)
+
 // Dao 表示数据访问对象，封装了数据库和缓存操作
 // 它是 EasySwap NFT 交易所数据持久化层的核心组件
 // 提供统一的数据访问接口，支持事务处理和缓存管理
 type Dao struct {
-	ctx     context.Context  // 上下文对象，用于传递请求范围内的信息
 	DB      *gorm.DB         // GORM 数据库连接，用于执行 SQL 操作
 	KvStore *xkv.Store       // 键值存储实例（Redis），用于缓存和会话管理
+	MaxPrice decimal.Decimal // 合理最大价格，统计查询会排除价格为负或超过该值的异常记录
+
+	CacheRetry   CacheRetryPolicy // 缓存读写的重试和熔断策略
+	cacheBreaker cacheBreaker     // 缓存熔断状态
 }
 
 // New 创建一个新的数据访问对象实例
 // 该函数初始化 Dao 结构体，将数据库连接和缓存实例传入
+// 所有查询方法都以请求的 context 作为第一个参数，请求取消或超时时查询会随之取消
 //
 // 参数:
-//   - ctx: 上下文对象，用于传递请求相关信息
 //   - db: GORM 数据库连接实例
 //   - kvStore: 键值存储实例，用于缓存操作
 //
 // 返回值:
 //   - *Dao: 初始化完成的数据访问对象
-func New(ctx context.Context, db *gorm.DB, kvStore *xkv.Store) *Dao {
+func New(db *gorm.DB, kvStore *xkv.Store) *Dao {
 	return &Dao{
-		ctx:     ctx,     // 保存上下文
 		DB:      db,      // 保存数据库连接
 		KvStore: kvStore, // 保存缓存实例
+		MaxPrice: DefaultMaxPrice, // 默认合理最大价格
+		CacheRetry: DefaultCacheRetryPolicy, // 默认缓存重试和熔断策略
+	}
+}
+
+// sanePrice 统计查询使用的价格过滤条件
+// 排除价格为负数或超过 MaxPrice 的异常订单/成交, 避免异常数据污染成交额等统计结果
+func (d *Dao) sanePrice(column string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Where(column + " >= 0")
+		if d.MaxPrice.IsPositive() {
+			db = db.Where(column+" <= ?", d.MaxPrice)
+		}
+		return db
 	}
 }
@@ -0,0 +1,63 @@
+package dao
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// CollectionRoyaltyTableName 集合版税配置表, 覆盖链配置中的默认版税
+// 建表语句:
+//
+//	CREATE TABLE `collection_royalty` (
+//	  `id` bigint NOT NULL AUTO_INCREMENT,
+//	  `chain_id` int NOT NULL,
+//	  `collection_address` varchar(64) NOT NULL,
+//	  `royalty_bps` int NOT NULL DEFAULT 0,
+//	  `royalty_recipient` varchar(42) NOT NULL DEFAULT '',
+//	  `update_time` bigint NOT NULL DEFAULT 0,
+//	  PRIMARY KEY (`id`),
+//	  UNIQUE KEY `uk_chain_collection` (`chain_id`, `collection_address`)
+//	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+const CollectionRoyaltyTableName = "collection_royalty"
+
+// CollectionRoyalty 集合单独配置的版税
+type CollectionRoyalty struct {
+	Id                int64  `gorm:"column:id" json:"id"`
+	ChainId           int    `gorm:"column:chain_id" json:"chain_id"`
+	CollectionAddress string `gorm:"column:collection_address" json:"collection_address"`
+	RoyaltyBps        int    `gorm:"column:royalty_bps" json:"royalty_bps"`
+	RoyaltyRecipient  string `gorm:"column:royalty_recipient" json:"royalty_recipient"`
+	UpdateTime        int64  `gorm:"column:update_time" json:"update_time"`
+}
+
+// QueryCollectionRoyalties 查询链上所有单独配置了版税的集合
+func (d *Dao) QueryCollectionRoyalties(ctx context.Context, chainID int) ([]CollectionRoyalty, error) {
+	var royalties []CollectionRoyalty
+	if err := d.DB.WithContext(ctx).
+		Table(CollectionRoyaltyTableName).
+		Where("chain_id = ?", chainID).
+		Order("collection_address asc").
+		Find(&royalties).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query collection royalties")
+	}
+
+	return royalties, nil
+}
+
+// QueryCollectionRoyalty 查询集合单独配置的版税, 未配置时返回 nil
+func (d *Dao) QueryCollectionRoyalty(ctx context.Context, chainID int, collectionAddr string) (*CollectionRoyalty, error) {
+	var royalties []CollectionRoyalty
+	if err := d.DB.WithContext(ctx).
+		Table(CollectionRoyaltyTableName).
+		Where("chain_id = ? and collection_address = ?", chainID, collectionAddr).
+		Limit(1).
+		Find(&royalties).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query collection royalty")
+	}
+	if len(royalties) == 0 {
+		return nil, nil
+	}
+
+	return &royalties[0], nil
+}
@@ -0,0 +1,21 @@
+package dao
+
+import (
+	"context"
+
+	"github.com/joinmouse/EasySwapBase/stores/gdb/orderbookmodel/base"
+	"github.com/pkg/errors"
+)
+
+// QueryIndexedStatus 查询索引服务在指定链上各类索引任务的同步进度
+func (d *Dao) QueryIndexedStatus(ctx context.Context, chainID int) ([]base.IndexedStatus, error) {
+	var statuses []base.IndexedStatus
+	if err := d.DB.WithContext(ctx).
+		Table(base.IndexedStatusTableName()).
+		Where("chain_id = ?", chainID).
+		Find(&statuses).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query indexed status")
+	}
+
+	return statuses, nil
+}
@@ -3,11 +3,13 @@ package dao
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/joinmouse/EasySwapBase/stores/gdb/orderbookmodel/multi"
 	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 
@@ -53,6 +55,7 @@ func (d *Dao) QueryCollectionBids(ctx context.Context, chain string, collectionA
 		Table(multi.OrderTableName(chain)).
 		Where("collection_address = ? and order_type = ? and order_status = ? and expire_time > ?",
 			collectionAddr, multi.CollectionBidOrder, multi.OrderStatusActive, time.Now().Unix()).
+		Scopes(d.sanePrice("price")).
 		Group("price").
 		Count(&count).Error; err != nil {
 		return nil, 0, errors.Wrap(err, "failed on count user items")
@@ -77,6 +80,7 @@ func (d *Dao) QueryCollectionBids(ctx context.Context, chain string, collectionA
 		Where(`collection_address = ? and order_type = ? and order_status = ? 
 			   and expire_time > ? and quantity_remaining > 0`,
 			collectionAddr, multi.CollectionBidOrder, multi.OrderStatusActive, time.Now().Unix()).
+		Scopes(d.sanePrice("price")).
 		Group("price").
 		Order("price desc").
 		Limit(int(pageSize)).
@@ -88,6 +92,169 @@ func (d *Dao) QueryCollectionBids(ctx context.Context, chain string, collectionA
 	return bids, count, nil
 }
 
+// QueryCollectionBidMakers 查询集合中指定价位有效 Collection Bid 的出价人
+// 返回价格到出价人地址的映射, 同一价位的出价人按最早出价时间排序, 每个价位最多返回 limit 个
+func (d *Dao) QueryCollectionBidMakers(ctx context.Context, chain string, collectionAddr string, prices []decimal.Decimal, limit int) (map[string][]string, error) {
+	makers := make(map[string][]string)
+	if len(prices) == 0 {
+		return makers, nil
+	}
+
+	var rows []struct {
+		Price decimal.Decimal
+		Maker string
+	}
+	if err := d.DB.WithContext(ctx).
+		Table(multi.OrderTableName(chain)).
+		Select("price, maker, min(event_time) AS first_time").
+		Where(`collection_address = ? and order_type = ? and order_status = ?
+			   and expire_time > ? and quantity_remaining > 0 and price in (?)`,
+			collectionAddr, multi.CollectionBidOrder, multi.OrderStatusActive, time.Now().Unix(), prices).
+		Group("price, maker").
+		Order("price desc, first_time asc").
+		Scan(&rows).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query collection bid makers")
+	}
+
+	for _, row := range rows {
+		key := row.Price.String()
+		if len(makers[key]) < limit {
+			makers[key] = append(makers[key], row.Maker)
+		}
+	}
+
+	return makers, nil
+}
+
+// QueryCollectionBidLevels 按价格档位聚合集合内有效的 Collection Bid
+// 每个价位统计出价原始数量之和(size)、剩余未成交数量之和(quantity_remaining)以及不同出价人数
+// 结果按价格降序排列,最多返回limit个价位
+func (d *Dao) QueryCollectionBidLevels(ctx context.Context, chain string, collectionAddr string, limit int) ([]types.BidPriceLevel, error) {
+	var levels []types.BidPriceLevel
+	if err := d.DB.WithContext(ctx).
+		Table(multi.OrderTableName(chain)).
+		Select(`price,
+			sum(size) AS bid_size,
+			sum(quantity_remaining) AS bid_unfilled,
+			sum(quantity_remaining)*price AS total,
+			COUNT(DISTINCT maker) AS makers`).
+		Where(`collection_address = ? and order_type = ? and order_status = ?
+			   and expire_time > ? and quantity_remaining > 0`,
+			collectionAddr, multi.CollectionBidOrder, multi.OrderStatusActive, time.Now().Unix()).
+		Scopes(d.sanePrice("price")).
+		Group("price").
+		Order("price desc").
+		Limit(limit).
+		Scan(&levels).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query collection bid levels")
+	}
+
+	return levels, nil
+}
+
+// QueryCollectionListingLevels 按价格档位聚合集合内有效的挂单
+// 只统计未过期且有剩余数量的活跃挂单, 已取消、已成交的挂单订单状态不是活跃状态
+// 每个价位统计挂单数量、剩余可成交数量之和以及不同卖家数量, 结果按价格升序排列, 最多返回limit个价位
+func (d *Dao) QueryCollectionListingLevels(ctx context.Context, chain string, collectionAddr string, limit int) ([]types.ListingInfo, error) {
+	var rows []struct {
+		Price    decimal.Decimal
+		Count    int64
+		Quantity int64
+		Sellers  int64
+	}
+	if err := d.DB.WithContext(ctx).
+		Table(multi.OrderTableName(chain)).
+		Select(`price,
+			COUNT(*) AS count,
+			sum(quantity_remaining) AS quantity,
+			COUNT(DISTINCT maker) AS sellers`).
+		Where(`collection_address = ? and order_type = ? and order_status = ?
+			   and expire_time > ? and quantity_remaining > 0`,
+			collectionAddr, multi.ListingOrder, multi.OrderStatusActive, time.Now().Unix()).
+		Scopes(d.sanePrice("price")).
+		Group("price").
+		Order("price asc").
+		Limit(limit).
+		Scan(&rows).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query collection listing levels")
+	}
+
+	levels := make([]types.ListingInfo, 0, len(rows))
+	for _, row := range rows {
+		levels = append(levels, types.ListingInfo{
+			Price:    row.Price,
+			Count:    row.Count,
+			Quantity: row.Quantity,
+			Sellers:  row.Sellers,
+		})
+	}
+
+	return levels, nil
+}
+
+// QueryCollectionItemBidsByTrait 按 Trait值分组汇总集合内有效的Item出价
+// 订单表中没有单独的 Trait出价类型, 针对 Item 的出价通过 Trait表关联到该 Item 拥有的每个 Trait值,
+// 按 (trait, trait_value) 分组统计最高出价、剩余未成交数量之和以及出价数量
+func (d *Dao) QueryCollectionItemBidsByTrait(ctx context.Context, chain string, collectionAddr string) ([]types.TraitItemBids, error) {
+	var traitBids []types.TraitItemBids
+	if err := d.DB.WithContext(ctx).
+		Table(fmt.Sprintf("%s as gf_order", multi.OrderTableName(chain))).
+		Select(`gf_attribute.collection_address as collection_address,
+			gf_attribute.trait as trait,
+			gf_attribute.trait_value as trait_value,
+			max(gf_order.price) as price,
+			sum(gf_order.quantity_remaining) as total_size,
+			count(distinct gf_order.order_id) as bids`).
+		Joins(fmt.Sprintf("join %s as gf_attribute on gf_order.collection_address = gf_attribute.collection_address "+
+			"and gf_order.token_id = gf_attribute.token_id", multi.ItemTraitTableName(chain))).
+		Where(`gf_order.collection_address = ? and gf_order.order_type = ? and gf_order.order_status = ?
+			   and gf_order.expire_time > ? and gf_order.quantity_remaining > 0`,
+			collectionAddr, multi.ItemBidOrder, multi.OrderStatusActive, time.Now().Unix()).
+		Scopes(d.sanePrice("gf_order.price")).
+		Group("gf_attribute.collection_address, gf_attribute.trait, gf_attribute.trait_value").
+		Order("gf_attribute.trait asc, price desc").
+		Scan(&traitBids).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query collection item bids by trait")
+	}
+
+	return traitBids, nil
+}
+
+// applyItemTraitFilters 按特征过滤集合内的Item
+// 每个特征生成一个 token_id in (子查询) 条件, 不同特征之间为 AND, 同一特征的多个值通过 in 实现 OR
+// 子查询只按 collection_address、trait、trait_value 等值过滤, 可以使用特征表上
+// (collection_address, trait, trait_value) 的联合索引, 与 Item 表通过 (collection_address, token_id) 关联
+func applyItemTraitFilters(db *gorm.DB, chain, collectionAddr string, traits map[string][]string) {
+	if len(traits) == 0 {
+		return
+	}
+
+	// 按特征名称排序, 保证生成的SQL稳定
+	names := make([]string, 0, len(traits))
+	for name, values := range traits {
+		if len(values) > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		db.Where(fmt.Sprintf("ci.token_id in (select token_id from %s where collection_address = ? "+
+			"and trait = ? and trait_value in (?))", multi.ItemTraitTableName(chain)),
+			collectionAddr, name, traits[name])
+	}
+}
+
+// havingPriceRange 在按 token_id 分组的查询上按价格区间过滤
+func havingPriceRange(db *gorm.DB, priceExpr string, filter types.CollectionItemFilterParams) {
+	if filter.MinPrice != nil {
+		db.Having(priceExpr+" >= ?", filter.MinPrice.Decimal)
+	}
+	if filter.MaxPrice != nil {
+		db.Having(priceExpr+" <= ?", filter.MaxPrice.Decimal)
+	}
+}
+
 // QueryCollectionItemOrder 查询集合内NFT Item的订单信息
 
 func (d *Dao) QueryCollectionItemOrder(ctx context.Context, chain string, filter types.CollectionItemFilterParams, collectionAddr string) ([]*CollectionItem, int64, error) {
@@ -103,12 +270,18 @@ func (d *Dao) QueryCollectionItemOrder(ctx context.Context, chain string, filter
 	// 根据状态过滤查询
 	// status: 1-buy now(立即购买), 2-has offer(有报价), 3-all(所有)
 	if len(filter.Status) == 1 {
+		// 排序和价格区间过滤使用的价格: 立即购买为最低挂单价格, 有报价为最高出价
+		priceExpr := "min(co.price)"
+		if filter.Status[0] == HasOffer {
+			priceExpr = "max(co.price)"
+		}
+
 		// 构建基础SELECT语句
 		db.Select(
 			"ci.id as id, ci.chain_id as chain_id, " +
 				"ci.collection_address as collection_address,ci.token_id as token_id, " +
 				"ci.name as name, ci.owner as owner, " +
-				"min(co.price) as list_price, " +
+				priceExpr + " as list_price, " +
 				"SUBSTRING_INDEX(GROUP_CONCAT(co.marketplace_id ORDER BY co.price,co.marketplace_id),',', 1) AS market_id, " +
 				"min(co.price) != 0 as listing")
 
@@ -141,6 +314,7 @@ func (d *Dao) QueryCollectionItemOrder(ctx context.Context, chain string, filter
 			}
 
 			db.Group("co.token_id")
+			havingPriceRange(db, priceExpr, filter)
 		}
 
 		// 处理有报价状态
@@ -170,7 +344,15 @@ func (d *Dao) QueryCollectionItemOrder(ctx context.Context, chain string, filter
 				db.Where("ci.owner =?", filter.UserAddress)
 			}
 
+			// 只返回已上架的Item时, 要求Item同时存在所有者的有效挂单
+			if filter.ListedOnly {
+				db.Where(fmt.Sprintf("exists (select 1 from %s lo where lo.collection_address=ci.collection_address "+
+					"and lo.token_id=ci.token_id and lo.order_type = ? and lo.order_status = ? and lo.maker = ci.owner)",
+					coTableName), multi.ListingOrder, multi.OrderStatusActive)
+			}
+
 			db.Group("co.token_id")
+			havingPriceRange(db, priceExpr, filter)
 		}
 	} else if len(filter.Status) == 2 {
 		// 处理同时有买卖订单的情况
@@ -180,15 +362,16 @@ func (d *Dao) QueryCollectionItemOrder(ctx context.Context, chain string, filter
 		// 3. 分组后需同时存在listing和offer订单
 		// 选择字段:
 		// 1. 基本信息:id、chain_id、collection_address、token_id、name、owner
-		// 2. list_price: 取最低挂单价格(min(co.price))
+		// 2. list_price: 只取挂单中的最低价格, 出价不参与, 排序和价格区间过滤都使用该价格
 		// 3. market_id: 使用SUBSTRING_INDEX和GROUP_CONCAT组合取最低价格对应的市场ID
 		//    - GROUP_CONCAT按价格和市场ID排序,将marketplace_id连接成字符串
 		//    - SUBSTRING_INDEX取第一个值,即最低价格对应的市场ID
+		listPriceExpr := fmt.Sprintf("min(case when co.order_type = %d then co.price end)", multi.ListingOrder)
 		db.Select(
 			"ci.id as id, ci.chain_id as chain_id," +
 				"ci.collection_address as collection_address,ci.token_id as token_id, " +
 				"ci.name as name, ci.owner as owner, " +
-				"min(co.price) as list_price, " +
+				listPriceExpr + " as list_price, " +
 				"SUBSTRING_INDEX(GROUP_CONCAT(co.marketplace_id ORDER BY co.price,co.marketplace_id),',', 1) AS market_id")
 
 		db.Joins(fmt.Sprintf(
@@ -216,6 +399,7 @@ func (d *Dao) QueryCollectionItemOrder(ctx context.Context, chain string, filter
 		db.Group("co.token_id").Having(
 			"min(co.type)=? and max(co.type)=?",
 			multi.ListingOrder, multi.OfferOrder)
+		havingPriceRange(db, listPriceExpr, filter)
 
 	} else {
 		// 处理所有状态
@@ -261,13 +445,27 @@ func (d *Dao) QueryCollectionItemOrder(ctx context.Context, chain string, filter
 		if filter.UserAddress != "" {
 			db.Where(fmt.Sprintf("ci.owner = '%s'", filter.UserAddress))
 		}
+
+		// 价格过滤只匹配已上架的Item
+		if filter.ListedOnly || filter.MinPrice != nil || filter.MaxPrice != nil {
+			db.Where("co.list_price is not null")
+		}
+		if filter.MinPrice != nil {
+			db.Where("co.list_price >= ?", filter.MinPrice.Decimal)
+		}
+		if filter.MaxPrice != nil {
+			db.Where("co.list_price <= ?", filter.MaxPrice.Decimal)
+		}
 	}
 
+	// 特征过滤
+	applyItemTraitFilters(db, chain, collectionAddr, filter.Traits)
+
 	// 统计总记录数
 	var count int64
 	countTx := db.Session(&gorm.Session{})
 	if err := countTx.Count(&count).Error; err != nil {
-		return nil, 0, errors.Wrap(db.Error, "failed on count items")
+		return nil, 0, errors.Wrap(err, "failed on count items")
 	}
 
 	// 处理排序
@@ -372,7 +570,7 @@ func (d *Dao) QueryLastSalePrice(ctx context.Context, chain string,
 		multi.ActivityTableName(chain),
 		multi.ActivityTableName(chain))
 
-	if err := d.DB.Raw(sql, collectionAddr, tokenIds,
+	if err := d.DB.WithContext(ctx).Raw(sql, collectionAddr, tokenIds,
 		multi.Sale, multi.Sale).Scan(&lastSales).Error; err != nil {
 		return nil, errors.Wrap(err, "failed on get item last sale price")
 	}
@@ -403,7 +601,7 @@ func (d *Dao) QueryBestBids(ctx context.Context, chain string, userAddr string,
 	if userAddr == "" {
 		sql = fmt.Sprintf(`
 			SELECT order_id, token_id, event_time, price, salt, 
-				expire_time, maker, order_type, quantity_remaining, size   
+				expire_time, maker, order_type, quantity_remaining, size, currency_address   
 			FROM %s
 			WHERE collection_address = ?
 				AND token_id IN (?)
@@ -415,7 +613,7 @@ func (d *Dao) QueryBestBids(ctx context.Context, chain string, userAddr string,
 	} else {
 		sql = fmt.Sprintf(`
 			SELECT order_id, token_id, event_time, price, salt, 
-				expire_time, maker, order_type, quantity_remaining, size   
+				expire_time, maker, order_type, quantity_remaining, size, currency_address   
 			FROM %s
 			WHERE collection_address = ?
 				AND token_id IN (?)
@@ -427,7 +625,7 @@ func (d *Dao) QueryBestBids(ctx context.Context, chain string, userAddr string,
 		`, multi.OrderTableName(chain), userAddr)
 	}
 
-	if err := d.DB.Raw(sql, collectionAddr, tokenIds,
+	if err := d.DB.WithContext(ctx).Raw(sql, collectionAddr, tokenIds,
 		multi.ItemBidOrder, multi.OrderStatusActive,
 		time.Now().Unix()).Scan(&bestBids).Error; err != nil {
 		return nil, errors.Wrap(err, "failed on get item best bids")
@@ -486,7 +684,7 @@ SELECT order_id, token_id, event_time, price, salt, expire_time, maker, order_ty
 	}
 
 	// 执行SQL查询
-	if err := d.DB.Raw(sql, conditions, multi.ItemBidOrder, multi.OrderStatusActive, time.Now().Unix()).Scan(&bestBids).Error; err != nil {
+	if err := d.DB.WithContext(ctx).Raw(sql, conditions, multi.ItemBidOrder, multi.OrderStatusActive, time.Now().Unix()).Scan(&bestBids).Error; err != nil {
 		return nil, errors.Wrap(err, "failed on get item best bids")
 	}
 
@@ -530,7 +728,7 @@ func (d *Dao) QueryCollectionsBestBid(ctx context.Context, chain string, userAdd
 	}
 
 	// 5. 执行查询
-	if err := d.DB.Raw(sql, collectionAddrs, multi.CollectionBidOrder, multi.OrderStatusActive, time.Now().Unix(), multi.CollectionBidOrder, multi.OrderStatusActive, time.Now().Unix()).Scan(&bestBid).Error; err != nil {
+	if err := d.DB.WithContext(ctx).Raw(sql, collectionAddrs, multi.CollectionBidOrder, multi.OrderStatusActive, time.Now().Unix(), multi.CollectionBidOrder, multi.OrderStatusActive, time.Now().Unix()).Scan(&bestBid).Error; err != nil {
 		return bestBid, errors.Wrap(err, "failed on get item best bids")
 	}
 
@@ -559,7 +757,7 @@ func (d *Dao) QueryCollectionBestBid(ctx context.Context, chain string,
 	if userAddr == "" {
 		sql = fmt.Sprintf(`
 			SELECT order_id, price, event_time, expire_time, salt, maker, 
-				order_type, quantity_remaining, size  
+				order_type, quantity_remaining, size, currency_address  
 			FROM %s
 			WHERE collection_address = ?
 			AND order_type = ?
@@ -572,7 +770,7 @@ func (d *Dao) QueryCollectionBestBid(ctx context.Context, chain string,
 	} else {
 		sql = fmt.Sprintf(`
 			SELECT order_id, price, event_time, expire_time, salt, maker, 
-				order_type, quantity_remaining, size  
+				order_type, quantity_remaining, size, currency_address  
 			FROM %s
 			WHERE collection_address = ?
 			AND order_type = ?
@@ -585,7 +783,7 @@ func (d *Dao) QueryCollectionBestBid(ctx context.Context, chain string,
 		`, multi.OrderTableName(chain), userAddr)
 	}
 
-	if err := d.DB.Raw(sql, collectionAddr, multi.CollectionBidOrder,
+	if err := d.DB.WithContext(ctx).Raw(sql, collectionAddr, multi.CollectionBidOrder,
 		multi.OrderStatusActive, time.Now().Unix()).Scan(&bestBid).Error; err != nil {
 		return bestBid, errors.Wrap(err, "failed on get item best bids")
 	}
@@ -644,7 +842,7 @@ func (d *Dao) QueryCollectionTopNBid(ctx context.Context, chain string,
 	}
 
 	// 执行SQL查询
-	if err := d.DB.Raw(sql, collectionAddr, multi.CollectionBidOrder,
+	if err := d.DB.WithContext(ctx).Raw(sql, collectionAddr, multi.CollectionBidOrder,
 		multi.OrderStatusActive, time.Now().Unix()).Scan(&bestBids).Error; err != nil {
 		return nil, errors.Wrap(err, "failed on get item best bids")
 	}
@@ -667,7 +865,6 @@ func (d *Dao) QueryCollectionTopNBid(ctx context.Context, chain string,
 var collectionDetailFields = []string{"id", "chain_id", "token_standard", "name", "address", "image_uri", "floor_price", "sale_price", "item_amount", "owner_amount"}
 
 const OrderType = 1
-const OrderStatus = 0
 
 // QueryListedAmount 查询集合中已上架NFT的数量
 func (d *Dao) QueryListedAmount(ctx context.Context, chain string, collectionAddr string) (int64, error) {
@@ -678,7 +875,7 @@ func (d *Dao) QueryListedAmount(ctx context.Context, chain string, collectionAdd
 	// 4. WHERE条件:
 	//   - 指定集合地址
 	//   - 订单类型为listing(OrderType=1)
-	//   - 订单状态为active(OrderStatus=0)
+	//   - 订单状态为active(OrderStatusActive)
 	//   - 卖家是NFT当前所有者
 	//   - 排除marketplace_id=1的订单
 	sql := fmt.Sprintf(`SELECT count(distinct (co.token_id)) as counts
@@ -693,7 +890,7 @@ func (d *Dao) QueryListedAmount(ctx context.Context, chain string, collectionAdd
 		sql,
 		collectionAddr,
 		OrderType,
-		OrderStatus,
+		types.OrderStatusActive,
 		1,
 	).Scan(&counts).Error; err != nil {
 		return 0, errors.Wrap(err, "failed on get listed item amount")
@@ -716,7 +913,7 @@ func (d *Dao) QueryListedAmountEachCollection(ctx context.Context, chain string,
 	//    - 集合地址在给定列表中
 	//    - NFT所有者在给定用户列表中
 	//    - 订单类型为listing(OrderType=1)
-	//    - 订单状态为active(OrderStatus=0)
+	//    - 订单状态为active(OrderStatusActive)
 	//    - 卖家是NFT当前所有者
 	//    - 排除marketplace_id=1的订单
 	// 5. 按集合地址分组,获取每个集合的统计结果
@@ -731,7 +928,7 @@ func (d *Dao) QueryListedAmountEachCollection(ctx context.Context, chain string,
 		collectionAddrs,
 		userAddrs,
 		OrderType,
-		OrderStatus,
+		types.OrderStatusActive,
 		1,
 	).Scan(&counts).Error; err != nil {
 		return nil, errors.Wrap(err, "failed on get listed item amount")
@@ -973,6 +1170,80 @@ func (d *Dao) QueryItemListInfo(ctx context.Context, chain, collectionAddr, toke
 	return &collectionItem, nil
 }
 
+// QueryItemsListInfo 批量查询NFT Item的挂单信息
+// 主要功能:
+// 1. 一次查询指定token id列表中每个Item的最低挂单价格
+// 2. 批量查询最低价订单的详细信息(订单ID、过期时间等)并合并
+// 3. 没有挂单的Item不会出现在返回结果中
+func (d *Dao) QueryItemsListInfo(ctx context.Context, chain, collectionAddr string, tokenIDs []string) ([]*CollectionItem, error) {
+	var collectionItems []*CollectionItem
+	coTableName := multi.OrderTableName(chain)
+
+	// SQL解释:
+	// 1. 从items表和orders表联表查询
+	// 2. 选择NFT基本信息和挂单信息,按token分组取最低价及其市场ID
+	// 3. 过滤条件:匹配NFT、活跃订单、owner是卖家
+	if err := d.DB.WithContext(ctx).Table(fmt.Sprintf("%s as ci", multi.ItemTableName(chain))).
+		Select(
+			"ci.id as id, ci.chain_id as chain_id, "+
+				"ci.collection_address as collection_address,ci.token_id as token_id, "+
+				"ci.name as name, ci.owner as owner, "+
+				"min(co.price) as list_price, "+
+				"SUBSTRING_INDEX(GROUP_CONCAT(co.marketplace_id ORDER BY co.price,co.marketplace_id),',', 1) AS market_id, "+
+				"min(co.price) != 0 as listing").
+		Joins(fmt.Sprintf("join %s co on co.collection_address=ci.collection_address and co.token_id=ci.token_id",
+			coTableName)).
+		Where("ci.collection_address =? and ci.token_id in (?) and co.order_type = ? and co.order_status=? "+
+			"and co.maker = ci.owner",
+			collectionAddr, tokenIDs, multi.ListingOrder, multi.OrderStatusActive).
+		Group("ci.collection_address,ci.token_id").
+		Scan(&collectionItems).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query items list info")
+	}
+
+	var priceInfos []types.ItemPriceInfo
+	for _, item := range collectionItems {
+		if item.Listing {
+			priceInfos = append(priceInfos, types.ItemPriceInfo{
+				CollectionAddress: item.CollectionAddress,
+				TokenID:           item.TokenId,
+				Maker:             item.Owner,
+				Price:             item.ListPrice,
+				OrderStatus:       multi.OrderStatusActive,
+			})
+		}
+	}
+	if len(priceInfos) == 0 {
+		return collectionItems, nil
+	}
+
+	// 批量查询最低价订单的详细信息
+	orders, err := d.QueryListingInfo(ctx, chain, priceInfos)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on query items order info")
+	}
+
+	ordersInfo := make(map[string]multi.Order)
+	for _, order := range orders {
+		ordersInfo[strings.ToLower(order.TokenId)] = order
+	}
+
+	// 填充订单详细信息
+	for _, item := range collectionItems {
+		order, ok := ordersInfo[strings.ToLower(item.TokenId)]
+		if !ok {
+			continue
+		}
+		item.OrderID = order.OrderID
+		item.ListExpireTime = order.ExpireTime
+		item.ListMaker = order.Maker
+		item.ListSalt = order.Salt
+		item.ListTime = order.EventTime
+	}
+
+	return collectionItems, nil
+}
+
 // QueryListingInfo 查询订单上架信息
 // 该函数主要功能:
 // 1. 根据传入的价格信息列表查询对应的订单详情
@@ -1077,17 +1348,45 @@ func (d *Dao) QueryMultiChainListingInfo(ctx context.Context, priceInfos []Multi
 	return orders, nil
 }
 
+// ItemListing 单个NFT在某个市场、某种币种下的最低挂单
+type ItemListing struct {
+	TokenId string `gorm:"column:token_id"`
+	types.ListingInfo
+}
+
+// QueryItemsListings 批量查询NFT在各市场的挂单
+// 只统计当前持有人的有效挂单, 按 token id、市场和支付币种分别取最低价
+func (d *Dao) QueryItemsListings(ctx context.Context, chain, collectionAddr string, tokenIDs []string) ([]ItemListing, error) {
+	var listings []ItemListing
+	if err := d.DB.WithContext(ctx).
+		Table(fmt.Sprintf("%s as co", multi.OrderTableName(chain))).
+		Select("co.token_id as token_id, co.marketplace_id as marketplace_id, "+
+			"co.currency_address as currency_address, min(co.price) as price").
+		Joins(fmt.Sprintf("join %s ci on ci.collection_address = co.collection_address and ci.token_id = co.token_id",
+			multi.ItemTableName(chain))).
+		Where("co.collection_address = ? and co.token_id in (?) and co.order_type = ? and co.order_status = ? "+
+			"and co.maker = ci.owner",
+			collectionAddr, tokenIDs, multi.ListingOrder, multi.OrderStatusActive).
+		Group("co.token_id, co.marketplace_id, co.currency_address").
+		Scan(&listings).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query items listings")
+	}
+
+	return listings, nil
+}
+
 // QueryItemListingAcrossPlatforms 查询NFT在各平台的挂单价格信息
+// 不同支付币种的价格不可比较, 按市场和币种分别取最低价
 func (d *Dao) QueryItemListingAcrossPlatforms(ctx context.Context, chain, collectionAddr, tokenID string, user []string) ([]types.ListingInfo, error) {
 	var listings []types.ListingInfo
 	if err := d.DB.WithContext(ctx).Table(multi.OrderTableName(chain)).
-		Select("marketplace_id, min(price) as price").
+		Select("marketplace_id, currency_address, min(price) as price").
 		Where("collection_address=? and token_id=? and maker in (?) and order_type=? and order_status = ?",
 			collectionAddr,
 			tokenID,
 			user,
 			multi.ListingOrder,
-			multi.OrderStatusActive).Group("marketplace_id").Scan(&listings).Error; err != nil {
+			multi.OrderStatusActive).Group("marketplace_id, currency_address").Scan(&listings).Error; err != nil {
 		return nil, errors.Wrap(err, "failed on query listing from db")
 	}
 
@@ -1107,7 +1406,8 @@ func (d *Dao) QueryItemInfo(ctx context.Context, chain, collectionAddr, tokenID
 			"ci.collection_address as collection_address, "+
 			"ci.token_id as token_id, "+
 			"ci.name as name, "+
-			"ci.owner as owner").
+			"ci.owner as owner, "+
+			"ci.update_time as update_time").
 		Where("ci.collection_address =? and ci.token_id = ? ",
 			collectionAddr, tokenID).
 		Scan(&item).Error
@@ -1119,6 +1419,29 @@ func (d *Dao) QueryItemInfo(ctx context.Context, chain, collectionAddr, tokenID
 	return &item, nil
 }
 
+// QueryItemsInfo 批量查询NFT Item的基本信息
+// 使用一次 WHERE token_id IN (...) 查询,不存在的token id不会出现在返回结果中
+func (d *Dao) QueryItemsInfo(ctx context.Context, chain, collectionAddr string, tokenIDs []string) ([]multi.Item, error) {
+	var items []multi.Item
+
+	if err := d.DB.WithContext(ctx).
+		Table(fmt.Sprintf("%s as ci", multi.ItemTableName(chain))).
+		Select("ci.id as id, "+
+			"ci.chain_id as chain_id, "+
+			"ci.collection_address as collection_address, "+
+			"ci.token_id as token_id, "+
+			"ci.name as name, "+
+			"ci.owner as owner, "+
+			"ci.update_time as update_time").
+		Where("ci.collection_address =? and ci.token_id in (?)",
+			collectionAddr, tokenIDs).
+		Scan(&items).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query items info")
+	}
+
+	return items, nil
+}
+
 // QueryTraitsPrice 查询NFT Trait的价格信息
 // 主要功能:
 // 1. 查询指定NFT集合中特定token id的 Trait价格
@@ -1136,14 +1459,17 @@ func (d *Dao) QueryTraitsPrice(ctx context.Context, chain, collectionAddr string
 		Where("gf_order.collection_address=? and gf_order.order_type=? and gf_order.order_status = ?",
 			collectionAddr,
 			multi.ListingOrder,
-			multi.OrderStatusActive).
-		// 条件2: Trait必须在指定token的 Trait列表中
-		Where("(gf_attribute.trait,gf_attribute.trait_value) in (?)",
+			multi.OrderStatusActive)
+
+	// 条件2: 指定了token时, Trait必须在指定token的 Trait列表中; 否则统计合集全部 Trait
+	if len(tokenIds) > 0 {
+		listSubQuery = listSubQuery.Where("(gf_attribute.trait,gf_attribute.trait_value) in (?)",
 			d.DB.WithContext(ctx).
 				Table(fmt.Sprintf("%s as gf_attr", multi.ItemTraitTableName(chain))).
 				Select("gf_attr.trait, gf_attr.trait_value").
 				Where("gf_attr.collection_address=? and gf_attr.token_id in (?)",
 					collectionAddr, tokenIds))
+	}
 
 	// 关联 Trait表,按 Trait分组查询
 	if err := listSubQuery.
@@ -1166,6 +1492,22 @@ func (d *Dao) UpdateItemOwner(ctx context.Context, chain string, collectionAddr,
 	return nil
 }
 
+// QueryItemHolders 查询Item的持有者列表, 按地址升序排列
+// Item 表的 supply 是 Item 最多可以有多少份, 不是持有者的持有数量;
+// 表中没有按持有者记录的余额, 只返回持有者地址, 持有数量由调用方从链上读取
+func (d *Dao) QueryItemHolders(ctx context.Context, chain string, collectionAddr, tokenID string) ([]types.ItemOwnerBalance, error) {
+	var holders []types.ItemOwnerBalance
+	if err := d.DB.WithContext(ctx).Table(multi.ItemTableName(chain)).
+		Select("distinct owner").
+		Where("collection_address = ? and token_id = ? and owner != ''", collectionAddr, tokenID).
+		Order("owner asc").
+		Scan(&holders).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query item holders")
+	}
+
+	return holders, nil
+}
+
 // QueryItemBids 查询Item的出价信息
 func (d *Dao) QueryItemBids(ctx context.Context, chain string, collectionAddr, tokenID string,
 	page, pageSize int) ([]types.ItemBid, int64, error) {
@@ -1208,3 +1550,46 @@ func (d *Dao) QueryItemBids(ctx context.Context, chain string, collectionAddr, t
 
 	return itemBids, count, nil
 }
+
+// QueryCollectionTokenIDs 按入库顺序查询集合中的 token id, 最多返回 limit 个
+func (d *Dao) QueryCollectionTokenIDs(ctx context.Context, chain string, collectionAddr string, limit int) ([]string, error) {
+	var tokenIDs []string
+	if err := d.DB.WithContext(ctx).
+		Table(multi.ItemTableName(chain)).
+		Where("collection_address = ?", collectionAddr).
+		Order("id asc").
+		Limit(limit).
+		Pluck("token_id", &tokenIDs).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query collection token ids")
+	}
+
+	return tokenIDs, nil
+}
+
+// QueryItemOffers 查询适用于指定Item的有效出价, 包括该Item的出价和集合出价
+// 只返回未过期且有剩余数量的活跃出价, 按价格降序排列, 最多返回 limit 条
+// excludeMaker 不为空时排除该地址发起的出价
+func (d *Dao) QueryItemOffers(ctx context.Context, chain string, collectionAddr, tokenID, excludeMaker string, limit int) ([]multi.Order, error) {
+	var offers []multi.Order
+	now := time.Now().Unix()
+	db := d.DB.WithContext(ctx).
+		Table(multi.OrderTableName(chain)).
+		Select("marketplace_id, collection_address, token_id, order_id, order_type, currency_address, "+
+			"price, maker, quantity_remaining, size, event_time, expire_time").
+		Where("collection_address = ? and order_status = ? and expire_time > ? and quantity_remaining > 0",
+			collectionAddr, multi.OrderStatusActive, now).
+		Where("(order_type = ? or (order_type = ? and token_id = ?))",
+			multi.CollectionBidOrder, multi.ItemBidOrder, tokenID)
+	if excludeMaker != "" {
+		db = db.Where("maker != ?", excludeMaker)
+	}
+	if err := db.
+		Scopes(d.sanePrice("price")).
+		Order("price desc, event_time asc").
+		Limit(limit).
+		Scan(&offers).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query item offers")
+	}
+
+	return offers, nil
+}
@@ -0,0 +1,114 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/joinmouse/EasySwapBackend/src/types/v1"
+)
+
+// containsInOrder 判断 args 是否按顺序包含 want 中的所有值
+func containsInOrder(args []interface{}, want ...interface{}) bool {
+	i := 0
+	for _, arg := range args {
+		if i < len(want) && fmt.Sprint(arg) == fmt.Sprint(want[i]) {
+			i++
+		}
+	}
+	return i == len(want)
+}
+
+func TestQueryCollectionItemOrderTraitsAndPrice(t *testing.T) {
+	const collection = "0xabc"
+	traitClause := "ci.token_id in (select token_id from ob_item_trait_eth where collection_address = ? and trait = ? and trait_value in (%s))"
+
+	tests := []struct {
+		name string
+		// status 为空时查询所有Item, 价格条件作用于子查询的最低挂单价格
+		status []int
+		// priceExpr 排序列 list_price 的表达式, 价格区间必须作用于同一表达式
+		priceExpr string
+		priceCond []string
+	}{
+		{"all", nil, "co.list_price", []string{"co.list_price >= ?", "co.list_price <= ?"}},
+		{"buy now", []int{BuyNow}, "min(co.price)", []string{"HAVING min(co.price) >= ? AND min(co.price) <= ?"}},
+		{"has offer", []int{HasOffer}, "max(co.price)", []string{"HAVING max(co.price) >= ? AND max(co.price) <= ?"}},
+		{"buy now and has offer", []int{BuyNow, HasOffer}, "min(case when co.order_type = 1 then co.price end)",
+			[]string{"min(case when co.order_type = 1 then co.price end) >= ? AND min(case when co.order_type = 1 then co.price end) <= ?"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, stub := newStubDao(t)
+			_, _, err := d.QueryCollectionItemOrder(context.Background(), "eth", types.CollectionItemFilterParams{
+				Status:   tt.status,
+				Page:     1,
+				PageSize: 10,
+				Traits:   map[string][]string{"Eyes": {"Laser"}, "Background": {"Blue", "Red"}},
+				MinPrice: &types.Price{Decimal: decimal.NewFromInt(1)},
+				MaxPrice: &types.Price{Decimal: decimal.RequireFromString("2.5")},
+			}, collection)
+			if err != nil {
+				t.Fatalf("QueryCollectionItemOrder() error: %v", err)
+			}
+
+			queries := stub.Queries()
+			if len(queries) != 2 {
+				t.Fatalf("executed %d queries, want count and select", len(queries))
+			}
+			for _, q := range queries {
+				// 每个特征一个子查询, 特征之间为 AND, 同一特征的多个值为 in
+				background := fmt.Sprintf(traitClause, "?,?")
+				eyes := fmt.Sprintf(traitClause, "?")
+				if !strings.Contains(q.SQL, background+") AND ("+eyes) {
+					t.Errorf("missing trait conditions in %s", q.SQL)
+				}
+				for _, cond := range tt.priceCond {
+					if !strings.Contains(q.SQL, cond) {
+						t.Errorf("missing price condition %q in %s", cond, q.SQL)
+					}
+				}
+				if !containsInOrder(q.Args, collection, "Background", "Blue", "Red", collection, "Eyes", "Laser") {
+					t.Errorf("trait args = %v", q.Args)
+				}
+				if !containsInOrder(q.Args, "1", "2.5") {
+					t.Errorf("price args = %v", q.Args)
+				}
+			}
+
+			// 排序使用的 list_price 与价格过滤为同一表达式
+			selectSQL := queries[1].SQL
+			if !strings.Contains(selectSQL, tt.priceExpr+" as list_price") {
+				t.Errorf("list_price is not %s in %s", tt.priceExpr, selectSQL)
+			}
+			if !strings.Contains(selectSQL, "ORDER BY") || !strings.Contains(selectSQL, "list_price asc") {
+				t.Errorf("select is not ordered by list_price: %s", selectSQL)
+			}
+		})
+	}
+}
+
+func TestApplyItemTraitFiltersSkipsEmpty(t *testing.T) {
+	d, stub := newStubDao(t)
+	_, _, err := d.QueryCollectionItemOrder(context.Background(), "eth", types.CollectionItemFilterParams{
+		Status:   []int{BuyNow},
+		Page:     1,
+		PageSize: 10,
+		Traits:   map[string][]string{"Background": {}, "Eyes": {"Laser"}},
+	}, "0xabc")
+	if err != nil {
+		t.Fatalf("QueryCollectionItemOrder() error: %v", err)
+	}
+	for _, q := range stub.Queries() {
+		if n := strings.Count(q.SQL, "ob_item_trait_eth"); n != 1 {
+			t.Errorf("got %d trait subqueries, want 1: %s", n, q.SQL)
+		}
+		if strings.Contains(q.SQL, "HAVING") {
+			t.Errorf("unexpected price condition without price range: %s", q.SQL)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/joinmouse/EasySwapBase/stores/gdb/orderbookmodel/multi"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+
+	"github.com/joinmouse/EasySwapBackend/src/types/v1"
+)
+
+// ListedItem 集合中已上架的item及其最低挂单价格
+type ListedItem struct {
+	CollectionAddress string          `gorm:"column:collection_address" json:"-"`
+	ItemID            int64           `gorm:"column:item_id" json:"item_id"`
+	TokenId           string          `gorm:"column:token_id" json:"token_id"`
+	ListPrice         decimal.Decimal `gorm:"column:list_price" json:"list_price"`
+	MarketID          int             `gorm:"column:market_id" json:"market_id"`
+}
+
+// QueryListedItems 查询链上所有集合当前已上架的item及其最低挂单价格
+// 与 QueryCollectionItemOrder 的 listed_only 条件一致: 只统计 OrderBookDex 上由item当前所有者发起的有效挂单
+func (d *Dao) QueryListedItems(ctx context.Context, chain string) ([]ListedItem, error) {
+	var items []ListedItem
+
+	// SQL解释:
+	// 1. 关联订单表和Item表, 要求挂单人是Item当前所有者
+	// 2. 按集合和token_id分组, 取最低挂单价格及其对应的市场ID
+	if err := d.DB.WithContext(ctx).
+		Table(fmt.Sprintf("%s as co", multi.OrderTableName(chain))).
+		Select("co.collection_address as collection_address, co.token_id as token_id, "+
+			"min(ci.id) as item_id, min(co.price) as list_price, "+
+			"SUBSTRING_INDEX(GROUP_CONCAT(co.marketplace_id ORDER BY co.price,co.marketplace_id),',', 1) AS market_id").
+		Joins(fmt.Sprintf("join %s ci on ci.collection_address=co.collection_address and ci.token_id=co.token_id",
+			multi.ItemTableName(chain))).
+		Where("co.order_type = ? and co.order_status = ? and co.marketplace_id = ? and co.maker = ci.owner",
+			multi.ListingOrder, multi.OrderStatusActive, multi.OrderBookDex).
+		Group("co.collection_address, co.token_id").
+		Scan(&items).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query listed items")
+	}
+
+	return items, nil
+}
+
+// ListedItemsFastPath 判断集合Item列表查询能否直接使用已上架Item的缓存
+// 只支持 listed_only 且按挂单价格排序、不带状态/token/用户/特征/市场过滤的查询, 价格区间由调用方过滤
+// 返回值 desc 表示是否按价格降序
+func ListedItemsFastPath(filter types.CollectionItemFilterParams) (desc bool, ok bool) {
+	if !filter.ListedOnly || len(filter.Status) != 0 || filter.TokenID != "" ||
+		filter.UserAddress != "" || len(filter.Traits) != 0 {
+		return false, false
+	}
+	if len(filter.Markets) > 1 || (len(filter.Markets) == 1 && filter.Markets[0] != int(multi.OrderBookDex)) {
+		return false, false
+	}
+
+	switch filter.Sort {
+	case 0, listPriceAsc:
+		return false, true
+	case listPriceDesc:
+		return true, true
+	default:
+		return false, false
+	}
+}
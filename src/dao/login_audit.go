@@ -0,0 +1,64 @@
+package dao
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// UserLoginAuditTableName 用户登录审计表, 记录每一次登录尝试
+// 建表语句:
+//
+//	CREATE TABLE `user_login_audit` (
+//	  `id` bigint NOT NULL AUTO_INCREMENT,
+//	  `user_address` varchar(42) NOT NULL,
+//	  `chain_id` int NOT NULL DEFAULT 0,
+//	  `success` tinyint(1) NOT NULL DEFAULT 0,
+//	  `reason` varchar(255) NOT NULL DEFAULT '',
+//	  `ip` varchar(64) NOT NULL DEFAULT '',
+//	  `user_agent` varchar(512) NOT NULL DEFAULT '',
+//	  `create_time` bigint NOT NULL DEFAULT 0,
+//	  PRIMARY KEY (`id`),
+//	  KEY `idx_user_time` (`user_address`, `create_time`)
+//	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+const UserLoginAuditTableName = "user_login_audit"
+
+// UserLoginAudit 用户登录尝试记录
+type UserLoginAudit struct {
+	Id          int64  `gorm:"column:id" json:"id"`
+	UserAddress string `gorm:"column:user_address" json:"user_address"`
+	ChainId     int    `gorm:"column:chain_id" json:"chain_id"`
+	Success     bool   `gorm:"column:success" json:"success"`
+	Reason      string `gorm:"column:reason" json:"reason"` // 登录失败原因, 成功时为空
+	Ip          string `gorm:"column:ip" json:"ip"`
+	UserAgent   string `gorm:"column:user_agent" json:"user_agent"`
+	CreateTime  int64  `gorm:"column:create_time" json:"create_time"` // 毫秒时间戳
+}
+
+// InsertUserLoginAudit 写入一条登录尝试记录
+func (d *Dao) InsertUserLoginAudit(ctx context.Context, audit *UserLoginAudit) error {
+	audit.UserAddress = strings.ToLower(audit.UserAddress)
+	if err := d.DB.WithContext(ctx).
+		Table(UserLoginAuditTableName).
+		Create(audit).Error; err != nil {
+		return errors.Wrap(err, "failed on insert user login audit")
+	}
+
+	return nil
+}
+
+// QueryUserLoginAudits 查询用户最近的登录尝试记录, 按时间倒序排列
+func (d *Dao) QueryUserLoginAudits(ctx context.Context, userAddr string, limit int) ([]UserLoginAudit, error) {
+	var audits []UserLoginAudit
+	if err := d.DB.WithContext(ctx).
+		Table(UserLoginAuditTableName).
+		Where("user_address = ?", strings.ToLower(userAddr)).
+		Order("create_time desc, id desc").
+		Limit(limit).
+		Find(&audits).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query user login audit")
+	}
+
+	return audits, nil
+}
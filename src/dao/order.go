@@ -0,0 +1,65 @@
+package dao
+
+import (
+	"context"
+
+	"github.com/joinmouse/EasySwapBase/stores/gdb/orderbookmodel/multi"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// QueryOrdersByIDs 根据订单ID批量查询指定链上的订单
+func (d *Dao) QueryOrdersByIDs(ctx context.Context, chain string, orderIDs []string) ([]multi.Order, error) {
+	var orders []multi.Order
+	if len(orderIDs) == 0 {
+		return orders, nil
+	}
+
+	if err := d.DB.WithContext(ctx).Table(multi.OrderTableName(chain)).
+		Select("marketplace_id, collection_address, token_id, order_id, order_status, event_time, "+
+			"expire_time, currency_address, price, maker, taker, quantity_remaining, size, order_type, salt, update_time").
+		Where("order_id in (?)", orderIDs).
+		Find(&orders).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query orders by ids")
+	}
+
+	return orders, nil
+}
+
+// MaxOrderActivities 查询单个订单相关交易活动的最大数量
+const MaxOrderActivities = 200
+
+// OrderActivityFilter 匹配订单相关交易活动的条件
+// 交易活动表没有记录订单ID, 通过集合、token id、价格以及挂单人在活动中的角色匹配
+type OrderActivityFilter struct {
+	CollectionAddress string
+	TokenID           string // 为空时不限制 token id, 用于集合出价
+	Price             decimal.Decimal
+	Since             int64  // 只匹配订单创建之后的活动
+	Account           string // 订单的挂单人
+	MakerTypes        []int  // 挂单人为活动 maker 的活动类型, 如创建、取消订单以及挂单被购买
+	TakerTypes        []int  // 挂单人为活动 taker 的活动类型, 如出价被接受
+}
+
+// QueryOrderActivities 查询与订单相关的交易活动, 按时间升序排列
+func (d *Dao) QueryOrderActivities(ctx context.Context, chain string, filter OrderActivityFilter) ([]multi.Activity, error) {
+	var activities []multi.Activity
+
+	db := d.DB.WithContext(ctx).Table(multi.ActivityTableName(chain)).
+		Select("id, activity_type, maker, taker, collection_address, token_id, price, tx_hash, event_time").
+		Where("collection_address = ? and price = ? and event_time >= ?",
+			filter.CollectionAddress, filter.Price, filter.Since).
+		Where("(activity_type in (?) and maker = ?) or (activity_type in (?) and taker = ?)",
+			filter.MakerTypes, filter.Account, filter.TakerTypes, filter.Account)
+	if filter.TokenID != "" {
+		db = db.Where("token_id = ?", filter.TokenID)
+	}
+
+	if err := db.Order("event_time asc, id asc").
+		Limit(MaxOrderActivities).
+		Find(&activities).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query order activities")
+	}
+
+	return activities, nil
+}
@@ -0,0 +1,63 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/joinmouse/EasySwapBase/stores/gdb/orderbookmodel/multi"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// UserHolding 用户持有的 Item
+// Item 表没有按持有者记录的余额(supply 是 Item 最多可以有多少份), ERC-1155 的持有份数由调用方从链上读取
+type UserHolding struct {
+	CollectionAddress string `gorm:"column:collection_address"`
+	TokenId           string `gorm:"column:token_id"`
+}
+
+// ItemTopBid Item 的最高出价
+type ItemTopBid struct {
+	CollectionAddress string          `gorm:"column:collection_address"`
+	TokenId           string          `gorm:"column:token_id"`
+	Price             decimal.Decimal `gorm:"column:price"`
+}
+
+// QueryUserHoldings 查询用户在链上持有的所有 Item
+func (d *Dao) QueryUserHoldings(ctx context.Context, chain string, owner string) ([]UserHolding, error) {
+	var holdings []UserHolding
+	if err := d.DB.WithContext(ctx).
+		Table(multi.ItemTableName(chain)).
+		Select("collection_address, token_id").
+		Where("owner = ?", owner).
+		Scan(&holdings).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query user holdings")
+	}
+
+	return holdings, nil
+}
+
+// QueryUserItemsTopBids 查询用户持有的每个 Item 当前的最高 Item 出价, 不包括用户自己的出价
+func (d *Dao) QueryUserItemsTopBids(ctx context.Context, chain string, owner string) ([]ItemTopBid, error) {
+	var bids []ItemTopBid
+
+	// SQL解释:
+	// 1. 关联订单表和Item表, 只保留用户当前持有的Item上的出价
+	// 2. 条件: Item出价单、订单有效、剩余数量大于0、未过期、出价人不是用户自己
+	// 3. 按集合和token_id分组取最高出价
+	if err := d.DB.WithContext(ctx).
+		Table(fmt.Sprintf("%s as co", multi.OrderTableName(chain))).
+		Select("co.collection_address as collection_address, co.token_id as token_id, max(co.price) as price").
+		Joins(fmt.Sprintf("join %s gi on gi.collection_address = co.collection_address and gi.token_id = co.token_id",
+			multi.ItemTableName(chain))).
+		Where("gi.owner = ? and co.order_type = ? and co.order_status = ? and co.quantity_remaining > 0 "+
+			"and co.expire_time > ? and co.maker != ?",
+			owner, multi.ItemBidOrder, multi.OrderStatusActive, time.Now().Unix(), owner).
+		Group("co.collection_address, co.token_id").
+		Scan(&bids).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query user items top bids")
+	}
+
+	return bids, nil
+}
@@ -1,6 +1,7 @@
 package dao
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
@@ -37,7 +38,7 @@ var periodToEpoch = periodEpochMap{
 }
 
 // GetTradeInfoByCollection 获取指定时间段内集合的交易统计信息
-func (d *Dao) GetTradeInfoByCollection(chain, collectionAddr, period string) (*CollectionTrade, error) {
+func (d *Dao) GetTradeInfoByCollection(ctx context.Context, chain, collectionAddr, period string) (*CollectionTrade, error) {
 	// 查询当前时间段的交易信息
 	var tradeCount int64
 	var totalVolume decimal.Decimal
@@ -53,9 +54,10 @@ func (d *Dao) GetTradeInfoByCollection(chain, collectionAddr, period string) (*C
 	endTime := time.Now()
 
 	// 统计当前时间段内的交易数量和总交易额
-	err := d.DB.WithContext(d.ctx).Table(multi.ActivityTableName(chain)).
+	err := d.DB.WithContext(ctx).Table(multi.ActivityTableName(chain)).
 		Where("collection_address = ? AND activity_type = ? AND event_time >= ? AND event_time <= ?",
 			collectionAddr, multi.Sale, startTime, endTime).
+		Scopes(d.sanePrice("price")).
 		Select("COUNT(*) as trade_count, COALESCE(SUM(price), 0) as total_volume").
 		Row().Scan(&tradeCount, &totalVolume)
 	if err != nil {
@@ -63,9 +65,10 @@ func (d *Dao) GetTradeInfoByCollection(chain, collectionAddr, period string) (*C
 	}
 
 	// 获取当前时间段内的地板价(最低成交价)
-	err = d.DB.WithContext(d.ctx).Table(multi.ActivityTableName(chain)).
+	err = d.DB.WithContext(ctx).Table(multi.ActivityTableName(chain)).
 		Where("collection_address = ? AND activity_type = ? AND event_time >= ? AND event_time <= ?",
 			collectionAddr, multi.Sale, startTime, endTime).
+		Scopes(d.sanePrice("price")).
 		Select("COALESCE(MIN(price), 0)").
 		Row().Scan(&floorPrice)
 	if err != nil {
@@ -80,9 +83,10 @@ func (d *Dao) GetTradeInfoByCollection(chain, collectionAddr, period string) (*C
 	var prevFloorPrice decimal.Decimal
 
 	// 获取上一时段的总交易额
-	err = d.DB.WithContext(d.ctx).Table(multi.ActivityTableName(chain)).
+	err = d.DB.WithContext(ctx).Table(multi.ActivityTableName(chain)).
 		Where("collection_address = ? AND activity_type = ? AND event_time >= ? AND event_time <= ?",
 			collectionAddr, multi.Sale, prevStartTime, prevEndTime).
+		Scopes(d.sanePrice("price")).
 		Select("COALESCE(SUM(price), 0)").
 		Row().Scan(&prevVolume)
 	if err != nil {
@@ -90,9 +94,10 @@ func (d *Dao) GetTradeInfoByCollection(chain, collectionAddr, period string) (*C
 	}
 
 	// 获取上一时段的地板价
-	err = d.DB.WithContext(d.ctx).Table(multi.ActivityTableName(chain)).
+	err = d.DB.WithContext(ctx).Table(multi.ActivityTableName(chain)).
 		Where("collection_address = ? AND activity_type = ? AND event_time >= ? AND event_time <= ?",
 			collectionAddr, multi.Sale, prevStartTime, prevEndTime).
+		Scopes(d.sanePrice("price")).
 		Select("COALESCE(MIN(price), 0)").
 		Row().Scan(&prevFloorPrice)
 	if err != nil {
@@ -125,100 +130,57 @@ func (d *Dao) GetTradeInfoByCollection(chain, collectionAddr, period string) (*C
 	}, nil
 }
 
-// 根据Activity获取集合排行榜信息
-func (d *Dao) GetCollectionRankingByActivity(chain, period string) ([]*CollectionTrade, error) {
-	// 解析时间范围
-	// 获取时间段对应的epoch值
-	epoch, ok := periodToEpoch[period]
-	if !ok {
-		return nil, errors.Errorf("invalid period: %s", period)
+// 获取指定COllection的交易总量
+func (d *Dao) GetCollectionVolume(ctx context.Context, chain, collectionAddr string) (decimal.Decimal, error) {
+	var volume decimal.Decimal
+	err := d.DB.WithContext(ctx).Table(multi.ActivityTableName(chain)).
+		Where("collection_address = ? AND activity_type = ?", collectionAddr, multi.Sale).
+		Select("COALESCE(SUM(price), 0)").
+		Row().Scan(&volume)
+	if err != nil {
+		return decimal.Zero, errors.Wrap(err, "failed to get collection volume")
 	}
-	// 计算查询的时间范围
-	startTime := time.Now().Add(-time.Duration(epoch) * time.Minute)
-	endTime := time.Now()
 
-	// 计算上一个时间段
-	prevEndTime := startTime
-	prevStartTime := startTime.Add(-time.Duration(epoch) * time.Minute)
-
-	// 获取当前时间段的交易统计
-	type TradeStats struct {
-		CollectionAddress string
-		ItemCount         int64
-		Volume            decimal.Decimal
-		FloorPrice        decimal.Decimal
-	}
+	return volume, nil
+}
 
-	var currentStats []TradeStats
-	err := d.DB.WithContext(d.ctx).Table(multi.ActivityTableName(chain)).
-		Select("collection_address, COUNT(*) as item_count, COALESCE(SUM(price), 0) as volume, COALESCE(MIN(price), 0) as floor_price").
-		Where("activity_type = ? AND event_time >= ? AND event_time <= ?", multi.Sale, startTime, endTime).
-		Group("collection_address").
-		Find(&currentStats).Error
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to get current stats")
-	}
+// CollectionTradeStats 集合在一个时间窗口内的成交统计
+type CollectionTradeStats struct {
+	CollectionAddress string          `json:"collection_address"`
+	ItemCount         int64           `json:"item_count"`  // 成交笔数
+	Volume            decimal.Decimal `json:"volume"`      // 成交额
+	FloorPrice        decimal.Decimal `json:"floor_price"` // 最低成交价
+}
 
-	// 获取上一时间段的交易统计
-	var prevStats []TradeStats
-	err = d.DB.WithContext(d.ctx).Table(multi.ActivityTableName(chain)).
+// QueryCollectionTradeStats 按集合统计 [startTime, endTime) 内的成交笔数、成交额和最低成交价
+// 返回以小写集合地址为键的映射, 窗口内没有成交的集合不在结果中
+func (d *Dao) QueryCollectionTradeStats(ctx context.Context, chain string, startTime, endTime time.Time) (map[string]CollectionTradeStats, error) {
+	var stats []CollectionTradeStats
+	err := d.DB.WithContext(ctx).Table(multi.ActivityTableName(chain)).
 		Select("collection_address, COUNT(*) as item_count, COALESCE(SUM(price), 0) as volume, COALESCE(MIN(price), 0) as floor_price").
-		Where("activity_type = ? AND event_time >= ? AND event_time <= ?", multi.Sale, prevStartTime, prevEndTime).
+		Where("activity_type = ? AND event_time >= ? AND event_time < ?", multi.Sale, startTime.Unix(), endTime.Unix()).
+		Scopes(d.sanePrice("price")).
 		Group("collection_address").
-		Find(&prevStats).Error
+		Find(&stats).Error
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to get previous stats")
-	}
-
-	// 构建上一时间段数据的map
-	prevStatsMap := make(map[string]TradeStats)
-	for _, stat := range prevStats {
-		prevStatsMap[stat.CollectionAddress] = stat
+		return nil, errors.Wrap(err, "failed on query collection trade stats")
 	}
 
-	// 构建结果
-	var result []*CollectionTrade
-	for _, curr := range currentStats {
-		trade := &CollectionTrade{
-			ContractAddress: curr.CollectionAddress,
-			ItemCount:       curr.ItemCount,
-			Volume:          curr.Volume,
-			VolumeChange:    0,
-			PreFloorPrice:   decimal.Zero,
-			FloorChange:     0,
-		}
-
-		// 计算变化率
-		if prev, ok := prevStatsMap[curr.CollectionAddress]; ok {
-			trade.PreFloorPrice = prev.FloorPrice
-
-			if !prev.Volume.IsZero() {
-				volumeChangeDecimal := curr.Volume.Sub(prev.Volume).Div(prev.Volume).Mul(decimal.NewFromInt(100))
-				trade.VolumeChange = int(volumeChangeDecimal.IntPart())
-			}
-
-			if !prev.FloorPrice.IsZero() {
-				floorChangeDecimal := curr.FloorPrice.Sub(prev.FloorPrice).Div(prev.FloorPrice).Mul(decimal.NewFromInt(100))
-				trade.FloorChange = int(floorChangeDecimal.IntPart())
-			}
-		}
-
-		result = append(result, trade)
+	result := make(map[string]CollectionTradeStats, len(stats))
+	for _, stat := range stats {
+		result[strings.ToLower(stat.CollectionAddress)] = stat
 	}
 
 	return result, nil
 }
 
-// 获取指定COllection的交易总量
-func (d *Dao) GetCollectionVolume(chain, collectionAddr string) (decimal.Decimal, error) {
-	var volume decimal.Decimal
-	err := d.DB.WithContext(d.ctx).Table(multi.ActivityTableName(chain)).
-		Where("collection_address = ? AND activity_type = ?", collectionAddr, multi.Sale).
-		Select("COALESCE(SUM(price), 0)").
-		Row().Scan(&volume)
-	if err != nil {
-		return decimal.Zero, errors.Wrap(err, "failed to get collection volume")
-	}
+// GetCachedRanking 读取缓存的排行榜, 缓存不可用或熔断时按未命中处理
+func (d *Dao) GetCachedRanking(ctx context.Context, cacheKey string) (string, bool) {
+	value, ok := d.cacheGet(ctx, cacheKey)
+	return value, ok && value != ""
+}
 
-	return volume, nil
+// CacheRanking 缓存排行榜, seconds 为过期时间, 写入失败只记录日志
+func (d *Dao) CacheRanking(ctx context.Context, cacheKey, value string, seconds int) {
+	d.cacheSet(ctx, cacheKey, value, seconds)
 }
@@ -0,0 +1,90 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// stubQuery 执行过的一条 SQL 及其参数
+type stubQuery struct {
+	SQL  string
+	Args []interface{}
+}
+
+// stubDB 测试用的数据库驱动, 记录执行的 SQL, 所有查询都返回空结果
+type stubDB struct {
+	mu      sync.Mutex
+	queries []stubQuery
+}
+
+func (s *stubDB) record(query string, args []driver.NamedValue) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q := stubQuery{SQL: query}
+	for _, arg := range args {
+		q.Args = append(q.Args, arg.Value)
+	}
+	s.queries = append(s.queries, q)
+}
+
+// Queries 返回已执行的 SQL
+func (s *stubDB) Queries() []stubQuery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]stubQuery(nil), s.queries...)
+}
+
+func (s *stubDB) Connect(context.Context) (driver.Conn, error) { return &stubConn{db: s}, nil }
+func (s *stubDB) Driver() driver.Driver                        { return nil }
+
+type stubConn struct{ db *stubDB }
+
+func (c *stubConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c *stubConn) Close() error                        { return nil }
+func (c *stubConn) Begin() (driver.Tx, error)           { return stubTx{}, nil }
+
+func (c *stubConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.db.record(query, args)
+	return stubRows{}, nil
+}
+
+func (c *stubConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.db.record(query, args)
+	return driver.RowsAffected(0), nil
+}
+
+type stubTx struct{}
+
+func (stubTx) Commit() error   { return nil }
+func (stubTx) Rollback() error { return nil }
+
+type stubRows struct{}
+
+func (stubRows) Columns() []string         { return nil }
+func (stubRows) Close() error              { return nil }
+func (stubRows) Next([]driver.Value) error { return io.EOF }
+
+// newStubDao 创建连接到空数据库的数据访问对象
+func newStubDao(t *testing.T) (*Dao, *stubDB) {
+	t.Helper()
+
+	stub := &stubDB{}
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      sql.OpenDB(stub),
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open stub db: %v", err)
+	}
+
+	return New(db, nil), stub
+}
@@ -2,9 +2,12 @@ package dao
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/joinmouse/EasySwapBase/stores/gdb/orderbookmodel/multi"
 	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
 
 	"github.com/joinmouse/EasySwapBackend/src/types/v1"
 )
@@ -47,3 +50,46 @@ func (d *Dao) QueryCollectionTraits(ctx context.Context, chain string, collectio
 
 	return traitCounts, nil
 }
+
+// QueryCollectionItemsTraits 查询NFT合集中所有Item的 Trait信息
+// 用于计算合集的 Trait分布及每个Item的稀有度
+func (d *Dao) QueryCollectionItemsTraits(ctx context.Context, chain string, collectionAddr string) ([]multi.ItemTrait, error) {
+	var itemsTraits []multi.ItemTrait
+	if err := d.DB.WithContext(ctx).Table(multi.ItemTraitTableName(chain)).
+		Select("collection_address, token_id, trait, trait_value").
+		Where("collection_address = ?", collectionAddr).
+		Scan(&itemsTraits).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query collection items trait info")
+	}
+
+	return itemsTraits, nil
+}
+
+// TraitSaleStats 合集中某个 Trait值在统计窗口内的成交统计
+type TraitSaleStats struct {
+	Trait      string          `json:"trait"`
+	TraitValue string          `json:"trait_value"`
+	SampleSize int64           `json:"sample_size"` // 成交笔数
+	AvgPrice   decimal.Decimal `json:"avg_price"`   // 平均成交价
+}
+
+// QueryTraitSaleStats 按 Trait值统计合集自 since 以来的成交笔数和平均成交价
+// 窗口内没有成交的 Trait值不在结果中
+func (d *Dao) QueryTraitSaleStats(ctx context.Context, chain string, collectionAddr string, since time.Time) ([]TraitSaleStats, error) {
+	var stats []TraitSaleStats
+	if err := d.DB.WithContext(ctx).
+		Table(fmt.Sprintf("%s as gf_activity", multi.ActivityTableName(chain))).
+		Select("gf_attribute.trait, gf_attribute.trait_value, count(*) as sample_size, "+
+			"COALESCE(avg(gf_activity.price), 0) as avg_price").
+		Joins(fmt.Sprintf("join %s as gf_attribute on gf_activity.collection_address = gf_attribute.collection_address "+
+			"and gf_activity.token_id = gf_attribute.token_id", multi.ItemTraitTableName(chain))).
+		Where("gf_activity.collection_address = ? and gf_activity.activity_type = ? and gf_activity.event_time >= ?",
+			collectionAddr, multi.Sale, since.Unix()).
+		Scopes(d.sanePrice("gf_activity.price")).
+		Group("gf_attribute.trait, gf_attribute.trait_value").
+		Scan(&stats).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query trait sale stats")
+	}
+
+	return stats, nil
+}
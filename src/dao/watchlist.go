@@ -0,0 +1,93 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// UserWatchlistTableName 用户关注集合表
+// 建表语句:
+//
+//	CREATE TABLE `user_watchlist` (
+//	  `id` bigint NOT NULL AUTO_INCREMENT,
+//	  `user_address` varchar(42) NOT NULL,
+//	  `chain_id` int NOT NULL,
+//	  `collection_address` varchar(64) NOT NULL,
+//	  `create_time` bigint NOT NULL DEFAULT 0,
+//	  PRIMARY KEY (`id`),
+//	  UNIQUE KEY `uk_user_chain_collection` (`user_address`, `chain_id`, `collection_address`)
+//	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+const UserWatchlistTableName = "user_watchlist"
+
+// UserWatchlist 用户关注的集合
+type UserWatchlist struct {
+	Id                int64  `gorm:"column:id" json:"id"`
+	UserAddress       string `gorm:"column:user_address" json:"user_address"`
+	ChainId           int    `gorm:"column:chain_id" json:"chain_id"`
+	CollectionAddress string `gorm:"column:collection_address" json:"collection_address"`
+	CreateTime        int64  `gorm:"column:create_time" json:"create_time"`
+}
+
+// QueryUserWatchlist 查询用户关注的所有集合, 按关注时间倒序排列
+func (d *Dao) QueryUserWatchlist(ctx context.Context, userAddr string) ([]UserWatchlist, error) {
+	var watchlist []UserWatchlist
+	if err := d.DB.WithContext(ctx).
+		Table(UserWatchlistTableName).
+		Where("user_address = ?", strings.ToLower(userAddr)).
+		Order("create_time desc, id desc").
+		Find(&watchlist).Error; err != nil {
+		return nil, errors.Wrap(err, "failed on query user watchlist")
+	}
+
+	return watchlist, nil
+}
+
+// IsCollectionWatched 判断用户是否已关注指定集合
+func (d *Dao) IsCollectionWatched(ctx context.Context, userAddr string, chainID int, collectionAddr string) (bool, error) {
+	var count int64
+	if err := d.DB.WithContext(ctx).
+		Table(UserWatchlistTableName).
+		Where("user_address = ? and chain_id = ? and collection_address = ?",
+			strings.ToLower(userAddr), chainID, strings.ToLower(collectionAddr)).
+		Count(&count).Error; err != nil {
+		return false, errors.Wrap(err, "failed on query user watchlist")
+	}
+
+	return count > 0, nil
+}
+
+// AddUserWatchlist 在用户关注数量小于 limit 时添加关注的集合, 返回是否新增了记录
+// 数量检查和插入在同一条语句中完成, 并发添加时关注数量也不会超过上限
+// 已关注或已达到上限时不做任何修改, 返回 false
+func (d *Dao) AddUserWatchlist(ctx context.Context, userAddr string, chainID int, collectionAddr string, limit int) (bool, error) {
+	userAddr = strings.ToLower(userAddr)
+	sql := fmt.Sprintf(`INSERT IGNORE INTO %s (user_address, chain_id, collection_address, create_time)
+		SELECT ?, ?, ?, ? FROM DUAL
+		WHERE (SELECT COUNT(*) FROM %s WHERE user_address = ?) < ?`,
+		UserWatchlistTableName, UserWatchlistTableName)
+	result := d.DB.WithContext(ctx).Exec(sql,
+		userAddr, chainID, strings.ToLower(collectionAddr), time.Now().UnixMilli(),
+		userAddr, limit)
+	if result.Error != nil {
+		return false, errors.Wrap(result.Error, "failed on add user watchlist")
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// DeleteUserWatchlist 取消关注集合, 未关注时不返回错误
+func (d *Dao) DeleteUserWatchlist(ctx context.Context, userAddr string, chainID int, collectionAddr string) error {
+	if err := d.DB.WithContext(ctx).
+		Table(UserWatchlistTableName).
+		Where("user_address = ? and chain_id = ? and collection_address = ?",
+			strings.ToLower(userAddr), chainID, strings.ToLower(collectionAddr)).
+		Delete(&UserWatchlist{}).Error; err != nil {
+		return errors.Wrap(err, "failed on delete user watchlist")
+	}
+
+	return nil
+}
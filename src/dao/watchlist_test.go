@@ -0,0 +1,34 @@
+package dao
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestAddUserWatchlistLimit(t *testing.T) {
+	d, stub := newStubDao(t)
+
+	// 空数据库中插入语句影响 0 行, 视为未新增
+	added, err := d.AddUserWatchlist(context.Background(), "0xUSER", 1, "0xCOLLECTION", 500)
+	if err != nil {
+		t.Fatalf("AddUserWatchlist() error: %v", err)
+	}
+	if added {
+		t.Error("AddUserWatchlist() = true, want false")
+	}
+
+	queries := stub.Queries()
+	if len(queries) != 1 {
+		t.Fatalf("executed %d statements, want 1", len(queries))
+	}
+	// 数量检查和插入在同一条语句中完成
+	q := queries[0]
+	if !strings.HasPrefix(q.SQL, "INSERT IGNORE INTO user_watchlist") ||
+		!strings.Contains(q.SQL, "WHERE (SELECT COUNT(*) FROM user_watchlist WHERE user_address = ?) < ?") {
+		t.Errorf("unexpected statement: %s", q.SQL)
+	}
+	if !containsInOrder(q.Args, "0xuser", 1, "0xcollection") || !containsInOrder(q.Args, "0xuser", 500) {
+		t.Errorf("unexpected args: %v", q.Args)
+	}
+}
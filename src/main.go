@@ -4,8 +4,13 @@
 package main
 
 import (
+	"context"          // 用于日志上下文
 	"flag"             // 用于解析命令行参数
 	_ "net/http/pprof" // 导入pprof包，用于性能分析和调试
+	"os"               // 用于设置进程退出码
+
+	"github.com/joinmouse/EasySwapBase/logger/xzap" // 日志库，用于记录启动配置和退出错误
+	"go.uber.org/zap"                               // Uber的高性能日志库
 
 	"github.com/joinmouse/EasySwapBackend/src/api/router"  // 导入路由模块
 	"github.com/joinmouse/EasySwapBackend/src/app"         // 导入应用程序核心模块
@@ -22,7 +27,7 @@ const (
 )
 
 // main 是程序的主入口函数
-// 负责初始化配置、验证区块链配置、创建服务上下文、初始化路由器并启动应用程序
+// 负责初始化配置、创建服务上下文、初始化路由器并启动应用程序
 func main() {
 	// 解析命令行参数，获取配置文件路径
 	// -conf 参数用于指定配置文件路径，默认使用 defaultConfigPath
@@ -31,18 +36,18 @@ func main() {
 
 	// 从指定的配置文件中解析配置信息
 	// 配置文件包含数据库连接、API端口、支持的区块链网络等信息
+	// 解析后会校验所有配置项（包括每个区块链的链ID和名称），不合法时直接退出
 	c, err := config.UnmarshalConfig(*conf)
 	if err != nil {
 		panic(err)
 	}
 
-	// 验证支持的区块链配置
-	// 确保每个支持的区块链都有有效的链ID和名称
-	for _, chain := range c.ChainSupported {
-		if chain.ChainID == 0 || chain.Name == "" {
-			panic("无效的区块链配置：链ID不能为0，链名称不能为空")
-		}
+	// 初始化日志系统并记录生效的配置（敏感字段已脱敏），便于排查配置问题
+	// 服务上下文中会再次初始化日志系统，重复初始化不会生效
+	if _, err := xzap.SetUp(c.Log); err != nil {
+		panic(err)
 	}
+	c.LogSafe()
 
 	// 创建服务上下文，包含数据库连接、Redis连接、区块链服务等
 	// 服务上下文是整个应用程序的依赖注入容器
@@ -63,6 +68,9 @@ func main() {
 	}
 
 	// 启动应用程序服务器
-	// 开始监听HTTP请求并处理NFT交易相关的API调用
-	app.Start()
+	// 开始监听HTTP请求并处理NFT交易相关的API调用，收到退出信号后优雅关闭
+	if err := app.Start(); err != nil {
+		xzap.WithContext(context.Background()).Error("服务器异常退出", zap.Error(err))
+		os.Exit(1)
+	}
 }
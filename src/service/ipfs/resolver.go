@@ -0,0 +1,263 @@
+// Package ipfs 提供 IPFS 资源的网关解析
+// 公共 IPFS 网关经常不可用或响应缓慢, 该包在多个网关之间轮换, 并按 CID 短暂缓存可用的网关
+package ipfs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/joinmouse/EasySwapBase/logger/xzap"
+)
+
+const (
+	Scheme = "ipfs://"
+
+	// DefaultTimeout 单个网关请求的超时时间
+	DefaultTimeout = 5 * time.Second
+	// DefaultCacheTTL CID 对应的可用网关缓存时间
+	DefaultCacheTTL = 10 * time.Minute
+	// maxBodySize 通过网关获取的内容最大字节数
+	maxBodySize = 10 * 1024 * 1024
+	// maxCacheSize 网关缓存达到该数量时清理过期记录
+	maxCacheSize = 10000
+)
+
+// DefaultGateways 未配置网关时使用的公共网关列表
+var DefaultGateways = []string{
+	"https://ipfs.io/ipfs/",
+	"https://cloudflare-ipfs.com/ipfs/",
+	"https://gateway.pinata.cloud/ipfs/",
+}
+
+// ErrAllGatewaysFailed 所有网关均请求失败
+var ErrAllGatewaysFailed = errors.New("all ipfs gateways failed")
+
+// cachedGateway 记录 CID 最近一次请求成功的网关
+type cachedGateway struct {
+	gateway  int
+	expireAt time.Time
+}
+
+// Resolver IPFS 网关解析器
+// 主要功能包括:
+// 1. 将 ipfs:// 地址转换为可访问的网关地址, 非 ipfs 地址原样返回
+// 2. 网关请求失败或超时时依次切换到下一个网关
+// 3. 按 CID 缓存请求成功的网关, 缓存期内优先使用
+type Resolver struct {
+	gateways []string
+	client   *http.Client
+	cacheTTL time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedGateway
+}
+
+// New 创建 IPFS 网关解析器
+// gateways 为空时使用 DefaultGateways, timeout 为单个网关请求的超时时间, 为0时使用默认值
+func New(gateways []string, timeout time.Duration) *Resolver {
+	var normalized []string
+	for _, gateway := range gateways {
+		gateway = strings.TrimSpace(gateway)
+		if gateway == "" {
+			continue
+		}
+		if !strings.HasSuffix(gateway, "/") {
+			gateway += "/"
+		}
+		normalized = append(normalized, gateway)
+	}
+	if len(normalized) == 0 {
+		normalized = DefaultGateways
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	return &Resolver{
+		gateways: normalized,
+		client:   &http.Client{Timeout: timeout},
+		cacheTTL: DefaultCacheTTL,
+		cache:    make(map[string]cachedGateway),
+	}
+}
+
+// IsIpfsURI 判断是否为 ipfs:// 地址
+func IsIpfsURI(uri string) bool {
+	return strings.HasPrefix(strings.ToLower(uri), Scheme)
+}
+
+// parseURI 解析 ipfs:// 地址, 返回 CID 和网关下的资源路径
+// 兼容 ipfs://<cid>/path 和 ipfs://ipfs/<cid>/path 两种格式
+func parseURI(uri string) (cid string, path string) {
+	path = strings.TrimLeft(uri[len(Scheme):], "/")
+	path = strings.TrimPrefix(path, "ipfs/")
+	cid = path
+	if i := strings.Index(path, "/"); i >= 0 {
+		cid = path[:i]
+	}
+
+	return cid, path
+}
+
+// Resolve 将 ipfs:// 地址解析为可访问的网关地址
+// 按顺序探测网关, 返回第一个可用网关下的地址, 非 ipfs 地址原样返回
+func (r *Resolver) Resolve(ctx context.Context, uri string) (string, error) {
+	if !IsIpfsURI(uri) {
+		return uri, nil
+	}
+
+	var resolved string
+	err := r.try(ctx, uri, func(url string) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := r.client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= http.StatusBadRequest {
+			return fmt.Errorf("unexpected status %d", resp.StatusCode)
+		}
+
+		resolved = url
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return resolved, nil
+}
+
+// Fetch 通过网关获取 ipfs:// 地址的内容, 非 ipfs 地址直接请求
+func (r *Resolver) Fetch(ctx context.Context, uri string) ([]byte, error) {
+	if !IsIpfsURI(uri) {
+		return r.get(ctx, uri)
+	}
+
+	var body []byte
+	err := r.try(ctx, uri, func(url string) error {
+		data, err := r.get(ctx, url)
+		if err != nil {
+			return err
+		}
+
+		body = data
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+// try 从缓存的网关开始依次尝试所有网关, 成功后更新缓存
+func (r *Resolver) try(ctx context.Context, uri string, fn func(url string) error) error {
+	cid, path := parseURI(uri)
+	if cid == "" {
+		return errors.Errorf("invalid ipfs uri: %s", uri)
+	}
+
+	start := r.cachedGateway(cid)
+	var lastErr error
+	for i := 0; i < len(r.gateways); i++ {
+		if ctx.Err() != nil {
+			return errors.Wrap(ctx.Err(), "failed on resolve ipfs uri")
+		}
+
+		idx := (start + i) % len(r.gateways)
+		url := r.gateways[idx] + path
+		if err := fn(url); err != nil {
+			lastErr = err
+			xzap.WithContext(ctx).Warn("ipfs gateway request failed",
+				zap.String("gateway", r.gateways[idx]), zap.String("cid", cid), zap.Error(err))
+			r.forget(cid, idx)
+			continue
+		}
+
+		r.remember(cid, idx)
+		return nil
+	}
+
+	return errors.Wrapf(ErrAllGatewaysFailed, "uri: %s, last error: %v", uri, lastErr)
+}
+
+// get 请求地址并读取内容
+func (r *Resolver) get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on build request")
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on read body")
+	}
+
+	return body, nil
+}
+
+// cachedGateway 获取 CID 缓存的网关下标, 没有缓存或已过期时返回0
+func (r *Resolver) cachedGateway(cid string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cached, ok := r.cache[cid]
+	if !ok {
+		return 0
+	}
+	if time.Now().After(cached.expireAt) || cached.gateway >= len(r.gateways) {
+		delete(r.cache, cid)
+		return 0
+	}
+
+	return cached.gateway
+}
+
+// remember 缓存 CID 请求成功的网关
+func (r *Resolver) remember(cid string, gateway int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	// 缓存过多时清理过期记录, 避免缓存无限增长
+	if len(r.cache) >= maxCacheSize {
+		for key, cached := range r.cache {
+			if now.After(cached.expireAt) {
+				delete(r.cache, key)
+			}
+		}
+	}
+	r.cache[cid] = cachedGateway{gateway: gateway, expireAt: now.Add(r.cacheTTL)}
+}
+
+// forget 缓存的网关请求失败时删除缓存
+func (r *Resolver) forget(cid string, gateway int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if cached, ok := r.cache[cid]; ok && cached.gateway == gateway {
+		delete(r.cache, cid)
+	}
+}
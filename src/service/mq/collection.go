@@ -0,0 +1,33 @@
+package mq
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/joinmouse/EasySwapBase/stores/xkv"
+	"github.com/pkg/errors"
+
+	"github.com/joinmouse/EasySwapBackend/src/types/v1"
+)
+
+const CacheIndexCollectionKey = "cache:%s:%s:collection:index"
+
+func GetIndexCollectionKey(project, chain string) string {
+	return fmt.Sprintf(CacheIndexCollectionKey, strings.ToLower(project), strings.ToLower(chain))
+}
+
+// AddCollectionToIndexQueue 将集合加入收录队列, 由索引服务同步集合信息和链上数据
+// 去重由调用方负责, 队列本身是集合类型, 相同内容重复入队只保留一条
+func AddCollectionToIndexQueue(kvStore *xkv.Store, project, chainName string, collection types.IndexCollection) error {
+	rawInfo, err := json.Marshal(&collection)
+	if err != nil {
+		return errors.Wrap(err, "failed on marshal collection info")
+	}
+
+	if _, err := kvStore.Sadd(GetIndexCollectionKey(project, chainName), string(rawInfo)); err != nil {
+		return errors.Wrap(err, "failed on push collection to index queue")
+	}
+
+	return nil
+}
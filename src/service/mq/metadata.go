@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joinmouse/EasySwapBase/logger/xzap"
 	"github.com/joinmouse/EasySwapBase/stores/xkv"
@@ -21,17 +23,35 @@ func GetRefreshSingleItemMetadataKey(project, chain string) string {
 }
 
 const CacheRefreshPreventReentrancyKeyPrefix = "cache:es:item:refresh:prevent:reentrancy:%d:%s:%s"
-const PreventReentrancyPeriod = 10 //second
+const PreventReentrancyPeriod = 300 //second, 同一个 item 在冷却期内只会入队刷新一次
 
-func AddSingleItemToRefreshMetadataQueue(kvStore *xkv.Store, project, chainName string, chainID int64, collectionAddr, tokenID string) error {
-	isRefreshed, err := kvStore.Get(fmt.Sprintf(CacheRefreshPreventReentrancyKeyPrefix, chainID, collectionAddr, tokenID))
+// AddSingleItemToRefreshMetadataQueue 将 item 加入元数据刷新队列
+// 冷却期内重复请求不会再次入队，而是返回上一次入队的时间
+//
+// 返回值:
+//   - int64: 最近一次入队刷新的时间(Unix 秒)
+//   - bool: 本次请求是否命中冷却期(未重新入队)
+//   - error: 访问 Redis 失败时返回错误
+func AddSingleItemToRefreshMetadataQueue(kvStore *xkv.Store, project, chainName string, chainID int64, collectionAddr, tokenID string) (int64, bool, error) {
+	// 集合地址统一为小写, 保证不同实例、不同大小写的请求共享同一个冷却期
+	reentrancyKey := fmt.Sprintf(CacheRefreshPreventReentrancyKeyPrefix, chainID, strings.ToLower(collectionAddr), tokenID)
+	now := time.Now().Unix()
+
+	// 使用 SETNX 原子地占用冷却期，避免多个实例同时入队
+	ok, err := kvStore.SetnxEx(reentrancyKey, strconv.FormatInt(now, 10), PreventReentrancyPeriod)
 	if err != nil {
-		return errors.Wrap(err, "failed on check reentrancy status")
+		return 0, false, errors.Wrap(err, "failed on check reentrancy status")
 	}
 
-	if isRefreshed != "" {
-		xzap.WithContext(context.Background()).Info("refresh within 10s", zap.String("collection_addr", collectionAddr), zap.String("token_id", tokenID))
-		return nil
+	if !ok {
+		refreshedAt, err := kvStore.Get(reentrancyKey)
+		if err != nil {
+			return 0, false, errors.Wrap(err, "failed on get last refresh time")
+		}
+		xzap.WithContext(context.Background()).Info("refresh within cooldown period", zap.String("collection_addr", collectionAddr), zap.String("token_id", tokenID))
+		// 兼容旧版本写入的非时间戳值
+		ts, _ := strconv.ParseInt(refreshedAt, 10, 64)
+		return ts, true, nil
 	}
 
 	item := types.RefreshItem{
@@ -42,15 +62,16 @@ func AddSingleItemToRefreshMetadataQueue(kvStore *xkv.Store, project, chainName
 
 	rawInfo, err := json.Marshal(&item)
 	if err != nil {
-		return errors.Wrap(err, "failed on marshal item info")
+		_, _ = kvStore.Del(reentrancyKey)
+		return 0, false, errors.Wrap(err, "failed on marshal item info")
 	}
 
 	_, err = kvStore.Sadd(GetRefreshSingleItemMetadataKey(project, chainName), string(rawInfo))
 	if err != nil {
-		return errors.Wrap(err, "failed on push item to refresh metadata queue")
+		// 入队失败时释放冷却期，允许客户端立即重试
+		_, _ = kvStore.Del(reentrancyKey)
+		return 0, false, errors.Wrap(err, "failed on push item to refresh metadata queue")
 	}
 
-	_ = kvStore.Setex(fmt.Sprintf(CacheRefreshPreventReentrancyKeyPrefix, chainID, collectionAddr, tokenID), "true", PreventReentrancyPeriod)
-
-	return nil
+	return now, false, nil
 }
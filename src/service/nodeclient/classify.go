@@ -0,0 +1,128 @@
+package nodeclient
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+)
+
+// ErrorClass RPC 调用错误的分类
+type ErrorClass string
+
+const (
+	ClassNone      ErrorClass = ""          // 调用成功
+	ClassRetryable ErrorClass = "retryable" // 超时、限流、5xx、连接被拒绝/重置等暂时性错误, 切换端点重试
+	ClassReverted  ErrorClass = "reverted"  // 合约调用回滚, 说明请求本身不合法, 不重试
+	ClassPermanent ErrorClass = "permanent" // 方法不存在、参数错误等重试也不会成功的错误
+	ClassCanceled  ErrorClass = "canceled"  // 调用方 context 取消或超时, 不重试
+)
+
+// JSON-RPC 错误码
+const (
+	rpcCodeReverted       = 3      // 合约执行回滚(带 revert data)
+	rpcCodeServerError    = -32000 // 节点自定义错误, 需要结合错误信息判断
+	rpcCodeInternalError  = -32603 // 节点内部错误
+	rpcCodeLimitExceeded  = -32005 // 请求超过节点的限额
+	rpcCodeMethodNotFound = -32601
+	rpcCodeInvalidParams  = -32602
+)
+
+// retryableMessages 节点以普通错误返回的暂时性错误信息, 不同服务商的写法不统一, 按子串匹配
+// 限流信息按服务商的原文匹配, 不能使用 "limit exceeded" 等宽泛的写法, 否则 "gas limit exceeded" 等永久错误也会被重试
+var retryableMessages = []string{
+	"rate limit",                                     // 通用写法: rate limited / rate limit exceeded
+	"too many requests",                              // HTTP 429 的描述
+	"request rate exceeded",                          // Infura: project ID request rate exceeded
+	"daily request count exceeded",                   // Infura: daily request count exceeded, request rate limited
+	"exceeded its compute units per second capacity", // Alchemy
+	"header not found",
+	"timeout",
+	"timed out",
+	"connection reset",
+}
+
+// Classify 对 RPC 调用错误分类
+// 1. 调用方 context 取消或超时为 canceled
+// 2. HTTP 响应按状态码判断: 408、429 和 5xx 可重试, 其余为永久错误
+// 3. JSON-RPC 错误按错误码判断: 回滚为 reverted, 方法不存在和参数错误为永久错误, 限额和内部错误可重试,
+// -32000 等节点自定义错误按错误信息判断
+// 4. 连接被拒绝/重置、连接意外断开和网络超时可重试
+// 5. 其余错误视为永久错误
+func Classify(err error) ErrorClass {
+	if err == nil {
+		return ClassNone
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return ClassCanceled
+	}
+
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		switch {
+		case httpErr.StatusCode == http.StatusRequestTimeout,
+			httpErr.StatusCode == http.StatusTooManyRequests,
+			httpErr.StatusCode >= http.StatusInternalServerError:
+			return ClassRetryable
+		default:
+			return ClassPermanent
+		}
+	}
+
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		switch rpcErr.ErrorCode() {
+		case rpcCodeReverted:
+			return ClassReverted
+		case rpcCodeMethodNotFound, rpcCodeInvalidParams:
+			return ClassPermanent
+		case rpcCodeLimitExceeded, rpcCodeInternalError:
+			return ClassRetryable
+		case rpcCodeServerError:
+			return classifyMessage(rpcErr.Error())
+		default:
+			return ClassPermanent
+		}
+	}
+
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
+		return ClassRetryable
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return ClassRetryable
+	}
+
+	return classifyMessage(err.Error())
+}
+
+// classifyMessage 根据错误信息分类, 用于没有明确错误码的节点错误
+func classifyMessage(msg string) ErrorClass {
+	msg = strings.ToLower(msg)
+	if strings.Contains(msg, "execution reverted") || strings.Contains(msg, "revert") {
+		return ClassReverted
+	}
+	for _, m := range retryableMessages {
+		if strings.Contains(msg, m) {
+			return ClassRetryable
+		}
+	}
+	return ClassPermanent
+}
+
+// IsRetryable 判断错误是否应切换端点重试
+func IsRetryable(err error) bool {
+	return Classify(err) == ClassRetryable
+}
+
+// IsReverted 判断错误是否为合约调用回滚
+func IsReverted(err error) bool {
+	return Classify(err) == ClassReverted
+}
@@ -0,0 +1,150 @@
+package nodeclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// stubResponse 模拟节点对一次请求的响应方式
+type stubResponse int
+
+const (
+	stubOK stubResponse = iota
+	stubTooManyRequests
+	stubUnavailable
+	stubMethodNotFound
+	stubReverted
+	stubGasLimitExceeded
+	stubConnectionReset
+)
+
+// stubNode 按固定方式响应所有 JSON-RPC 请求的测试节点, 记录收到的请求数
+type stubNode struct {
+	*httptest.Server
+	calls atomic.Int32
+}
+
+func newStubNode(t *testing.T, resp stubResponse) *stubNode {
+	t.Helper()
+
+	node := &stubNode{}
+	node.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		node.calls.Add(1)
+
+		var req struct {
+			ID json.RawMessage `json:"id"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		writeError := func(code int, message, data string) {
+			body := map[string]interface{}{"code": code, "message": message}
+			if data != "" {
+				body["data"] = data
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "error": body})
+		}
+
+		switch resp {
+		case stubOK:
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":"0x10"}`, req.ID)
+		case stubTooManyRequests:
+			http.Error(w, "too many requests", http.StatusTooManyRequests)
+		case stubUnavailable:
+			http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+		case stubMethodNotFound:
+			writeError(-32601, "the method eth_foo does not exist/is not available", "")
+		case stubReverted:
+			writeError(3, "execution reverted", "0x08c379a0")
+		case stubGasLimitExceeded:
+			writeError(-32000, "gas limit exceeded", "")
+		case stubConnectionReset:
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Errorf("hijack connection: %v", err)
+				return
+			}
+			// SO_LINGER 为 0 时关闭连接会发送 RST, 客户端收到 connection reset
+			_ = conn.(*net.TCPConn).SetLinger(0)
+			_ = conn.Close()
+		}
+	}))
+	t.Cleanup(node.Close)
+
+	return node
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		resp stubResponse
+		want ErrorClass
+	}{
+		{"ok", stubOK, ClassNone},
+		{"429", stubTooManyRequests, ClassRetryable},
+		{"503", stubUnavailable, ClassRetryable},
+		{"method not found", stubMethodNotFound, ClassPermanent},
+		{"reverted", stubReverted, ClassReverted},
+		{"gas limit exceeded", stubGasLimitExceeded, ClassPermanent},
+		{"connection reset", stubConnectionReset, ClassRetryable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := newStubNode(t, tt.resp)
+			client, err := rpc.DialContext(context.Background(), node.URL)
+			if err != nil {
+				t.Fatalf("dial stub node: %v", err)
+			}
+			defer client.Close()
+
+			var result string
+			err = client.CallContext(context.Background(), &result, "eth_blockNumber")
+			if got := Classify(err); got != tt.want {
+				t.Errorf("Classify(%v) = %q, want %q", err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassifyMessage(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want ErrorClass
+	}{
+		{"gas limit exceeded", ClassPermanent},
+		{"exceeds block gas limit", ClassPermanent},
+		{"project ID request rate exceeded", ClassRetryable},
+		{"daily request count exceeded, request rate limited", ClassRetryable},
+		{"Your app has exceeded its compute units per second capacity.", ClassRetryable},
+		{"header not found", ClassRetryable},
+		{"execution reverted: ERC721: invalid token ID", ClassReverted},
+	}
+
+	for _, tt := range tests {
+		if got := classifyMessage(tt.msg); got != tt.want {
+			t.Errorf("classifyMessage(%q) = %q, want %q", tt.msg, got, tt.want)
+		}
+	}
+}
+
+func TestClassifyCanceled(t *testing.T) {
+	for _, err := range []error{context.Canceled, fmt.Errorf("call: %w", context.DeadlineExceeded)} {
+		if got := Classify(err); got != ClassCanceled {
+			t.Errorf("Classify(%v) = %q, want %q", err, got, ClassCanceled)
+		}
+	}
+	if got := Classify(errors.New("unknown")); got != ClassPermanent {
+		t.Errorf("Classify(unknown) = %q, want %q", got, ClassPermanent)
+	}
+}
@@ -0,0 +1,301 @@
+// Package nodeclient 提供支持多 RPC 端点故障转移的区块链节点客户端
+// 该包在 EasySwapBase 的 ChainClient 之上封装了重试、指数退避和端点健康状态跟踪
+package nodeclient
+
+import (
+	"context"
+	"math/big"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/joinmouse/EasySwapBase/chain/chainclient"
+	logTypes "github.com/joinmouse/EasySwapBase/chain/types"
+	"github.com/joinmouse/EasySwapBase/logger/xzap"
+)
+
+const (
+	DefaultMaxAttempts = 3                      // 单次调用的最少尝试次数，端点数更多时每个端点至少尝试一次
+	DefaultBaseBackoff = 100 * time.Millisecond // 首次重试前的等待时间，之后每次翻倍
+	DefaultMaxBackoff  = 2 * time.Second        // 重试等待时间上限
+)
+
+// ErrUnsupportedClient 端点的底层客户端不是以太坊客户端, 不支持 CodeAt 等 ChainClient 接口之外的调用
+var ErrUnsupportedClient = errors.New("unsupported chain client")
+
+// endpoint 表示单个 RPC 端点及其健康状态
+type endpoint struct {
+	url      string
+	client   chainclient.ChainClient
+	healthy  bool      // 最近一次调用是否成功
+	failures int       // 连续失败次数
+	lastErr  error     // 最近一次失败的错误
+	lastSeen time.Time // 最近一次调用的时间
+}
+
+// FailoverClient 是支持多端点故障转移的 ChainClient 实现
+// 主要功能包括:
+// 1. 优先使用最近一次调用成功的端点(last-known-good)
+// 2. 遇到超时、限流、连接错误或 5xx 响应时按带随机抖动的指数退避切换到下一个端点重试
+// 3. 合约执行失败、方法不存在等永久错误不重试，直接返回给调用方
+type FailoverClient struct {
+	chainName string
+
+	mu        sync.RWMutex
+	endpoints []*endpoint
+	preferred int // 最近一次调用成功的端点下标
+}
+
+// 编译期检查 FailoverClient 实现了 ChainClient 接口
+var _ chainclient.ChainClient = (*FailoverClient)(nil)
+
+// New 为指定链的所有 RPC 端点创建节点客户端
+// 参数:
+//   - chainID: 区块链 ID
+//   - chainName: 区块链名称，用于日志
+//   - urls: RPC 端点列表，按优先级排列
+//
+// 返回值:
+//   - *FailoverClient: 故障转移客户端
+//   - error: 端点列表为空或创建节点客户端失败时返回错误
+func New(chainID int, chainName string, urls []string) (*FailoverClient, error) {
+	if len(urls) == 0 {
+		return nil, errors.New("no rpc endpoint configured")
+	}
+
+	endpoints := make([]*endpoint, 0, len(urls))
+	for _, url := range urls {
+		client, err := chainclient.New(chainID, url)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed on create node client for %s", url)
+		}
+		endpoints = append(endpoints, &endpoint{url: url, client: client, healthy: true})
+	}
+
+	return &FailoverClient{
+		chainName: chainName,
+		endpoints: endpoints,
+	}, nil
+}
+
+// order 返回本次调用的端点尝试顺序
+// 最近成功的端点排在最前，其次是健康端点，最后是已标记为故障的端点
+func (f *FailoverClient) order() []int {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	order := make([]int, 0, len(f.endpoints))
+	order = append(order, f.preferred)
+	var unhealthy []int
+	for i := 1; i < len(f.endpoints); i++ {
+		idx := (f.preferred + i) % len(f.endpoints)
+		if f.endpoints[idx].healthy {
+			order = append(order, idx)
+		} else {
+			unhealthy = append(unhealthy, idx)
+		}
+	}
+
+	return append(order, unhealthy...)
+}
+
+// markSuccess 记录端点调用成功，并将其设为优先端点
+func (f *FailoverClient) markSuccess(idx int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	e := f.endpoints[idx]
+	e.healthy = true
+	e.failures = 0
+	e.lastErr = nil
+	e.lastSeen = time.Now()
+	f.preferred = idx
+}
+
+// markFailure 记录端点调用失败
+func (f *FailoverClient) markFailure(idx int, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	e := f.endpoints[idx]
+	e.healthy = false
+	e.failures++
+	e.lastErr = err
+	e.lastSeen = time.Now()
+}
+
+// jitter 在退避时间的 [1/2, 1] 区间内随机取值, 避免多个请求同时重试压垮节点
+func jitter(backoff time.Duration) time.Duration {
+	half := backoff / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// do 按故障转移顺序执行一次节点调用
+// 仅在超时、限流、5xx、连接错误等暂时性错误时重试，合约回滚、方法不存在等永久错误直接返回
+func (f *FailoverClient) do(ctx context.Context, method string, call func(client chainclient.ChainClient) error) error {
+	order := f.order()
+	attempts := len(order)
+	if attempts < DefaultMaxAttempts {
+		attempts = DefaultMaxAttempts
+	}
+
+	var lastErr error
+	backoff := DefaultBaseBackoff
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return errors.Wrapf(lastErr, "failed on %s: %v", method, ctx.Err())
+			case <-time.After(jitter(backoff)):
+			}
+			backoff *= 2
+			if backoff > DefaultMaxBackoff {
+				backoff = DefaultMaxBackoff
+			}
+		}
+
+		idx := order[i%len(order)]
+		err := call(f.endpoints[idx].client)
+		if err == nil {
+			f.markSuccess(idx)
+			return nil
+		}
+		if !IsRetryable(err) {
+			return err
+		}
+
+		f.markFailure(idx, err)
+		lastErr = err
+		xzap.WithContext(ctx).Warn("rpc endpoint failed, try next endpoint",
+			zap.String("chain", f.chainName), zap.String("method", method),
+			zap.String("endpoint", f.endpoints[idx].url), zap.Int("attempt", i+1), zap.Error(err))
+	}
+
+	return errors.Wrapf(lastErr, "failed on %s after %d attempts", method, attempts)
+}
+
+func (f *FailoverClient) FilterLogs(ctx context.Context, q logTypes.FilterQuery) ([]interface{}, error) {
+	var logs []interface{}
+	err := f.do(ctx, "FilterLogs", func(client chainclient.ChainClient) error {
+		var err error
+		logs, err = client.FilterLogs(ctx, q)
+		return err
+	})
+	return logs, err
+}
+
+func (f *FailoverClient) BlockTimeByNumber(ctx context.Context, number *big.Int) (uint64, error) {
+	var blockTime uint64
+	err := f.do(ctx, "BlockTimeByNumber", func(client chainclient.ChainClient) error {
+		var err error
+		blockTime, err = client.BlockTimeByNumber(ctx, number)
+		return err
+	})
+	return blockTime, err
+}
+
+// Client 返回当前优先端点的底层客户端
+func (f *FailoverClient) Client() interface{} {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.endpoints[f.preferred].client.Client()
+}
+
+func (f *FailoverClient) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	var result []byte
+	err := f.do(ctx, "CallContract", func(client chainclient.ChainClient) error {
+		var err error
+		result, err = client.CallContract(ctx, msg, blockNumber)
+		return err
+	})
+	return result, err
+}
+
+func (f *FailoverClient) CallContractByChain(ctx context.Context, param logTypes.CallParam) (interface{}, error) {
+	var result interface{}
+	err := f.do(ctx, "CallContractByChain", func(client chainclient.ChainClient) error {
+		var err error
+		result, err = client.CallContractByChain(ctx, param)
+		return err
+	})
+	return result, err
+}
+
+func (f *FailoverClient) BlockNumber() (uint64, error) {
+	var number uint64
+	err := f.do(context.Background(), "BlockNumber", func(client chainclient.ChainClient) error {
+		var err error
+		number, err = client.BlockNumber()
+		return err
+	})
+	return number, err
+}
+
+func (f *FailoverClient) BlockWithTxs(ctx context.Context, blockNumber uint64) (interface{}, error) {
+	var block interface{}
+	err := f.do(ctx, "BlockWithTxs", func(client chainclient.ChainClient) error {
+		var err error
+		block, err = client.BlockWithTxs(ctx, blockNumber)
+		return err
+	})
+	return block, err
+}
+
+// ethClient 返回端点的底层以太坊客户端
+func ethClient(client chainclient.ChainClient) (*ethclient.Client, error) {
+	ec, ok := client.Client().(*ethclient.Client)
+	if !ok {
+		return nil, ErrUnsupportedClient
+	}
+	return ec, nil
+}
+
+// ChainID 查询节点的链ID
+func (f *FailoverClient) ChainID(ctx context.Context) (*big.Int, error) {
+	var id *big.Int
+	err := f.do(ctx, "ChainID", func(client chainclient.ChainClient) error {
+		ec, err := ethClient(client)
+		if err != nil {
+			return err
+		}
+		id, err = ec.ChainID(ctx)
+		return err
+	})
+	return id, err
+}
+
+// CodeAt 查询地址在指定区块的合约代码, blockNumber 为 nil 时查询最新区块
+func (f *FailoverClient) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	var code []byte
+	err := f.do(ctx, "CodeAt", func(client chainclient.ChainClient) error {
+		ec, err := ethClient(client)
+		if err != nil {
+			return err
+		}
+		code, err = ec.CodeAt(ctx, account, blockNumber)
+		return err
+	})
+	return code, err
+}
+
+// HeaderByNumber 查询指定区块的区块头, number 为 nil 时查询最新区块
+func (f *FailoverClient) HeaderByNumber(ctx context.Context, number *big.Int) (*ethTypes.Header, error) {
+	var header *ethTypes.Header
+	err := f.do(ctx, "HeaderByNumber", func(client chainclient.ChainClient) error {
+		ec, err := ethClient(client)
+		if err != nil {
+			return err
+		}
+		header, err = ec.HeaderByNumber(ctx, number)
+		return err
+	})
+	return header, err
+}
@@ -0,0 +1,144 @@
+package nodeclient
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/joinmouse/EasySwapBase/chain"
+	logging "github.com/joinmouse/EasySwapBase/logger"
+	"github.com/joinmouse/EasySwapBase/logger/xzap"
+)
+
+func TestMain(m *testing.M) {
+	// 重试时会记录告警日志, 需要先初始化全局 logger
+	if _, err := xzap.SetUp(logging.LogConf{Mode: "console", Path: os.TempDir(), Level: "error"}); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+func newTestClient(t *testing.T, nodes ...*stubNode) *FailoverClient {
+	t.Helper()
+
+	urls := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		urls = append(urls, node.URL)
+	}
+	client, err := New(chain.EthChainID, "eth", urls)
+	if err != nil {
+		t.Fatalf("create failover client: %v", err)
+	}
+	return client
+}
+
+func TestFailoverAttempts(t *testing.T) {
+	tests := []struct {
+		name      string
+		resp      stubResponse
+		wantClass ErrorClass
+		wantCalls int32
+	}{
+		// 暂时性错误按 DefaultMaxAttempts 重试
+		{"429", stubTooManyRequests, ClassRetryable, DefaultMaxAttempts},
+		{"503", stubUnavailable, ClassRetryable, DefaultMaxAttempts},
+		{"connection reset", stubConnectionReset, ClassRetryable, DefaultMaxAttempts},
+		// 永久错误和合约回滚只调用一次
+		{"method not found", stubMethodNotFound, ClassPermanent, 1},
+		{"reverted", stubReverted, ClassReverted, 1},
+		{"gas limit exceeded", stubGasLimitExceeded, ClassPermanent, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := newStubNode(t, tt.resp)
+			client := newTestClient(t, node)
+
+			_, err := client.BlockNumber()
+			if err == nil {
+				t.Fatal("BlockNumber() returned no error")
+			}
+			if got := Classify(err); got != tt.wantClass {
+				t.Errorf("Classify(%v) = %q, want %q", err, got, tt.wantClass)
+			}
+			if got := node.calls.Load(); got != tt.wantCalls {
+				t.Errorf("node received %d calls, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestFailoverSwitchEndpoint(t *testing.T) {
+	bad := newStubNode(t, stubUnavailable)
+	good := newStubNode(t, stubOK)
+	client := newTestClient(t, bad, good)
+
+	number, err := client.BlockNumber()
+	if err != nil {
+		t.Fatalf("BlockNumber() error: %v", err)
+	}
+	if number != 0x10 {
+		t.Errorf("BlockNumber() = %d, want %d", number, 0x10)
+	}
+	if bad.calls.Load() != 1 || good.calls.Load() != 1 {
+		t.Errorf("calls = (%d, %d), want (1, 1)", bad.calls.Load(), good.calls.Load())
+	}
+
+	// 成功的端点成为优先端点, 下一次调用不再访问故障端点
+	if _, err := client.BlockNumber(); err != nil {
+		t.Fatalf("BlockNumber() error: %v", err)
+	}
+	if bad.calls.Load() != 1 || good.calls.Load() != 2 {
+		t.Errorf("calls = (%d, %d), want (1, 2)", bad.calls.Load(), good.calls.Load())
+	}
+}
+
+func TestFailoverCanceled(t *testing.T) {
+	node := newStubNode(t, stubUnavailable)
+	client := newTestClient(t, node)
+
+	// 第一次失败后等待退避时 context 已取消, 不再继续重试
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultBaseBackoff*2/5)
+	defer cancel()
+	if _, err := client.BlockTimeByNumber(ctx, nil); err == nil {
+		t.Fatal("BlockTimeByNumber() returned no error")
+	}
+	if got := node.calls.Load(); got != 1 {
+		t.Errorf("node received %d calls, want 1", got)
+	}
+}
+
+func TestFailoverCodeAt(t *testing.T) {
+	bad := newStubNode(t, stubUnavailable)
+	good := newStubNode(t, stubOK)
+	client := newTestClient(t, bad, good)
+
+	// ChainClient 接口之外的调用同样经过重试和端点切换
+	code, err := client.CodeAt(context.Background(), common.Address{}, nil)
+	if err != nil {
+		t.Fatalf("CodeAt() error: %v", err)
+	}
+	if len(code) != 1 || code[0] != 0x10 {
+		t.Errorf("CodeAt() = %x, want 10", code)
+	}
+	if bad.calls.Load() != 1 || good.calls.Load() != 1 {
+		t.Errorf("calls = (%d, %d), want (1, 1)", bad.calls.Load(), good.calls.Load())
+	}
+}
+
+func TestJitter(t *testing.T) {
+	backoff := DefaultBaseBackoff
+	seen := make(map[time.Duration]struct{})
+	for i := 0; i < 100; i++ {
+		d := jitter(backoff)
+		if d < backoff/2 || d > backoff {
+			t.Fatalf("jitter(%v) = %v, want in [%v, %v]", backoff, d, backoff/2, backoff)
+		}
+		seen[d] = struct{}{}
+	}
+	if len(seen) < 2 {
+		t.Errorf("jitter(%v) returned the same value %d times", backoff, 100)
+	}
+}
@@ -4,21 +4,32 @@ package svc
 
 import (
 	"context"
+	"sync/atomic"
+	"time"
 
+	goredis "github.com/go-redis/redis/v8"                    // go-redis 客户端，用于 Redis 发布订阅
 	"github.com/joinmouse/EasySwapBase/chain/nftchainservice" // NFT 区块链服务，用于与区块链交互
 	"github.com/joinmouse/EasySwapBase/logger/xzap"         // 结构化日志库
 	"github.com/joinmouse/EasySwapBase/stores/gdb"          // 数据库操作封装
 	"github.com/joinmouse/EasySwapBase/stores/xkv"          // 键值存储操作封装
 	"github.com/pkg/errors"                                // 错误处理库
+	"github.com/shopspring/decimal"                        // 精度十进制运算库
 	"github.com/zeromicro/go-zero/core/stores/cache"        // go-zero 缓存组件
 	"github.com/zeromicro/go-zero/core/stores/kv"           // go-zero 键值存储组件
 	"github.com/zeromicro/go-zero/core/stores/redis"        // go-zero Redis 组件
+	"go.uber.org/zap"                                      // Uber 高性能日志库
 	"gorm.io/gorm"                                         // GORM ORM 框架
 
+	"github.com/joinmouse/EasySwapBackend/src/common/tracing" // 链路追踪
 	"github.com/joinmouse/EasySwapBackend/src/config"       // 配置管理模块
 	"github.com/joinmouse/EasySwapBackend/src/dao"          // 数据访问层
+	"github.com/joinmouse/EasySwapBackend/src/service/ipfs" // IPFS 网关解析
+	"github.com/joinmouse/EasySwapBackend/src/service/nodeclient" // 多端点故障转移的区块链节点客户端
 )
 
+// tracingShutdownTimeout 关闭时等待剩余 span 导出的最长时间
+const tracingShutdownTimeout = 5 * time.Second
+
 // ServerCtx 表示服务器的上下文信息
 // 它包含了运行 EasySwap NFT 交易所后端服务所需的所有依赖组件
 // 该结构体通过依赖注入的方式统一管理各种服务
@@ -29,6 +40,17 @@ type ServerCtx struct {
 	KvStore  *xkv.Store                            // 键值存储实例，主要用于缓存和会话管理
 	RankKey  string                                // 排行榜缓存的键名前缀
 	NodeSrvs map[int64]*nftchainservice.Service    // 区块链服务实例映射，键为链ID，值为对应的区块链服务
+	PubSub   goredis.UniversalClient               // Redis 发布订阅客户端，用于实时推送交易活动
+	Ipfs     *ipfs.Resolver                        // IPFS 网关解析器，在多个网关之间轮换获取 ipfs:// 资源
+	shutdownTracing func(context.Context) error    // 关闭链路追踪导出器，导出剩余的 span
+	shuttingDown    atomic.Bool                     // 是否已开始优雅关闭，开始后就绪检查立即返回 503
+	DegradedChains  map[int64]*DegradedChain        // 初始化失败的区块链，键为链ID，仅在开启 allow_degraded_chains 时存在
+}
+
+// DegradedChain 初始化失败而被标记为降级的区块链
+type DegradedChain struct {
+	Name  string // 区块链名称
+	Error string // 初始化失败的原因
 }
 
 // NewServiceContext 创建一个新的服务上下文实例
@@ -55,6 +77,16 @@ func NewServiceContext(c *config.Config) (*ServerCtx, error) {
 		return nil, err
 	}
 
+	// 初始化链路追踪，未配置导出端点时只透传 traceparent
+	serviceName := ""
+	if c.ProjectCfg != nil {
+		serviceName = c.ProjectCfg.Name
+	}
+	shutdownTracing, err := tracing.Init(c.Trace, serviceName)
+	if err != nil {
+		return nil, err
+	}
+
 	// 构建 Redis 配置
 	// 将配置文件中的 Redis 配置转换为 go-zero 所需的格式
 	var kvConf kv.KvConf
@@ -71,37 +103,66 @@ func NewServiceContext(c *config.Config) (*ServerCtx, error) {
 
 	// 初始化 Redis 存储
 	store := xkv.NewStore(kvConf)
+
+	// 初始化 Redis 发布订阅客户端
+	// go-zero 的 Redis 封装不支持 pub/sub，这里使用 go-redis 连接第一个 Redis 节点
+	pubSub := newPubSubClient(c.Kv.Redis[0])
 	
 	// 初始化数据库连接
-	db, err := gdb.NewDB(&c.DB)
+	db, err := gdb.NewDB(&c.DB.Config)
 	if err != nil {
 		return nil, err
 	}
 
+	// 按配置调整数据库连接池
+	if err := applyDBPool(db, c.DB.PoolConfig()); err != nil {
+		return nil, err
+	}
+
+	// 为每条SQL设置默认超时时间, 避免慢查询一直占用请求
+	if err := registerQueryTimeout(db, c.DB.QueryTimeoutDuration()); err != nil {
+		return nil, err
+	}
+
+	// 为每条SQL创建链路追踪的 span
+	if err := registerDBTracing(db, c.DB.Database); err != nil {
+		return nil, err
+	}
+
 	// 初始化区块链服务
 	// 为每个支持的区块链创建对应的服务实例
+	// 默认任一链初始化失败即启动失败; 开启 allow_degraded_chains 时跳过失败的链, 将其标记为降级
 	nodeSrvs := make(map[int64]*nftchainservice.Service)
+	degradedChains := make(map[int64]*DegradedChain)
 	for _, supported := range c.ChainSupported {
-		// 为每个区块链创建 NFT 链上服务
-		nodeSrvs[int64(supported.ChainID)], err = nftchainservice.New(
-			context.Background(),
-			supported.Endpoint,           // 区块链 RPC 端点
-			supported.Name,               // 区块链名称
-			supported.ChainID,            // 区块链 ID
-			c.MetadataParse.NameTags,     // NFT 名称字段标签
-			c.MetadataParse.ImageTags,    // NFT 图片字段标签
-			c.MetadataParse.AttributesTags, // NFT 属性字段标签
-			c.MetadataParse.TraitNameTags,  // NFT 特征名称字段标签
-			c.MetadataParse.TraitValueTags, // NFT 特征值字段标签
-		)
-
+		nodeSrv, err := newNodeSrv(c, supported)
 		if err != nil {
-			return nil, errors.Wrap(err, "初始化区块链同步服务失败")
+			if !c.AllowDegradedChains {
+				return nil, err
+			}
+			xzap.WithContext(context.Background()).Error("区块链初始化失败，标记为降级",
+				zap.String("chain", supported.Name), zap.Int("chain_id", supported.ChainID), zap.Error(err))
+			degradedChains[int64(supported.ChainID)] = &DegradedChain{Name: supported.Name, Error: err.Error()}
+			continue
 		}
+		nodeSrvs[int64(supported.ChainID)] = nodeSrv
+	}
+
+	// 缓存读写的重试和熔断策略
+	retry := c.Kv.RetryConfig()
+	cacheRetry := dao.CacheRetryPolicy{
+		Attempts:         retry.Attempts,
+		Backoff:          time.Duration(retry.BackoffMs) * time.Millisecond,
+		BreakerThreshold: retry.BreakerThreshold,
+		BreakerCooldown:  time.Duration(retry.BreakerCooldown) * time.Second,
 	}
 
 	// 初始化数据访问层
-	dao := dao.New(context.Background(), db, store)
+	dao := dao.New(db, store)
+	if c.MaxPrice > 0 {
+		dao.MaxPrice = decimal.NewFromFloat(c.MaxPrice)
+	}
+	dao.CacheRetry = cacheRetry
 	
 	// 使用选项模式创建服务上下文
 	serverCtx := NewServerCtx(
@@ -113,6 +174,131 @@ func NewServiceContext(c *config.Config) (*ServerCtx, error) {
 	// 设置其他属性
 	serverCtx.C = c               // 保存配置引用
 	serverCtx.NodeSrvs = nodeSrvs // 保存区块链服务映射
+	serverCtx.DegradedChains = degradedChains // 保存降级的区块链
+	serverCtx.PubSub = pubSub     // 保存发布订阅客户端
+	serverCtx.Ipfs = ipfs.New(c.IpfsGateways, ipfs.DefaultTimeout) // 初始化 IPFS 网关解析器
+	serverCtx.shutdownTracing = shutdownTracing                   // 保存链路追踪关闭函数
 
 	return serverCtx, nil
 }
+
+// newNodeSrv 为一条区块链创建 NFT 链上服务, 并替换为支持多端点故障转移的节点客户端
+// 返回的错误中包含链名称和链ID, 便于定位是哪条链初始化失败
+func newNodeSrv(c *config.Config, supported *config.ChainSupported) (*nftchainservice.Service, error) {
+	nodeSrv, err := nftchainservice.New(
+		context.Background(),
+		supported.Endpoints[0],         // 区块链 RPC 端点，实际调用由下方的故障转移客户端接管
+		supported.Name,                 // 区块链名称
+		supported.ChainID,              // 区块链 ID
+		c.MetadataParse.NameTags,       // NFT 名称字段标签
+		c.MetadataParse.ImageTags,      // NFT 图片字段标签
+		c.MetadataParse.AttributesTags, // NFT 属性字段标签
+		c.MetadataParse.TraitNameTags,  // NFT 特征名称字段标签
+		c.MetadataParse.TraitValueTags, // NFT 特征值字段标签
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "初始化区块链同步服务失败, chain: %s, chain_id: %d", supported.Name, supported.ChainID)
+	}
+
+	// 使用支持多端点故障转移的节点客户端替换默认的单端点客户端
+	nodeClient, err := nodeclient.New(supported.ChainID, supported.Name, supported.Endpoints)
+	if err != nil {
+		return nil, errors.Wrapf(err, "初始化区块链节点客户端失败, chain: %s, chain_id: %d", supported.Name, supported.ChainID)
+	}
+	nodeSrv.NodeClient = nodeClient
+
+	return nodeSrv, nil
+}
+
+// IsChainDegraded 返回区块链是否因初始化失败被标记为降级
+func (s *ServerCtx) IsChainDegraded(chainID int64) bool {
+	_, ok := s.DegradedChains[chainID]
+	return ok
+}
+
+// applyDBPool 将连接池配置应用到 GORM 底层的 sql.DB, 并打印生效的配置
+func applyDBPool(db *gorm.DB, pool config.DBPool) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return errors.Wrap(err, "failed on get sql db")
+	}
+
+	sqlDB.SetMaxOpenConns(pool.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(pool.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(time.Duration(pool.ConnMaxLifetime) * time.Second)
+	sqlDB.SetConnMaxIdleTime(time.Duration(pool.ConnMaxIdleTime) * time.Second)
+
+	xzap.WithContext(context.Background()).Info("database connection pool configured",
+		zap.Int("max_open_conns", pool.MaxOpenConns),
+		zap.Int("max_idle_conns", pool.MaxIdleConns),
+		zap.Int("conn_max_lifetime", pool.ConnMaxLifetime),
+		zap.Int("conn_max_idle_time", pool.ConnMaxIdleTime))
+
+	return nil
+}
+
+// newPubSubClient 根据 Redis 配置创建发布订阅客户端
+// 集群模式使用 ClusterClient，其余情况使用单节点客户端
+func newPubSubClient(conf *config.Redis) goredis.UniversalClient {
+	if conf.Type == redis.ClusterType {
+		return goredis.NewClusterClient(&goredis.ClusterOptions{
+			Addrs:    []string{conf.Host},
+			Password: conf.Pass,
+		})
+	}
+
+	return goredis.NewClient(&goredis.Options{
+		Addr:     conf.Host,
+		Password: conf.Pass,
+	})
+}
+
+// SetShuttingDown 标记服务已开始优雅关闭
+// 由退出信号处理逻辑在关闭 HTTP 服务器之前调用，之后就绪检查失败，负载均衡不再转发新请求
+func (s *ServerCtx) SetShuttingDown() {
+	s.shuttingDown.Store(true)
+}
+
+// IsShuttingDown 返回服务是否已开始优雅关闭
+func (s *ServerCtx) IsShuttingDown() bool {
+	return s.shuttingDown.Load()
+}
+
+// Close 释放服务上下文持有的外部资源
+// 在 HTTP 服务器关闭之后调用，确保数据库连接池和发布订阅客户端被正确关闭
+// KvStore 的 Redis 连接由 go-zero 的客户端管理器统一维护，没有提供单独的关闭接口，进程退出时随之释放
+//
+// 返回值:
+//   - error: 关闭过程中的错误
+func (s *ServerCtx) Close() error {
+	if s.shutdownTracing != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		err := s.shutdownTracing(ctx)
+		cancel()
+		if err != nil {
+			xzap.WithContext(context.Background()).Warn("关闭链路追踪导出器失败", zap.Error(err))
+		}
+	}
+
+	if s.PubSub != nil {
+		if err := s.PubSub.Close(); err != nil {
+			return errors.Wrap(err, "failed on close redis pubsub client")
+		}
+	}
+
+	if s.DB == nil {
+		return nil
+	}
+
+	// 获取底层的 sql.DB 并关闭连接池
+	sqlDB, err := s.DB.DB()
+	if err != nil {
+		return errors.Wrap(err, "failed on get sql db")
+	}
+
+	if err := sqlDB.Close(); err != nil {
+		return errors.Wrap(err, "failed on close db")
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package svc
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+const (
+	queryTimeoutCancelKey = "easyswap:query_timeout_cancel"
+	queryTimeoutParentKey = "easyswap:query_timeout_parent"
+)
+
+// registerQueryTimeout 为每条SQL设置默认的超时时间
+// 1. 在执行前基于请求的 context 创建带超时的 context, 请求先取消或已有更早的截止时间时以请求为准
+// 2. 执行结束后释放超时 context 并恢复原 context, 同一个查询链多次执行(如先 Count 再 Find)时互不影响
+// 3. Row/Rows 查询的结果在回调结束后才读取, 提前释放会导致读取失败, 执行后只恢复原 context,
+// 超时 context 在到期后自行释放
+func registerQueryTimeout(db *gorm.DB, timeout time.Duration) error {
+	if timeout <= 0 {
+		return nil
+	}
+
+	before := func(tx *gorm.DB) {
+		ctx := tx.Statement.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
+		tx.InstanceSet(queryTimeoutParentKey, tx.Statement.Context)
+		tx.InstanceSet(queryTimeoutCancelKey, cancel)
+		tx.Statement.Context = timeoutCtx
+	}
+	restore := func(tx *gorm.DB) {
+		if parent, ok := tx.InstanceGet(queryTimeoutParentKey); ok {
+			tx.Statement.Context, _ = parent.(context.Context)
+		}
+	}
+	after := func(tx *gorm.DB) {
+		cancel, ok := tx.InstanceGet(queryTimeoutCancelKey)
+		if !ok {
+			return
+		}
+		cancel.(context.CancelFunc)()
+		restore(tx)
+	}
+
+	callback := db.Callback()
+	if err := callback.Query().Before("gorm:query").Register("easyswap:query_timeout_before", before); err != nil {
+		return errors.Wrap(err, "failed on register query timeout callback")
+	}
+	if err := callback.Query().After("gorm:query").Register("easyswap:query_timeout_after", after); err != nil {
+		return errors.Wrap(err, "failed on register query timeout callback")
+	}
+	if err := callback.Raw().Before("gorm:raw").Register("easyswap:raw_timeout_before", before); err != nil {
+		return errors.Wrap(err, "failed on register raw timeout callback")
+	}
+	if err := callback.Raw().After("gorm:raw").Register("easyswap:raw_timeout_after", after); err != nil {
+		return errors.Wrap(err, "failed on register raw timeout callback")
+	}
+	if err := callback.Row().Before("gorm:row").Register("easyswap:row_timeout_before", before); err != nil {
+		return errors.Wrap(err, "failed on register row timeout callback")
+	}
+	if err := callback.Row().After("gorm:row").Register("easyswap:row_timeout_after", restore); err != nil {
+		return errors.Wrap(err, "failed on register row timeout callback")
+	}
+	if err := callback.Create().Before("gorm:create").Register("easyswap:create_timeout_before", before); err != nil {
+		return errors.Wrap(err, "failed on register create timeout callback")
+	}
+	if err := callback.Create().After("gorm:create").Register("easyswap:create_timeout_after", after); err != nil {
+		return errors.Wrap(err, "failed on register create timeout callback")
+	}
+	if err := callback.Update().Before("gorm:update").Register("easyswap:update_timeout_before", before); err != nil {
+		return errors.Wrap(err, "failed on register update timeout callback")
+	}
+	if err := callback.Update().After("gorm:update").Register("easyswap:update_timeout_after", after); err != nil {
+		return errors.Wrap(err, "failed on register update timeout callback")
+	}
+	if err := callback.Delete().Before("gorm:delete").Register("easyswap:delete_timeout_before", before); err != nil {
+		return errors.Wrap(err, "failed on register delete timeout callback")
+	}
+	if err := callback.Delete().After("gorm:delete").Register("easyswap:delete_timeout_after", after); err != nil {
+		return errors.Wrap(err, "failed on register delete timeout callback")
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package svc
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+
+	"github.com/joinmouse/EasySwapBackend/src/common/tracing"
+)
+
+const (
+	dbSpanKey       = "easyswap:trace_span"
+	dbSpanParentKey = "easyswap:trace_parent"
+)
+
+// registerDBTracing 为每条SQL创建一个 client span, 父节点为请求 context 中的 span
+// 1. 执行前最先创建 span 并替换 Statement.Context, 包在超时回调外层, 两者恢复 context 时不会互相覆盖
+// 2. 执行结束后最后记录SQL、影响行数和错误, 并恢复原 context
+// Row/Rows 查询的 span 只覆盖执行阶段, 不包括调用方读取结果的时间
+// 记录不存在属于正常的业务结果, 不标记为错误
+func registerDBTracing(db *gorm.DB, dbName string) error {
+	before := func(operation string) func(tx *gorm.DB) {
+		return func(tx *gorm.DB) {
+			ctx := tx.Statement.Context
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			spanCtx, span := tracing.Tracer().Start(ctx, "db."+operation,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					semconv.DBSystemMySQL,
+					semconv.DBName(dbName),
+					semconv.DBOperation(operation),
+				),
+			)
+			tx.InstanceSet(dbSpanParentKey, tx.Statement.Context)
+			tx.InstanceSet(dbSpanKey, span)
+			tx.Statement.Context = spanCtx
+		}
+	}
+	after := func(tx *gorm.DB) {
+		value, ok := tx.InstanceGet(dbSpanKey)
+		if !ok {
+			return
+		}
+		span := value.(trace.Span)
+		if span.IsRecording() {
+			span.SetAttributes(
+				semconv.DBStatement(tx.Statement.SQL.String()),
+				attribute.Int64("db.rows_affected", tx.Statement.RowsAffected),
+			)
+			if tx.Statement.Table != "" {
+				span.SetAttributes(semconv.DBSQLTable(tx.Statement.Table))
+			}
+		}
+		err := tx.Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = nil
+		}
+		tracing.End(span, err)
+
+		if parent, ok := tx.InstanceGet(dbSpanParentKey); ok {
+			tx.Statement.Context, _ = parent.(context.Context)
+		}
+	}
+
+	callback := db.Callback()
+	if err := callback.Query().Before("*").Register("easyswap:query_trace_before", before("query")); err != nil {
+		return errors.Wrap(err, "failed on register query trace callback")
+	}
+	if err := callback.Query().After("*").Register("easyswap:query_trace_after", after); err != nil {
+		return errors.Wrap(err, "failed on register query trace callback")
+	}
+	if err := callback.Raw().Before("*").Register("easyswap:raw_trace_before", before("raw")); err != nil {
+		return errors.Wrap(err, "failed on register raw trace callback")
+	}
+	if err := callback.Raw().After("*").Register("easyswap:raw_trace_after", after); err != nil {
+		return errors.Wrap(err, "failed on register raw trace callback")
+	}
+	if err := callback.Row().Before("*").Register("easyswap:row_trace_before", before("row")); err != nil {
+		return errors.Wrap(err, "failed on register row trace callback")
+	}
+	if err := callback.Row().After("*").Register("easyswap:row_trace_after", after); err != nil {
+		return errors.Wrap(err, "failed on register row trace callback")
+	}
+	if err := callback.Create().Before("*").Register("easyswap:create_trace_before", before("insert")); err != nil {
+		return errors.Wrap(err, "failed on register create trace callback")
+	}
+	if err := callback.Create().After("*").Register("easyswap:create_trace_after", after); err != nil {
+		return errors.Wrap(err, "failed on register create trace callback")
+	}
+	if err := callback.Update().Before("*").Register("easyswap:update_trace_before", before("update")); err != nil {
+		return errors.Wrap(err, "failed on register update trace callback")
+	}
+	if err := callback.Update().After("*").Register("easyswap:update_trace_after", after); err != nil {
+		return errors.Wrap(err, "failed on register update trace callback")
+	}
+	if err := callback.Delete().Before("*").Register("easyswap:delete_trace_before", before("delete")); err != nil {
+		return errors.Wrap(err, "failed on register delete trace callback")
+	}
+	if err := callback.Delete().After("*").Register("easyswap:delete_trace_after", after); err != nil {
+		return errors.Wrap(err, "failed on register delete trace callback")
+	}
+
+	return nil
+}
@@ -5,31 +5,75 @@ import (
 
 	"github.com/pkg/errors"
 
+	"github.com/joinmouse/EasySwapBackend/src/common/tracing"
+	"github.com/joinmouse/EasySwapBackend/src/dao"
 	"github.com/joinmouse/EasySwapBackend/src/service/svc"
 	"github.com/joinmouse/EasySwapBackend/src/types/v1"
 )
 
-func GetMultiChainActivities(ctx context.Context, svcCtx *svc.ServerCtx, chainID []int, chainName []string, collectionAddrs []string, tokenID string, userAddrs []string, eventTypes []string, page, pageSize int) (*types.ActivityResp, error) {
-	activities, total, err := svcCtx.Dao.QueryMultiChainActivities(ctx, chainName, collectionAddrs, tokenID, userAddrs, eventTypes, page, pageSize)
+// MaxTimeRangeDays since/until 时间过滤允许的最大跨度(天)
+const MaxTimeRangeDays = 365
+
+func GetMultiChainActivities(ctx context.Context, svcCtx *svc.ServerCtx, chainName []string, params types.ActivityMultiChainFilterParams) (*types.ActivityResp, error) {
+	ctx, span := tracing.Start(ctx, "service.GetMultiChainActivities")
+	defer span.End()
+
+	// 解析游标, 为空时从最新的活动开始查询
+	var activityCursor *dao.ActivityCursor
+	if params.Cursor != "" {
+		var err error
+		activityCursor, err = dao.DecodeActivityCursor(params.Cursor)
+		if err != nil {
+			return nil, ErrInvalidFilter
+		}
+	}
+
+	// 校验事件类型并展开别名
+	eventTypes, err := dao.ExpandActivityEventTypes(params.EventTypes)
+	if err != nil {
+		return nil, ErrInvalidEventType
+	}
+
+	filter := dao.ActivityFilter{
+		ChainNames:      chainName,
+		CollectionAddrs: params.CollectionAddresses,
+		TokenID:         params.TokenID,
+		UserAddrs:       params.UserAddresses,
+		Maker:           params.Maker,
+		Taker:           params.Taker,
+		EventTypes:      eventTypes,
+	}
+	if params.TimeRange != nil {
+		filter.Since, filter.Until = params.TimeRange.Since, params.TimeRange.Until
+	}
+	activities, total, err := svcCtx.Dao.QueryMultiChainActivities(ctx, filter, activityCursor, params.Page, params.PageSize)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed on query multi-chain activity")
 	}
 
 	if total == 0 || len(activities) == 0 {
 		return &types.ActivityResp{
-			Result: nil,
+			Result: []types.ActivityInfo{},
 			Count:  0,
 		}, nil
 	}
 
 	//external info query
-	results, err := svcCtx.Dao.QueryMultiChainActivityExternalInfo(ctx, chainID, chainName, activities)
+	results, err := svcCtx.Dao.QueryMultiChainActivityExternalInfo(ctx, params.ChainID, chainName, activities)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed on query activity external info")
 	}
 
+	// 本页已满时返回指向最后一条活动的游标
+	var nextCursor string
+	if len(activities) == params.PageSize {
+		last := activities[len(activities)-1]
+		nextCursor = dao.EncodeActivityCursor(dao.ActivityCursor{EventTime: last.EventTime, ID: last.Id})
+	}
+
 	return &types.ActivityResp{
-		Result: results,
-		Count:  total,
+		Result:     results,
+		Count:      total,
+		NextCursor: nextCursor,
 	}, nil
 }
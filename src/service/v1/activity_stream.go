@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/joinmouse/EasySwapBase/logger/xzap"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/joinmouse/EasySwapBackend/src/common/tracing"
+	"github.com/joinmouse/EasySwapBackend/src/dao"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+	"github.com/joinmouse/EasySwapBackend/src/types/v1"
+)
+
+const (
+	// ActivitySnapshotSize 建立连接时推送的最近活动数量
+	ActivitySnapshotSize = 20
+	// MaxStreamConnsPerCollection 单个集合允许的最大实时推送连接数
+	MaxStreamConnsPerCollection = 200
+
+	// DefaultActivityStreamInterval 轮询新写入活动的默认间隔(秒)
+	DefaultActivityStreamInterval = 2
+	// ActivityPublishBatch 每次轮询从活动表读取的新活动数量上限
+	ActivityPublishBatch = 500
+	// ActivityPublishedTTL 活动发布标记的保留时间, 需要大于多个实例之间轮询进度的差距
+	ActivityPublishedTTL = 10 * time.Minute
+)
+
+// ErrTooManyStreamConns 集合的实时推送连接数已达上限
+var ErrTooManyStreamConns = errors.New("too many stream connections for collection")
+
+// streamActivityTypes 实时推送关注的活动类型: 成交、挂单和出价
+var streamActivityTypes = []string{"sale", "buy", "list", "offer", "item_bid", "collection_bid"}
+
+// isStreamActivityType 活动类型是否需要实时推送
+func isStreamActivityType(activityType int) bool {
+	eventType, ok := dao.ActivityEventType(activityType)
+	if !ok {
+		return false
+	}
+	for _, streamType := range streamActivityTypes {
+		if eventType == streamType {
+			return true
+		}
+	}
+	return false
+}
+
+// streamConns 记录每个集合当前的实时推送连接数
+var streamConns = struct {
+	sync.Mutex
+	counts map[string]int
+}{counts: make(map[string]int)}
+
+// AcquireActivityStream 为集合占用一个实时推送连接名额
+// 超过MaxStreamConnsPerCollection时返回ErrTooManyStreamConns, 成功时返回释放函数
+func AcquireActivityStream(chain, collectionAddr string) (func(), error) {
+	key := dao.ActivityChannel(chain, collectionAddr)
+
+	streamConns.Lock()
+	defer streamConns.Unlock()
+	if streamConns.counts[key] >= MaxStreamConnsPerCollection {
+		return nil, ErrTooManyStreamConns
+	}
+	streamConns.counts[key]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			streamConns.Lock()
+			defer streamConns.Unlock()
+			streamConns.counts[key]--
+			if streamConns.counts[key] <= 0 {
+				delete(streamConns.counts, key)
+			}
+		})
+	}, nil
+}
+
+// GetActivitySnapshot 获取集合最近的成交、挂单和出价活动
+func GetActivitySnapshot(ctx context.Context, svcCtx *svc.ServerCtx, chainID int, chain, collectionAddr string) ([]types.ActivityInfo, error) {
+	ctx, span := tracing.Start(ctx, "service.GetActivitySnapshot")
+	defer span.End()
+
+	res, err := GetMultiChainActivities(ctx, svcCtx, []string{chain}, types.ActivityMultiChainFilterParams{
+		ChainID:             []int{chainID},
+		CollectionAddresses: []string{strings.ToLower(collectionAddr)},
+		EventTypes:          streamActivityTypes,
+		Page:                1,
+		PageSize:            ActivitySnapshotSize,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get activity snapshot")
+	}
+
+	activities, ok := res.Result.([]types.ActivityInfo)
+	if !ok {
+		return []types.ActivityInfo{}, nil
+	}
+
+	return activities, nil
+}
+
+// SubscribeActivities 订阅集合的实时交易活动频道
+// 调用方负责在连接断开时关闭返回的订阅
+func SubscribeActivities(ctx context.Context, svcCtx *svc.ServerCtx, chain, collectionAddr string) (*goredis.PubSub, error) {
+	pubSub := svcCtx.PubSub.Subscribe(ctx, dao.ActivityChannel(chain, collectionAddr))
+	// 等待订阅确认, 确保Redis可用
+	if _, err := pubSub.Receive(ctx); err != nil {
+		pubSub.Close()
+		return nil, errors.Wrap(err, "failed on subscribe activity channel")
+	}
+
+	return pubSub, nil
+}
+
+// LatestActivityID 获取链上活动表当前最大的活动ID, 作为轮询新活动的起点
+func LatestActivityID(ctx context.Context, svcCtx *svc.ServerCtx, chain string) (int64, error) {
+	id, err := svcCtx.Dao.QueryLatestActivityID(ctx, chain)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed on get latest activity id")
+	}
+
+	return id, nil
+}
+
+// PublishNewActivities 将链上 afterID 之后写入的成交、挂单和出价活动发布到集合的实时推送频道
+// 1. 活动由同步服务写入, 按ID升序读取新活动, 每次最多 ActivityPublishBatch 条, 返回已读取的最大ID作为下次轮询的起点
+// 2. 活动转换为与快照相同的 ActivityInfo 后通过 svcCtx.PubSub 发布
+// 3. 发布前写入发布标记, 多个实例同时轮询时同一条活动只发布一次; 单条活动发布失败只记录日志
+func PublishNewActivities(ctx context.Context, svcCtx *svc.ServerCtx, chainID int, chain string, afterID int64) (lastID int64, published int, err error) {
+	ctx, span := tracing.Start(ctx, "service.PublishNewActivities")
+	defer span.End()
+
+	activities, err := svcCtx.Dao.QueryActivitiesAfterID(ctx, chain, afterID, ActivityPublishBatch)
+	if err != nil {
+		return afterID, 0, errors.Wrap(err, "failed on get new activities")
+	}
+	if len(activities) == 0 {
+		return afterID, 0, nil
+	}
+	lastID = activities[len(activities)-1].Id
+
+	var streamActivities []dao.ActivityMultiChainInfo
+	for _, activity := range activities {
+		if isStreamActivityType(activity.ActivityType) {
+			streamActivities = append(streamActivities, activity)
+		}
+	}
+	if len(streamActivities) == 0 {
+		return lastID, 0, nil
+	}
+
+	infos, err := svcCtx.Dao.QueryMultiChainActivityExternalInfo(ctx, []int{chainID}, []string{chain}, streamActivities)
+	if err != nil {
+		return afterID, 0, errors.Wrap(err, "failed on get new activities external info")
+	}
+
+	for i, info := range infos {
+		activityID := streamActivities[i].Id
+		first, err := svcCtx.PubSub.SetNX(ctx, dao.ActivityPublishedKey(chain, activityID), 1, ActivityPublishedTTL).Result()
+		if err != nil {
+			xzap.WithContext(ctx).Warn("failed on mark activity published",
+				zap.String("chain", chain), zap.Int64("activity_id", activityID), zap.Error(err))
+			continue
+		}
+		if !first {
+			// 其他实例已经发布过
+			continue
+		}
+
+		payload, err := json.Marshal(&info)
+		if err != nil {
+			xzap.WithContext(ctx).Warn("failed on marshal activity", zap.Int64("activity_id", activityID), zap.Error(err))
+			continue
+		}
+		channel := dao.ActivityChannel(chain, info.CollectionAddress)
+		if err := svcCtx.PubSub.Publish(ctx, channel, payload).Err(); err != nil {
+			xzap.WithContext(ctx).Warn("failed on publish activity",
+				zap.String("channel", channel), zap.Int64("activity_id", activityID), zap.Error(err))
+			continue
+		}
+		published++
+	}
+
+	return lastID, published, nil
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joinmouse/EasySwapBase/stores/gdb/orderbookmodel/multi"
+
+	"github.com/joinmouse/EasySwapBackend/src/dao"
+	"github.com/joinmouse/EasySwapBackend/src/types/v1"
+)
+
+const testOtherCollection = "0x00000000000000000000000000000000000000cd"
+
+// newActivityRows 构造活动表新活动的查询结果
+func newActivityRows() *stubRows {
+	row := func(id int64, collection string, activityType int) []driver.Value {
+		return []driver.Value{"eth", id, collection, "1", "", int64(activityType), "0xmaker", "0xtaker", "1.5", "0xtx", int64(1700000000), int64(0)}
+	}
+	return &stubRows{
+		columns: []string{"chain_name", "id", "collection_address", "token_id", "currency_address", "activity_type",
+			"maker", "taker", "price", "tx_hash", "event_time", "marketplace_id"},
+		values: [][]driver.Value{
+			row(11, testCollection, multi.Sale),
+			row(12, testCollection, multi.Transfer),
+			row(13, testOtherCollection, multi.Listing),
+		},
+	}
+}
+
+func TestPublishNewActivities(t *testing.T) {
+	svcCtx, stub := newStubServerCtx(t)
+	stub.results = func(query string) *stubRows {
+		if strings.Contains(query, "id > ?") {
+			return newActivityRows()
+		}
+		return nil
+	}
+
+	ctx := context.Background()
+	sub := svcCtx.PubSub.Subscribe(ctx, dao.ActivityChannel("eth", testCollection), dao.ActivityChannel("eth", testOtherCollection))
+	defer sub.Close()
+	if _, err := sub.Receive(ctx); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	lastID, published, err := PublishNewActivities(ctx, svcCtx, 1, "eth", 10)
+	if err != nil {
+		t.Fatalf("PublishNewActivities() error: %v", err)
+	}
+	// 转账活动不推送, 但读取位置越过它
+	if lastID != 13 || published != 2 {
+		t.Fatalf("PublishNewActivities() = (%d, %d), want (13, 2)", lastID, published)
+	}
+
+	want := map[string]string{
+		dao.ActivityChannel("eth", testCollection):      "sale",
+		dao.ActivityChannel("eth", testOtherCollection): "list",
+	}
+	for i := 0; i < len(want); i++ {
+		msgCtx, cancel := context.WithTimeout(ctx, time.Second)
+		msg, err := sub.ReceiveMessage(msgCtx)
+		cancel()
+		if err != nil {
+			t.Fatalf("receive message: %v", err)
+		}
+
+		// 推送的消息与快照中的活动格式相同
+		var info types.ActivityInfo
+		if err := json.Unmarshal([]byte(msg.Payload), &info); err != nil {
+			t.Fatalf("unmarshal %s: %v", msg.Payload, err)
+		}
+		if info.EventType != want[msg.Channel] || info.ChainID != 1 || info.ItemName != "#1" || info.Price.String() != "1.5" {
+			t.Errorf("message on %s = %+v", msg.Channel, info)
+		}
+	}
+
+	// 其他实例轮询到同一批活动时不重复发布
+	if _, published, err := PublishNewActivities(ctx, svcCtx, 1, "eth", 10); err != nil || published != 0 {
+		t.Errorf("second PublishNewActivities() = (%d, %v), want (0, nil)", published, err)
+	}
+}
+
+func TestPublishNewActivitiesEmpty(t *testing.T) {
+	svcCtx, _ := newStubServerCtx(t)
+
+	lastID, published, err := PublishNewActivities(context.Background(), svcCtx, 1, "eth", 42)
+	if err != nil || lastID != 42 || published != 0 {
+		t.Errorf("PublishNewActivities() = (%d, %d, %v), want (42, 0, nil)", lastID, published, err)
+	}
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/joinmouse/EasySwapBase/logger/xzap"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/joinmouse/EasySwapBackend/src/common/tracing"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+	"github.com/joinmouse/EasySwapBackend/src/types/v1"
+)
+
+const (
+	DefaultBidLevels = 50  // 默认返回的价位数量
+	MaxBidLevels     = 200 // 最多返回的价位数量
+)
+
+const (
+	DefaultListingLevels = 50  // 默认返回的挂单价位数量
+	MaxListingLevels     = 200 // 最多返回的挂单价位数量
+
+	AggregatedListingsCacheKey = "cache:es:collection:listings:aggregated:%s:%s:%d"
+	AggregatedListingsCacheTTL = 10 // second
+)
+
+// GetAggregatedBids 获取按价格档位聚合的 Collection Bid 深度
+// 价位按价格降序排列, 并计算从最高价开始累计的剩余未成交数量
+func GetAggregatedBids(ctx context.Context, svcCtx *svc.ServerCtx, chain, collectionAddr string, limit int) ([]types.BidPriceLevel, error) {
+	ctx, span := tracing.Start(ctx, "service.GetAggregatedBids")
+	defer span.End()
+
+	if limit <= 0 {
+		limit = DefaultBidLevels
+	}
+	if limit > MaxBidLevels {
+		limit = MaxBidLevels
+	}
+
+	levels, err := svcCtx.Dao.QueryCollectionBidLevels(ctx, chain, collectionAddr, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get collection bid levels")
+	}
+
+	var cumulative int64
+	for i := range levels {
+		cumulative += levels[i].BidUnfilled
+		levels[i].CumulativeUnfilled = cumulative
+	}
+
+	if levels == nil {
+		levels = []types.BidPriceLevel{}
+	}
+
+	return levels, nil
+}
+
+// GetAggregatedListings 获取按价格档位聚合的挂单深度, 与 GetAggregatedBids 一起组成深度图的卖方和买方
+// 价位按价格升序排列, 聚合结果缓存 AggregatedListingsCacheTTL 秒
+func GetAggregatedListings(ctx context.Context, svcCtx *svc.ServerCtx, chain, collectionAddr string, limit int) ([]types.ListingInfo, error) {
+	ctx, span := tracing.Start(ctx, "service.GetAggregatedListings")
+	defer span.End()
+
+	if limit <= 0 {
+		limit = DefaultListingLevels
+	}
+	if limit > MaxListingLevels {
+		limit = MaxListingLevels
+	}
+
+	cacheKey := fmt.Sprintf(AggregatedListingsCacheKey, strings.ToLower(chain), strings.ToLower(collectionAddr), limit)
+	if cached, err := svcCtx.KvStore.Get(cacheKey); err == nil && cached != "" {
+		var levels []types.ListingInfo
+		if err := json.Unmarshal([]byte(cached), &levels); err == nil {
+			return levels, nil
+		}
+	}
+
+	levels, err := svcCtx.Dao.QueryCollectionListingLevels(ctx, chain, collectionAddr, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get collection listing levels")
+	}
+
+	if raw, err := json.Marshal(levels); err == nil {
+		if err := svcCtx.KvStore.Setex(cacheKey, string(raw), AggregatedListingsCacheTTL); err != nil {
+			xzap.WithContext(ctx).Warn("failed on cache aggregated listings", zap.Error(err))
+		}
+	}
+
+	return levels, nil
+}
+
+// GetCollectionItemBidsByTrait 按 Trait值分组获取集合内有效Item出价的最高价格及剩余未成交数量
+// 订单簿中没有 Trait出价, 返回的每一组都是拥有该 Trait值的 Item 上的Item出价; 集合没有Item出价时返回空列表
+func GetCollectionItemBidsByTrait(ctx context.Context, svcCtx *svc.ServerCtx, chain, collectionAddr string) ([]types.TraitItemBids, error) {
+	ctx, span := tracing.Start(ctx, "service.GetCollectionItemBidsByTrait")
+	defer span.End()
+
+	traitBids, err := svcCtx.Dao.QueryCollectionItemBidsByTrait(ctx, chain, collectionAddr)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get collection item bids by trait")
+	}
+
+	if traitBids == nil {
+		traitBids = []types.TraitItemBids{}
+	}
+	for i := range traitBids {
+		traitBids[i].BidType = BidTypeItem
+	}
+
+	return traitBids, nil
+}
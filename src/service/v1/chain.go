@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/joinmouse/EasySwapBase/logger/xzap"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/joinmouse/EasySwapBackend/src/common/metrics"
+	"github.com/joinmouse/EasySwapBackend/src/common/tracing"
+	"github.com/joinmouse/EasySwapBackend/src/service/nodeclient"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+	"github.com/joinmouse/EasySwapBackend/src/types/v1"
+)
+
+// GetSupportedChains 获取配置中支持的区块链列表
+func GetSupportedChains(svcCtx *svc.ServerCtx) []types.SupportedChain {
+	chains := make([]types.SupportedChain, 0, len(svcCtx.C.ChainSupported))
+	for _, c := range svcCtx.C.ChainSupported {
+		if c == nil {
+			continue
+		}
+		chains = append(chains, types.SupportedChain{
+			Name:                c.Name,
+			ChainID:             c.ChainID,
+			NativeSymbol:        nativeSymbol(c),
+			MarketplaceContract: c.MarketplaceContract,
+		})
+	}
+
+	return chains
+}
+
+// SyncStatusRPCTimeout 查询链上最新区块高度的超时时间
+const SyncStatusRPCTimeout = 3 * time.Second
+
+// GetChainSyncStatus 获取索引服务在指定链上的同步状态
+// 1. 从 ob_indexed_status 表读取各类索引任务的同步进度, 取最慢的一个作为数据实际反映的区块高度
+// 2. 通过链上节点查询最新区块高度, 计算落后的区块数和估算的秒数
+// 3. 落后区块数不超过链配置的 max_sync_lag 时视为健康, 没有同步记录时视为不健康
+func GetChainSyncStatus(ctx context.Context, svcCtx *svc.ServerCtx, chainID int) (*types.ChainSyncStatus, error) {
+	ctx, span := tracing.Start(ctx, "service.GetChainSyncStatus")
+	defer span.End()
+
+	chainCfg := chainConfigByID(svcCtx, chainID)
+	if chainCfg == nil {
+		return nil, ErrInvalidChainID
+	}
+
+	statuses, err := svcCtx.Dao.QueryIndexedStatus(ctx, chainID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get indexed status")
+	}
+
+	nodeSrv, ok := svcCtx.NodeSrvs[int64(chainID)]
+	if !ok || nodeSrv == nil {
+		return nil, ErrUpstreamRPC
+	}
+	client, ok := nodeSrv.NodeClient.(*nodeclient.FailoverClient)
+	if !ok {
+		return nil, ErrUpstreamRPC
+	}
+	rpcCtx, cancel := context.WithTimeout(ctx, SyncStatusRPCTimeout)
+	defer cancel()
+	head, err := client.HeaderByNumber(rpcCtx, nil)
+	metrics.ObserveRPC(nodeSrv.ChainName, "HeaderByNumber", err)
+	if err != nil {
+		xzap.WithContext(ctx).Error("failed on get chain head", zap.Int("chain_id", chainID), zap.Error(err))
+		return nil, ErrUpstreamRPC
+	}
+
+	res := types.ChainSyncStatus{
+		ChainID:    chainID,
+		HeadBlock:  head.Number.Int64(),
+		MaxSyncLag: chainCfg.MaxSyncLagOrDefault(),
+	}
+	for i, status := range statuses {
+		if i == 0 || status.LastIndexedBlock < res.IndexedBlock {
+			res.IndexedBlock = status.LastIndexedBlock
+			res.IndexedTime = status.LastIndexedTime
+		}
+	}
+
+	if res.HeadBlock > res.IndexedBlock {
+		res.LagBlocks = res.HeadBlock - res.IndexedBlock
+	}
+	res.LagSeconds = res.LagBlocks * int64(chainCfg.BlockTimeOrDefault())
+	res.Healthy = len(statuses) > 0 && res.LagBlocks <= res.MaxSyncLag
+
+	return &res, nil
+}
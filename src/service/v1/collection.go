@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/joinmouse/EasySwapBase/errcode"
 	"github.com/joinmouse/EasySwapBase/evm/eip"
 	"github.com/joinmouse/EasySwapBase/logger/xzap"
@@ -14,31 +16,152 @@ import (
 	"github.com/pkg/errors"
 	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
 
+	"github.com/joinmouse/EasySwapBackend/src/common/metrics"
+	"github.com/joinmouse/EasySwapBackend/src/common/tracing"
 	"github.com/joinmouse/EasySwapBackend/src/dao"
+	"github.com/joinmouse/EasySwapBackend/src/service/ipfs"
 	"github.com/joinmouse/EasySwapBackend/src/service/mq"
+	"github.com/joinmouse/EasySwapBackend/src/service/nodeclient"
 	"github.com/joinmouse/EasySwapBackend/src/service/svc"
 	"github.com/joinmouse/EasySwapBackend/src/types/v1"
 )
 
-func GetBids(ctx context.Context, svcCtx *svc.ServerCtx, chain string, collectionAddr string, page, pageSize int) (*types.CollectionBidsResp, error) {
+// GetBids 分页获取集合按价位聚合的 Collection Bid, 每个价位附带出价人地址
+// resolveEns 为 true 时尽力将出价人地址解析为 ENS 名称, refreshEns 为 true 时忽略 ENS 负缓存
+func GetBids(ctx context.Context, svcCtx *svc.ServerCtx, chain string, collectionAddr string, page, pageSize int, resolveEns, refreshEns bool) (*types.CollectionBidsResp, error) {
+	ctx, span := tracing.Start(ctx, "service.GetBids")
+	defer span.End()
+
 	bids, count, err := svcCtx.Dao.QueryCollectionBids(ctx, chain, collectionAddr, page, pageSize)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed on get item info")
 	}
+	if bids == nil {
+		bids = []types.CollectionBids{}
+	}
+
+	prices := make([]decimal.Decimal, 0, len(bids))
+	for _, bid := range bids {
+		prices = append(prices, bid.Price)
+	}
+	makers, err := svcCtx.Dao.QueryCollectionBidMakers(ctx, chain, collectionAddr, prices, MaxBidLevelMakers)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get bid makers")
+	}
+
+	// ENS 解析是尽力而为的, 解析失败时只返回原始地址
+	var ensNames map[string]string
+	if resolveEns {
+		var addrs []string
+		for _, levelMakers := range makers {
+			addrs = append(addrs, levelMakers...)
+		}
+		ensNames = ResolveEnsNames(ctx, svcCtx, addrs, refreshEns)
+	}
+
+	for i := range bids {
+		bids[i].Makers = []types.BidMaker{}
+		for _, maker := range makers[bids[i].Price.String()] {
+			bids[i].Makers = append(bids[i].Makers, types.BidMaker{
+				Address: maker,
+				EnsName: ensNames[strings.ToLower(maker)],
+			})
+		}
+	}
 
 	return &types.CollectionBidsResp{
-		Result: bids,
-		Count:  count,
+		Result:   bids,
+		Count:    count,
+		Page:     page,
+		PageSize: pageSize,
+		HasMore:  int64(page*pageSize) < count,
 	}, nil
 }
 
+// item 链上存在性缓存, 集合已收录但数据库中没有该 item 时使用
+const (
+	// ItemOnChainCacheKey item 链上存在性查询结果的缓存, 格式为 chainID:collection:tokenID
+	ItemOnChainCacheKey = "cache:es:item:onchain:%d:%s:%s"
+	// ItemOnChainTTL item 在链上存在时的缓存时间(秒), 同步完成后直接从数据库返回, 不再读取该缓存
+	ItemOnChainTTL = 60 * 60
+	// ItemNotOnChainTTL item 不存在或链上查询失败时的缓存时间(秒), 避免每个请求都访问节点
+	ItemNotOnChainTTL = 5 * 60
+
+	itemOnChain    = "1"
+	itemNotOnChain = "0"
+)
+
+// itemOnChainCacheKey item 链上存在性缓存的键
+func itemOnChainCacheKey(chainID int64, collectionAddr, tokenID string) string {
+	return fmt.Sprintf(ItemOnChainCacheKey, chainID, strings.ToLower(collectionAddr), tokenID)
+}
+
+// itemNotFoundError 集合已收录但数据库中没有该item时, 通过链上查询区分item不存在和尚未同步
+// 链上查询失败时按item不存在处理; 查询结果写入缓存, 不存在和查询失败使用较短的缓存时间(负缓存)
+// forceRefresh 为 true 时忽略负缓存重新查询链上数据
+func itemNotFoundError(ctx context.Context, svcCtx *svc.ServerCtx, chainID int64, chain, collectionAddr, tokenID string, forceRefresh bool) error {
+	cacheKey := itemOnChainCacheKey(chainID, collectionAddr, tokenID)
+	cached, err := svcCtx.KvStore.Get(cacheKey)
+	if err != nil {
+		xzap.WithContext(ctx).Warn("failed on get item onchain cache", zap.String("key", cacheKey), zap.Error(err))
+	}
+	switch {
+	case cached == itemOnChain:
+		return ErrItemNotIndexed
+	case cached == itemNotOnChain && !forceRefresh:
+		return ErrItemNotFound
+	}
+
+	nodeSrv, ok := svcCtx.NodeSrvs[chainID]
+	if !ok || nodeSrv == nil {
+		return ErrItemNotFound
+	}
+
+	value, ttl := itemOnChain, ItemOnChainTTL
+	owner, err := nodeSrv.FetchNftOwner(collectionAddr, tokenID)
+	metrics.ObserveRPC(chain, "FetchNftOwner", err)
+	if err != nil {
+		xzap.WithContext(ctx).Debug("item not found on chain", zap.String("collection", collectionAddr),
+			zap.String("token_id", tokenID), zap.Error(err))
+		value, ttl = itemNotOnChain, ItemNotOnChainTTL
+	} else if owner == (common.Address{}) {
+		value, ttl = itemNotOnChain, ItemNotOnChainTTL
+	}
+	if err := svcCtx.KvStore.Setex(cacheKey, value, ttl); err != nil {
+		xzap.WithContext(ctx).Warn("failed on cache item onchain status", zap.String("key", cacheKey), zap.Error(err))
+	}
+
+	if value == itemNotOnChain {
+		return ErrItemNotFound
+	}
+
+	return ErrItemNotIndexed
+}
+
+// MaxBidLevelMakers 集合出价列表每个价位最多返回的出价人数量
+const MaxBidLevelMakers = 20
+
+// MaxItemTraitFilters 集合Item列表最多支持同时过滤的特征数量
+const MaxItemTraitFilters = 10
+
 // GetItems 获取NFT Item列表信息：Item基本信息、订单信息、图片信息、用户持有数量、最近成交价格、最高出价信息
-func GetItems(ctx context.Context, svcCtx *svc.ServerCtx, chain string, filter types.CollectionItemFilterParams, collectionAddr string) (*types.NFTListingInfoResp, error) {
-	// 1. 查询基础Item信息和订单信息
-	items, count, err := svcCtx.Dao.QueryCollectionItemOrder(ctx, chain, filter, collectionAddr)
+func GetItems(ctx context.Context, svcCtx *svc.ServerCtx, chain string, filter types.CollectionItemFilterParams, collectionAddr string) (*types.PageResp, error) {
+	ctx, span := tracing.Start(ctx, "service.GetItems")
+	defer span.End()
+
+	// 1. 查询基础Item信息和订单信息, listed_only 查询优先读取已上架Item缓存, 缓存未预热时回退到实时查询
+	items, count, cached, err := queryCachedListedItems(ctx, svcCtx, chain, filter, collectionAddr)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed on get item info")
+		return nil, errors.Wrap(err, "failed on get cached listed items")
+	}
+	if !cached {
+		items, count, err = svcCtx.Dao.QueryCollectionItemOrder(ctx, chain, filter, collectionAddr)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed on get item info")
+		}
 	}
 
 	// 2. 提取需要查询的ItemID和所有者地址
@@ -178,8 +301,14 @@ func GetItems(ctx context.Context, svcCtx *svc.ServerCtx, chain string, filter t
 		return nil, errors.Wrap(queryErr, "failed on get items info")
 	}
 
-	// 5. 整合所有信息
-	var respItems []*types.NFTListingInfo
+	// 5. 查询集合的费率, 用于计算已上架Item的买家总价
+	rates, err := collectionFeeRates(ctx, svcCtx, chainIDByName(svcCtx, chain), collectionAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	// 6. 整合所有信息
+	respItems := []*types.NFTListingInfo{}
 	for _, item := range items {
 		// 设置Item名称
 		nameStr := item.Name
@@ -206,6 +335,11 @@ func GetItems(ctx context.Context, svcCtx *svc.ServerCtx, chain string, filter t
 			BidUnfilled:       collectionBestBid.QuantityRemaining,
 		}
 
+		// 添加买家总价, 列表中的挂单价格为各市场最低价, 按原生代币精度计算费用
+		if item.Listing {
+			respItem.ListTotalPrice, respItem.ListFees = listingCost(rates, item.ListPrice, currencyDecimals(svcCtx, chain, ""))
+		}
+
 		// 添加订单信息
 		listOrder, ok := ordersInfo[strings.ToLower(item.CollectionAddress+item.TokenId)]
 		if ok {
@@ -264,38 +398,41 @@ func GetItems(ctx context.Context, svcCtx *svc.ServerCtx, chain string, filter t
 		respItems = append(respItems, respItem)
 	}
 
-	return &types.NFTListingInfoResp{
-		Result: respItems,
-		Count:  count,
+	return &types.PageResp{
+		Total:    count,
+		Page:     filter.Page,
+		PageSize: filter.PageSize,
+		Items:    respItems,
 	}, nil
 }
 
 // GetItem 获取单个NFT的详细信息
-func GetItem(ctx context.Context, svcCtx *svc.ServerCtx, chain string, chainID int, collectionAddr, tokenID string) (*types.ItemDetailInfoResp, error) {
+// 数据库中没有该NFT时查询链上数据区分不存在和尚未同步, forceRefresh 为 true 时忽略链上查询的负缓存
+func GetItem(ctx context.Context, svcCtx *svc.ServerCtx, chain string, chainID int, collectionAddr, tokenID string, forceRefresh bool) (*types.ItemDetailInfoResp, error) {
+	ctx, span := tracing.Start(ctx, "service.GetItem")
+	defer span.End()
+
 	var queryErr error
 	var wg sync.WaitGroup
 
 	// 并发查询以下信息:
 	// 1. 查询collection信息
+	// collection和item的查询错误单独记录, 用于区分集合未收录和item不存在
 	var collection *multi.Collection
+	var collectionErr error
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		collection, queryErr = svcCtx.Dao.QueryCollectionInfo(ctx, chain, collectionAddr)
-		if queryErr != nil {
-			return
-		}
+		collection, collectionErr = svcCtx.Dao.QueryCollectionInfo(ctx, chain, collectionAddr)
 	}()
 
 	// 2. 查询item基本信息
 	var item *multi.Item
+	var itemErr error
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		item, queryErr = svcCtx.Dao.QueryItemInfo(ctx, chain, collectionAddr, tokenID)
-		if queryErr != nil {
-			return
-		}
+		item, itemErr = svcCtx.Dao.QueryItemInfo(ctx, chain, collectionAddr, tokenID)
 	}()
 
 	// 3. 查询item挂单信息
@@ -377,8 +514,39 @@ func GetItem(ctx context.Context, svcCtx *svc.ServerCtx, chain string, chainID i
 		collectionBestBid = bid
 	}()
 
+	// 8. 查询item在各市场的挂单
+	var itemListings map[string][]types.ListingInfo
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		listings, err := svcCtx.Dao.QueryItemsListings(ctx, chain, collectionAddr, []string{tokenID})
+		if err != nil {
+			queryErr = errors.Wrap(err, "failed on get item listings")
+			return
+		}
+		rates, err := collectionFeeRates(ctx, svcCtx, chainID, collectionAddr)
+		if err != nil {
+			queryErr = err
+			return
+		}
+		itemListings = groupItemListings(svcCtx, chain, rates, listings)
+	}()
+
 	// 等待所有查询完成
 	wg.Wait()
+	if collectionErr != nil {
+		if errors.Is(collectionErr, gorm.ErrRecordNotFound) {
+			return nil, ErrCollectionNotFound
+		}
+		return nil, errors.Wrap(collectionErr, "failed on get collection info")
+	}
+	if itemErr != nil {
+		return nil, errors.Wrap(itemErr, "failed on get item info")
+	}
+	if item == nil || item.TokenId == "" {
+		return nil, itemNotFoundError(ctx, svcCtx, int64(chainID), chain, collectionAddr, tokenID, forceRefresh)
+	}
+	// 其余查询的错误不代表集合不存在, 只有集合查询返回 ErrRecordNotFound 时返回 ErrCollectionNotFound
 	if queryErr != nil {
 		return nil, errors.Wrap(queryErr, "failed on get items info")
 	}
@@ -393,6 +561,7 @@ func GetItem(ctx context.Context, svcCtx *svc.ServerCtx, chain string, chainID i
 		itemDetail.CollectionAddress = item.CollectionAddress
 		itemDetail.TokenID = item.TokenId
 		itemDetail.OwnerAddress = item.Owner
+		itemDetail.IndexedAt = item.UpdateTime / 1000
 		// 设置collection级别的最高出价信息
 		itemDetail.BidOrderID = collectionBestBid.OrderID
 		itemDetail.BidExpireTime = collectionBestBid.ExpireTime
@@ -403,6 +572,7 @@ func GetItem(ctx context.Context, svcCtx *svc.ServerCtx, chain string, chainID i
 		itemDetail.BidType = getBidType(collectionBestBid.OrderType)
 		itemDetail.BidSize = collectionBestBid.Size
 		itemDetail.BidUnfilled = collectionBestBid.QuantityRemaining
+		itemDetail.BidCurrency, itemDetail.BidCurrencyAddress = ResolveCurrency(svcCtx, chain, collectionBestBid.CurrencyAddress)
 	}
 
 	// 如果item级别的最高出价大于collection级别的最高出价,则使用item级别的出价信息
@@ -418,6 +588,7 @@ func GetItem(ctx context.Context, svcCtx *svc.ServerCtx, chain string, chainID i
 			itemDetail.BidType = getBidType(bidOrder.OrderType)
 			itemDetail.BidSize = bidOrder.Size
 			itemDetail.BidUnfilled = bidOrder.QuantityRemaining
+			itemDetail.BidCurrency, itemDetail.BidCurrencyAddress = ResolveCurrency(svcCtx, chain, bidOrder.CurrencyAddress)
 		}
 	}
 
@@ -431,10 +602,12 @@ func GetItem(ctx context.Context, svcCtx *svc.ServerCtx, chain string, chainID i
 		itemDetail.ListSalt = itemListInfo.ListSalt
 		itemDetail.ListMaker = itemListInfo.ListMaker
 	}
+	setItemListings(&itemDetail, itemListings[strings.ToLower(tokenID)])
 
 	// 设置collection信息
 	if collection != nil {
 		itemDetail.CollectionName = collection.Name
+		itemDetail.TokenStandard = tokenStandardName(collection.TokenStandard)
 		itemDetail.FloorPrice = collection.FloorPrice
 		itemDetail.CollectionImageURI = collection.ImageUri
 		if itemDetail.Name == "" {
@@ -465,87 +638,47 @@ func GetItem(ctx context.Context, svcCtx *svc.ServerCtx, chain string, chainID i
 		}
 	}
 
+	itemDetail.AsOf = time.Now().Unix()
+
 	return &types.ItemDetailInfoResp{
 		Result: itemDetail,
 	}, nil
 }
 
-// GetItemTopTraitPrice 获取指定 token ids的Trait的最高价格信息
-func GetItemTopTraitPrice(ctx context.Context, svcCtx *svc.ServerCtx, chain, collectionAddr string, tokenIDs []string) (*types.ItemTopTraitResp, error) {
-	// 1. 查询Trait对应的最低挂单价格
-	traitsPrice, err := svcCtx.Dao.QueryTraitsPrice(ctx, chain, collectionAddr, tokenIDs)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed on calc top trait")
-	}
+// DefaultHistorySalesTimeRange 未指定时间范围时默认查询最近7天的成交
+const DefaultHistorySalesTimeRange = "7d"
 
-	// 2. 空结果处理
-	if len(traitsPrice) == 0 {
-		return &types.ItemTopTraitResp{
-			Result: []types.TraitPrice{},
-		}, nil
-	}
+// HistorySalesTimeRanges 成交历史支持的时间范围(秒), all 表示不限制时间
+var HistorySalesTimeRanges = map[string]int64{
+	"24h": 24 * 60 * 60,
+	"7d":  7 * 24 * 60 * 60,
+	"30d": 30 * 24 * 60 * 60,
+	"all": 0,
+}
 
-	// 3. 构建 Trait -> 最低挂单价格映射
-	traitsPrices := make(map[string]decimal.Decimal)
-	for _, traitPrice := range traitsPrice {
-		traitsPrices[strings.ToLower(fmt.Sprintf("%s:%s", traitPrice.Trait, traitPrice.TraitValue))] = traitPrice.Price
-	}
+// GetHistorySalesPrice 分页获取集合的成交历史, 支持按时间范围和价格区间过滤
+// between 不为空时按指定的时间区间过滤, 忽略 timeRange
+func GetHistorySalesPrice(ctx context.Context, svcCtx *svc.ServerCtx, chain, collectionAddr, timeRange string, between *types.TimeRange, minPrice, maxPrice *decimal.Decimal, page, pageSize int) (*types.PageResp, error) {
+	ctx, span := tracing.Start(ctx, "service.GetHistorySalesPrice")
+	defer span.End()
 
-	// 4. 查询指定 token ids的 所有Trait
-	traits, err := svcCtx.Dao.QueryItemsTraits(ctx, chain, collectionAddr, tokenIDs)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed on query items trait")
+	duration, ok := HistorySalesTimeRanges[timeRange]
+	if !ok {
+		return nil, ErrInvalidFilter
 	}
 
-	// 5. 计算指定 token ids的 最高价值 Trait
-	topTraits := make(map[string]types.TraitPrice)
-	for _, trait := range traits {
-		key := strings.ToLower(fmt.Sprintf("%s:%s", trait.Trait, trait.TraitValue))
-		price, ok := traitsPrices[key]
-		if ok {
-			topPrice, ok := topTraits[trait.TokenId]
-			// 如果已有最高价且当前价格不高于最高价,跳过
-			if ok {
-				if price.LessThanOrEqual(topPrice.Price) {
-					continue
-				}
-			}
-
-			// 更新最高价值 Trait
-			topTraits[trait.TokenId] = types.TraitPrice{
-				CollectionAddress: collectionAddr,
-				TokenID:           trait.TokenId,
-				Trait:             trait.Trait,
-				TraitValue:        trait.TraitValue,
-				Price:             price,
-			}
-		}
-	}
-
-	// 6. 整理返回结果
-	var results []types.TraitPrice
-	for _, topTrait := range topTraits {
-		results = append(results, topTrait)
+	filter := types.HistorySalesFilter{
+		MinPrice: minPrice,
+		MaxPrice: maxPrice,
 	}
-
-	return &types.ItemTopTraitResp{
-		Result: results,
-	}, nil
-}
-
-func GetHistorySalesPrice(ctx context.Context, svcCtx *svc.ServerCtx, chain, collectionAddr, duration string) ([]types.HistorySalesPriceInfo, error) {
-	var durationTimeStamp int64
-	if duration == "24h" {
-		durationTimeStamp = 24 * 60 * 60
-	} else if duration == "7d" {
-		durationTimeStamp = 7 * 24 * 60 * 60
-	} else if duration == "30d" {
-		durationTimeStamp = 30 * 24 * 60 * 60
-	} else {
-		return nil, errors.New("only support 24h/7d/30d")
+	switch {
+	case between != nil:
+		filter.Since, filter.Until = between.Since, between.Until
+	case duration > 0:
+		filter.Since = time.Now().Unix() - duration
 	}
 
-	historySalesPriceInfo, err := svcCtx.Dao.QueryHistorySalesPriceInfo(ctx, chain, collectionAddr, durationTimeStamp)
+	historySalesPriceInfo, total, err := svcCtx.Dao.QueryHistorySalesPriceInfo(ctx, chain, collectionAddr, filter, page, pageSize)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed on get history sales price info")
 	}
@@ -560,16 +693,110 @@ func GetHistorySalesPrice(ctx context.Context, svcCtx *svc.ServerCtx, chain, col
 		}
 	}
 
-	return res, nil
+	return &types.PageResp{
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+		Items:    res,
+	}, nil
+}
+
+// GetItemPriceHistory 分页获取单个NFT Item的历史成交记录
+// 从未成交过的Item返回空列表
+func GetItemPriceHistory(ctx context.Context, svcCtx *svc.ServerCtx, chain, collectionAddr, tokenID string, page, pageSize int) (*types.PageResp, error) {
+	ctx, span := tracing.Start(ctx, "service.GetItemPriceHistory")
+	defer span.End()
+
+	sales, total, err := svcCtx.Dao.QueryItemSalesHistory(ctx, chain, collectionAddr, tokenID, page, pageSize)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get item sales history")
+	}
+
+	res := make([]types.ItemSaleInfo, len(sales))
+	for i, sale := range sales {
+		currency, currencyAddr := ResolveCurrency(svcCtx, chain, sale.CurrencyAddress)
+		// 成交活动中 maker 为卖家, taker 为买家
+		res[i] = types.ItemSaleInfo{
+			Price:           sale.Price,
+			Currency:        currency,
+			CurrencyAddress: currencyAddr,
+			Seller:          sale.Maker,
+			Buyer:           sale.Taker,
+			MarketplaceID:   sale.MarketplaceID,
+			TxHash:          sale.TxHash,
+			TimeStamp:       sale.EventTime,
+		}
+	}
+
+	return &types.PageResp{
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+		Items:    res,
+	}, nil
 }
 
 // GetItemOwner 获取NFT Item的所有者信息
+// 1. 根据集合记录判断合约实现标准, 未记录时通过 ERC-165 从链上识别
+// 2. ERC-1155 从数据库查询所有持有者, 持有数量通过 balanceOfBatch 从链上读取
+// 3. ERC-721 从链上获取唯一持有者并更新数据库
+// 4. 标准仍无法识别时, 数据库中存在多个持有者则按 ERC-1155 返回, 否则按 ERC-721 处理;
+// ownerOf 回滚且数据库中有持有者时返回持有者列表, 不返回404
 func GetItemOwner(ctx context.Context, svcCtx *svc.ServerCtx, chainID int64, chain, collectionAddr, tokenID string) (*types.ItemOwner, error) {
+	ctx, span := tracing.Start(ctx, "service.GetItemOwner")
+	defer span.End()
+
+	standard := TokenStandardUnknown
+	collection, err := svcCtx.Dao.QueryCollectionInfo(ctx, chain, collectionAddr)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.Wrap(err, "failed on get collection info")
+	}
+	if err == nil {
+		standard = tokenStandardName(collection.TokenStandard)
+	}
+	if standard == TokenStandardUnknown {
+		// 识别失败时继续按数据库中的持有者判断
+		if detected, err := detectTokenStandard(ctx, svcCtx, int(chainID), collectionAddr); err == nil {
+			standard = detected
+		} else {
+			xzap.WithContext(ctx).Warn("failed on detect token standard", zap.String("address", collectionAddr), zap.Error(err))
+		}
+	}
+
+	var holders []types.ItemOwnerBalance
+	if standard != TokenStandardERC721 {
+		holders, err = svcCtx.Dao.QueryItemHolders(ctx, chain, collectionAddr, tokenID)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed on get item holders")
+		}
+		if standard == TokenStandardERC1155 || len(holders) > 1 {
+			return itemHoldersOwner(ctx, svcCtx, chainID, collectionAddr, tokenID, standard, holders)
+		}
+	}
+
 	// 从链上获取NFT所有者地址
-	address, err := svcCtx.NodeSrvs[chainID].FetchNftOwner(collectionAddr, tokenID)
+	nodeSrv, ok := svcCtx.NodeSrvs[chainID]
+	if !ok || nodeSrv == nil {
+		return nil, ErrUpstreamRPC
+	}
+	address, err := nodeSrv.FetchNftOwner(collectionAddr, tokenID)
+	metrics.ObserveRPC(chain, "FetchNftOwner", err)
 	if err != nil {
+		// ownerOf 回滚说明 token 不存在, 直接返回404; 其他错误重试后仍失败时返回502
+		// 标准未知时回滚也可能是未实现 ownerOf 的 ERC-1155 合约, 数据库中有持有者时返回持有者列表
+		if nodeclient.IsReverted(err) {
+			if len(holders) == 0 {
+				return nil, ErrItemNotFound
+			}
+			res, err := itemHoldersOwner(ctx, svcCtx, chainID, collectionAddr, tokenID, standard, holders)
+			if errors.Is(err, ErrUnsupportedContract) {
+				// balanceOfBatch 也回滚, 不是 ERC-1155 合约, token 不存在
+				return nil, ErrItemNotFound
+			}
+			return res, err
+		}
 		xzap.WithContext(ctx).Error("failed on fetch nft owner onchain", zap.Error(err))
-		return nil, errcode.ErrUnexpected
+		return nil, ErrUpstreamRPC
 	}
 
 	// 将地址转换为校验和格式
@@ -588,10 +815,40 @@ func GetItemOwner(ctx context.Context, svcCtx *svc.ServerCtx, chainID int64, cha
 	return &types.ItemOwner{
 		CollectionAddress: collectionAddr,
 		TokenID:           tokenID,
+		TokenStandard:     standard,
 		Owner:             owner,
 	}, nil
 }
 
+// itemHoldersOwner 返回 Item 的持有者列表, 每个持有者的持有数量从链上读取
+// 数据库中的持有记录可能滞后于链上, 链上数量为0的持有者也原样返回
+func itemHoldersOwner(ctx context.Context, svcCtx *svc.ServerCtx, chainID int64, collectionAddr, tokenID, standard string, holders []types.ItemOwnerBalance) (*types.ItemOwner, error) {
+	if holders == nil {
+		holders = []types.ItemOwnerBalance{}
+	}
+
+	owners := make([]string, len(holders))
+	tokenIDs := make([]string, len(holders))
+	for i, holder := range holders {
+		owners[i] = holder.Owner
+		tokenIDs[i] = tokenID
+	}
+	balances, err := fetchERC1155Balances(ctx, svcCtx, chainID, collectionAddr, owners, tokenIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range holders {
+		holders[i].Balance = balances[i]
+	}
+
+	return &types.ItemOwner{
+		CollectionAddress: collectionAddr,
+		TokenID:           tokenID,
+		TokenStandard:     standard,
+		Owners:            holders,
+	}, nil
+}
+
 // GetItemTraits 获取NFT的 Trait信息
 // 主要功能:
 // 1. 并发查询三个信息:
@@ -602,7 +859,10 @@ func GetItemOwner(ctx context.Context, svcCtx *svc.ServerCtx, chainID int64, cha
 // 2. 计算每个 Trait的百分比
 // 3. 组装返回数据
 func GetItemTraits(ctx context.Context, svcCtx *svc.ServerCtx, chain, collectionAddr, tokenID string) ([]types.TraitInfo, error) {
-	var traitInfos []types.TraitInfo
+	ctx, span := tracing.Start(ctx, "service.GetItemTraits")
+	defer span.End()
+
+	traitInfos := []types.TraitInfo{}
 	var itemTraits []multi.ItemTrait
 	var collection *multi.Collection
 	var traitCounts []types.TraitCount
@@ -694,14 +954,20 @@ func GetItemTraits(ctx context.Context, svcCtx *svc.ServerCtx, chain, collection
 
 // GetCollectionDetail 获取NFT集合的详细信息：基本信息、24小时交易信息、上架数量、地板价、卖单价格、总交易量
 func GetCollectionDetail(ctx context.Context, svcCtx *svc.ServerCtx, chain string, collectionAddr string) (*types.CollectionDetailResp, error) {
+	ctx, span := tracing.Start(ctx, "service.GetCollectionDetail")
+	defer span.End()
+
 	// 查询集合基本信息
 	collection, err := svcCtx.Dao.QueryCollectionInfo(ctx, chain, collectionAddr)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCollectionNotFound
+		}
 		return nil, errors.Wrap(err, "failed on get collection info")
 	}
 
 	// 获取集合24小时交易信息
-	tradeInfos, err := svcCtx.Dao.GetTradeInfoByCollection(chain, collectionAddr, "1d")
+	tradeInfos, err := svcCtx.Dao.GetTradeInfoByCollection(ctx, chain, collectionAddr, "1d")
 	if err != nil {
 		xzap.WithContext(ctx).Error("failed on get collection trade info", zap.Error(err))
 		//return nil, errcode.NewCustomErr("cache error")
@@ -752,13 +1018,19 @@ func GetCollectionDetail(ctx context.Context, svcCtx *svc.ServerCtx, chain strin
 
 	// 查询总交易量
 	var allVol decimal.Decimal
-	collectionVol, err := svcCtx.Dao.GetCollectionVolume(chain, collectionAddr)
+	collectionVol, err := svcCtx.Dao.GetCollectionVolume(ctx, chain, collectionAddr)
 	if err != nil {
 		xzap.WithContext(ctx).Error("failed on query collection all volume", zap.Error(err))
 	} else {
 		allVol = collectionVol
 	}
 
+	// 被隐藏的集合按地址直接查询时仍然返回, 并标记为 flagged
+	flagged, err := svcCtx.Dao.IsCollectionHidden(ctx, collection.ChainId, collectionAddr)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get collection flag")
+	}
+
 	// 构建返回结果
 	detail := types.CollectionDetail{
 		ImageUri:    collection.ImageUri, // svcCtx.ImageMgr.GetFileUrl(collection.ImageUri),
@@ -773,6 +1045,7 @@ func GetCollectionDetail(ctx context.Context, svcCtx *svc.ServerCtx, chain strin
 		ListAmount:  listed,
 		TotalSupply: collection.ItemAmount,
 		OwnerAmount: collection.OwnerAmount,
+		Flagged:     flagged,
 	}
 
 	return &types.CollectionDetailResp{
@@ -780,22 +1053,81 @@ func GetCollectionDetail(ctx context.Context, svcCtx *svc.ServerCtx, chain strin
 	}, nil
 }
 
+// refreshFlight 合并同一进程内针对同一 item 的并发刷新请求
+var refreshFlight singleflight.Group
+
+// enqueueItemRefresh 将 item 加入元数据刷新队列, 返回刷新时间以及是否处于冷却期
+var enqueueItemRefresh = mq.AddSingleItemToRefreshMetadataQueue
+
+// refreshFlightKey 元数据刷新的合并键, 格式为 chainID:collection:tokenID
+func refreshFlightKey(chainId int64, collectionAddress, tokenId string) string {
+	return fmt.Sprintf("%d:%s:%s", chainId, strings.ToLower(collectionAddress), tokenId)
+}
+
 // RefreshItemMetadata refresh item meta data.
-func RefreshItemMetadata(ctx context.Context, svcCtx *svc.ServerCtx, chainName string, chainId int64, collectionAddress, tokenId string) error {
-	if err := mq.AddSingleItemToRefreshMetadataQueue(svcCtx.KvStore, svcCtx.C.ProjectCfg.Name, chainName, chainId, collectionAddress, tokenId); err != nil {
-		xzap.WithContext(ctx).Error("failed on add item to refresh queue", zap.Error(err), zap.String("collection address: ", collectionAddress), zap.String("item_id", tokenId))
-		return errcode.ErrUnexpected
+// 1. 同一进程内同一 item 的并发请求通过 singleflight 合并为一次入队操作, 所有调用方共享结果
+// 2. 不同实例之间通过 Redis 冷却期去重, 冷却期内重复刷新直接返回上一次的刷新时间
+// 3. 调用方请求取消时立即返回, 已经开始的入队操作继续执行并供其他调用方使用
+func RefreshItemMetadata(ctx context.Context, svcCtx *svc.ServerCtx, chainName string, chainId int64, collectionAddress, tokenId string) (*types.ItemMetadataRefreshResp, error) {
+	ctx, span := tracing.Start(ctx, "service.RefreshItemMetadata")
+	defer span.End()
+
+	key := refreshFlightKey(chainId, collectionAddress, tokenId)
+	ch := refreshFlight.DoChan(key, func() (interface{}, error) {
+		refreshedAt, cached, err := enqueueItemRefresh(svcCtx.KvStore, svcCtx.C.ProjectCfg.Name, chainName, chainId, collectionAddress, tokenId)
+		if err != nil {
+			return nil, err
+		}
+
+		result := "Success to joined the refresh queue and waiting for refresh."
+		if cached {
+			result = "Item was refreshed recently, please try again later."
+		}
+
+		return &types.ItemMetadataRefreshResp{
+			Result:      result,
+			RefreshedAt: refreshedAt,
+			Cached:      cached,
+		}, nil
+	})
+
+	var res singleflight.Result
+	select {
+	case res = <-ch:
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "failed on wait item refresh")
+	}
+	if res.Err != nil {
+		xzap.WithContext(ctx).Error("failed on add item to refresh queue", zap.Error(res.Err), zap.String("collection address: ", collectionAddress), zap.String("item_id", tokenId))
+		return nil, errcode.ErrUnexpected
+	}
+	if res.Shared {
+		xzap.WithContext(ctx).Debug("item refresh coalesced", zap.String("key", key))
 	}
 
-	return nil
+	resp := res.Val.(*types.ItemMetadataRefreshResp)
+	if !resp.Cached {
+		invalidateCollectionTraits(ctx, svcCtx, chainName, collectionAddress)
+		// 刷新后重新查询链上数据, 清除之前的负缓存
+		if _, err := svcCtx.KvStore.Del(itemOnChainCacheKey(chainId, collectionAddress, tokenId)); err != nil {
+			xzap.WithContext(ctx).Warn("failed on clear item onchain cache", zap.Error(err))
+		}
+	}
 
+	return resp, nil
 }
 
 func GetItemImage(ctx context.Context, svcCtx *svc.ServerCtx, chain string, collectionAddress, tokenId string) (*types.ItemImage, error) {
+	ctx, span := tracing.Start(ctx, "service.GetItemImage")
+	defer span.End()
+
 	items, err := svcCtx.Dao.QueryCollectionItemsImage(ctx, chain, collectionAddress, []string{tokenId})
-	if err != nil || len(items) == 0 {
+	if err != nil {
 		return nil, errors.Wrap(err, "failed on get item image")
 	}
+	if len(items) == 0 {
+		return nil, ErrItemNotFound
+	}
 	var imageUri string
 	if items[0].IsUploadedOss {
 		imageUri = items[0].OssUri // svcCtx.ImageMgr.GetSmallSizeImageUrl(items[0].OssUri)
@@ -803,6 +1135,16 @@ func GetItemImage(ctx context.Context, svcCtx *svc.ServerCtx, chain string, coll
 		imageUri = items[0].ImageUri // svcCtx.ImageMgr.GetSmallSizeImageUrl(items[0].ImageUri)
 	}
 
+	// ipfs:// 地址转换为可访问的网关地址, 所有网关都不可用时返回原地址
+	if svcCtx.Ipfs != nil && ipfs.IsIpfsURI(imageUri) {
+		resolved, err := svcCtx.Ipfs.Resolve(ctx, imageUri)
+		if err != nil {
+			xzap.WithContext(ctx).Warn("failed on resolve ipfs image uri", zap.String("image_uri", imageUri), zap.Error(err))
+		} else {
+			imageUri = resolved
+		}
+	}
+
 	return &types.ItemImage{
 		CollectionAddress: collectionAddress,
 		TokenID:           tokenId,
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/joinmouse/EasySwapBackend/src/common/tracing"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+	"github.com/joinmouse/EasySwapBackend/src/types/v1"
+)
+
+// MaxCompareCollections 单次对比的最大集合数量
+const MaxCompareCollections = 5
+
+// collectionNotIndexedReason 未收录集合在对比结果中的说明
+const collectionNotIndexedReason = "Collection is not indexed."
+
+// CompareCollections 对比多个集合的统计信息
+// 1. 批量查询集合信息, 未收录的集合放入 skipped 列表, 不影响其他集合
+// 2. 并发查询各集合的聚合统计信息(与 GetCollectionStats 相同, 优先读取缓存)
+// 3. 结果按请求中的地址顺序返回
+func CompareCollections(ctx context.Context, svcCtx *svc.ServerCtx, chain string, collectionAddrs []string) (*types.CollectionCompareResp, error) {
+	ctx, span := tracing.Start(ctx, "service.CompareCollections")
+	defer span.End()
+
+	collections, err := svcCtx.Dao.QueryCollectionsInfo(ctx, chain, collectionAddrs)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get collections info")
+	}
+	names := make(map[string]string, len(collections))
+	for _, collection := range collections {
+		names[strings.ToLower(collection.Address)] = collection.Name
+	}
+
+	// 每个协程只写入自己下标的元素, 不需要加锁
+	stats := make([]*types.CollectionStats, len(collectionAddrs))
+	var g errgroup.Group
+	for i, addr := range collectionAddrs {
+		i, addr := i, addr
+		if _, ok := names[strings.ToLower(addr)]; !ok {
+			continue
+		}
+		g.Go(func() error {
+			s, err := GetCollectionStats(ctx, svcCtx, chain, addr)
+			if err != nil {
+				// 查询期间集合被删除时按未收录处理
+				if errors.Is(err, ErrCollectionNotFound) {
+					return nil
+				}
+				return err
+			}
+			stats[i] = s
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	resp := &types.CollectionCompareResp{
+		Collections: make([]types.CollectionCompareItem, 0, len(collectionAddrs)),
+		Skipped:     []types.CollectionCompareSkipped{},
+	}
+	for i, addr := range collectionAddrs {
+		if stats[i] == nil {
+			resp.Skipped = append(resp.Skipped, types.CollectionCompareSkipped{
+				CollectionAddress: addr,
+				Reason:            collectionNotIndexedReason,
+			})
+			continue
+		}
+		resp.Collections = append(resp.Collections, types.CollectionCompareItem{
+			CollectionAddress: addr,
+			Name:              names[strings.ToLower(addr)],
+			CollectionStats:   *stats[i],
+		})
+	}
+
+	return resp, nil
+}
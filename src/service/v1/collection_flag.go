@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/joinmouse/EasySwapBase/logger/xzap"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/joinmouse/EasySwapBackend/src/common/tracing"
+	"github.com/joinmouse/EasySwapBackend/src/config"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+	"github.com/joinmouse/EasySwapBackend/src/types/v1"
+)
+
+// SetCollectionHidden 设置集合的隐藏标记
+// 被隐藏的集合不出现在搜索和排名中, 数据不会被删除, 按地址直接查询时返回 flagged 标记
+// 设置后使排行榜接口的响应缓存失效, 隐藏或恢复立即生效
+func SetCollectionHidden(ctx context.Context, svcCtx *svc.ServerCtx, chainID int, collectionAddr string, hidden bool, reason string) (*types.CollectionHiddenResp, error) {
+	ctx, span := tracing.Start(ctx, "service.SetCollectionHidden")
+	defer span.End()
+
+	chainCfg := chainConfigByID(svcCtx, chainID)
+	if chainCfg == nil {
+		return nil, ErrInvalidChainID
+	}
+
+	if _, err := svcCtx.Dao.QueryCollectionInfo(ctx, chainCfg.Name, collectionAddr); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCollectionNotFound
+		}
+		return nil, errors.Wrap(err, "failed on get collection info")
+	}
+
+	if err := svcCtx.Dao.SetCollectionHidden(ctx, chainID, collectionAddr, hidden, reason); err != nil {
+		return nil, errors.Wrap(err, "failed on set collection hidden")
+	}
+	// 排行榜接口的响应缓存中包含被隐藏的集合, 递增缓存版本号使其立即失效
+	if err := svcCtx.Dao.BumpApiCacheVersion(ctx, config.CacheRouteRanking); err != nil {
+		xzap.WithContext(ctx).Warn("failed on invalidate ranking api cache", zap.Error(err))
+	}
+
+	return &types.CollectionHiddenResp{
+		ChainID:           chainID,
+		CollectionAddress: strings.ToLower(collectionAddr),
+		Hidden:            hidden,
+		Reason:            reason,
+	}, nil
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/google/uuid"
+	"github.com/joinmouse/EasySwapBase/logger/xzap"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/joinmouse/EasySwapBackend/src/common/metrics"
+	"github.com/joinmouse/EasySwapBackend/src/common/tracing"
+	"github.com/joinmouse/EasySwapBackend/src/service/mq"
+	"github.com/joinmouse/EasySwapBackend/src/service/nodeclient"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+	"github.com/joinmouse/EasySwapBackend/src/types/v1"
+)
+
+// JobTypeCollectionIndex 集合收录任务, 由索引服务消费收录队列后更新状态
+const JobTypeCollectionIndex = "collection_index"
+
+const (
+	// CollectionIndexLockKey 同一集合同时只允许一个收录任务, 值为任务ID
+	CollectionIndexLockKey = "cache:es:collection:index:lock:%d:%s"
+	// CollectionIndexLockTTL 收录任务的去重时间(秒), 超时后集合仍未收录时允许重新提交
+	CollectionIndexLockTTL = 60 * 60
+
+	// CollectionIndexRPCTimeout 校验合约时链上调用的超时时间
+	CollectionIndexRPCTimeout = 5 * time.Second
+)
+
+var (
+	// supportsInterfaceSelector ERC-165 supportsInterface(bytes4) 的函数选择器
+	supportsInterfaceSelector = []byte{0x01, 0xff, 0xc9, 0xa7}
+	// ERC-721 和 ERC-1155 的 ERC-165 接口ID
+	erc721InterfaceID  = []byte{0x80, 0xac, 0x58, 0xcd}
+	erc1155InterfaceID = []byte{0xd9, 0xb6, 0x7a, 0x26}
+)
+
+// RequestCollectionIndex 提交集合收录请求
+// 1. 集合已收录时直接返回, Indexed 为 true
+// 2. 同一集合已有收录任务在进行时返回该任务, 不会重复校验和入队
+// 3. 通过链上节点校验地址是合约, 且通过 ERC-165 声明支持 ERC-721 或 ERC-1155
+// 4. 创建收录任务并加入收录队列, 由索引服务异步同步集合数据
+func RequestCollectionIndex(ctx context.Context, svcCtx *svc.ServerCtx, chainName string, chainID int, collectionAddr string) (*types.CollectionIndexResp, error) {
+	ctx, span := tracing.Start(ctx, "service.RequestCollectionIndex")
+	defer span.End()
+
+	if chainConfigByID(svcCtx, chainID) == nil {
+		return nil, ErrInvalidChainID
+	}
+
+	res := &types.CollectionIndexResp{ChainID: chainID, CollectionAddress: collectionAddr}
+
+	// 1. 已收录
+	collection, err := svcCtx.Dao.QueryCollectionInfo(ctx, chainName, collectionAddr)
+	if err == nil {
+		res.Indexed = true
+		res.TokenStandard = tokenStandardName(collection.TokenStandard)
+		return res, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.Wrap(err, "failed on get collection info")
+	}
+
+	// 2. 已有收录任务
+	lockKey := fmt.Sprintf(CollectionIndexLockKey, chainID, strings.ToLower(collectionAddr))
+	running, err := runningIndexJob(ctx, svcCtx, lockKey)
+	if err != nil {
+		return nil, err
+	}
+	if running != nil {
+		res.Job = running
+		return res, nil
+	}
+
+	// 3. 校验合约
+	standard, err := detectTokenStandard(ctx, svcCtx, chainID, collectionAddr)
+	if err != nil {
+		return nil, err
+	}
+	res.TokenStandard = standard
+
+	// 4. 创建任务并入队, 并发请求中只有抢到锁的一个会入队
+	job := &types.JobInfo{
+		JobID:     uuid.NewString(),
+		Type:      JobTypeCollectionIndex,
+		Status:    JobStatusPending,
+		Total:     1,
+		CreatedAt: time.Now().Unix(),
+	}
+	ok, err := svcCtx.KvStore.SetnxEx(lockKey, job.JobID, CollectionIndexLockTTL)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on lock collection index")
+	}
+	if !ok {
+		// 校验期间其他请求已经创建了任务
+		running, err := runningIndexJob(ctx, svcCtx, lockKey)
+		if err != nil {
+			return nil, err
+		}
+		if running == nil {
+			return nil, errors.New("collection index job is in progress")
+		}
+		res.Job = running
+		return res, nil
+	}
+
+	if err := saveJob(svcCtx, job); err != nil {
+		_, _ = svcCtx.KvStore.Del(lockKey)
+		return nil, err
+	}
+	if err := mq.AddCollectionToIndexQueue(svcCtx.KvStore, svcCtx.C.ProjectCfg.Name, chainName, types.IndexCollection{
+		ChainID:        int64(chainID),
+		CollectionAddr: collectionAddr,
+		TokenStandard:  standard,
+		JobID:          job.JobID,
+	}); err != nil {
+		// 入队失败时释放锁, 允许客户端立即重试
+		_, _ = svcCtx.KvStore.Del(lockKey)
+		return nil, err
+	}
+	res.Job = job
+
+	return res, nil
+}
+
+// runningIndexJob 查询集合正在进行的收录任务, 没有任务时返回 nil
+func runningIndexJob(ctx context.Context, svcCtx *svc.ServerCtx, lockKey string) (*types.JobInfo, error) {
+	runningID, err := svcCtx.KvStore.Get(lockKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get running index job")
+	}
+	if runningID == "" {
+		return nil, nil
+	}
+
+	job, err := GetJob(ctx, svcCtx, runningID)
+	if err != nil {
+		if errors.Is(err, ErrJobNotFound) {
+			// 锁存在但任务记录已丢失, 等待锁过期
+			return nil, errors.New("collection index job is in progress")
+		}
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// detectTokenStandard 通过链上调用识别集合合约的实现标准
+// 地址上没有合约代码时返回 ErrNotContract, 未通过 ERC-165 声明支持 ERC-721 或 ERC-1155 时返回 ErrUnsupportedContract
+// 节点不可用时返回 ErrUpstreamRPC
+func detectTokenStandard(ctx context.Context, svcCtx *svc.ServerCtx, chainID int, collectionAddr string) (string, error) {
+	nodeSrv, ok := svcCtx.NodeSrvs[int64(chainID)]
+	if !ok || nodeSrv == nil {
+		return "", ErrUpstreamRPC
+	}
+	client, ok := nodeSrv.NodeClient.(*nodeclient.FailoverClient)
+	if !ok {
+		return "", ErrUpstreamRPC
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, CollectionIndexRPCTimeout)
+	defer cancel()
+
+	addr := common.HexToAddress(collectionAddr)
+	code, err := client.CodeAt(ctx, addr, nil)
+	metrics.ObserveRPC(nodeSrv.ChainName, "CodeAt", err)
+	if err != nil {
+		xzap.WithContext(ctx).Error("failed on get contract code", zap.String("address", collectionAddr), zap.Error(err))
+		return "", ErrUpstreamRPC
+	}
+	if len(code) == 0 {
+		return "", ErrNotContract
+	}
+
+	for _, candidate := range []struct {
+		standard    string
+		interfaceID []byte
+	}{
+		{TokenStandardERC721, erc721InterfaceID},
+		{TokenStandardERC1155, erc1155InterfaceID},
+	} {
+		result, err := nodeSrv.NodeClient.CallContract(ctx, ethereum.CallMsg{
+			To:   &addr,
+			Data: append(append([]byte{}, supportsInterfaceSelector...), common.RightPadBytes(candidate.interfaceID, 32)...),
+		}, nil)
+		metrics.ObserveRPC(nodeSrv.ChainName, "supportsInterface", err)
+		if err != nil {
+			if !nodeclient.IsReverted(err) {
+				xzap.WithContext(ctx).Error("failed on call supportsInterface", zap.String("address", collectionAddr), zap.Error(err))
+				return "", ErrUpstreamRPC
+			}
+			// 调用回滚说明合约没有实现 ERC-165
+			return "", ErrUnsupportedContract
+		}
+		if len(result) >= 32 && result[31] == 1 {
+			return candidate.standard, nil
+		}
+	}
+
+	return "", ErrUnsupportedContract
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/joinmouse/EasySwapBase/stores/xkv"
+
+	"github.com/joinmouse/EasySwapBackend/src/config"
+	"github.com/joinmouse/EasySwapBackend/src/types/v1"
+)
+
+// stubEnqueue 替换元数据刷新入队函数, 统计调用次数, 在 release 关闭前阻塞
+func stubEnqueue(t *testing.T) (calls *atomic.Int32, release chan struct{}) {
+	t.Helper()
+
+	calls = &atomic.Int32{}
+	release = make(chan struct{})
+	orig := enqueueItemRefresh
+	enqueueItemRefresh = func(_ *xkv.Store, _, _ string, _ int64, _, _ string) (int64, bool, error) {
+		calls.Add(1)
+		<-release
+		return 1700000000, false, nil
+	}
+	t.Cleanup(func() { enqueueItemRefresh = orig })
+
+	return calls, release
+}
+
+func TestRefreshItemMetadataCoalesces(t *testing.T) {
+	svcCtx, _ := newStubServerCtx(t)
+	svcCtx.C.ProjectCfg = &config.ProjectCfg{Name: "test"}
+	calls, release := stubEnqueue(t)
+
+	const n = 20
+	var wg sync.WaitGroup
+	results := make([]*types.ItemMetadataRefreshResp, n)
+	errs := make([]error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// 集合地址大小写不同的请求也合并为一次
+			addr := "0x00000000000000000000000000000000000000ab"
+			if i%2 == 1 {
+				addr = "0x00000000000000000000000000000000000000AB"
+			}
+			results[i], errs[i] = RefreshItemMetadata(context.Background(), svcCtx, "eth", 1, addr, "1")
+		}(i)
+	}
+
+	// 等待所有请求进入 singleflight 后再让入队操作返回
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("enqueue called %d times, want 1", got)
+	}
+	for i := 0; i < n; i++ {
+		if errs[i] != nil {
+			t.Fatalf("request %d error: %v", i, errs[i])
+		}
+		if results[i].RefreshedAt != 1700000000 || results[i].Cached {
+			t.Errorf("request %d result = %+v", i, results[i])
+		}
+	}
+}
+
+func TestRefreshItemMetadataCallerCanceled(t *testing.T) {
+	svcCtx, _ := newStubServerCtx(t)
+	svcCtx.C.ProjectCfg = &config.ProjectCfg{Name: "test"}
+	calls, release := stubEnqueue(t)
+	defer close(release)
+
+	// 调用方取消时立即返回, 入队操作仍在后台进行
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := RefreshItemMetadata(ctx, svcCtx, "eth", 1, testCollection, "9"); err == nil {
+		t.Fatal("RefreshItemMetadata() with canceled context returned no error")
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("enqueue called %d times, want 1", got)
+	}
+}
+
+func TestGetItemImageNotFound(t *testing.T) {
+	svcCtx, _ := newStubServerCtx(t)
+
+	if _, err := GetItemImage(context.Background(), svcCtx, "eth", testCollection, "1"); err != ErrItemNotFound {
+		t.Errorf("GetItemImage() error = %v, want %v", err, ErrItemNotFound)
+	}
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/joinmouse/EasySwapBackend/src/config"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+)
+
+const (
+	// ZeroAddress 订单使用原生代币支付时的币种地址
+	ZeroAddress = "0x0000000000000000000000000000000000000000"
+	// DefaultNativeSymbol 链未配置原生代币符号时使用的默认值
+	DefaultNativeSymbol = "ETH"
+)
+
+func chainConfigByName(svcCtx *svc.ServerCtx, chain string) *config.ChainSupported {
+	for _, c := range svcCtx.C.ChainSupported {
+		if c != nil && strings.EqualFold(c.Name, chain) {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// nativeSymbol 获取链的原生代币符号
+func nativeSymbol(chainCfg *config.ChainSupported) string {
+	if chainCfg == nil || chainCfg.NativeSymbol == "" {
+		return DefaultNativeSymbol
+	}
+
+	return chainCfg.NativeSymbol
+}
+
+// ResolveCurrency 根据订单的支付代币地址解析币种符号
+// 1. 地址为空或零地址时返回链的原生代币符号, 代币地址返回空
+// 2. ERC-20 代币从链配置的 currencies 中查找符号, 未配置时符号为空
+func ResolveCurrency(svcCtx *svc.ServerCtx, chain, currencyAddr string) (symbol string, address string) {
+	chainCfg := chainConfigByName(svcCtx, chain)
+	if currencyAddr == "" || strings.EqualFold(currencyAddr, ZeroAddress) {
+		return nativeSymbol(chainCfg), ""
+	}
+
+	address = strings.ToLower(currencyAddr)
+	if chainCfg == nil {
+		return "", address
+	}
+	for _, currency := range chainCfg.Currencies {
+		if currency != nil && strings.EqualFold(currency.Address, currencyAddr) {
+			return currency.Symbol, address
+		}
+	}
+
+	return "", address
+}
+
+// currencyDecimals 获取支付代币的精度
+// 原生代币和未在链配置中找到的代币使用默认精度
+func currencyDecimals(svcCtx *svc.ServerCtx, chain, currencyAddr string) int32 {
+	chainCfg := chainConfigByName(svcCtx, chain)
+	if chainCfg == nil || currencyAddr == "" || strings.EqualFold(currencyAddr, ZeroAddress) {
+		return config.DefaultCurrencyDecimals
+	}
+	for _, currency := range chainCfg.Currencies {
+		if currency != nil && strings.EqualFold(currency.Address, currencyAddr) {
+			return currency.DecimalsOrDefault()
+		}
+	}
+
+	return config.DefaultCurrencyDecimals
+}
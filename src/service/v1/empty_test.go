@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/joinmouse/EasySwapBackend/src/types/v1"
+)
+
+const (
+	testCollection = "0x0000000000000000000000000000000000000001"
+	testUser       = "0x0000000000000000000000000000000000000002"
+)
+
+// assertEmptyList 断言响应序列化后 field 字段为 [] 而不是 null
+func assertEmptyList(t *testing.T, resp interface{}, path ...string) {
+	t.Helper()
+
+	raw, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatalf("marshal response: %v", err)
+	}
+	var node json.RawMessage = raw
+	for _, key := range path {
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(node, &obj); err != nil {
+			t.Fatalf("decode %s: %v", raw, err)
+		}
+		node = obj[key]
+	}
+	if string(node) != "[]" {
+		t.Errorf("%v = %s, want []; response: %s", path, node, raw)
+	}
+}
+
+func TestEmptyListResponses(t *testing.T) {
+	svcCtx, _ := newStubServerCtx(t)
+	ctx := context.Background()
+	users := []string{testUser}
+
+	t.Run("items", func(t *testing.T) {
+		resp, err := GetItems(ctx, svcCtx, "eth", types.CollectionItemFilterParams{Page: 1, PageSize: 10}, testCollection)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEmptyList(t, resp, "items")
+	})
+	t.Run("collection bids", func(t *testing.T) {
+		resp, err := GetBids(ctx, svcCtx, "eth", testCollection, 1, 10, false, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEmptyList(t, resp, "result")
+	})
+	t.Run("item bids", func(t *testing.T) {
+		resp, err := GetItemBidsInfo(ctx, svcCtx, "eth", testCollection, "1", 1, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEmptyList(t, resp, "result")
+	})
+	t.Run("activities", func(t *testing.T) {
+		resp, err := GetMultiChainActivities(ctx, svcCtx, []string{"eth"},
+			types.ActivityMultiChainFilterParams{ChainID: []int{1}, Page: 1, PageSize: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEmptyList(t, resp, "result")
+	})
+	t.Run("portfolio collections", func(t *testing.T) {
+		resp, err := GetMultiChainUserCollections(ctx, svcCtx, []int{1}, []string{"eth"}, users, "", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEmptyList(t, resp, "result", "collection_info")
+		assertEmptyList(t, resp, "result", "chain_info")
+	})
+	t.Run("portfolio items", func(t *testing.T) {
+		resp, err := GetMultiChainUserItems(ctx, svcCtx, []int{1}, []string{"eth"}, users, nil, 1, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEmptyList(t, resp, "result")
+	})
+	t.Run("portfolio listings", func(t *testing.T) {
+		resp, err := GetMultiChainUserListings(ctx, svcCtx, []int{1}, []string{"eth"}, users, nil, 1, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEmptyList(t, resp, "result")
+	})
+	t.Run("portfolio bids", func(t *testing.T) {
+		resp, err := GetMultiChainUserBids(ctx, svcCtx, []int{1}, []string{"eth"}, users, nil, 1, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEmptyList(t, resp, "result")
+	})
+}
+
+func TestEmptyBidsAndRankings(t *testing.T) {
+	bids := processBids(nil, nil, nil, testCollection)
+	assertEmptyList(t, struct {
+		Result []types.ItemBid `json:"result"`
+	}{bids}, "result")
+
+	for _, rankings := range [][][]*types.CollectionRankingInfo{nil, {}, {nil, {}}} {
+		merged := MergeRankings(rankings, RankingSortVolume, 10)
+		assertEmptyList(t, struct {
+			Result []*types.CollectionRankingInfo `json:"result"`
+		}{merged}, "result")
+	}
+}
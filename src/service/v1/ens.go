@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/joinmouse/EasySwapBase/chain"
+	"github.com/joinmouse/EasySwapBase/chain/nftchainservice"
+	"github.com/joinmouse/EasySwapBase/logger/xzap"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/joinmouse/EasySwapBackend/src/common/metrics"
+	"github.com/joinmouse/EasySwapBackend/src/common/tracing"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+)
+
+const (
+	// EnsNameCacheKey 地址反向解析得到的 ENS 名称缓存
+	EnsNameCacheKey = "cache:es:ens:name:%s"
+	// EnsNameTTL 解析到 ENS 名称时的缓存时间(秒)
+	EnsNameTTL = 24 * 60 * 60
+	// EnsNoNameTTL 地址没有设置 ENS 名称时的缓存时间(秒)
+	EnsNoNameTTL = 60 * 60
+	// EnsLookupFailedTTL 链上解析失败时的缓存时间(秒), 避免节点异常时每个请求都重试
+	EnsLookupFailedTTL = 5 * 60
+	// EnsResolveTimeout 单次请求中解析 ENS 名称的最长时间, 超时后未解析的地址不返回名称
+	EnsResolveTimeout = 2 * time.Second
+	// ensResolveWorkers 并发解析 ENS 名称的数量
+	ensResolveWorkers = 8
+	// ensNoName 地址没有 ENS 名称时写入缓存的值, 合法的 ENS 名称总是包含"."
+	ensNoName = "-"
+)
+
+var (
+	// ensRegistry ENS Registry 合约地址, 主网和测试网相同
+	ensRegistry = common.HexToAddress("0x00000000000C2E074eC69A0dFb2997BA6C7d2e1e")
+	// resolver(bytes32) 的函数选择器
+	ensResolverSelector = []byte{0x01, 0x78, 0xb8, 0xbf}
+	// name(bytes32) 的函数选择器
+	ensNameSelector = []byte{0x69, 0x1f, 0x34, 0x31}
+	// addr(bytes32) 的函数选择器
+	ensAddrSelector = []byte{0x3b, 0x3b, 0x57, 0xde}
+)
+
+// ResolveEnsNames 批量将地址反向解析为 ENS 名称, 返回地址(小写)到名称的映射
+// ENS 名称注册在以太坊主网上, 未配置主网节点时不解析
+// 解析是尽力而为的: 缓存读写失败、链上调用失败或超时的地址只是不出现在结果中, 不会返回错误
+// 没有名称和解析失败的结果也会缓存(负缓存), forceRefresh 为 true 时忽略负缓存重新解析, 解析成功后覆盖负缓存
+func ResolveEnsNames(ctx context.Context, svcCtx *svc.ServerCtx, addrs []string, forceRefresh bool) map[string]string {
+	ctx, span := tracing.Start(ctx, "service.ResolveEnsNames")
+	defer span.End()
+
+	names := make(map[string]string)
+	var pending []string
+	seen := make(map[string]bool)
+	for _, addr := range addrs {
+		addr = strings.ToLower(addr)
+		if seen[addr] || !common.IsHexAddress(addr) {
+			continue
+		}
+		seen[addr] = true
+
+		cached, err := svcCtx.KvStore.Get(fmt.Sprintf(EnsNameCacheKey, addr))
+		if err != nil {
+			xzap.WithContext(ctx).Warn("failed on get ens name cache", zap.String("address", addr), zap.Error(err))
+		}
+		switch {
+		case cached == "" || (cached == ensNoName && forceRefresh):
+			pending = append(pending, addr)
+		case cached == ensNoName:
+		default:
+			names[addr] = cached
+		}
+	}
+
+	nodeSrv, ok := svcCtx.NodeSrvs[int64(chain.EthChainID)]
+	if len(pending) == 0 || !ok || nodeSrv == nil {
+		return names
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, EnsResolveTimeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, ensResolveWorkers)
+	for _, addr := range pending {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(addr string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			name, err := lookupEnsName(ctx, nodeSrv, common.HexToAddress(addr))
+			value, ttl := name, EnsNameTTL
+			switch {
+			case err != nil:
+				xzap.WithContext(ctx).Debug("failed on lookup ens name", zap.String("address", addr), zap.Error(err))
+				// 超时是本次请求的解析预算用完, 不代表节点异常, 不写入负缓存
+				if ctx.Err() != nil {
+					return
+				}
+				value, ttl = ensNoName, EnsLookupFailedTTL
+			case name == "":
+				value, ttl = ensNoName, EnsNoNameTTL
+			}
+			if err := svcCtx.KvStore.Setex(fmt.Sprintf(EnsNameCacheKey, addr), value, ttl); err != nil {
+				xzap.WithContext(ctx).Warn("failed on cache ens name", zap.String("address", addr), zap.Error(err))
+			}
+			if name != "" {
+				mu.Lock()
+				names[addr] = name
+				mu.Unlock()
+			}
+		}(addr)
+	}
+	wg.Wait()
+
+	return names
+}
+
+// lookupEnsName 通过 ENS 反向记录查询地址的主名称
+// 反向记录可以由地址持有人任意设置, 因此还需要正向解析名称, 只有解析结果指回该地址时才采用
+// 地址没有设置反向记录或正向校验不通过时返回空字符串
+func lookupEnsName(ctx context.Context, nodeSrv *nftchainservice.Service, addr common.Address) (string, error) {
+	reverseNode := ensNamehash(strings.ToLower(strings.TrimPrefix(addr.Hex(), "0x")) + ".addr.reverse")
+	resolver, err := ensResolver(ctx, nodeSrv, reverseNode)
+	if err != nil || resolver == (common.Address{}) {
+		return "", err
+	}
+
+	result, err := ensCall(ctx, nodeSrv, resolver, ensNameSelector, reverseNode)
+	if err != nil {
+		return "", errors.Wrap(err, "failed on call ens name")
+	}
+	name, ok := decodeAbiString(result)
+	if !ok || !strings.Contains(name, ".") {
+		return "", nil
+	}
+
+	// 正向校验
+	node := ensNamehash(name)
+	resolver, err = ensResolver(ctx, nodeSrv, node)
+	if err != nil || resolver == (common.Address{}) {
+		return "", err
+	}
+	result, err = ensCall(ctx, nodeSrv, resolver, ensAddrSelector, node)
+	if err != nil {
+		return "", errors.Wrap(err, "failed on call ens addr")
+	}
+	if len(result) < 32 || common.BytesToAddress(result[:32]) != addr {
+		return "", nil
+	}
+
+	return name, nil
+}
+
+// ensResolver 查询 ENS 节点的解析器合约地址
+func ensResolver(ctx context.Context, nodeSrv *nftchainservice.Service, node []byte) (common.Address, error) {
+	result, err := ensCall(ctx, nodeSrv, ensRegistry, ensResolverSelector, node)
+	if err != nil {
+		return common.Address{}, errors.Wrap(err, "failed on call ens resolver")
+	}
+	if len(result) < 32 {
+		return common.Address{}, nil
+	}
+
+	return common.BytesToAddress(result[:32]), nil
+}
+
+// ensCall 调用参数为单个 bytes32 的 ENS 合约方法
+func ensCall(ctx context.Context, nodeSrv *nftchainservice.Service, to common.Address, selector []byte, node []byte) ([]byte, error) {
+	result, err := nodeSrv.NodeClient.CallContract(ctx, ethereum.CallMsg{
+		To:   &to,
+		Data: append(append([]byte{}, selector...), node...),
+	}, nil)
+	metrics.ObserveRPC(nodeSrv.ChainName, "ens", err)
+
+	return result, err
+}
+
+// ensNamehash 按 EIP-137 计算 ENS 名称的 namehash
+func ensNamehash(name string) []byte {
+	node := make([]byte, 32)
+	if name == "" {
+		return node
+	}
+
+	labels := strings.Split(name, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		node = crypto.Keccak256(node, crypto.Keccak256([]byte(labels[i])))
+	}
+
+	return node
+}
+
+// decodeAbiString 解码 ABI 编码的单个 string 返回值
+func decodeAbiString(data []byte) (string, bool) {
+	if len(data) < 64 {
+		return "", false
+	}
+
+	offset := new(big.Int).SetBytes(data[:32])
+	if !offset.IsUint64() || offset.Uint64() > uint64(len(data)-32) {
+		return "", false
+	}
+	start := offset.Uint64() + 32
+	length := new(big.Int).SetBytes(data[offset.Uint64():start])
+	if !length.IsUint64() || length.Uint64() > uint64(len(data))-start {
+		return "", false
+	}
+
+	return string(data[start : start+length.Uint64()]), true
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/joinmouse/EasySwapBase/logger/xzap"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/joinmouse/EasySwapBackend/src/common/metrics"
+	"github.com/joinmouse/EasySwapBackend/src/service/nodeclient"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+)
+
+const (
+	// ERC1155BalanceBatchSize 单次 balanceOfBatch 调用查询的最大数量
+	ERC1155BalanceBatchSize = 100
+	// ERC1155BalanceRPCTimeout 查询 ERC-1155 持有数量时链上调用的超时时间
+	ERC1155BalanceRPCTimeout = 5 * time.Second
+)
+
+// erc1155ABI ERC-1155 balanceOfBatch(address[],uint256[]) 的 ABI
+var erc1155ABI = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(`[{"type":"function","name":"balanceOfBatch","stateMutability":"view",` +
+		`"inputs":[{"name":"accounts","type":"address[]"},{"name":"ids","type":"uint256[]"}],` +
+		`"outputs":[{"name":"","type":"uint256[]"}]}]`))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}()
+
+// fetchERC1155Balances 通过 ERC-1155 balanceOfBatch 查询 owners[i] 持有 tokenIDs[i] 的数量
+// 数据库只记录持有者, 没有按持有者记录的余额, 持有数量只能从链上读取
+// 超过 ERC1155BalanceBatchSize 时分批调用; 数量超过 int64 范围时按 math.MaxInt64 返回
+// 节点不可用时返回 ErrUpstreamRPC, 合约不支持 balanceOfBatch(调用回滚)时返回 ErrUnsupportedContract
+func fetchERC1155Balances(ctx context.Context, svcCtx *svc.ServerCtx, chainID int64, collectionAddr string, owners, tokenIDs []string) ([]int64, error) {
+	if len(owners) != len(tokenIDs) {
+		return nil, errors.New("owners and token ids length mismatch")
+	}
+	if len(owners) == 0 {
+		return []int64{}, nil
+	}
+
+	nodeSrv, ok := svcCtx.NodeSrvs[chainID]
+	if !ok || nodeSrv == nil {
+		return nil, ErrUpstreamRPC
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ERC1155BalanceRPCTimeout)
+	defer cancel()
+
+	contract := common.HexToAddress(collectionAddr)
+	balances := make([]int64, 0, len(owners))
+	for start := 0; start < len(owners); start += ERC1155BalanceBatchSize {
+		end := start + ERC1155BalanceBatchSize
+		if end > len(owners) {
+			end = len(owners)
+		}
+
+		accounts := make([]common.Address, 0, end-start)
+		ids := make([]*big.Int, 0, end-start)
+		for i := start; i < end; i++ {
+			id, ok := new(big.Int).SetString(tokenIDs[i], 10)
+			if !ok {
+				return nil, errors.Errorf("invalid token id: %s", tokenIDs[i])
+			}
+			accounts = append(accounts, common.HexToAddress(owners[i]))
+			ids = append(ids, id)
+		}
+
+		data, err := erc1155ABI.Pack("balanceOfBatch", accounts, ids)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed on pack balanceOfBatch")
+		}
+		result, err := nodeSrv.NodeClient.CallContract(ctx, ethereum.CallMsg{To: &contract, Data: data}, nil)
+		metrics.ObserveRPC(nodeSrv.ChainName, "balanceOfBatch", err)
+		if err != nil {
+			if nodeclient.IsReverted(err) {
+				return nil, ErrUnsupportedContract
+			}
+			xzap.WithContext(ctx).Error("failed on call balanceOfBatch", zap.String("address", collectionAddr), zap.Error(err))
+			return nil, ErrUpstreamRPC
+		}
+
+		var batch []*big.Int
+		if err := erc1155ABI.UnpackIntoInterface(&batch, "balanceOfBatch", result); err != nil || len(batch) != len(ids) {
+			// 返回数据不符合 ERC-1155 规范, 按合约不支持处理
+			return nil, ErrUnsupportedContract
+		}
+		for _, balance := range batch {
+			if !balance.IsInt64() {
+				balances = append(balances, math.MaxInt64)
+				continue
+			}
+			balances = append(balances, balance.Int64())
+		}
+	}
+
+	return balances, nil
+}
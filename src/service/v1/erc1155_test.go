@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"database/sql/driver"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/joinmouse/EasySwapBase/chain/chainclient"
+	"github.com/joinmouse/EasySwapBase/chain/nftchainservice"
+	"github.com/joinmouse/EasySwapBase/stores/gdb/orderbookmodel/multi"
+)
+
+// stubChainClient 按 balances 返回 balanceOfBatch 结果, 未设置的持有者数量为0
+type stubChainClient struct {
+	chainclient.ChainClient
+	balances map[common.Address]int64
+	calls    int
+}
+
+func (c *stubChainClient) CallContract(_ context.Context, msg ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	c.calls++
+	method := erc1155ABI.Methods["balanceOfBatch"]
+	args, err := method.Inputs.Unpack(msg.Data[4:])
+	if err != nil {
+		return nil, err
+	}
+	accounts := args[0].([]common.Address)
+	balances := make([]*big.Int, len(accounts))
+	for i, account := range accounts {
+		balances[i] = big.NewInt(c.balances[account])
+	}
+	return method.Outputs.Pack(balances)
+}
+
+func TestFetchERC1155BalancesBatches(t *testing.T) {
+	svcCtx, _ := newStubServerCtx(t)
+	client := &stubChainClient{balances: map[common.Address]int64{common.HexToAddress(testOwner): 3}}
+	svcCtx.NodeSrvs = map[int64]*nftchainservice.Service{1: {NodeClient: client, ChainName: "eth"}}
+
+	owners := make([]string, ERC1155BalanceBatchSize+1)
+	tokenIDs := make([]string, len(owners))
+	for i := range owners {
+		owners[i] = "0x00000000000000000000000000000000000000bb"
+		tokenIDs[i] = "1"
+	}
+	owners[len(owners)-1] = testOwner
+
+	balances, err := fetchERC1155Balances(context.Background(), svcCtx, 1, testCollection, owners, tokenIDs)
+	if err != nil {
+		t.Fatalf("fetchERC1155Balances() error: %v", err)
+	}
+	if client.calls != 2 {
+		t.Errorf("balanceOfBatch calls = %d, want 2", client.calls)
+	}
+	if len(balances) != len(owners) || balances[0] != 0 || balances[len(balances)-1] != 3 {
+		t.Errorf("fetchERC1155Balances() = %v", balances)
+	}
+}
+
+func TestGetItemOwnerERC1155Balances(t *testing.T) {
+	svcCtx, stub := newStubServerCtx(t)
+	client := &stubChainClient{balances: map[common.Address]int64{common.HexToAddress(testOwner): 5}}
+	svcCtx.NodeSrvs = map[int64]*nftchainservice.Service{1: {NodeClient: client, ChainName: "eth"}}
+	stub.results = func(query string) *stubRows {
+		switch {
+		case strings.Contains(query, multi.CollectionTableName("eth")):
+			return &stubRows{
+				columns: []string{"address", "token_standard"},
+				values:  [][]driver.Value{{testCollection, int64(tokenStandardERC1155)}},
+			}
+		case strings.Contains(query, "distinct owner"):
+			return &stubRows{
+				columns: []string{"owner"},
+				values:  [][]driver.Value{{testOwner}, {testUser}},
+			}
+		}
+		return nil
+	}
+
+	owner, err := GetItemOwner(context.Background(), svcCtx, 1, "eth", testCollection, "1")
+	if err != nil {
+		t.Fatalf("GetItemOwner() error: %v", err)
+	}
+	if owner.TokenStandard != TokenStandardERC1155 || len(owner.Owners) != 2 {
+		t.Fatalf("GetItemOwner() = %+v", owner)
+	}
+	// 链上数量为0的持有者也返回
+	if owner.Owners[0].Balance != 5 || owner.Owners[1].Balance != 0 {
+		t.Errorf("GetItemOwner() owners = %+v", owner.Owners)
+	}
+}
+
+func TestComputePortfolioValueERC1155Quantity(t *testing.T) {
+	svcCtx, stub := newStubServerCtx(t)
+	client := &stubChainClient{balances: map[common.Address]int64{common.HexToAddress(testOwner): 3}}
+	svcCtx.NodeSrvs = map[int64]*nftchainservice.Service{1: {NodeClient: client, ChainName: "eth"}}
+	stub.results = func(query string) *stubRows {
+		switch {
+		case strings.Contains(query, multi.CollectionTableName("eth")):
+			return &stubRows{
+				columns: []string{"address", "token_standard", "floor_price"},
+				values:  [][]driver.Value{{testCollection, int64(tokenStandardERC1155), "0.5"}},
+			}
+		case strings.HasPrefix(query, "SELECT collection_address, token_id FROM"):
+			return &stubRows{
+				columns: []string{"collection_address", "token_id"},
+				values:  [][]driver.Value{{testCollection, "1"}},
+			}
+		}
+		return nil
+	}
+
+	res, err := computePortfolioValue(context.Background(), svcCtx, "eth", 1, testOwner)
+	if err != nil {
+		t.Fatalf("computePortfolioValue() error: %v", err)
+	}
+	// 按地板价估值, 持有3份
+	if res.ItemCount != 3 || res.PricedCount != 3 || res.TotalValue.String() != "1.5" {
+		t.Errorf("computePortfolioValue() = %+v", res)
+	}
+}
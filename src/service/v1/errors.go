@@ -0,0 +1,31 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/joinmouse/EasySwapBase/errcode"
+)
+
+// 业务错误定义
+// 每个错误带有固定的业务状态码(响应体code字段和X-GW-Error-Code响应头)和对应的HTTP状态码,
+// 客户端可以根据code区分参数错误、资源不存在和上游服务异常
+var (
+	ErrInvalidChainID      = errcode.NewErr(20001, "Invalid chain id", http.StatusBadRequest)
+	ErrInvalidFilter       = errcode.NewErr(20002, "Invalid filter param", http.StatusBadRequest)
+	ErrCollectionNotFound  = errcode.NewErr(20003, "Collection not found", http.StatusNotFound)
+	ErrUpstreamRPC         = errcode.NewErr(20004, "Upstream rpc error", http.StatusBadGateway)
+	ErrLoginNonceExpired   = errcode.NewErr(20005, "Login message expired, please request a new one", http.StatusUnauthorized)
+	ErrLoginNonceUsed      = errcode.NewErr(20006, "Login message already used, please request a new one", http.StatusUnauthorized)
+	ErrLoginNonceInvalid   = errcode.NewErr(20007, "Login message mismatch", http.StatusUnauthorized)
+	ErrWatchlistFull       = errcode.NewErr(20008, "Watchlist is full", http.StatusBadRequest)
+	ErrItemNotFound        = errcode.NewErr(20009, "Item not found", http.StatusNotFound)
+	ErrItemNotIndexed      = errcode.NewErr(20010, "Item exists on chain but is not indexed yet, please refresh metadata", http.StatusNotFound)
+	ErrQueryTimeout        = errcode.NewErr(20011, "Query timeout", http.StatusGatewayTimeout)
+	ErrInvalidSignature    = errcode.NewErr(20012, "Invalid login signature", http.StatusUnauthorized)
+	ErrSignatureRejected   = errcode.NewErr(20013, "Signature rejected by contract wallet", http.StatusUnauthorized)
+	ErrJobNotFound         = errcode.NewErr(20014, "Job not found", http.StatusNotFound)
+	ErrInvalidEventType    = errcode.NewErr(20015, "Invalid event type", http.StatusBadRequest)
+	ErrNotContract         = errcode.NewErr(20016, "Address is not a contract", http.StatusBadRequest)
+	ErrUnsupportedContract = errcode.NewErr(20017, "Contract is not an ERC-721 or ERC-1155 collection", http.StatusBadRequest)
+	ErrOrderNotFound       = errcode.NewErr(20018, "Order not found", http.StatusNotFound)
+)
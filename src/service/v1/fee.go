@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+
+	"github.com/joinmouse/EasySwapBackend/src/common/tracing"
+	"github.com/joinmouse/EasySwapBackend/src/config"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+	"github.com/joinmouse/EasySwapBackend/src/types/v1"
+)
+
+func chainConfigByID(svcCtx *svc.ServerCtx, chainID int) *config.ChainSupported {
+	for _, c := range svcCtx.C.ChainSupported {
+		if c != nil && c.ChainID == chainID {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// chainIDByName 根据链名称查询链ID, 未配置的链返回0
+func chainIDByName(svcCtx *svc.ServerCtx, chain string) int {
+	for _, c := range svcCtx.C.ChainSupported {
+		if c != nil && c.Name == chain {
+			return c.ChainID
+		}
+	}
+
+	return 0
+}
+
+// GetChainFees 获取链的市场手续费和版税信息
+// 手续费和默认版税来自链配置, 集合单独配置的版税从数据库读取
+func GetChainFees(ctx context.Context, svcCtx *svc.ServerCtx, chainID int) (*types.ChainFees, error) {
+	ctx, span := tracing.Start(ctx, "service.GetChainFees")
+	defer span.End()
+
+	chainCfg := chainConfigByID(svcCtx, chainID)
+	if chainCfg == nil {
+		return nil, ErrInvalidChainID
+	}
+
+	res := types.ChainFees{
+		ChainID:          chainID,
+		RoyaltyOverrides: []types.CollectionRoyalty{},
+	}
+	if chainCfg.Fees != nil {
+		res.MarketplaceBps = chainCfg.Fees.MarketplaceBps
+		res.FeeRecipient = chainCfg.Fees.FeeRecipient
+		res.DefaultRoyaltyBps = chainCfg.Fees.DefaultRoyaltyBps
+	}
+
+	royalties, err := svcCtx.Dao.QueryCollectionRoyalties(ctx, chainID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get collection royalties")
+	}
+	for _, royalty := range royalties {
+		res.RoyaltyOverrides = append(res.RoyaltyOverrides, types.CollectionRoyalty{
+			CollectionAddress: royalty.CollectionAddress,
+			RoyaltyBps:        royalty.RoyaltyBps,
+			RoyaltyRecipient:  royalty.RoyaltyRecipient,
+		})
+	}
+
+	return &res, nil
+}
+
+// feeRates 成交时收取的费率, 单位为基点
+type feeRates struct {
+	MarketplaceBps int
+	RoyaltyBps     int
+}
+
+// collectionFeeRates 获取集合成交时的市场手续费和版税费率
+// 集合单独配置了版税时使用集合版税, 否则使用链配置的默认版税; 链未配置时费率为0
+func collectionFeeRates(ctx context.Context, svcCtx *svc.ServerCtx, chainID int, collectionAddr string) (feeRates, error) {
+	var rates feeRates
+	chainCfg := chainConfigByID(svcCtx, chainID)
+	if chainCfg == nil {
+		return rates, nil
+	}
+	if chainCfg.Fees != nil {
+		rates.MarketplaceBps = chainCfg.Fees.MarketplaceBps
+		rates.RoyaltyBps = chainCfg.Fees.DefaultRoyaltyBps
+	}
+
+	royalty, err := svcCtx.Dao.QueryCollectionRoyalty(ctx, chainID, collectionAddr)
+	if err != nil {
+		return rates, errors.Wrap(err, "failed on get collection royalty")
+	}
+	if royalty != nil {
+		rates.RoyaltyBps = royalty.RoyaltyBps
+	}
+
+	return rates, nil
+}
+
+// feeOf 按费率(基点)计算费用, 结果按代币精度四舍五入
+func feeOf(amount decimal.Decimal, bps int, decimals int32) decimal.Decimal {
+	return amount.Mul(decimal.NewFromInt(int64(bps))).Div(decimal.NewFromInt(config.MaxFeeBps)).Round(decimals)
+}
+
+// listingCost 计算买家购买挂单需要支付的总价及费用明细
+// 总价 = 挂单价格 + 市场手续费 + 版税, 费用按支付代币的精度取整
+func listingCost(rates feeRates, price decimal.Decimal, decimals int32) (decimal.Decimal, *types.ListingFees) {
+	fees := &types.ListingFees{
+		MarketplaceBps: rates.MarketplaceBps,
+		MarketplaceFee: feeOf(price, rates.MarketplaceBps, decimals),
+		RoyaltyBps:     rates.RoyaltyBps,
+		RoyaltyFee:     feeOf(price, rates.RoyaltyBps, decimals),
+	}
+
+	return price.Add(fees.MarketplaceFee).Add(fees.RoyaltyFee), fees
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+
+	"github.com/joinmouse/EasySwapBackend/src/common/tracing"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+	"github.com/joinmouse/EasySwapBackend/src/types/v1"
+)
+
+// FloorHistoryIntervals 地板价历史支持的分桶间隔(秒)
+var FloorHistoryIntervals = map[string]int64{
+	"1h": 60 * 60,
+	"1d": 24 * 60 * 60,
+	"1w": 7 * 24 * 60 * 60,
+}
+
+// MaxFloorHistoryRange 地板价历史支持查询的最大时间范围(秒)
+const MaxFloorHistoryRange = 90 * 24 * 60 * 60
+
+var floorHistoryRangeUnits = map[byte]int64{
+	'h': 60 * 60,
+	'd': 24 * 60 * 60,
+	'w': 7 * 24 * 60 * 60,
+}
+
+// ParseFloorHistoryRange 解析 "24h"、"7d"、"4w" 形式的时间范围, 返回秒数
+// 时间范围不能超过 MaxFloorHistoryRange
+func ParseFloorHistoryRange(s string) (int64, error) {
+	if len(s) < 2 {
+		return 0, errors.Errorf("invalid range: %s", s)
+	}
+
+	unit, ok := floorHistoryRangeUnits[s[len(s)-1]]
+	if !ok {
+		return 0, errors.Errorf("invalid range unit: %s", s)
+	}
+
+	n, err := strconv.ParseInt(s[:len(s)-1], 10, 64)
+	if err != nil || n <= 0 {
+		return 0, errors.Errorf("invalid range: %s", s)
+	}
+
+	if n*unit > MaxFloorHistoryRange {
+		return 0, errors.Errorf("range exceeds 90 days: %s", s)
+	}
+
+	return n * unit, nil
+}
+
+// GetFloorPriceHistory 获取集合在时间区间 [since, until) 内按间隔分桶的地板价历史
+// 1. 按间隔对齐起始时间, 查询每个分桶的最低地板价
+// 2. 查询起始时间之前最后一次记录的地板价, 作为第一个分桶的补齐值
+// 3. 没有记录的分桶沿用上一个分桶的地板价
+func GetFloorPriceHistory(ctx context.Context, svcCtx *svc.ServerCtx, chain, collectionAddr string, interval int64, between types.TimeRange) ([]types.FloorPricePoint, error) {
+	ctx, span := tracing.Start(ctx, "service.GetFloorPriceHistory")
+	defer span.End()
+
+	end := between.Until
+	start := between.Since / interval * interval
+
+	buckets, err := svcCtx.Dao.QueryCollectionFloorPriceHistory(ctx, chain, collectionAddr, start, end, interval)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on query floor price history")
+	}
+
+	lastFloor, err := svcCtx.Dao.QueryCollectionFloorPriceBefore(ctx, chain, collectionAddr, start)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on query floor price before range")
+	}
+
+	bucketPrices := make(map[int64]decimal.Decimal, len(buckets))
+	for _, bucket := range buckets {
+		bucketPrices[bucket.Bucket] = bucket.Price
+	}
+
+	points := make([]types.FloorPricePoint, 0, (end-start)/interval+1)
+	for ts := start; ts < end; ts += interval {
+		if price, ok := bucketPrices[ts]; ok {
+			lastFloor = price
+		}
+		points = append(points, types.FloorPricePoint{
+			Timestamp:  ts,
+			FloorPrice: lastFloor,
+		})
+	}
+
+	return points, nil
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/joinmouse/EasySwapBackend/src/service/nodeclient"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+	"github.com/joinmouse/EasySwapBackend/src/types/v1"
+)
+
+// ReadinessCheckTimeout 单个依赖检查的超时时间
+const ReadinessCheckTimeout = 3 * time.Second
+
+// CheckReadiness 检查服务依赖是否就绪
+// 主要功能:
+// 1. 并发检查数据库、Redis以及每条链的RPC节点(eth_chainId)
+// 2. 每项检查都有独立的超时时间,避免单个依赖阻塞整个检查
+// 3. 返回每个依赖的检查结果,以及是否全部就绪
+// 4. 初始化失败的降级链不再检查,直接报告为 degraded; 降级链只影响该链的接口,不影响整体就绪
+func CheckReadiness(ctx context.Context, svcCtx *svc.ServerCtx) (*types.ReadinessResp, bool) {
+	checks := map[string]func(ctx context.Context) error{
+		"db": func(ctx context.Context) error {
+			sqlDB, err := svcCtx.DB.DB()
+			if err != nil {
+				return errors.Wrap(err, "failed on get sql db")
+			}
+			return sqlDB.PingContext(ctx)
+		},
+		"redis": func(ctx context.Context) error {
+			if !svcCtx.KvStore.Redis.PingCtx(ctx) {
+				return errors.New("failed on ping redis")
+			}
+			return nil
+		},
+	}
+	for chainID, nodeSrv := range svcCtx.NodeSrvs {
+		chainID, nodeSrv := chainID, nodeSrv
+		checks["chain:"+nodeSrv.ChainName] = func(ctx context.Context) error {
+			client, ok := nodeSrv.NodeClient.(*nodeclient.FailoverClient)
+			if !ok {
+				return errors.New("unsupported chain client")
+			}
+			id, err := client.ChainID(ctx)
+			if err != nil {
+				return errors.Wrap(err, "failed on call eth_chainId")
+			}
+			if id.Int64() != chainID {
+				return fmt.Errorf("chain id mismatch, expect %d got %s", chainID, id.String())
+			}
+			return nil
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	res := types.ReadinessResp{Status: types.HealthStatusOK}
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check func(ctx context.Context) error) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, ReadinessCheckTimeout)
+			defer cancel()
+
+			status := types.DependencyStatus{Name: name, Status: types.HealthStatusOK}
+			if err := check(checkCtx); err != nil {
+				status.Status = types.HealthStatusUnavailable
+				status.Error = err.Error()
+			}
+
+			mu.Lock()
+			res.Dependencies = append(res.Dependencies, status)
+			if status.Status != types.HealthStatusOK {
+				res.Status = types.HealthStatusUnavailable
+			}
+			mu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+
+	for _, degraded := range svcCtx.DegradedChains {
+		res.Dependencies = append(res.Dependencies, types.DependencyStatus{
+			Name:   "chain:" + degraded.Name,
+			Status: types.HealthStatusDegraded,
+			Error:  degraded.Error,
+		})
+		if res.Status == types.HealthStatusOK {
+			res.Status = types.HealthStatusDegraded
+		}
+	}
+
+	sort.Slice(res.Dependencies, func(i, j int) bool {
+		return res.Dependencies[i].Name < res.Dependencies[j].Name
+	})
+
+	return &res, res.Status != types.HealthStatusUnavailable
+}
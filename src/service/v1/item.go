@@ -2,18 +2,34 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/joinmouse/EasySwapBase/stores/gdb/orderbookmodel/multi"
 	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"golang.org/x/sync/errgroup"
 
+	"github.com/joinmouse/EasySwapBackend/src/common"
+	"github.com/joinmouse/EasySwapBackend/src/common/tracing"
+	"github.com/joinmouse/EasySwapBackend/src/dao"
 	"github.com/joinmouse/EasySwapBackend/src/service/svc"
 	"github.com/joinmouse/EasySwapBackend/src/types/v1"
 )
 
 func GetItemBidsInfo(ctx context.Context, svcCtx *svc.ServerCtx, chain string, collectionAddr, tokenID string, page, pageSize int) (*types.CollectionBidsResp, error) {
+	ctx, span := tracing.Start(ctx, "service.GetItemBidsInfo")
+	defer span.End()
+
 	bids, count, err := svcCtx.Dao.QueryItemBids(ctx, chain, collectionAddr, tokenID, page, pageSize)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed on get item info")
 	}
+	if bids == nil {
+		bids = []types.ItemBid{}
+	}
 
 	for i := 0; i < len(bids); i++ {
 		bids[i].OrderType = getBidType(bids[i].OrderType)
@@ -23,3 +39,263 @@ func GetItemBidsInfo(ctx context.Context, svcCtx *svc.ServerCtx, chain string, c
 		Count:  count,
 	}, nil
 }
+
+// MaxBatchItemDetail 批量查询NFT Item详情的最大数量
+const MaxBatchItemDetail = 50
+
+// GetItemsDetail 批量获取NFT Item详情
+// 主要功能:
+// 1. 并发批量查询collection信息、item基本信息、挂单、图片、最近成交价和最高出价
+// 2. 每类信息都只查询一次数据库(token_id in (...))
+// 3. 组装为token_id到ItemDetailInfo的映射,不存在的token_id直接忽略
+func GetItemsDetail(ctx context.Context, svcCtx *svc.ServerCtx, chain string, chainID int, collectionAddr string, tokenIDs []string) (*types.ItemDetailBatchResp, error) {
+	ctx, span := tracing.Start(ctx, "service.GetItemsDetail")
+	defer span.End()
+
+	// 任一查询失败时取消其余查询
+	g, gctx := errgroup.WithContext(ctx)
+
+	// 1. 查询collection信息
+	var collection *multi.Collection
+	g.Go(func() error {
+		c, err := svcCtx.Dao.QueryCollectionInfo(gctx, chain, collectionAddr)
+		if err != nil {
+			return errors.Wrap(err, "failed on get collection info")
+		}
+		collection = c
+		return nil
+	})
+
+	// 2. 批量查询item基本信息
+	var items []multi.Item
+	g.Go(func() error {
+		res, err := svcCtx.Dao.QueryItemsInfo(gctx, chain, collectionAddr, tokenIDs)
+		if err != nil {
+			return errors.Wrap(err, "failed on get items info")
+		}
+		items = res
+		return nil
+	})
+
+	// 3. 批量查询item挂单信息
+	itemsListInfo := make(map[string]*dao.CollectionItem)
+	g.Go(func() error {
+		listInfos, err := svcCtx.Dao.QueryItemsListInfo(gctx, chain, collectionAddr, tokenIDs)
+		if err != nil {
+			return errors.Wrap(err, "failed on get items list info")
+		}
+		for _, listInfo := range listInfos {
+			itemsListInfo[strings.ToLower(listInfo.TokenId)] = listInfo
+		}
+		return nil
+	})
+
+	// 4. 批量查询item图片和视频信息
+	itemsExternal := make(map[string]multi.ItemExternal)
+	g.Go(func() error {
+		externals, err := svcCtx.Dao.QueryCollectionItemsImage(gctx, chain, collectionAddr, tokenIDs)
+		if err != nil {
+			return errors.Wrap(err, "failed on get items image info")
+		}
+		for _, external := range externals {
+			itemsExternal[strings.ToLower(external.TokenId)] = external
+		}
+		return nil
+	})
+
+	// 5. 批量查询最近成交价格
+	lastSales := make(map[string]decimal.Decimal)
+	g.Go(func() error {
+		lastSale, err := svcCtx.Dao.QueryLastSalePrice(gctx, chain, collectionAddr, tokenIDs)
+		if err != nil {
+			return errors.Wrap(err, "failed on get items last sale info")
+		}
+		for _, v := range lastSale {
+			lastSales[strings.ToLower(v.TokenId)] = v.Price
+		}
+		return nil
+	})
+
+	// 6. 批量查询item级别最高出价
+	bestBids := make(map[string]multi.Order)
+	g.Go(func() error {
+		bids, err := svcCtx.Dao.QueryBestBids(gctx, chain, "", collectionAddr, tokenIDs)
+		if err != nil {
+			return errors.Wrap(err, "failed on get items best bids info")
+		}
+		for _, bid := range bids {
+			order, ok := bestBids[strings.ToLower(bid.TokenId)]
+			if !ok || bid.Price.GreaterThan(order.Price) {
+				bestBids[strings.ToLower(bid.TokenId)] = bid
+			}
+		}
+		return nil
+	})
+
+	// 7. 查询collection级别最高出价
+	var collectionBestBid multi.Order
+	g.Go(func() error {
+		bid, err := svcCtx.Dao.QueryCollectionBestBid(gctx, chain, "", collectionAddr)
+		if err != nil {
+			return errors.Wrap(err, "failed on get collection best bid info")
+		}
+		collectionBestBid = bid
+		return nil
+	})
+
+	// 8. 批量查询item在各市场的挂单
+	var itemsListings map[string][]types.ListingInfo
+	g.Go(func() error {
+		listings, err := svcCtx.Dao.QueryItemsListings(gctx, chain, collectionAddr, tokenIDs)
+		if err != nil {
+			return errors.Wrap(err, "failed on get items listings")
+		}
+		rates, err := collectionFeeRates(gctx, svcCtx, chainID, collectionAddr)
+		if err != nil {
+			return err
+		}
+		itemsListings = groupItemListings(svcCtx, chain, rates, listings)
+		return nil
+	})
+
+	if err := g.Wait(); err != nil {
+		return nil, errors.Wrap(err, "failed on get items detail")
+	}
+
+	// 组装返回数据,只包含数据库中存在的item
+	asOf := time.Now().Unix()
+	result := make(map[string]types.ItemDetailInfo, len(items))
+	for _, item := range items {
+		tokenKey := strings.ToLower(item.TokenId)
+		itemDetail := types.ItemDetailInfo{
+			ChainID:           chainID,
+			Name:              item.Name,
+			CollectionAddress: item.CollectionAddress,
+			TokenID:           item.TokenId,
+			OwnerAddress:      item.Owner,
+			IndexedAt:         item.UpdateTime / 1000,
+			AsOf:              asOf,
+		}
+
+		// 默认使用collection级别最高出价,item级别出价更高时使用item级别出价
+		bidOrder := collectionBestBid
+		if itemBid, ok := bestBids[tokenKey]; ok && itemBid.Price.GreaterThan(collectionBestBid.Price) {
+			bidOrder = itemBid
+		}
+		itemDetail.BidOrderID = bidOrder.OrderID
+		itemDetail.BidExpireTime = bidOrder.ExpireTime
+		itemDetail.BidPrice = bidOrder.Price
+		itemDetail.BidTime = bidOrder.EventTime
+		itemDetail.BidSalt = bidOrder.Salt
+		itemDetail.BidMaker = bidOrder.Maker
+		itemDetail.BidType = getBidType(bidOrder.OrderType)
+		itemDetail.BidSize = bidOrder.Size
+		itemDetail.BidUnfilled = bidOrder.QuantityRemaining
+		itemDetail.BidCurrency, itemDetail.BidCurrencyAddress = ResolveCurrency(svcCtx, chain, bidOrder.CurrencyAddress)
+
+		// 设置挂单信息
+		if listInfo, ok := itemsListInfo[tokenKey]; ok {
+			itemDetail.ListPrice = listInfo.ListPrice
+			itemDetail.MarketplaceID = listInfo.MarketID
+			itemDetail.ListOrderID = listInfo.OrderID
+			itemDetail.ListTime = listInfo.ListTime
+			itemDetail.ListExpireTime = listInfo.ListExpireTime
+			itemDetail.ListSalt = listInfo.ListSalt
+			itemDetail.ListMaker = listInfo.ListMaker
+		}
+		setItemListings(&itemDetail, itemsListings[tokenKey])
+
+		// 设置collection信息
+		if collection != nil {
+			itemDetail.CollectionName = collection.Name
+			itemDetail.TokenStandard = tokenStandardName(collection.TokenStandard)
+			itemDetail.FloorPrice = collection.FloorPrice
+			itemDetail.CollectionImageURI = collection.ImageUri
+			if itemDetail.Name == "" {
+				itemDetail.Name = fmt.Sprintf("%s #%s", collection.Name, item.TokenId)
+			}
+		}
+
+		// 设置最近成交价格
+		if price, ok := lastSales[tokenKey]; ok {
+			itemDetail.LastSellPrice = price
+		}
+
+		// 设置图片和视频信息
+		if itemExternal, ok := itemsExternal[tokenKey]; ok {
+			itemDetail.ImageURI = itemExternal.ImageUri
+			if itemExternal.IsUploadedOss {
+				itemDetail.ImageURI = itemExternal.OssUri
+			}
+			if len(itemExternal.VideoUri) > 0 {
+				itemDetail.VideoType = itemExternal.VideoType
+				if itemExternal.IsVideoUploaded {
+					itemDetail.VideoURI = itemExternal.VideoOssUri
+				} else {
+					itemDetail.VideoURI = itemExternal.VideoUri
+				}
+			}
+		}
+
+		result[item.TokenId] = itemDetail
+	}
+
+	return &types.ItemDetailBatchResp{Result: result}, nil
+}
+
+// groupItemListings 按 token id(小写) 整理各市场的挂单, 每个 Item 的挂单按价格升序排列
+// 同时按集合的费率计算每个挂单的买家总价和费用明细
+func groupItemListings(svcCtx *svc.ServerCtx, chain string, rates feeRates, rows []dao.ItemListing) map[string][]types.ListingInfo {
+	listings := make(map[string][]types.ListingInfo)
+	for _, row := range rows {
+		listing := row.ListingInfo
+		listing.Currency, listing.CurrencyAddress = ResolveCurrency(svcCtx, chain, listing.CurrencyAddress)
+		totalPrice, fees := listingCost(rates, listing.Price, currencyDecimals(svcCtx, chain, listing.CurrencyAddress))
+		listing.TotalPrice, listing.Fees = &totalPrice, fees
+		tokenKey := strings.ToLower(row.TokenId)
+		listings[tokenKey] = append(listings[tokenKey], listing)
+	}
+
+	for _, items := range listings {
+		sort.SliceStable(items, func(i, j int) bool {
+			if !items[i].Price.Equal(items[j].Price) {
+				return items[i].Price.LessThan(items[j].Price)
+			}
+			return items[i].MarketplaceId < items[j].MarketplaceId
+		})
+	}
+
+	return listings
+}
+
+// setItemListings 设置 Item 在各市场的挂单和价格最低的挂单
+// 旧版的 marketplace_id 和 list_price 字段与最低价挂单保持一致
+// 价格为0或负数的异常挂单仍然返回, 但不作为最低价挂单
+func setItemListings(itemDetail *types.ItemDetailInfo, listings []types.ListingInfo) {
+	if len(listings) == 0 {
+		itemDetail.Listings = []types.ListingInfo{}
+		return
+	}
+
+	itemDetail.Listings = listings
+	prices := make([]decimal.Decimal, 0, len(listings))
+	for _, listing := range listings {
+		prices = append(prices, listing.Price)
+	}
+	bestPrice := common.MinPrice(prices...)
+	if common.IsZeroOrNeg(bestPrice) {
+		return
+	}
+
+	// listings 已按价格和市场排序, 第一个等于最低价的即为最低价挂单
+	var best types.ListingInfo
+	for _, listing := range listings {
+		if listing.Price.Equal(bestPrice) {
+			best = listing
+			break
+		}
+	}
+	itemDetail.BestListing = &best
+	itemDetail.MarketplaceID = int(best.MarketplaceId)
+	itemDetail.ListPrice = best.Price
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joinmouse/EasySwapBase/logger/xzap"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/joinmouse/EasySwapBackend/src/common/tracing"
+	"github.com/joinmouse/EasySwapBackend/src/service/mq"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+	"github.com/joinmouse/EasySwapBackend/src/types/v1"
+)
+
+// 后台任务状态
+const (
+	JobStatusPending   = "pending"
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+	JobStatusFailed    = "failed"
+)
+
+// JobTypeCollectionMetadataRefresh 集合元数据批量刷新任务
+const JobTypeCollectionMetadataRefresh = "collection_metadata_refresh"
+
+const (
+	JobCacheKey = "cache:es:job:%s"
+	JobTTL      = 24 * 60 * 60 // 任务记录保留时间(秒)
+
+	// CollectionRefreshLockKey 同一集合同时只允许一个刷新任务, 值为任务ID
+	CollectionRefreshLockKey = "cache:es:collection:refresh:lock:%d:%s"
+	CollectionRefreshLockTTL = 60 * 60 // 任务异常退出时锁自动释放的时间(秒)
+
+	DefaultCollectionRefreshLimit = 1000  // 默认最多刷新的item数量
+	MaxCollectionRefreshLimit     = 10000 // 单个任务最多刷新的item数量
+
+	collectionRefreshWorkers  = 8   // 单个任务并发入队的协程数
+	maxRunningRefreshJobs     = 2   // 每个进程同时运行的刷新任务数, 超出的任务保持 pending
+	jobProgressReportInterval = 100 // 每处理多少个item写一次进度
+)
+
+// refreshJobSlots 限制进程内同时运行的刷新任务数量, 避免大集合把下游RPC打满
+var refreshJobSlots = make(chan struct{}, maxRunningRefreshJobs)
+
+func jobCacheKey(jobID string) string {
+	return fmt.Sprintf(JobCacheKey, jobID)
+}
+
+func saveJob(svcCtx *svc.ServerCtx, job *types.JobInfo) error {
+	job.UpdatedAt = time.Now().Unix()
+	raw, err := json.Marshal(job)
+	if err != nil {
+		return errors.Wrap(err, "failed on marshal job")
+	}
+	if err := svcCtx.KvStore.Setex(jobCacheKey(job.JobID), string(raw), JobTTL); err != nil {
+		return errors.Wrap(err, "failed on save job")
+	}
+
+	return nil
+}
+
+// GetJob 查询后台任务状态
+func GetJob(ctx context.Context, svcCtx *svc.ServerCtx, jobID string) (*types.JobInfo, error) {
+	_, span := tracing.Start(ctx, "service.GetJob")
+	defer span.End()
+
+	raw, err := svcCtx.KvStore.Get(jobCacheKey(jobID))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get job")
+	}
+	if raw == "" {
+		return nil, ErrJobNotFound
+	}
+
+	var job types.JobInfo
+	if err := json.Unmarshal([]byte(raw), &job); err != nil {
+		return nil, errors.Wrap(err, "failed on unmarshal job")
+	}
+
+	return &job, nil
+}
+
+// RefreshCollectionMetadata 创建集合元数据批量刷新任务并立即返回
+// 任务在后台把集合中最多 limit 个item加入元数据刷新队列, 进度通过 GetJob 查询
+// 同一集合已有刷新任务在进行时返回该任务, 不会重复创建
+func RefreshCollectionMetadata(ctx context.Context, svcCtx *svc.ServerCtx, chainName string, chainID int64, collectionAddr string, limit int) (*types.JobInfo, error) {
+	ctx, span := tracing.Start(ctx, "service.RefreshCollectionMetadata")
+	defer span.End()
+
+	if limit <= 0 {
+		limit = DefaultCollectionRefreshLimit
+	}
+	if limit > MaxCollectionRefreshLimit {
+		limit = MaxCollectionRefreshLimit
+	}
+
+	now := time.Now().Unix()
+	job := &types.JobInfo{
+		JobID:     uuid.NewString(),
+		Type:      JobTypeCollectionMetadataRefresh,
+		Status:    JobStatusPending,
+		CreatedAt: now,
+	}
+
+	lockKey := fmt.Sprintf(CollectionRefreshLockKey, chainID, strings.ToLower(collectionAddr))
+	ok, err := svcCtx.KvStore.SetnxEx(lockKey, job.JobID, CollectionRefreshLockTTL)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on lock collection refresh")
+	}
+	if !ok {
+		runningID, err := svcCtx.KvStore.Get(lockKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed on get running refresh job")
+		}
+		if running, err := GetJob(ctx, svcCtx, runningID); err == nil {
+			return running, nil
+		}
+		// 锁存在但任务记录已丢失, 等待锁过期
+		return nil, errors.New("collection refresh job is in progress")
+	}
+
+	if err := saveJob(svcCtx, job); err != nil {
+		_, _ = svcCtx.KvStore.Del(lockKey)
+		return nil, err
+	}
+
+	// 任务在请求结束后继续执行, 需要脱离请求的生命周期
+	jobCtx := context.WithoutCancel(ctx)
+	go func() {
+		defer func() {
+			_, _ = svcCtx.KvStore.Del(lockKey)
+		}()
+		runCollectionMetadataRefresh(jobCtx, svcCtx, job, chainName, chainID, collectionAddr, limit)
+	}()
+
+	return job, nil
+}
+
+// runCollectionMetadataRefresh 执行集合元数据刷新任务
+// 占用进程内的任务名额后, 使用固定数量的协程把item加入刷新队列
+func runCollectionMetadataRefresh(ctx context.Context, svcCtx *svc.ServerCtx, job *types.JobInfo, chainName string, chainID int64, collectionAddr string, limit int) {
+	refreshJobSlots <- struct{}{}
+	defer func() { <-refreshJobSlots }()
+
+	logger := xzap.WithContext(ctx)
+	jobField, collectionField := zap.String("job_id", job.JobID), zap.String("collection_address", collectionAddr)
+
+	fail := func(err error) {
+		logger.Error("collection metadata refresh job failed", jobField, collectionField, zap.Error(err))
+		job.Status = JobStatusFailed
+		job.Error = "failed to load collection items"
+		if err := saveJob(svcCtx, job); err != nil {
+			logger.Warn("failed on save job", jobField, zap.Error(err))
+		}
+	}
+
+	tokenIDs, err := svcCtx.Dao.QueryCollectionTokenIDs(ctx, chainName, collectionAddr, limit)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	job.Status = JobStatusRunning
+	job.Total = int64(len(tokenIDs))
+	if err := saveJob(svcCtx, job); err != nil {
+		logger.Warn("failed on save job", jobField, zap.Error(err))
+	}
+
+	var succeeded, skipped, failed, processed int64
+	var mu sync.Mutex
+	report := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		job.Succeeded = atomic.LoadInt64(&succeeded)
+		job.Skipped = atomic.LoadInt64(&skipped)
+		job.Failed = atomic.LoadInt64(&failed)
+		if err := saveJob(svcCtx, job); err != nil {
+			logger.Warn("failed on save job progress", jobField, zap.Error(err))
+		}
+	}
+
+	tasks := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < collectionRefreshWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for tokenID := range tasks {
+				_, cached, err := mq.AddSingleItemToRefreshMetadataQueue(svcCtx.KvStore, svcCtx.C.ProjectCfg.Name, chainName, chainID, collectionAddr, tokenID)
+				switch {
+				case err != nil:
+					atomic.AddInt64(&failed, 1)
+					logger.Warn("failed on add item to refresh queue", jobField, collectionField, zap.String("token_id", tokenID), zap.Error(err))
+				case cached:
+					atomic.AddInt64(&skipped, 1)
+				default:
+					atomic.AddInt64(&succeeded, 1)
+				}
+				if atomic.AddInt64(&processed, 1)%jobProgressReportInterval == 0 {
+					report()
+				}
+			}
+		}()
+	}
+	for _, tokenID := range tokenIDs {
+		tasks <- tokenID
+	}
+	close(tasks)
+	wg.Wait()
+
+	if succeeded > 0 {
+		invalidateCollectionTraits(ctx, svcCtx, chainName, collectionAddr)
+	}
+
+	job.Status = JobStatusCompleted
+	report()
+	logger.Info("collection metadata refresh job completed", jobField, collectionField,
+		zap.Int64("total", job.Total), zap.Int64("succeeded", job.Succeeded),
+		zap.Int64("skipped", job.Skipped), zap.Int64("failed", job.Failed))
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/joinmouse/EasySwapBase/logger/xzap"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/joinmouse/EasySwapBackend/src/common/metrics"
+	"github.com/joinmouse/EasySwapBackend/src/common/tracing"
+	"github.com/joinmouse/EasySwapBackend/src/dao"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+	"github.com/joinmouse/EasySwapBackend/src/types/v1"
+)
+
+const (
+	// ListedItemsCacheKey 集合已上架Item缓存, 每条链一个 hash, field 为小写的集合地址
+	// value 为按挂单价格升序排列的已上架Item列表, 由后台任务与集合行情一起刷新
+	ListedItemsCacheKey = "cache:es:collection:listed:%s"
+	// listedItemsCacheName 缓存命中率指标中的缓存名称
+	listedItemsCacheName = "listed_items"
+)
+
+// listedItemsSnapshot 集合已上架Item缓存的内容
+type listedItemsSnapshot struct {
+	Items     []dao.ListedItem `json:"items"`
+	UpdatedAt int64            `json:"updated_at"`
+}
+
+func listedItemsCacheKey(chain string) string {
+	return fmt.Sprintf(ListedItemsCacheKey, strings.ToLower(chain))
+}
+
+// RefreshListedItems 重新计算链上所有集合当前已上架的Item并写入缓存
+// 没有已上架Item的集合写入空列表, 保证下架后缓存不会保留旧数据
+func RefreshListedItems(ctx context.Context, svcCtx *svc.ServerCtx, chain string) (int, error) {
+	ctx, span := tracing.Start(ctx, "service.RefreshListedItems")
+	defer span.End()
+
+	collections, err := svcCtx.Dao.QueryAllCollectionInfo(ctx, chain)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed on get all collections info")
+	}
+
+	listed, err := svcCtx.Dao.QueryListedItems(ctx, chain)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed on get listed items")
+	}
+
+	now := time.Now().Unix()
+	snapshots := make(map[string]*listedItemsSnapshot, len(collections))
+	for _, collection := range collections {
+		snapshots[strings.ToLower(collection.Address)] = &listedItemsSnapshot{Items: []dao.ListedItem{}, UpdatedAt: now}
+	}
+	for _, item := range listed {
+		addr := strings.ToLower(item.CollectionAddress)
+		s, ok := snapshots[addr]
+		if !ok {
+			s = &listedItemsSnapshot{UpdatedAt: now}
+			snapshots[addr] = s
+		}
+		s.Items = append(s.Items, item)
+	}
+	if len(snapshots) == 0 {
+		return 0, nil
+	}
+
+	fields := make(map[string]string, len(snapshots))
+	for addr, s := range snapshots {
+		// 与实时查询的排序一致: 挂单价格升序, 价格相同时按 item id 升序
+		sort.Slice(s.Items, func(i, j int) bool {
+			if c := s.Items[i].ListPrice.Cmp(s.Items[j].ListPrice); c != 0 {
+				return c < 0
+			}
+			return s.Items[i].ItemID < s.Items[j].ItemID
+		})
+		raw, err := json.Marshal(s)
+		if err != nil {
+			return 0, errors.Wrap(err, "failed on marshal listed items snapshot")
+		}
+		fields[addr] = string(raw)
+	}
+
+	key := listedItemsCacheKey(chain)
+	if err := svcCtx.KvStore.Hmset(key, fields); err != nil {
+		return 0, errors.Wrap(err, "failed on cache listed items snapshot")
+	}
+	if err := svcCtx.KvStore.Expire(key, marketRefreshInterval(svcCtx)*marketCacheStaleFactor); err != nil {
+		xzap.WithContext(ctx).Warn("failed on set listed items cache expire", zap.Error(err))
+	}
+
+	return len(snapshots), nil
+}
+
+// getCachedListedItems 读取集合缓存的已上架Item, 缓存不存在或已过期时返回 false
+func getCachedListedItems(svcCtx *svc.ServerCtx, chain, collectionAddr string) ([]dao.ListedItem, bool) {
+	raw, err := svcCtx.KvStore.Hget(listedItemsCacheKey(chain), strings.ToLower(collectionAddr))
+	if err != nil || raw == "" {
+		return nil, false
+	}
+
+	var s listedItemsSnapshot
+	if err := json.Unmarshal([]byte(raw), &s); err != nil {
+		return nil, false
+	}
+	maxAge := int64(marketRefreshInterval(svcCtx) * marketCacheStaleFactor)
+	if time.Now().Unix()-s.UpdatedAt > maxAge {
+		return nil, false
+	}
+
+	return s.Items, true
+}
+
+// queryCachedListedItems 使用已上架Item缓存查询集合Item列表, 避免 listed_only 查询扫描整个Item表
+// 查询条件不支持或缓存未预热时返回 false, 调用方需要回退到实时查询
+func queryCachedListedItems(ctx context.Context, svcCtx *svc.ServerCtx, chain string, filter types.CollectionItemFilterParams, collectionAddr string) ([]*dao.CollectionItem, int64, bool, error) {
+	desc, ok := dao.ListedItemsFastPath(filter)
+	if !ok {
+		return nil, 0, false, nil
+	}
+
+	cached, ok := getCachedListedItems(svcCtx, chain, collectionAddr)
+	metrics.ObserveCache(listedItemsCacheName, ok)
+	if !ok {
+		return nil, 0, false, nil
+	}
+
+	// 价格区间过滤
+	listed := make([]dao.ListedItem, 0, len(cached))
+	for _, item := range cached {
+		if filter.MinPrice != nil && item.ListPrice.LessThan(filter.MinPrice.Decimal) {
+			continue
+		}
+		if filter.MaxPrice != nil && item.ListPrice.GreaterThan(filter.MaxPrice.Decimal) {
+			continue
+		}
+		listed = append(listed, item)
+	}
+	if desc {
+		// 价格降序, 价格相同时仍按 item id 升序
+		sort.SliceStable(listed, func(i, j int) bool {
+			return listed[i].ListPrice.GreaterThan(listed[j].ListPrice)
+		})
+	}
+
+	// 分页
+	count := int64(len(listed))
+	start := (filter.Page - 1) * filter.PageSize
+	if start < 0 || start >= len(listed) {
+		return []*dao.CollectionItem{}, count, true, nil
+	}
+	end := start + filter.PageSize
+	if end > len(listed) {
+		end = len(listed)
+	}
+	page := listed[start:end]
+
+	tokenIDs := make([]string, 0, len(page))
+	for _, item := range page {
+		tokenIDs = append(tokenIDs, item.TokenId)
+	}
+	infos, err := svcCtx.Dao.QueryItemsInfo(ctx, chain, collectionAddr, tokenIDs)
+	if err != nil {
+		return nil, 0, false, errors.Wrap(err, "failed on get items info")
+	}
+	infoByToken := make(map[string]int, len(infos))
+	for i, info := range infos {
+		infoByToken[info.TokenId] = i
+	}
+
+	// 保持缓存中的顺序, 缓存刷新后被删除的Item直接跳过
+	items := make([]*dao.CollectionItem, 0, len(page))
+	for _, item := range page {
+		i, ok := infoByToken[item.TokenId]
+		if !ok {
+			continue
+		}
+		info := infos[i]
+		info.ListPrice = item.ListPrice
+		items = append(items, &dao.CollectionItem{Item: info, MarketID: item.MarketID, Listing: true})
+	}
+
+	return items, count, true, nil
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/joinmouse/EasySwapBase/logger/xzap"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/joinmouse/EasySwapBackend/src/common/tracing"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+	"github.com/joinmouse/EasySwapBackend/src/types/v1"
+)
+
+const (
+	// CollectionMarketCacheKey 集合地板价和24小时成交数据缓存, 每条链一个 hash, field 为小写的集合地址
+	CollectionMarketCacheKey = "cache:es:collection:market:%s"
+	// DefaultMarketRefreshInterval 默认的刷新间隔(秒)
+	DefaultMarketRefreshInterval = 60
+	// marketCacheStaleFactor 缓存超过刷新间隔的该倍数未更新时视为过期, 读接口回退到实时查询
+	marketCacheStaleFactor = 3
+)
+
+func collectionMarketCacheKey(chain string) string {
+	return fmt.Sprintf(CollectionMarketCacheKey, strings.ToLower(chain))
+}
+
+// marketRefreshInterval 获取生效的刷新间隔(秒)
+func marketRefreshInterval(svcCtx *svc.ServerCtx) int {
+	if svcCtx.C != nil && svcCtx.C.Worker.MarketRefreshInterval > 0 {
+		return svcCtx.C.Worker.MarketRefreshInterval
+	}
+
+	return DefaultMarketRefreshInterval
+}
+
+// RefreshCollectionMarket 重新计算链上所有集合的地板价和24小时成交数据并写入缓存
+// 1. 查询所有集合, 保证已下架的集合地板价会被覆盖为0
+// 2. 批量查询地板价和24小时成交数据
+// 3. 一次写入链对应的 hash
+func RefreshCollectionMarket(ctx context.Context, svcCtx *svc.ServerCtx, chain string) (int, error) {
+	ctx, span := tracing.Start(ctx, "service.RefreshCollectionMarket")
+	defer span.End()
+
+	collections, err := svcCtx.Dao.QueryAllCollectionInfo(ctx, chain)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed on get all collections info")
+	}
+
+	floors, err := svcCtx.Dao.QueryCollectionsFloorPrice(ctx, chain)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed on get collections floor price")
+	}
+
+	now := time.Now()
+	volumes, err := svcCtx.Dao.QueryCollectionsVolume(ctx, chain, now.Add(-24*time.Hour).Unix())
+	if err != nil {
+		return 0, errors.Wrap(err, "failed on get collections volume")
+	}
+
+	snapshots := make(map[string]*types.CollectionMarketSnapshot, len(collections))
+	snapshot := func(addr string) *types.CollectionMarketSnapshot {
+		addr = strings.ToLower(addr)
+		s, ok := snapshots[addr]
+		if !ok {
+			s = &types.CollectionMarketSnapshot{UpdatedAt: now.Unix()}
+			snapshots[addr] = s
+		}
+		return s
+	}
+	for _, collection := range collections {
+		snapshot(collection.Address)
+	}
+	for _, floor := range floors {
+		snapshot(floor.CollectionAddress).FloorPrice = floor.FloorPrice
+	}
+	for _, volume := range volumes {
+		s := snapshot(volume.CollectionAddress)
+		s.Volume24h = volume.Volume
+		s.Sales24h = volume.Sales
+	}
+	if len(snapshots) == 0 {
+		return 0, nil
+	}
+
+	fields := make(map[string]string, len(snapshots))
+	for addr, s := range snapshots {
+		raw, err := json.Marshal(s)
+		if err != nil {
+			return 0, errors.Wrap(err, "failed on marshal collection market snapshot")
+		}
+		fields[addr] = string(raw)
+	}
+
+	key := collectionMarketCacheKey(chain)
+	if err := svcCtx.KvStore.Hmset(key, fields); err != nil {
+		return 0, errors.Wrap(err, "failed on cache collection market snapshot")
+	}
+	if err := svcCtx.KvStore.Expire(key, marketRefreshInterval(svcCtx)*marketCacheStaleFactor); err != nil {
+		xzap.WithContext(ctx).Warn("failed on set collection market cache expire", zap.Error(err))
+	}
+
+	return len(snapshots), nil
+}
+
+// isFreshSnapshot 判断缓存的数据是否仍在有效期内
+func isFreshSnapshot(svcCtx *svc.ServerCtx, s *types.CollectionMarketSnapshot) bool {
+	maxAge := int64(marketRefreshInterval(svcCtx) * marketCacheStaleFactor)
+	return time.Now().Unix()-s.UpdatedAt <= maxAge
+}
+
+// GetCachedCollectionMarket 读取缓存的集合地板价和24小时成交数据
+// 缓存不存在或已过期时返回 false, 调用方需要回退到实时查询
+func GetCachedCollectionMarket(svcCtx *svc.ServerCtx, chain, collectionAddr string) (*types.CollectionMarketSnapshot, bool) {
+	raw, err := svcCtx.KvStore.Hget(collectionMarketCacheKey(chain), strings.ToLower(collectionAddr))
+	if err != nil || raw == "" {
+		return nil, false
+	}
+
+	var s types.CollectionMarketSnapshot
+	if err := json.Unmarshal([]byte(raw), &s); err != nil || !isFreshSnapshot(svcCtx, &s) {
+		return nil, false
+	}
+
+	return &s, true
+}
+
+// GetCachedCollectionsMarket 读取链上所有集合缓存的地板价和24小时成交数据, key 为小写的集合地址
+func GetCachedCollectionsMarket(svcCtx *svc.ServerCtx, chain string) map[string]*types.CollectionMarketSnapshot {
+	result := make(map[string]*types.CollectionMarketSnapshot)
+	fields, err := svcCtx.KvStore.Hgetall(collectionMarketCacheKey(chain))
+	if err != nil {
+		return result
+	}
+
+	for addr, raw := range fields {
+		var s types.CollectionMarketSnapshot
+		if err := json.Unmarshal([]byte(raw), &s); err != nil || !isFreshSnapshot(svcCtx, &s) {
+			continue
+		}
+		result[addr] = &s
+	}
+
+	return result
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"sort"
+
+	"github.com/joinmouse/EasySwapBase/stores/gdb/orderbookmodel/multi"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+
+	"github.com/joinmouse/EasySwapBackend/src/common/tracing"
+	"github.com/joinmouse/EasySwapBackend/src/config"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+	"github.com/joinmouse/EasySwapBackend/src/types/v1"
+)
+
+const (
+	DefaultItemOffers = 50  // 默认返回的出价数量
+	MaxItemOffers     = 200 // 最多返回的出价数量
+)
+
+// 出价类型
+const (
+	BidTypeItem       = "item"
+	BidTypeCollection = "collection"
+)
+
+// sellerFeeBps 计算卖家成交时需要支付的费率(基点), 即市场手续费加版税
+// 集合单独配置了版税时使用集合版税, 否则使用链配置的默认版税
+func sellerFeeBps(ctx context.Context, svcCtx *svc.ServerCtx, chainID int, collectionAddr string) (int, error) {
+	if chainConfigByID(svcCtx, chainID) == nil {
+		return 0, ErrInvalidChainID
+	}
+
+	rates, err := collectionFeeRates(ctx, svcCtx, chainID, collectionAddr)
+	if err != nil {
+		return 0, err
+	}
+
+	return rates.MarketplaceBps + rates.RoyaltyBps, nil
+}
+
+// netOfFees 计算扣除费率后的金额
+func netOfFees(price decimal.Decimal, feeBps int) decimal.Decimal {
+	return price.Mul(decimal.NewFromInt(int64(config.MaxFeeBps - feeBps))).Div(decimal.NewFromInt(config.MaxFeeBps))
+}
+
+// GetItemOffers 获取适用于指定Item的所有有效出价
+// 合并Item出价和集合出价, 标注出价类型, 按扣除费用后的实际到手金额降序排列
+// 订单簿中没有独立的 Trait出价, trait-bids 接口返回的是Item出价按 Trait值分组的结果, 已包含在Item出价中
+func GetItemOffers(ctx context.Context, svcCtx *svc.ServerCtx, chain string, chainID int, collectionAddr, tokenID string, limit int) ([]types.ItemOffer, error) {
+	ctx, span := tracing.Start(ctx, "service.GetItemOffers")
+	defer span.End()
+
+	return queryItemOffers(ctx, svcCtx, chain, chainID, collectionAddr, tokenID, "", limit)
+}
+
+// queryItemOffers 查询并排序适用于指定Item的出价, excludeMaker 不为空时排除该地址发起的出价
+func queryItemOffers(ctx context.Context, svcCtx *svc.ServerCtx, chain string, chainID int, collectionAddr, tokenID, excludeMaker string, limit int) ([]types.ItemOffer, error) {
+	if limit <= 0 {
+		limit = DefaultItemOffers
+	}
+	if limit > MaxItemOffers {
+		limit = MaxItemOffers
+	}
+
+	feeBps, err := sellerFeeBps(ctx, svcCtx, chainID, collectionAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	orders, err := svcCtx.Dao.QueryItemOffers(ctx, chain, collectionAddr, tokenID, excludeMaker, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get item offers")
+	}
+
+	offers := make([]types.ItemOffer, 0, len(orders))
+	for _, order := range orders {
+		bidType := BidTypeItem
+		if order.OrderType == multi.CollectionBidOrder {
+			bidType = BidTypeCollection
+		}
+		offers = append(offers, types.ItemOffer{
+			OrderID:         order.OrderID,
+			MarketplaceId:   order.MarketplaceId,
+			BidType:         bidType,
+			Bidder:          order.Maker,
+			CurrencyAddress: order.CurrencyAddress,
+			Price:           order.Price,
+			EffectivePrice:  netOfFees(order.Price, feeBps),
+			BidSize:         order.Size,
+			BidUnfilled:     order.QuantityRemaining,
+			EventTime:       order.EventTime,
+			ExpireTime:      order.ExpireTime,
+		})
+	}
+
+	// 同一Item的所有出价适用相同的费率, 按实际到手金额排序与按价格排序一致, 这里保证排序稳定
+	sort.SliceStable(offers, func(i, j int) bool {
+		return offers[i].EffectivePrice.GreaterThan(offers[j].EffectivePrice)
+	})
+
+	return offers, nil
+}
+
+// PreviewAcceptOffer 预估持有人接受当前最优出价的到手金额
+// 1. Item不存在时返回 ErrItemNotFound
+// 2. 复用 GetItemOffers 的查询合并Item出价和集合出价(trait-bids 接口按 Trait分组的也是Item出价), 按实际到手金额取最优的一个
+// 3. 查询时排除当前持有人自己发起的出价, 持有人不能接受自己的出价
+// 4. 按集合的市场手续费和版税费率计算费用明细, 费用按支付代币的精度取整
+// 没有可接受的出价时返回 has_offer 为 false 的结果, 不返回错误
+func PreviewAcceptOffer(ctx context.Context, svcCtx *svc.ServerCtx, chain string, chainID int, collectionAddr, tokenID string) (*types.AcceptOfferPreview, error) {
+	ctx, span := tracing.Start(ctx, "service.PreviewAcceptOffer")
+	defer span.End()
+
+	item, err := svcCtx.Dao.QueryItemInfo(ctx, chain, collectionAddr, tokenID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get item info")
+	}
+	// QueryItemInfo 使用 Scan 查询, Item不存在时不返回错误而是返回空结果
+	if item.TokenId == "" {
+		return nil, ErrItemNotFound
+	}
+
+	offers, err := queryItemOffers(ctx, svcCtx, chain, chainID, collectionAddr, tokenID, item.Owner, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &types.AcceptOfferPreview{
+		CollectionAddress: collectionAddr,
+		TokenID:           tokenID,
+		Owner:             item.Owner,
+	}
+	if len(offers) == 0 {
+		return preview, nil
+	}
+	best := &offers[0]
+
+	rates, err := collectionFeeRates(ctx, svcCtx, chainID, collectionAddr)
+	if err != nil {
+		return nil, err
+	}
+	decimals := currencyDecimals(svcCtx, chain, best.CurrencyAddress)
+	fees := &types.ListingFees{
+		MarketplaceBps: rates.MarketplaceBps,
+		MarketplaceFee: feeOf(best.Price, rates.MarketplaceBps, decimals),
+		RoyaltyBps:     rates.RoyaltyBps,
+		RoyaltyFee:     feeOf(best.Price, rates.RoyaltyBps, decimals),
+	}
+
+	preview.HasOffer = true
+	preview.Offer = best
+	preview.Currency, _ = ResolveCurrency(svcCtx, chain, best.CurrencyAddress)
+	preview.Price = best.Price
+	preview.Fees = fees
+	preview.NetProceeds = best.Price.Sub(fees.MarketplaceFee).Sub(fees.RoyaltyFee)
+
+	return preview, nil
+}
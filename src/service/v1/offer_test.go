@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/joinmouse/EasySwapBase/stores/gdb/orderbookmodel/multi"
+
+	"github.com/joinmouse/EasySwapBackend/src/config"
+)
+
+const testOwner = "0x00000000000000000000000000000000000000aa"
+
+func TestPreviewAcceptOfferItemNotFound(t *testing.T) {
+	svcCtx, _ := newStubServerCtx(t)
+
+	if _, err := PreviewAcceptOffer(context.Background(), svcCtx, "eth", 1, testCollection, "1"); err != ErrItemNotFound {
+		t.Errorf("PreviewAcceptOffer() error = %v, want %v", err, ErrItemNotFound)
+	}
+}
+
+func TestPreviewAcceptOfferExcludesOwner(t *testing.T) {
+	svcCtx, stub := newStubServerCtx(t)
+	svcCtx.C.ChainSupported = []*config.ChainSupported{{ChainID: 1, Name: "eth"}}
+	stub.results = func(query string) *stubRows {
+		switch {
+		case strings.Contains(query, multi.ItemTableName("eth")):
+			return &stubRows{
+				columns: []string{"collection_address", "token_id", "owner"},
+				values:  [][]driver.Value{{testCollection, "1", testOwner}},
+			}
+		case strings.Contains(query, "maker != ?"):
+			return &stubRows{
+				columns: []string{"order_id", "order_type", "price", "maker"},
+				values:  [][]driver.Value{{"0xorder", int64(multi.ItemBidOrder), "1.5", "0xbidder"}},
+			}
+		}
+		return nil
+	}
+
+	preview, err := PreviewAcceptOffer(context.Background(), svcCtx, "eth", 1, testCollection, "1")
+	if err != nil {
+		t.Fatalf("PreviewAcceptOffer() error: %v", err)
+	}
+	// 持有人自己的出价在查询中排除, 不在内存中过滤
+	if !preview.HasOffer || preview.Offer.Bidder != "0xbidder" || preview.Price.String() != "1.5" {
+		t.Errorf("PreviewAcceptOffer() = %+v", preview)
+	}
+}
@@ -3,10 +3,14 @@ package service
 import (
 	"context"
 	"sort"
+	"strings"
+	"sync"
 
 	"github.com/joinmouse/EasySwapBase/stores/gdb/orderbookmodel/multi"
 	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
 
+	"github.com/joinmouse/EasySwapBackend/src/common/tracing"
 	"github.com/joinmouse/EasySwapBackend/src/service/svc"
 	"github.com/joinmouse/EasySwapBackend/src/types/v1"
 )
@@ -14,6 +18,9 @@ import (
 // GetOrderInfos 获取订单信息
 // 该函数主要用于获取指定NFT的出价信息,包括单个NFT的最高出价和整个Collection的最高出价
 func GetOrderInfos(ctx context.Context, svcCtx *svc.ServerCtx, chainID int, chain string, userAddr string, collectionAddr string, tokenIds []string) ([]types.ItemBid, error) {
+	ctx, span := tracing.Start(ctx, "service.GetOrderInfos")
+	defer span.End()
+
 	// 1. 构建NFT信息列表
 	var items []types.ItemInfo
 	for _, tokenID := range tokenIds {
@@ -52,6 +59,89 @@ func GetOrderInfos(ctx context.Context, svcCtx *svc.ServerCtx, chainID int, chai
 	return processBids(tokenIds, itemsBestBids, collectionBids, collectionAddr), nil
 }
 
+// MaxBatchOrderDetails 批量查询订单详情的最大数量
+const MaxBatchOrderDetails = 100
+
+// GetOrderDetails 根据订单ID批量查询订单详情
+// 1. 每条链只执行一次查询, 多条链并发查询
+// 2. 所有链上都不存在的订单ID放入 NotFound 返回
+// chains 为链ID到链名称的映射, orderIDs 需由调用方去重
+func GetOrderDetails(ctx context.Context, svcCtx *svc.ServerCtx, chains map[int]string, orderIDs []string) (*types.OrderDetailsResp, error) {
+	ctx, span := tracing.Start(ctx, "service.GetOrderDetails")
+	defer span.End()
+
+	var mu sync.Mutex
+	var g errgroup.Group
+	details := make([]types.OrderDetail, 0, len(orderIDs))
+	for chainID, chain := range chains {
+		chainID, chain := chainID, chain
+		g.Go(func() error {
+			orders, err := svcCtx.Dao.QueryOrdersByIDs(ctx, chain, orderIDs)
+			if err != nil {
+				return errors.Wrap(err, "failed on query orders")
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, order := range orders {
+				currency, currencyAddr := ResolveCurrency(svcCtx, chain, order.CurrencyAddress)
+				details = append(details, types.OrderDetail{
+					ChainID:           chainID,
+					OrderID:           order.OrderID,
+					OrderType:         order.OrderType,
+					OrderStatus:       order.OrderStatus,
+					MarketplaceID:     order.MarketplaceId,
+					CollectionAddress: order.CollectionAddress,
+					TokenID:           order.TokenId,
+					Maker:             order.Maker,
+					Taker:             order.Taker,
+					Price:             order.Price,
+					Currency:          currency,
+					CurrencyAddress:   currencyAddr,
+					Size:              order.Size,
+					QuantityRemaining: order.QuantityRemaining,
+					FilledAmount:      order.Size - order.QuantityRemaining,
+					Salt:              order.Salt,
+					EventTime:         order.EventTime,
+					ExpireTime:        order.ExpireTime,
+				})
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	// 按请求中的订单ID顺序返回
+	index := make(map[string]int, len(orderIDs))
+	for i, orderID := range orderIDs {
+		index[strings.ToLower(orderID)] = i
+	}
+	sort.SliceStable(details, func(i, j int) bool {
+		if details[i].OrderID != details[j].OrderID {
+			return index[strings.ToLower(details[i].OrderID)] < index[strings.ToLower(details[j].OrderID)]
+		}
+		return details[i].ChainID < details[j].ChainID
+	})
+
+	found := make(map[string]bool, len(details))
+	for _, detail := range details {
+		found[strings.ToLower(detail.OrderID)] = true
+	}
+	notFound := make([]string, 0)
+	for _, orderID := range orderIDs {
+		if !found[strings.ToLower(orderID)] {
+			notFound = append(notFound, orderID)
+		}
+	}
+
+	return &types.OrderDetailsResp{
+		Orders:   details,
+		NotFound: notFound,
+	}, nil
+}
+
 // processBids 处理NFT的出价信息,返回每个NFT的最高出价
 // 参数说明:
 // - tokenIds: NFT的token ID列表
@@ -78,7 +168,7 @@ func processBids(tokenIds []string, itemsBestBids map[string]multi.Order, collec
 		return itemsSortedBids[i].Price.LessThan(itemsSortedBids[j].Price)
 	})
 
-	var resultBids []types.ItemBid
+	resultBids := []types.ItemBid{}
 	var cBidIndex int // Collection级别出价的索引
 
 	// 处理没有单独出价的NFT
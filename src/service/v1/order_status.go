@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/joinmouse/EasySwapBase/stores/gdb/orderbookmodel/multi"
+	"github.com/pkg/errors"
+
+	"github.com/joinmouse/EasySwapBackend/src/common/tracing"
+	"github.com/joinmouse/EasySwapBackend/src/dao"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+	"github.com/joinmouse/EasySwapBackend/src/types/v1"
+)
+
+// orderActivityTypes 订单类型对应的创建和取消活动类型
+// bid 为 true 时挂单人是出价方, 成交活动中为 taker; 否则挂单人是卖方, 成交活动中为 maker
+type orderActivityTypes struct {
+	created   int
+	cancelled int
+	bid       bool
+}
+
+var orderActivities = map[int64]orderActivityTypes{
+	multi.ListingOrder:       {created: multi.Listing, cancelled: multi.CancelListing},
+	multi.OfferOrder:         {created: multi.MakeOffer, cancelled: multi.CancelOffer, bid: true},
+	multi.CollectionBidOrder: {created: multi.CollectionBid, cancelled: multi.CancelCollectionBid, bid: true},
+	multi.ItemBidOrder:       {created: multi.ItemBid, cancelled: multi.CancelItemBid, bid: true},
+}
+
+// fillActivityTypes 订单成交的活动类型
+var fillActivityTypes = []int{multi.Sale, multi.Buy}
+
+// GetOrderStatus 查询订单的当前状态和状态变化时间线
+// 1. 从订单表读取当前状态, 有效但已超过过期时间的订单视为已过期
+// 2. 交易活动表没有记录订单ID, 按集合、token id、价格和挂单人匹配订单的创建、成交和取消活动
+// 3. 每条成交活动按成交 1 个计算, 累计成交数量达到订单数量时为 filled, 否则为 partially_filled
+// 4. 订单状态已变化但没有匹配到对应活动时, 使用订单的更新时间补充事件, 不带交易哈希
+func GetOrderStatus(ctx context.Context, svcCtx *svc.ServerCtx, chainID int, chain string, orderID string) (*types.OrderStatusResp, error) {
+	ctx, span := tracing.Start(ctx, "service.GetOrderStatus")
+	defer span.End()
+
+	orders, err := svcCtx.Dao.QueryOrdersByIDs(ctx, chain, []string{orderID})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on query order")
+	}
+	if len(orders) == 0 {
+		return nil, ErrOrderNotFound
+	}
+	order := orders[0]
+
+	status := order.OrderStatus
+	now := time.Now().Unix()
+	if status == types.OrderStatusActive && order.ExpireTime > 0 && order.ExpireTime <= now {
+		status = types.OrderStatusExpired
+	}
+
+	res := &types.OrderStatusResp{
+		ChainID:           chainID,
+		OrderID:           order.OrderID,
+		OrderType:         order.OrderType,
+		Status:            status,
+		StatusLabel:       types.OrderStatusLabel(status),
+		Size:              order.Size,
+		QuantityRemaining: order.QuantityRemaining,
+		FilledAmount:      order.Size - order.QuantityRemaining,
+		Timeline:          []types.OrderStatusEvent{},
+	}
+
+	var activities []multi.Activity
+	if kinds, ok := orderActivities[order.OrderType]; ok {
+		filter := dao.OrderActivityFilter{
+			CollectionAddress: order.CollectionAddress,
+			Price:             order.Price,
+			Since:             order.EventTime,
+			Account:           order.Maker,
+			MakerTypes:        []int{kinds.created, kinds.cancelled},
+		}
+		if order.OrderType != multi.CollectionBidOrder {
+			filter.TokenID = order.TokenId
+		}
+		if kinds.bid {
+			filter.TakerTypes = fillActivityTypes
+		} else {
+			filter.MakerTypes = append(filter.MakerTypes, fillActivityTypes...)
+		}
+
+		activities, err = svcCtx.Dao.QueryOrderActivities(ctx, chain, filter)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed on query order activities")
+		}
+	}
+
+	res.Timeline = buildOrderTimeline(order, status, activities)
+	return res, nil
+}
+
+// buildOrderTimeline 根据订单和匹配到的交易活动构建状态变化时间线
+func buildOrderTimeline(order multi.Order, status int, activities []multi.Activity) []types.OrderStatusEvent {
+	kinds := orderActivities[order.OrderType]
+	size := order.Size
+	if size <= 0 {
+		size = 1
+	}
+
+	created := types.OrderStatusEvent{Event: types.OrderEventCreated, Timestamp: order.EventTime}
+	timeline := []types.OrderStatusEvent{}
+	var filled int64
+	var cancelled bool
+	for _, activity := range activities {
+		switch {
+		case activity.ActivityType == kinds.created:
+			if created.TxHash == "" {
+				created.TxHash = activity.TxHash
+			}
+		case activity.ActivityType == kinds.cancelled:
+			if !cancelled && filled < size {
+				cancelled = true
+				timeline = append(timeline, types.OrderStatusEvent{
+					Event: types.OrderEventCancelled, Timestamp: activity.EventTime, TxHash: activity.TxHash,
+				})
+			}
+		default:
+			// 成交
+			if cancelled || filled >= size {
+				continue
+			}
+			filled++
+			event := types.OrderEventPartiallyFilled
+			if filled >= size {
+				event = types.OrderEventFilled
+			}
+			timeline = append(timeline, types.OrderStatusEvent{
+				Event: event, Timestamp: activity.EventTime, TxHash: activity.TxHash, Filled: filled,
+			})
+		}
+	}
+	timeline = append([]types.OrderStatusEvent{created}, timeline...)
+
+	// 订单状态已变化但没有匹配到对应的活动, 使用订单的更新时间(毫秒)补充事件
+	updatedAt := order.UpdateTime / 1000
+	last := &timeline[len(timeline)-1]
+	switch status {
+	case types.OrderStatusFilled:
+		if last.Event == types.OrderEventPartiallyFilled {
+			// ERC-1155 单次成交可能不止 1 个, 以订单表的成交数量为准
+			last.Event = types.OrderEventFilled
+			last.Filled = order.Size
+		} else if last.Event != types.OrderEventFilled {
+			timeline = append(timeline, types.OrderStatusEvent{Event: types.OrderEventFilled, Timestamp: updatedAt, Filled: order.Size})
+		}
+	case types.OrderStatusCancelled:
+		if !cancelled {
+			timeline = append(timeline, types.OrderStatusEvent{Event: types.OrderEventCancelled, Timestamp: updatedAt})
+		}
+	case types.OrderStatusExpired:
+		timeline = append(timeline, types.OrderStatusEvent{Event: types.OrderEventExpired, Timestamp: order.ExpireTime})
+	}
+
+	return timeline
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/joinmouse/EasySwapBase/stores/gdb/orderbookmodel/multi"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"github.com/joinmouse/EasySwapBackend/src/common"
+	"github.com/joinmouse/EasySwapBackend/src/common/tracing"
+	"github.com/joinmouse/EasySwapBackend/src/config"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+	"github.com/joinmouse/EasySwapBackend/src/types/v1"
+)
+
+const (
+	// MinOrderLifetime 订单剩余有效期低于该值时提示用户, 签名和上链期间订单可能已经过期
+	MinOrderLifetime = 10 * time.Minute
+	// ListingBelowFloorRatio 挂单价格低于地板价的该比例时提示用户, 避免误填价格
+	ListingBelowFloorRatio = 0.5
+)
+
+// orderValidator 收集订单各字段的校验结果
+type orderValidator struct {
+	results []types.OrderCheck
+}
+
+func (v *orderValidator) add(field, level, code, message string) {
+	v.results = append(v.results, types.OrderCheck{Field: field, Level: level, Code: code, Message: message})
+}
+
+func (v *orderValidator) ok(field string) {
+	v.add(field, types.OrderCheckOK, "ok", "")
+}
+
+func (v *orderValidator) warn(field, code, message string) {
+	v.add(field, types.OrderCheckWarning, code, message)
+}
+
+func (v *orderValidator) fail(field, code, message string) {
+	v.add(field, types.OrderCheckError, code, message)
+}
+
+// ValidateOrder 在用户签名前校验订单, 不写入任何数据
+// 主要功能:
+// 1. 校验链是否支持、订单类型、地址和 token id 格式
+// 2. 校验集合是否已收录、是否被隐藏, 以及 ERC-721 订单的数量
+// 3. 校验支付代币是否为链配置中支持的币种
+// 4. 校验价格是否在合理范围内, 与地板价偏离较大时给出警告
+// 5. 校验过期时间, 已过期为错误, 即将过期为警告
+// 每个字段返回一条校验结果, 前置字段不合法导致无法继续的检查会被跳过
+func ValidateOrder(ctx context.Context, svcCtx *svc.ServerCtx, req types.OrderValidateReq) (*types.OrderValidateResp, error) {
+	ctx, span := tracing.Start(ctx, "service.ValidateOrder")
+	defer span.End()
+
+	v := &orderValidator{}
+
+	// 1. 链
+	chainCfg := chainConfigByID(svcCtx, req.ChainID)
+	if chainCfg == nil {
+		v.fail("chain_id", "unsupported_chain", fmt.Sprintf("Chain %d is not supported.", req.ChainID))
+	} else if svcCtx.IsChainDegraded(int64(req.ChainID)) {
+		v.fail("chain_id", "chain_unavailable", "Chain is temporarily unavailable.")
+		chainCfg = nil
+	} else {
+		v.ok("chain_id")
+	}
+
+	// 2. 订单类型
+	switch req.OrderType {
+	case multi.ListingOrder, multi.CollectionBidOrder, multi.ItemBidOrder:
+		v.ok("order_type")
+	default:
+		v.fail("order_type", "invalid_order_type", "Order type must be 1 (listing), 3 (collection bid) or 4 (item bid).")
+	}
+
+	// 3. 挂单人地址
+	if _, err := common.UnifyAddress(req.Maker); err != nil {
+		v.fail("maker", "invalid_address", "Maker is not a valid address.")
+	} else {
+		v.ok("maker")
+	}
+
+	// 4. token id, 集合出价对整个集合生效, 不需要 token id
+	switch {
+	case req.OrderType == multi.CollectionBidOrder:
+		if req.TokenID != "" {
+			v.warn("token_id", "token_id_ignored", "Collection bids apply to every item, token_id is ignored.")
+		} else {
+			v.ok("token_id")
+		}
+	case req.TokenID == "":
+		v.fail("token_id", "required", "token_id is required.")
+	default:
+		if _, err := common.NormalizeTokenID(req.TokenID); err != nil {
+			v.fail("token_id", "invalid_token_id", "token_id must be a non-negative integer.")
+		} else {
+			v.ok("token_id")
+		}
+	}
+
+	// 5. 集合及数量
+	collection, err := validateOrderCollection(ctx, svcCtx, v, chainCfg, req)
+	if err != nil {
+		return nil, err
+	}
+	size := req.Size
+	if size == 0 {
+		size = 1
+	}
+	switch {
+	case size < 0:
+		v.fail("size", "invalid_size", "Size must be a positive integer.")
+	case size > 1 && req.OrderType != multi.CollectionBidOrder && collection != nil &&
+		tokenStandardName(collection.TokenStandard) == TokenStandardERC721:
+		v.fail("size", "invalid_size", "ERC-721 orders must have a size of 1.")
+	default:
+		v.ok("size")
+	}
+
+	// 6. 支付代币
+	switch {
+	case chainCfg == nil:
+	case req.CurrencyAddress == "" || strings.EqualFold(req.CurrencyAddress, ZeroAddress):
+		v.ok("currency_address")
+	default:
+		if symbol, _ := ResolveCurrency(svcCtx, chainCfg.Name, req.CurrencyAddress); symbol == "" {
+			v.fail("currency_address", "unsupported_currency", "Currency is not supported on this chain.")
+		} else {
+			v.ok("currency_address")
+		}
+	}
+
+	// 7. 价格
+	validateOrderPrice(svcCtx, v, collection, req)
+
+	// 8. 过期时间
+	now := time.Now()
+	switch {
+	case req.ExpireTime <= now.Unix():
+		v.fail("expire_time", "expired", "Order has already expired.")
+	case req.ExpireTime < now.Add(MinOrderLifetime).Unix():
+		v.warn("expire_time", "expiring_soon", "Order expires in less than 10 minutes and may expire before it is accepted.")
+	default:
+		v.ok("expire_time")
+	}
+
+	resp := &types.OrderValidateResp{Valid: true, Status: types.OrderCheckOK, Results: v.results}
+	for _, result := range v.results {
+		switch result.Level {
+		case types.OrderCheckError:
+			resp.Valid = false
+			resp.Status = types.OrderCheckError
+		case types.OrderCheckWarning:
+			if resp.Status == types.OrderCheckOK {
+				resp.Status = types.OrderCheckWarning
+			}
+		}
+	}
+
+	return resp, nil
+}
+
+// validateOrderCollection 校验订单的集合地址, 集合已收录时返回集合信息
+func validateOrderCollection(ctx context.Context, svcCtx *svc.ServerCtx, v *orderValidator, chainCfg *config.ChainSupported, req types.OrderValidateReq) (*multi.Collection, error) {
+	collectionAddr, err := common.UnifyAddress(req.CollectionAddress)
+	if err != nil {
+		v.fail("collection_address", "invalid_address", "Collection address is not a valid address.")
+		return nil, nil
+	}
+	if chainCfg == nil {
+		return nil, nil
+	}
+
+	collection, err := svcCtx.Dao.QueryCollectionInfo(ctx, chainCfg.Name, collectionAddr)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			v.fail("collection_address", "unsupported_collection", "Collection is not supported.")
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "failed on get collection info")
+	}
+
+	hidden, err := svcCtx.Dao.IsCollectionHidden(ctx, chainCfg.ChainID, collectionAddr)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get collection flag")
+	}
+	if hidden {
+		v.warn("collection_address", "collection_flagged", "Collection has been reported and is hidden from search.")
+	} else {
+		v.ok("collection_address")
+	}
+
+	return collection, nil
+}
+
+// validateOrderPrice 校验订单价格
+// 价格必须为正数且不超过配置的最大价格; 挂单远低于地板价、出价高于地板价时给出警告
+func validateOrderPrice(svcCtx *svc.ServerCtx, v *orderValidator, collection *multi.Collection, req types.OrderValidateReq) {
+	if req.Price == nil {
+		v.fail("price", "required", "price is required.")
+		return
+	}
+	price := req.Price.Decimal
+	if common.IsZeroOrNeg(price) {
+		v.fail("price", "invalid_price", "Price must be greater than 0.")
+		return
+	}
+	if !IsSanePrice(svcCtx, price) {
+		v.fail("price", "price_too_high", "Price exceeds the maximum allowed price.")
+		return
+	}
+
+	floor := decimal.Zero
+	if collection != nil {
+		floor = collection.FloorPrice
+	}
+	if common.IsZeroOrNeg(floor) {
+		v.ok("price")
+		return
+	}
+
+	switch req.OrderType {
+	case multi.ListingOrder:
+		if price.LessThan(floor.Mul(decimal.NewFromFloat(ListingBelowFloorRatio))) {
+			v.warn("price", "below_floor", fmt.Sprintf("Price is far below the floor price %s.", floor.String()))
+			return
+		}
+	case multi.CollectionBidOrder, multi.ItemBidOrder:
+		if price.GreaterThan(floor) {
+			v.warn("price", "above_floor", fmt.Sprintf("Bid is above the floor price %s, the item may be bought for less.", floor.String()))
+			return
+		}
+	}
+	v.ok("price")
+}
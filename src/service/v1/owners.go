@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/joinmouse/EasySwapBase/logger/xzap"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/joinmouse/EasySwapBackend/src/common/tracing"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+	"github.com/joinmouse/EasySwapBackend/src/types/v1"
+)
+
+const (
+	OwnerDistributionCacheKey = "cache:es:collection:owners:distribution:%s:%s"
+	OwnerDistributionCacheTTL = 5 * 60 // second
+
+	// WhaleTopHolders 计算持有集中度时统计的头部持有人数量
+	WhaleTopHolders = 10
+)
+
+func ownerDistributionCacheKey(chain, collectionAddr string) string {
+	return fmt.Sprintf(OwnerDistributionCacheKey, strings.ToLower(chain), strings.ToLower(collectionAddr))
+}
+
+// GetCollectionOwners 分页获取集合的持有人及持有数量, 并返回持有人分布概览
+// 持有数量按持有的 Item 记录数统计, ERC-1155 集合没有持有者余额数据, 每条持有记录计为1个
+func GetCollectionOwners(ctx context.Context, svcCtx *svc.ServerCtx, chain, collectionAddr string, page, pageSize int) (*types.CollectionOwnersResp, error) {
+	ctx, span := tracing.Start(ctx, "service.GetCollectionOwners")
+	defer span.End()
+
+	if _, err := svcCtx.Dao.QueryCollectionInfo(ctx, chain, collectionAddr); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCollectionNotFound
+		}
+		return nil, errors.Wrap(err, "failed on get collection info")
+	}
+
+	owners, total, err := svcCtx.Dao.QueryCollectionOwners(ctx, chain, collectionAddr, page, pageSize)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get collection owners")
+	}
+	if owners == nil {
+		owners = []types.ItemOwnerBalance{}
+	}
+
+	distribution, err := getOwnerDistribution(ctx, svcCtx, chain, collectionAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.CollectionOwnersResp{
+		PageResp: types.PageResp{
+			Total:    total,
+			Page:     page,
+			PageSize: pageSize,
+			Items:    owners,
+		},
+		Distribution: distribution,
+	}, nil
+}
+
+// GetOwnerItems 分页获取持有人在集合中持有的 Item 详情
+// 返回的 Item 详情与批量查询 Item 详情相同
+func GetOwnerItems(ctx context.Context, svcCtx *svc.ServerCtx, chain string, chainID int, collectionAddr, owner string, page, pageSize int) (*types.PageResp, error) {
+	ctx, span := tracing.Start(ctx, "service.GetOwnerItems")
+	defer span.End()
+
+	if _, err := svcCtx.Dao.QueryCollectionInfo(ctx, chain, collectionAddr); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCollectionNotFound
+		}
+		return nil, errors.Wrap(err, "failed on get collection info")
+	}
+
+	tokenIDs, total, err := svcCtx.Dao.QueryOwnerTokenIDs(ctx, chain, collectionAddr, owner, page, pageSize)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get owner items")
+	}
+
+	items := make([]types.ItemDetailInfo, 0, len(tokenIDs))
+	if len(tokenIDs) > 0 {
+		details, err := GetItemsDetail(ctx, svcCtx, chain, chainID, collectionAddr, tokenIDs)
+		if err != nil {
+			return nil, err
+		}
+		// 按 token id 的查询顺序返回
+		for _, tokenID := range tokenIDs {
+			if detail, ok := details.Result[tokenID]; ok {
+				items = append(items, detail)
+			}
+		}
+	}
+
+	return &types.PageResp{
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+		Items:    items,
+	}, nil
+}
+
+// getOwnerDistribution 获取集合的持有人分布
+// 优先读取Redis缓存,缓存不存在或已过期时从数据库重新计算并写回缓存
+func getOwnerDistribution(ctx context.Context, svcCtx *svc.ServerCtx, chain, collectionAddr string) (*types.CollectionOwnerDistribution, error) {
+	cacheKey := ownerDistributionCacheKey(chain, collectionAddr)
+	if cached, err := svcCtx.KvStore.Get(cacheKey); err == nil && cached != "" {
+		var dist types.CollectionOwnerDistribution
+		if err := json.Unmarshal([]byte(cached), &dist); err == nil {
+			return &dist, nil
+		}
+	}
+
+	stats, err := svcCtx.Dao.QueryCollectionOwnerDistribution(ctx, chain, collectionAddr, WhaleTopHolders)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get collection owner distribution")
+	}
+
+	dist := &types.CollectionOwnerDistribution{
+		Holders:   stats.Holders,
+		TotalHeld: stats.TotalHeld,
+		Buckets: []types.OwnerBucket{
+			{Range: "1", Holders: stats.Holding1},
+			{Range: "2-5", Holders: stats.Holding2To5},
+			{Range: "6-20", Holders: stats.Holding6To20},
+			{Range: "20+", Holders: stats.HoldingOver},
+		},
+		TopHolders: WhaleTopHolders,
+		TopHeld:    stats.TopHeld,
+	}
+	if stats.TotalHeld > 0 {
+		dist.TopHeldShare = float64(stats.TopHeld) * 100 / float64(stats.TotalHeld)
+	}
+
+	raw, err := json.Marshal(dist)
+	if err == nil {
+		if err := svcCtx.KvStore.Setex(cacheKey, string(raw), OwnerDistributionCacheTTL); err != nil {
+			xzap.WithContext(ctx).Warn("failed on cache owner distribution", zap.Error(err))
+		}
+	}
+
+	return dist, nil
+}
@@ -6,11 +6,17 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/joinmouse/EasySwapBase/logger/xzap"
 	"github.com/joinmouse/EasySwapBase/stores/gdb/orderbookmodel/multi"
 	"github.com/pkg/errors"
 	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
 
+	"github.com/joinmouse/EasySwapBackend/src/common"
+	"github.com/joinmouse/EasySwapBackend/src/common/tracing"
 	"github.com/joinmouse/EasySwapBackend/src/dao"
 	"github.com/joinmouse/EasySwapBackend/src/service/svc"
 	"github.com/joinmouse/EasySwapBackend/src/types/v1"
@@ -18,6 +24,9 @@ import (
 
 const BidTypeOffset = 3
 
+// PerChainQueryTimeout 多链聚合查询时单条链的超时时间
+const PerChainQueryTimeout = 5 * time.Second
+
 func getBidType(origin int64) int64 {
 	if origin >= BidTypeOffset {
 		return origin - BidTypeOffset
@@ -26,8 +35,29 @@ func getBidType(origin int64) int64 {
 	}
 }
 
-// GetMultiChainUserCollections 获取用户拥有Collection信息： 拥有item数量、上架数量、floor price
-func GetMultiChainUserCollections(ctx context.Context, svcCtx *svc.ServerCtx, chainIDs []int, chainNames []string, userAddrs []string) (*types.UserCollectionsResp, error) {
+// 用户持有集合列表的排序方式
+const (
+	PortfolioCollectionSortValue = "value" // 按地板价估算的持仓价值降序
+	PortfolioCollectionSortCount = "count" // 按持有数量降序
+	PortfolioCollectionSortName  = "name"  // 按集合名称升序
+)
+
+// IsPortfolioCollectionSort 判断是否为支持的持有集合排序方式, 空字符串使用默认的持仓价值排序
+func IsPortfolioCollectionSort(sortBy string) bool {
+	switch sortBy {
+	case "", PortfolioCollectionSortValue, PortfolioCollectionSortCount, PortfolioCollectionSortName:
+		return true
+	}
+	return false
+}
+
+// GetMultiChainUserCollections 获取用户拥有Collection信息： 拥有item数量、上架数量、floor price、持仓估值
+// 持有数量和估值由按集合分组的查询一次算出; minItems 过滤持有数量较少的集合, 不影响各链的汇总信息
+// sortBy 为空时按持仓估值降序, 排序值相同时按链ID和集合地址排序, 保证顺序稳定
+func GetMultiChainUserCollections(ctx context.Context, svcCtx *svc.ServerCtx, chainIDs []int, chainNames []string, userAddrs []string, sortBy string, minItems int64) (*types.UserCollectionsResp, error) {
+	ctx, span := tracing.Start(ctx, "service.GetMultiChainUserCollections")
+	defer span.End()
+
 	// 1. 查询用户在多条链上的Collection基本信息
 	collections, err := svcCtx.Dao.QueryMultiChainUserCollectionInfos(ctx, chainIDs, chainNames, userAddrs)
 	if err != nil {
@@ -78,33 +108,44 @@ func GetMultiChainUserCollections(ctx context.Context, svcCtx *svc.ServerCtx, ch
 	}
 
 	// 6. 组装最终结果
-	var results types.UserCollectionsData
+	results := types.UserCollectionsData{
+		CollectionInfos: []types.CollectionInfo{},
+		ChainInfos:      []types.ChainInfo{},
+	}
 	chainInfos := make(map[int]types.ChainInfo)
 	for _, collection := range collections {
 		// 6.1 添加Collection信息
-		listCount := collectionsListed[strings.ToLower(collection.Address)]
-		results.CollectionInfos = append(results.CollectionInfos, types.CollectionInfo{
-			ChainID:    collection.ChainID,
-			Name:       collection.Name,
-			Address:    collection.Address,
-			Symbol:     collection.Symbol,
-			ImageURI:   collection.ImageURI,
-			ListAmount: listCount,
-			ItemAmount: collection.ItemCount,
-			FloorPrice: collection.FloorPrice,
-		})
+		// 地板价异常(为负或超过最大价格)的集合不计入持仓价值
+		itemValue := decimal.Zero
+		if IsSanePrice(svcCtx, collection.FloorPrice) {
+			itemValue = decimal.New(collection.ItemCount, 0).Mul(collection.FloorPrice)
+		}
+		if collection.ItemCount >= minItems {
+			listCount := collectionsListed[strings.ToLower(collection.Address)]
+			results.CollectionInfos = append(results.CollectionInfos, types.CollectionInfo{
+				ChainID:        collection.ChainID,
+				Name:           collection.Name,
+				Address:        collection.Address,
+				Symbol:         collection.Symbol,
+				ImageURI:       collection.ImageURI,
+				ListAmount:     listCount,
+				ItemAmount:     collection.ItemCount,
+				FloorPrice:     collection.FloorPrice,
+				EstimatedValue: itemValue,
+			})
+		}
 
 		// 6.2 计算每条链的统计信息
 		chainInfo, ok := chainInfos[collection.ChainID]
 		if ok {
 			chainInfo.ItemOwned += collection.ItemCount
-			chainInfo.ItemValue = chainInfo.ItemValue.Add(decimal.New(collection.ItemCount, 0).Mul(collection.FloorPrice))
+			chainInfo.ItemValue = common.SumPrices(chainInfo.ItemValue, itemValue)
 			chainInfos[collection.ChainID] = chainInfo
 		} else {
 			chainInfos[collection.ChainID] = types.ChainInfo{
 				ChainID:   collection.ChainID,
 				ItemOwned: collection.ItemCount,
-				ItemValue: decimal.New(collection.ItemCount, 0).Mul(collection.FloorPrice),
+				ItemValue: itemValue,
 			}
 		}
 	}
@@ -113,19 +154,57 @@ func GetMultiChainUserCollections(ctx context.Context, svcCtx *svc.ServerCtx, ch
 	for _, chainInfo := range chainInfos {
 		results.ChainInfos = append(results.ChainInfos, chainInfo)
 	}
+	sort.Slice(results.ChainInfos, func(i, j int) bool {
+		return results.ChainInfos[i].ChainID < results.ChainInfos[j].ChainID
+	})
+
+	// 7. 排序
+	sortUserCollections(results.CollectionInfos, sortBy)
 
 	return &types.UserCollectionsResp{
 		Result: results,
 	}, nil
 }
 
+// sortUserCollections 按排序方式排列用户持有的集合, 排序值相同时按链ID和集合地址排序
+func sortUserCollections(collections []types.CollectionInfo, sortBy string) {
+	sort.Slice(collections, func(i, j int) bool {
+		a, b := collections[i], collections[j]
+		switch sortBy {
+		case PortfolioCollectionSortCount:
+			if a.ItemAmount != b.ItemAmount {
+				return a.ItemAmount > b.ItemAmount
+			}
+		case PortfolioCollectionSortName:
+			if an, bn := strings.ToLower(a.Name), strings.ToLower(b.Name); an != bn {
+				return an < bn
+			}
+		default:
+			if !a.EstimatedValue.Equal(b.EstimatedValue) {
+				return a.EstimatedValue.GreaterThan(b.EstimatedValue)
+			}
+		}
+		if a.ChainID != b.ChainID {
+			return a.ChainID < b.ChainID
+		}
+		return strings.ToLower(a.Address) < strings.ToLower(b.Address)
+	})
+}
+
 // GetMultiChainUserItems 查询用户拥有nft的Item基本信息，list信息和bid信息，从Item表和Activity表中查询
+// Item 列表跨链查询, 失败时整个请求失败; 详情按链查询, 某条链失败时只缺失该链的详情
 func GetMultiChainUserItems(ctx context.Context, svcCtx *svc.ServerCtx, chainID []int, chain []string, userAddrs []string, contractAddrs []string, page, pageSize int) (*types.UserItemsResp, error) {
+	ctx, span := tracing.Start(ctx, "service.GetMultiChainUserItems")
+	defer span.End()
+
 	// 1.
 	items, count, err := svcCtx.Dao.QueryMultiChainUserItemInfos(ctx, chain, userAddrs, contractAddrs, page, pageSize)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed on get user items info")
 	}
+	if items == nil {
+		items = []types.PortfolioItemInfo{}
+	}
 
 	// 如果没有Item,直接返回空结果
 	if count == 0 {
@@ -141,28 +220,24 @@ func GetMultiChainUserItems(ctx context.Context, svcCtx *svc.ServerCtx, chainID
 		chainIDToChainName[chainID[i]] = chain[i]
 	}
 
-	// 3. 准备查询参数
-	var collectionAddrs [][]string                          // Collection地址和链名称对
-	var itemInfos []dao.MultiChainItemInfo                  // Item信息
-	var chainCollections = make(map[string][]string)        // 按链分组的Collection地址
-	var multichainItems = make(map[string][]types.ItemInfo) // 按链分组的Item信息
-
-	// 遍历Item,构建查询参数
+	// 3. 按链分组准备查询参数
+	var chainNames []string
+	chainQueries := make(map[string]*chainItemsQuery)
 	for _, item := range items {
-		collectionAddrs = append(collectionAddrs, []string{strings.ToLower(item.CollectionAddress), chainIDToChainName[item.ChainID]})
-		itemInfos = append(itemInfos, dao.MultiChainItemInfo{
-			ItemInfo: types.ItemInfo{
-				CollectionAddress: item.CollectionAddress,
-				TokenID:           item.TokenID,
-			},
-			ChainName: chainIDToChainName[item.ChainID],
-		})
-		chainCollections[strings.ToLower(chainIDToChainName[item.ChainID])] = append(chainCollections[strings.ToLower(chainIDToChainName[item.ChainID])], item.CollectionAddress)
-		multichainItems[chainIDToChainName[item.ChainID]] = append(multichainItems[chainIDToChainName[item.ChainID]], types.ItemInfo{
+		chainName := chainIDToChainName[item.ChainID]
+		q, ok := chainQueries[chainName]
+		if !ok {
+			q = &chainItemsQuery{}
+			chainQueries[chainName] = q
+			chainNames = append(chainNames, chainName)
+		}
+		q.collectionAddrs = append(q.collectionAddrs, item.CollectionAddress)
+		q.items = append(q.items, types.ItemInfo{
 			CollectionAddress: item.CollectionAddress,
 			TokenID:           item.TokenID,
 		})
 	}
+	sort.Strings(chainNames)
 
 	// 4. 获取用户地址
 	var userAddr string
@@ -172,130 +247,65 @@ func GetMultiChainUserItems(ctx context.Context, svcCtx *svc.ServerCtx, chainID
 		userAddr = userAddrs[0]
 	}
 
-	// 5. 并发查询Collection最高出价信息
+	// 5. 按链并发查询出价、Collection、挂单和图片信息
+	// 每条链单独设置超时, 某条链失败或超时时该链上的Item只返回基本信息, 并在 failed_chains 中返回
 	collectionBestBids := make(map[types.MultichainCollection]multi.Order)
-	var wg sync.WaitGroup
+	itemsBestBids := make(map[dao.MultiChainItemInfo]multi.Order)
+	collectionInfos := make(map[string]multi.Collection)
+	listingInfos := make(map[string]*dao.CollectionItem)
+	orderIds := make(map[string]multi.Order)
+	itemExternals := make(map[string]multi.ItemExternal)
+	var failedChains []string
 	var mu sync.Mutex
-	var queryErr error
-	for chain, collections := range chainCollections {
-		wg.Add(1)
-		go func(chainName string, collectionArray []string) {
-			defer wg.Done()
-			bestBids, err := svcCtx.Dao.QueryCollectionsBestBid(ctx, chainName, userAddr, collectionArray)
-			if err != nil {
-				queryErr = errors.Wrap(err, "failed on query collections best bids")
-				return
-			}
+	var g errgroup.Group
+	for _, chainName := range chainNames {
+		chainName := chainName
+		g.Go(func() error {
+			chainCtx, cancel := context.WithTimeout(ctx, PerChainQueryTimeout)
+			defer cancel()
+
+			details, err := queryChainItemDetails(chainCtx, svcCtx, chainName, userAddr, userAddrs, chainQueries[chainName])
+
 			mu.Lock()
 			defer mu.Unlock()
-			for _, bestBid := range bestBids {
+			if err != nil {
+				xzap.WithContext(ctx).Error("failed on query chain item details", zap.String("chain", chainName), zap.Error(err))
+				failedChains = append(failedChains, chainName)
+				return nil
+			}
+
+			for _, bestBid := range details.collectionBids {
 				collectionBestBids[types.MultichainCollection{
 					CollectionAddress: strings.ToLower(bestBid.CollectionAddress),
 					Chain:             chainName,
 				}] = *bestBid
 			}
-		}(chain, collections)
-	}
-	wg.Wait()
-	if queryErr != nil {
-		return nil, errors.Wrap(err, "failed on query collection bids")
-	}
-
-	// 6. 并发查询Item最高出价信息
-	itemsBestBids := make(map[dao.MultiChainItemInfo]multi.Order)
-	for chain, items := range multichainItems {
-		wg.Add(1)
-		go func(chainName string, itemInfos []types.ItemInfo) {
-			defer wg.Done()
-			bids, err := svcCtx.Dao.QueryItemsBestBids(ctx, chainName, userAddr, itemInfos)
-			if err != nil {
-				queryErr = errors.Wrap(err, "failed on query items best bids")
-				return
-			}
-
-			mu.Lock()
-			defer mu.Unlock()
-			for _, bid := range bids {
-				order, ok := itemsBestBids[dao.MultiChainItemInfo{ItemInfo: types.ItemInfo{CollectionAddress: strings.ToLower(bid.CollectionAddress), TokenID: bid.TokenId}, ChainName: chainName}]
-				if !ok {
-					itemsBestBids[dao.MultiChainItemInfo{ItemInfo: types.ItemInfo{CollectionAddress: strings.ToLower(bid.CollectionAddress), TokenID: bid.TokenId}, ChainName: chainName}] = bid
-					continue
-				}
-				if bid.Price.GreaterThan(order.Price) {
-					itemsBestBids[dao.MultiChainItemInfo{ItemInfo: types.ItemInfo{CollectionAddress: strings.ToLower(bid.CollectionAddress), TokenID: bid.TokenId}, ChainName: chainName}] = bid
+			for _, bid := range details.itemBids {
+				key := dao.MultiChainItemInfo{ItemInfo: types.ItemInfo{CollectionAddress: strings.ToLower(bid.CollectionAddress), TokenID: bid.TokenId}, ChainName: chainName}
+				order, ok := itemsBestBids[key]
+				if !ok || bid.Price.GreaterThan(order.Price) {
+					itemsBestBids[key] = bid
 				}
 			}
-		}(chain, items)
-	}
-	wg.Wait()
-	if queryErr != nil {
-		return nil, errors.Wrap(err, "failed on query items best bids")
-	}
-
-	// 7. 查询Collection信息
-	collections, err := svcCtx.Dao.QueryMultiChainCollectionsInfo(ctx, collectionAddrs)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed on query collections info")
-	}
-
-	collectionInfos := make(map[string]multi.Collection)
-	for _, collection := range collections {
-		collectionInfos[strings.ToLower(collection.Address)] = collection
-	}
-
-	// 8. 查询Item挂单信息
-	listings, err := svcCtx.Dao.QueryMultiChainUserItemsListInfo(ctx, userAddrs, itemInfos)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed on query item list info")
-	}
-
-	listingInfos := make(map[string]*dao.CollectionItem)
-	for _, listing := range listings {
-		listingInfos[strings.ToLower(listing.CollectionAddress+listing.TokenId)] = listing
-	}
-
-	// 9. 获取挂单价格信息
-	var itemPrice []dao.MultiChainItemPriceInfo
-	for _, item := range listingInfos {
-		if item.Listing {
-			itemPrice = append(itemPrice, dao.MultiChainItemPriceInfo{
-				ItemPriceInfo: types.ItemPriceInfo{
-					CollectionAddress: item.CollectionAddress,
-					TokenID:           item.TokenId,
-					Maker:             item.Owner,
-					Price:             item.ListPrice,
-					OrderStatus:       multi.OrderStatusActive,
-				},
-				ChainName: chainIDToChainName[item.ChainId],
-			})
-		}
-	}
-
-	// 10. 查询挂单订单信息
-	orderIds := make(map[string]multi.Order)
-	if len(itemPrice) > 0 {
-		orders, err := svcCtx.Dao.QueryMultiChainListingInfo(ctx, itemPrice)
-		if err != nil {
-			return nil, errors.Wrap(err, "failed on query item order id")
-		}
-
-		for _, order := range orders {
-			orderIds[strings.ToLower(order.CollectionAddress+order.TokenId)] = order
-		}
-	}
-
-	// 11. 查询Item图片信息
-	itemImages, err := svcCtx.Dao.QueryMultiChainCollectionsItemsImage(ctx, itemInfos)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed on query item image info")
-	}
-
-	itemExternals := make(map[string]multi.ItemExternal)
-	for _, item := range itemImages {
-		itemExternals[strings.ToLower(item.CollectionAddress+item.TokenId)] = item
+			for _, collection := range details.collections {
+				collectionInfos[strings.ToLower(collection.Address)] = collection
+			}
+			for _, listing := range details.listings {
+				listingInfos[strings.ToLower(listing.CollectionAddress+listing.TokenId)] = listing
+			}
+			for _, order := range details.listingOrders {
+				orderIds[strings.ToLower(order.CollectionAddress+order.TokenId)] = order
+			}
+			for _, image := range details.images {
+				itemExternals[strings.ToLower(image.CollectionAddress+image.TokenId)] = image
+			}
+			return nil
+		})
 	}
+	_ = g.Wait()
+	sort.Strings(failedChains)
 
-	// 12. 组装最终结果
+	// 6. 组装最终结果
 	for i := 0; i < len(items); i++ {
 		// 设置出价信息
 		bidOrder, ok := itemsBestBids[dao.MultiChainItemInfo{ItemInfo: types.ItemInfo{CollectionAddress: strings.ToLower(items[i].CollectionAddress), TokenID: items[i].TokenID}, ChainName: chainIDToChainName[items[i].ChainID]}]
@@ -372,14 +382,97 @@ func GetMultiChainUserItems(ctx context.Context, svcCtx *svc.ServerCtx, chainID
 	}
 
 	return &types.UserItemsResp{
-		Result: items,
-		Count:  count,
+		Result:       items,
+		Count:        count,
+		FailedChains: failedChains,
 	}, nil
 }
 
+// chainItemsQuery 单条链上需要补充详情的Item
+type chainItemsQuery struct {
+	collectionAddrs []string
+	items           []types.ItemInfo
+}
+
+// chainItemDetails 单条链上Item的出价、Collection、挂单和图片信息
+type chainItemDetails struct {
+	collectionBids []*multi.Order
+	itemBids       []multi.Order
+	collections    []multi.Collection
+	listings       []*dao.CollectionItem
+	listingOrders  []multi.Order
+	images         []multi.ItemExternal
+}
+
+// queryChainItemDetails 查询单条链上Item的出价、Collection、挂单和图片信息, 任一查询失败时返回错误
+func queryChainItemDetails(ctx context.Context, svcCtx *svc.ServerCtx, chainName, userAddr string, userAddrs []string, q *chainItemsQuery) (*chainItemDetails, error) {
+	var details chainItemDetails
+	var err error
+
+	details.collectionBids, err = svcCtx.Dao.QueryCollectionsBestBid(ctx, chainName, userAddr, q.collectionAddrs)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on query collections best bids")
+	}
+
+	details.itemBids, err = svcCtx.Dao.QueryItemsBestBids(ctx, chainName, userAddr, q.items)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on query items best bids")
+	}
+
+	var collectionAddrs [][]string
+	var itemInfos []dao.MultiChainItemInfo
+	for _, item := range q.items {
+		collectionAddrs = append(collectionAddrs, []string{strings.ToLower(item.CollectionAddress), chainName})
+		itemInfos = append(itemInfos, dao.MultiChainItemInfo{ItemInfo: item, ChainName: chainName})
+	}
+
+	details.collections, err = svcCtx.Dao.QueryMultiChainCollectionsInfo(ctx, collectionAddrs)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on query collections info")
+	}
+
+	details.listings, err = svcCtx.Dao.QueryMultiChainUserItemsListInfo(ctx, userAddrs, itemInfos)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on query item list info")
+	}
+
+	// 查询挂单中Item的订单信息
+	var itemPrice []dao.MultiChainItemPriceInfo
+	for _, item := range details.listings {
+		if item.Listing {
+			itemPrice = append(itemPrice, dao.MultiChainItemPriceInfo{
+				ItemPriceInfo: types.ItemPriceInfo{
+					CollectionAddress: item.CollectionAddress,
+					TokenID:           item.TokenId,
+					Maker:             item.Owner,
+					Price:             item.ListPrice,
+					OrderStatus:       multi.OrderStatusActive,
+				},
+				ChainName: chainName,
+			})
+		}
+	}
+	if len(itemPrice) > 0 {
+		details.listingOrders, err = svcCtx.Dao.QueryMultiChainListingInfo(ctx, itemPrice)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed on query item order id")
+		}
+	}
+
+	details.images, err = svcCtx.Dao.QueryMultiChainCollectionsItemsImage(ctx, itemInfos)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on query item image info")
+	}
+
+	return &details, nil
+}
+
 // GetMultiChainUserListings 获取用户在多条链上的挂单信息
 func GetMultiChainUserListings(ctx context.Context, svcCtx *svc.ServerCtx, chainID []int, chain []string, userAddrs []string, contractAddrs []string, page, pageSize int) (*types.UserListingsResp, error) {
-	var result []types.Listing
+	ctx, span := tracing.Start(ctx, "service.GetMultiChainUserListings")
+	defer span.End()
+
+	result := []types.Listing{}
 	// 1. 查询用户挂单Item基本信息
 	items, count, err := svcCtx.Dao.QueryMultiChainUserListingItemInfos(ctx, chain, userAddrs, contractAddrs, page, pageSize)
 	if err != nil {
@@ -389,7 +482,8 @@ func GetMultiChainUserListings(ctx context.Context, svcCtx *svc.ServerCtx, chain
 	// 如果没有挂单,直接返回空结果
 	if count == 0 {
 		return &types.UserListingsResp{
-			Count: count,
+			Count:  count,
+			Result: result,
 		}, nil
 	}
 
@@ -556,7 +650,7 @@ func GetMultiChainUserListings(ctx context.Context, svcCtx *svc.ServerCtx, chain
 		itemExternals[strings.ToLower(item.CollectionAddress+item.TokenId)] = item
 	}
 
-	// 12. 组装最终结果
+	// 6. 组装最终结果
 	for i := 0; i < len(items); i++ {
 		var resultlisting types.Listing
 		listing, ok := listingInfos[strings.ToLower(items[i].CollectionAddress+items[i].TokenID)]
@@ -672,6 +766,9 @@ type multiOrder struct {
 // - *types.UserBidsResp: 用户出价信息响应
 // - error: 错误信息
 func GetMultiChainUserBids(ctx context.Context, svcCtx *svc.ServerCtx, chainID []int, chainNames []string, userAddrs []string, contractAddrs []string, page, pageSize int) (*types.UserBidsResp, error) {
+	ctx, span := tracing.Start(ctx, "service.GetMultiChainUserBids")
+	defer span.End()
+
 	// 1. 遍历每条链,查询用户出价信息
 	var totalBids []multiOrder
 	for i, chain := range chainNames {
@@ -760,7 +857,7 @@ func GetMultiChainUserBids(ctx context.Context, svcCtx *svc.ServerCtx, chainID [
 	}
 
 	// 4. 组装最终结果
-	var results []types.UserBid
+	results := []types.UserBid{}
 	for _, userBid := range bidsMap {
 		// 设置Collection名称和图片信息
 		if c, ok := collectionInfos[fmt.Sprintf("%d:%s", userBid.ChainID, strings.ToLower(userBid.CollectionAddress))]; ok {
@@ -771,9 +868,25 @@ func GetMultiChainUserBids(ctx context.Context, svcCtx *svc.ServerCtx, chainID [
 		results = append(results, userBid)
 	}
 
-	// 5. 按过期时间降序排序
-	sort.SliceStable(results, func(i, j int) bool {
-		return results[i].ExpireTime > (results[j].ExpireTime)
+	// 5. 按过期时间降序排序, 以链ID、集合地址、token_id、价格、市场和出价类型作为补充条件, 保证结果顺序稳定
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		switch {
+		case a.ExpireTime != b.ExpireTime:
+			return a.ExpireTime > b.ExpireTime
+		case a.ChainID != b.ChainID:
+			return a.ChainID < b.ChainID
+		case a.CollectionAddress != b.CollectionAddress:
+			return a.CollectionAddress < b.CollectionAddress
+		case a.TokenID != b.TokenID:
+			return a.TokenID < b.TokenID
+		case !a.BidPrice.Equal(b.BidPrice):
+			return a.BidPrice.GreaterThan(b.BidPrice)
+		case a.MarketplaceID != b.MarketplaceID:
+			return a.MarketplaceID < b.MarketplaceID
+		default:
+			return a.BidType < b.BidType
+		}
 	})
 
 	return &types.UserBidsResp{
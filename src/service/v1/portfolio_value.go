@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/joinmouse/EasySwapBase/logger/xzap"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+
+	"github.com/joinmouse/EasySwapBackend/src/common/tracing"
+	"github.com/joinmouse/EasySwapBackend/src/dao"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+	"github.com/joinmouse/EasySwapBackend/src/types/v1"
+)
+
+const (
+	// PortfolioValueCacheKey 用户持仓估值缓存, 按链和用户地址区分
+	PortfolioValueCacheKey = "cache:es:portfolio:value:%d:%s"
+	// PortfolioValueCacheTTL 持仓估值的缓存时间(秒)
+	PortfolioValueCacheTTL = 60
+)
+
+func portfolioValueCacheKey(chainID int, userAddr string) string {
+	return fmt.Sprintf(PortfolioValueCacheKey, chainID, strings.ToLower(userAddr))
+}
+
+// GetPortfolioValue 估算用户在指定链上持有的 NFT 的总价值
+// 1. 每个持有的Item按最高出价(Item出价和集合出价中较高者)估值, 没有出价时使用集合地板价
+// 2. ERC-721 每条持有记录按1个计算, ERC-1155 的持有份数通过 balanceOfBatch 从链上读取, 估值和数量按份数放大
+// 3. 既没有出价也没有地板价(或价格异常)的持仓不计入总价值, 只计入持有数量
+// 结果按用户缓存 PortfolioValueCacheTTL 秒
+func GetPortfolioValue(ctx context.Context, svcCtx *svc.ServerCtx, chainID int, userAddr string) (*types.PortfolioValueResp, error) {
+	ctx, span := tracing.Start(ctx, "service.GetPortfolioValue")
+	defer span.End()
+
+	chainCfg := chainConfigByID(svcCtx, chainID)
+	if chainCfg == nil {
+		return nil, ErrInvalidChainID
+	}
+
+	cacheKey := portfolioValueCacheKey(chainID, userAddr)
+	if raw, err := svcCtx.KvStore.Get(cacheKey); err == nil && raw != "" {
+		var res types.PortfolioValueResp
+		if err := json.Unmarshal([]byte(raw), &res); err == nil {
+			return &res, nil
+		}
+	}
+
+	res, err := computePortfolioValue(ctx, svcCtx, chainCfg.Name, chainID, userAddr)
+	if err != nil {
+		return nil, err
+	}
+	res.Currency = nativeSymbol(chainCfg)
+
+	if raw, err := json.Marshal(res); err == nil {
+		if err := svcCtx.KvStore.Setex(cacheKey, string(raw), PortfolioValueCacheTTL); err != nil {
+			xzap.WithContext(ctx).Warn("failed on cache portfolio value", zap.Error(err))
+		}
+	}
+
+	return res, nil
+}
+
+// computePortfolioValue 查询用户持仓、出价和集合地板价并计算估值
+func computePortfolioValue(ctx context.Context, svcCtx *svc.ServerCtx, chain string, chainID int, userAddr string) (*types.PortfolioValueResp, error) {
+	res := &types.PortfolioValueResp{
+		ChainID:     chainID,
+		TotalValue:  decimal.Zero,
+		Collections: []types.PortfolioCollectionValue{},
+		UpdatedAt:   time.Now().Unix(),
+	}
+
+	holdings, err := svcCtx.Dao.QueryUserHoldings(ctx, chain, userAddr)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get user holdings")
+	}
+	if len(holdings) == 0 {
+		return res, nil
+	}
+
+	var collectionAddrs []string
+	seen := make(map[string]bool)
+	for _, holding := range holdings {
+		addr := strings.ToLower(holding.CollectionAddress)
+		if !seen[addr] {
+			seen[addr] = true
+			collectionAddrs = append(collectionAddrs, holding.CollectionAddress)
+		}
+	}
+
+	collections, err := svcCtx.Dao.QueryCollectionsInfo(ctx, chain, collectionAddrs)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get collections info")
+	}
+
+	collectionBids, err := svcCtx.Dao.QueryCollectionsBestBid(ctx, chain, userAddr, collectionAddrs)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get collections best bid")
+	}
+	bestBids := make(map[string]decimal.Decimal, len(collectionBids))
+	for _, bid := range collectionBids {
+		bestBids[strings.ToLower(bid.CollectionAddress)] = bid.Price
+	}
+
+	itemBids, err := svcCtx.Dao.QueryUserItemsTopBids(ctx, chain, userAddr)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get items top bids")
+	}
+	itemTopBids := make(map[string]decimal.Decimal, len(itemBids))
+	for _, bid := range itemBids {
+		itemTopBids[strings.ToLower(bid.CollectionAddress)+":"+bid.TokenId] = bid.Price
+	}
+
+	values := make(map[string]*types.PortfolioCollectionValue, len(collections))
+	for _, collection := range collections {
+		addr := strings.ToLower(collection.Address)
+		values[addr] = &types.PortfolioCollectionValue{
+			CollectionAddress: collection.Address,
+			Name:              collection.Name,
+			ImageURI:          collection.ImageUri,
+			TokenStandard:     tokenStandardName(collection.TokenStandard),
+			FloorPrice:        collection.FloorPrice,
+			BestBid:           bestBids[addr],
+			Value:             decimal.Zero,
+		}
+	}
+
+	quantities, err := userERC1155Quantities(ctx, svcCtx, chainID, userAddr, holdings, values)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, holding := range holdings {
+		addr := strings.ToLower(holding.CollectionAddress)
+		value, ok := values[addr]
+		if !ok {
+			// 集合未收录, 没有市场数据
+			continue
+		}
+
+		quantity := int64(1)
+		if q, ok := quantities[addr+":"+holding.TokenId]; ok {
+			quantity = q
+		}
+		if quantity <= 0 {
+			// 链上已不再持有, 数据库记录滞后
+			continue
+		}
+		value.ItemCount += quantity
+
+		// 单价: 最高出价, 没有出价时使用地板价
+		price := value.BestBid
+		if itemBid, ok := itemTopBids[addr+":"+holding.TokenId]; ok && itemBid.GreaterThan(price) {
+			price = itemBid
+		}
+		if !price.IsPositive() || !IsSanePrice(svcCtx, price) {
+			price = value.FloorPrice
+		}
+		if !price.IsPositive() || !IsSanePrice(svcCtx, price) {
+			continue
+		}
+
+		value.PricedCount += quantity
+		value.Value = value.Value.Add(price.Mul(decimal.NewFromInt(quantity)))
+	}
+
+	for _, value := range values {
+		if value.ItemCount == 0 {
+			continue
+		}
+		res.ItemCount += value.ItemCount
+		res.PricedCount += value.PricedCount
+		res.TotalValue = res.TotalValue.Add(value.Value)
+		res.Collections = append(res.Collections, *value)
+	}
+	sort.Slice(res.Collections, func(i, j int) bool {
+		if c := res.Collections[i].Value.Cmp(res.Collections[j].Value); c != 0 {
+			return c > 0
+		}
+		return res.Collections[i].CollectionAddress < res.Collections[j].CollectionAddress
+	})
+
+	return res, nil
+}
+
+// userERC1155Quantities 从链上读取用户在 ERC-1155 集合中每个持仓的持有份数, 键为 小写集合地址:token_id
+// 只查询 values 中标准为 ERC-1155 的集合, 其他持仓不在结果中, 按1个计算
+func userERC1155Quantities(ctx context.Context, svcCtx *svc.ServerCtx, chainID int, userAddr string,
+	holdings []dao.UserHolding, values map[string]*types.PortfolioCollectionValue) (map[string]int64, error) {
+	tokenIDs := make(map[string][]string)
+	for _, holding := range holdings {
+		addr := strings.ToLower(holding.CollectionAddress)
+		if value, ok := values[addr]; ok && value.TokenStandard == TokenStandardERC1155 {
+			tokenIDs[addr] = append(tokenIDs[addr], holding.TokenId)
+		}
+	}
+
+	quantities := make(map[string]int64)
+	for addr, ids := range tokenIDs {
+		owners := make([]string, len(ids))
+		for i := range owners {
+			owners[i] = userAddr
+		}
+		balances, err := fetchERC1155Balances(ctx, svcCtx, int64(chainID), addr, owners, ids)
+		if err != nil {
+			return nil, err
+		}
+		for i, id := range ids {
+			quantities[addr+":"+id] = balances[i]
+		}
+	}
+
+	return quantities, nil
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"github.com/shopspring/decimal"
+
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+)
+
+// maxPrice 获取合理最大价格, 未配置时使用数据访问层的默认值
+func maxPrice(svcCtx *svc.ServerCtx) decimal.Decimal {
+	if svcCtx.Dao != nil && svcCtx.Dao.MaxPrice.IsPositive() {
+		return svcCtx.Dao.MaxPrice
+	}
+
+	return decimal.Zero
+}
+
+// IsSanePrice 判断价格是否在合理范围内: 不为负数且不超过配置的最大价格
+func IsSanePrice(svcCtx *svc.ServerCtx, price decimal.Decimal) bool {
+	if price.IsNegative() {
+		return false
+	}
+	max := maxPrice(svcCtx)
+
+	return max.IsZero() || price.LessThanOrEqual(max)
+}
+
+// ClampPrice 将价格限制在 [0, 最大价格] 范围内
+// 第二个返回值表示价格是否被修正, 调用方可以据此记录异常数据
+func ClampPrice(svcCtx *svc.ServerCtx, price decimal.Decimal) (decimal.Decimal, bool) {
+	if price.IsNegative() {
+		return decimal.Zero, true
+	}
+	max := maxPrice(svcCtx)
+	if !max.IsZero() && price.GreaterThan(max) {
+		return max, true
+	}
+
+	return price, false
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/joinmouse/EasySwapBackend/src/dao"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+)
+
+// oneWei 价格以代币数量计, 1 wei 为 1e-18
+var oneWei = decimal.New(1, -18)
+
+func TestIsSanePrice(t *testing.T) {
+	max := dao.DefaultMaxPrice
+	svcCtx := &svc.ServerCtx{Dao: &dao.Dao{MaxPrice: max}}
+
+	tests := []struct {
+		name  string
+		price decimal.Decimal
+		want  bool
+	}{
+		{"zero", decimal.Zero, true},
+		{"one wei", oneWei, true},
+		{"equal to max", max, true},
+		{"max minus one wei", max.Sub(oneWei), true},
+		{"max plus one wei", max.Add(oneWei), false},
+		{"far above max", max.Mul(decimal.NewFromInt(10)), false},
+		{"negative one wei", oneWei.Neg(), false},
+		{"negative", decimal.NewFromInt(-1), false},
+	}
+	for _, tt := range tests {
+		if got := IsSanePrice(svcCtx, tt.price); got != tt.want {
+			t.Errorf("%s: IsSanePrice(%s) = %v, want %v", tt.name, tt.price, got, tt.want)
+		}
+	}
+}
+
+func TestIsSanePriceWithoutMax(t *testing.T) {
+	// 未配置最大价格时只排除负数
+	for _, svcCtx := range []*svc.ServerCtx{{}, {Dao: &dao.Dao{}}} {
+		if !IsSanePrice(svcCtx, decimal.New(1, 30)) {
+			t.Error("IsSanePrice(1e30) without max = false, want true")
+		}
+		if !IsSanePrice(svcCtx, decimal.Zero) {
+			t.Error("IsSanePrice(0) without max = false, want true")
+		}
+		if IsSanePrice(svcCtx, oneWei.Neg()) {
+			t.Error("IsSanePrice(-1 wei) without max = true, want false")
+		}
+	}
+}
+
+func TestClampPrice(t *testing.T) {
+	max := dao.DefaultMaxPrice
+	svcCtx := &svc.ServerCtx{Dao: &dao.Dao{MaxPrice: max}}
+
+	tests := []struct {
+		name      string
+		price     decimal.Decimal
+		want      decimal.Decimal
+		corrected bool
+	}{
+		{"zero", decimal.Zero, decimal.Zero, false},
+		{"equal to max", max, max, false},
+		{"max plus one wei", max.Add(oneWei), max, true},
+		{"negative", decimal.NewFromInt(-1), decimal.Zero, true},
+	}
+	for _, tt := range tests {
+		got, corrected := ClampPrice(svcCtx, tt.price)
+		if !got.Equal(tt.want) || corrected != tt.corrected {
+			t.Errorf("%s: ClampPrice(%s) = (%s, %v), want (%s, %v)", tt.name, tt.price, got, corrected, tt.want, tt.corrected)
+		}
+	}
+}
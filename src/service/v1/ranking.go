@@ -2,16 +2,22 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/joinmouse/EasySwapBase/errcode"
 	"github.com/joinmouse/EasySwapBase/logger/xzap"
 	"github.com/joinmouse/EasySwapBase/stores/gdb/orderbookmodel/multi"
+	"github.com/pkg/errors"
 	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 
+	"github.com/joinmouse/EasySwapBackend/src/common/tracing"
 	"github.com/joinmouse/EasySwapBackend/src/dao"
 	"github.com/joinmouse/EasySwapBackend/src/service/svc"
 	"github.com/joinmouse/EasySwapBackend/src/types/v1"
@@ -21,45 +27,206 @@ const MinuteSeconds = 60
 const HourSeconds = 60 * 60
 const DaySeconds = 3600 * 24
 
+// 排行榜排序指标
+const (
+	RankingSortVolume      = "volume"       // 窗口内成交额
+	RankingSortSales       = "sales"        // 窗口内成交笔数
+	RankingSortFloorChange = "floor_change" // 窗口内地板价变化
+	RankingSortAvgPrice    = "avg_price"    // 窗口内平均成交价
+)
+
+const (
+	DefaultRankingSort   = RankingSortVolume
+	DefaultRankingWindow = "24h"
+
+	RankingCacheKey = "cache:es:ranking:%s:%s:%s"
+	RankingCacheTTL = 60 // second
+)
+
+// rankingWindows 排行榜支持的时间窗口, 1d 为 24h 的别名, 15m 和 30d 兼容旧的 range 参数
+var rankingWindows = map[string]int64{
+	"15m": MinuteSeconds * 15,
+	"1h":  HourSeconds,
+	"6h":  HourSeconds * 6,
+	"24h": DaySeconds,
+	"1d":  DaySeconds,
+	"7d":  DaySeconds * 7,
+	"30d": DaySeconds * 30,
+}
+
+var rankingSorts = map[string]bool{
+	RankingSortVolume:      true,
+	RankingSortSales:       true,
+	RankingSortFloorChange: true,
+	RankingSortAvgPrice:    true,
+}
+
+// ParseRankingParams 校验排行榜的排序指标和时间窗口, 为空时使用默认值
+// 返回统一后的窗口名称, 1d 统一为 24h, 保证同一窗口只缓存一份
+func ParseRankingParams(sortBy, window string) (string, string, error) {
+	if sortBy == "" {
+		sortBy = DefaultRankingSort
+	}
+	if !rankingSorts[sortBy] {
+		return "", "", ErrInvalidFilter
+	}
+
+	if window == "" {
+		window = DefaultRankingWindow
+	}
+	if _, ok := rankingWindows[window]; !ok {
+		return "", "", ErrInvalidFilter
+	}
+	if window == "1d" {
+		window = "24h"
+	}
+
+	return sortBy, window, nil
+}
+
+func rankingCacheKey(chain, sortBy, window string) string {
+	return fmt.Sprintf(RankingCacheKey, strings.ToLower(chain), sortBy, window)
+}
+
 // GetTopRanking 获取指定链上的NFT集合排名信息
+// 1. 按 (链, 排序指标, 时间窗口) 读取Redis缓存, 未命中时实时计算并缓存完整排名
+// 2. 统计当前窗口和上一个等长窗口的成交数据, 计算排序指标及其相对上一窗口的变化百分比
+// 3. 按指标降序排列后截取前 limit 个
 // @param ctx context.Context 上下文
 // @param svcCtx *svc.ServerCtx 服务上下文
 // @param chain string 链名称
-// @param period string 时间范围(15m/1h/6h/1d/7d/30d)
+// @param sortBy string 排序指标(volume/sales/floor_change/avg_price)
+// @param window string 时间窗口(1h/6h/24h/7d, 兼容15m/1d/30d)
 // @param limit int64 返回结果数量限制
 // @return []*types.CollectionRankingInfo 返回集合排名信息列表
 // @return error 错误信息
-func GetTopRanking(ctx context.Context, svcCtx *svc.ServerCtx, chain string, period string, limit int64) ([]*types.CollectionRankingInfo, error) {
-	// 获取集合交易信息
-	tradeInfos, err := svcCtx.Dao.GetCollectionRankingByActivity(chain, period)
+func GetTopRanking(ctx context.Context, svcCtx *svc.ServerCtx, chain, sortBy, window string, limit int64, includeHidden bool) ([]*types.CollectionRankingInfo, error) {
+	ctx, span := tracing.Start(ctx, "service.GetTopRanking")
+	defer span.End()
+
+	sortBy, window, err := ParseRankingParams(sortBy, window)
 	if err != nil {
-		xzap.WithContext(ctx).Error("failed on get collection trade info", zap.Error(err))
-		//return nil, errcode.NewCustomErr("cache error")
+		return nil, err
 	}
 
-	// 构建交易信息map
-	collectionTradeMap := make(map[string]dao.CollectionTrade)
-	for _, tradeInfo := range tradeInfos {
-		collectionTradeMap[strings.ToLower(tradeInfo.ContractAddress)] = *tradeInfo
+	cacheKey := rankingCacheKey(chain, sortBy, window)
+	var respInfos []*types.CollectionRankingInfo
+	if cached, ok := svcCtx.Dao.GetCachedRanking(ctx, cacheKey); ok {
+		if err := json.Unmarshal([]byte(cached), &respInfos); err != nil {
+			respInfos = nil
+		}
 	}
 
-	// 时间范围映射表
-	periodTime := map[string]int64{
-		"15m": MinuteSeconds * 15,
-		"1h":  HourSeconds,
-		"6h":  HourSeconds * 6,
-		"1d":  DaySeconds,
-		"7d":  DaySeconds * 7,
-		"30d": DaySeconds * 30,
+	if respInfos == nil {
+		respInfos, err = computeRanking(ctx, svcCtx, chain, sortBy, rankingWindows[window])
+		if err != nil {
+			return nil, err
+		}
+
+		if raw, err := json.Marshal(respInfos); err == nil {
+			svcCtx.Dao.CacheRanking(ctx, cacheKey, string(raw), RankingCacheTTL)
+		}
 	}
-	// 获取地板价变化信息
-	collectionFloorChange, err := svcCtx.Dao.QueryCollectionFloorChange(chain, periodTime[period])
+
+	// 缓存中是完整排名, 隐藏标记在读取后过滤, 切换标记后立即生效
+	if !includeHidden {
+		respInfos, err = filterHiddenRankings(ctx, svcCtx, chain, respInfos)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 限制返回数量
+	if limit >= 0 && limit < int64(len(respInfos)) {
+		respInfos = respInfos[:limit]
+	}
+	if respInfos == nil {
+		respInfos = []*types.CollectionRankingInfo{}
+	}
+
+	return respInfos, nil
+}
+
+// filterHiddenRankings 过滤排名中被隐藏的集合
+func filterHiddenRankings(ctx context.Context, svcCtx *svc.ServerCtx, chain string, infos []*types.CollectionRankingInfo) ([]*types.CollectionRankingInfo, error) {
+	hidden, err := svcCtx.Dao.QueryHiddenCollections(ctx, chainIDByName(svcCtx, chain))
 	if err != nil {
-		xzap.WithContext(ctx).Error("failed on get collection floor change", zap.Error(err))
+		return nil, errors.Wrap(err, "failed on get hidden collections")
+	}
+	if len(hidden) == 0 {
+		return infos, nil
+	}
+
+	hiddenSet := make(map[string]bool, len(hidden))
+	for _, addr := range hidden {
+		hiddenSet[strings.ToLower(addr)] = true
+	}
+	filtered := make([]*types.CollectionRankingInfo, 0, len(infos))
+	for _, info := range infos {
+		if !hiddenSet[strings.ToLower(info.Address)] {
+			filtered = append(filtered, info)
+		}
 	}
 
+	return filtered, nil
+}
+
+// computeRanking 实时计算指定链上所有集合的排名, 按排序指标降序排列
+func computeRanking(ctx context.Context, svcCtx *svc.ServerCtx, chain, sortBy string, windowSeconds int64) ([]*types.CollectionRankingInfo, error) {
+	now := time.Now()
+	startTime := now.Add(-time.Duration(windowSeconds) * time.Second)
+	prevStartTime := startTime.Add(-time.Duration(windowSeconds) * time.Second)
+
 	var wg sync.WaitGroup
+	var mu sync.Mutex
 	var queryErr error
+	setErr := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if queryErr == nil {
+			queryErr = err
+		}
+	}
+
+	// 并发获取当前窗口和上一窗口的成交统计
+	var currentStats, prevStats map[string]dao.CollectionTradeStats
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		stats, err := svcCtx.Dao.QueryCollectionTradeStats(ctx, chain, startTime, now)
+		if err != nil {
+			xzap.WithContext(ctx).Error("failed on get collection trade stats", zap.Error(err))
+			setErr(errcode.NewCustomErr("failed on get collection trade stats"))
+			return
+		}
+		currentStats = stats
+	}()
+	go func() {
+		defer wg.Done()
+		stats, err := svcCtx.Dao.QueryCollectionTradeStats(ctx, chain, prevStartTime, startTime)
+		if err != nil {
+			xzap.WithContext(ctx).Error("failed on get previous collection trade stats", zap.Error(err))
+			setErr(errcode.NewCustomErr("failed on get collection trade stats"))
+			return
+		}
+		prevStats = stats
+	}()
+
+	// 获取地板价变化信息, 失败时变化率按0处理
+	var collectionFloorChange map[string]float64
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		floorChange, err := svcCtx.Dao.QueryCollectionFloorChange(ctx, chain, windowSeconds)
+		if err != nil {
+			xzap.WithContext(ctx).Error("failed on get collection floor change", zap.Error(err))
+			return
+		}
+		collectionFloorChange = make(map[string]float64, len(floorChange))
+		for addr, change := range floorChange {
+			collectionFloorChange[strings.ToLower(addr)] = change
+		}
+	}()
 
 	// 并发获取集合销售价格信息
 	collectionSells := make(map[string]multi.Collection)
@@ -69,7 +236,7 @@ func GetTopRanking(ctx context.Context, svcCtx *svc.ServerCtx, chain string, per
 		sellInfos, err := svcCtx.Dao.QueryCollectionsSellPrice(ctx, chain)
 		if err != nil {
 			xzap.WithContext(ctx).Error("failed on get all collections info", zap.Error(err))
-			queryErr = errcode.NewCustomErr("failed on get all collections info")
+			setErr(errcode.NewCustomErr("failed on get all collections info"))
 			return
 		}
 		for _, sell := range sellInfos {
@@ -82,12 +249,13 @@ func GetTopRanking(ctx context.Context, svcCtx *svc.ServerCtx, chain string, per
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		allCollections, err = svcCtx.Dao.QueryAllCollectionInfo(ctx, chain)
+		collections, err := svcCtx.Dao.QueryAllCollectionInfo(ctx, chain)
 		if err != nil {
 			xzap.WithContext(ctx).Error("failed on get all collections info", zap.Error(err))
-			queryErr = errcode.NewCustomErr("failed on get all collections info")
+			setErr(errcode.NewCustomErr("failed on get all collections info"))
 			return
 		}
+		allCollections = collections
 	}()
 
 	wg.Wait()
@@ -96,24 +264,29 @@ func GetTopRanking(ctx context.Context, svcCtx *svc.ServerCtx, chain string, per
 		return nil, queryErr
 	}
 
+	// 后台任务缓存的地板价比集合表中的更新及时, 缓存可用时优先使用
+	cachedMarkets := GetCachedCollectionsMarket(svcCtx, chain)
+
 	// 构建返回结果
 	var respInfos []*types.CollectionRankingInfo
 	for _, collection := range allCollections {
-		var priceChange float64
-		var volume decimal.Decimal
-		var sellPrice decimal.Decimal
-		var sales int64
+		addr := strings.ToLower(collection.Address) // 统一小写
+		asOf := now.Unix()
+		if market, ok := cachedMarkets[addr]; ok {
+			collection.FloorPrice = market.FloorPrice
+			if market.UpdatedAt > 0 && market.UpdatedAt < asOf {
+				asOf = market.UpdatedAt
+			}
+		}
 
 		// 获取交易相关信息
-		tradeInfo, ok := collectionTradeMap[strings.ToLower(collection.Address)] // 统一小写
-		if ok {
-			priceChange = collectionFloorChange[strings.ToLower(collection.Address)]
-			volume = tradeInfo.Volume
-			sales = tradeInfo.ItemCount
-		}
+		current := currentStats[addr]
+		prev := prevStats[addr]
+		priceChange := collectionFloorChange[addr]
+
 		// 获取销售价格信息
-		sellInfo, ok := collectionSells[strings.ToLower(collection.Address)]
-		if ok {
+		var sellPrice decimal.Decimal
+		if sellInfo, ok := collectionSells[addr]; ok {
 			sellPrice = sellInfo.SalePrice
 		}
 
@@ -126,27 +299,102 @@ func GetTopRanking(ctx context.Context, svcCtx *svc.ServerCtx, chain string, per
 			listAmount = listed[0].Count
 		}
 
+		metricValue, metricChange := rankingMetric(sortBy, current, prev, collection.FloorPrice, priceChange)
+
 		// 构建单个集合的排名信息
 		respInfos = append(respInfos, &types.CollectionRankingInfo{
-			Name:        collection.Name,
-			Address:     collection.Address,
-			ImageUri:    collection.ImageUri,
-			FloorPrice:  collection.FloorPrice.String(),
-			FloorChange: strconv.FormatFloat(priceChange, 'f', 4, 32),
-			SellPrice:   sellPrice.String(),
-			Volume:      volume,
-			ItemSold:    sales,
-			ItemNum:     collection.ItemAmount,
-			ItemOwner:   collection.OwnerAmount,
-			ListAmount:  listAmount,
-			ChainID:     collection.ChainId,
+			Name:         collection.Name,
+			Address:      collection.Address,
+			ImageUri:     collection.ImageUri,
+			FloorPrice:   collection.FloorPrice.String(),
+			FloorChange:  strconv.FormatFloat(priceChange, 'f', 4, 32),
+			SellPrice:    sellPrice.String(),
+			Volume:       current.Volume,
+			ItemSold:     current.ItemCount,
+			ItemNum:      collection.ItemAmount,
+			ItemOwner:    collection.OwnerAmount,
+			ListAmount:   listAmount,
+			ChainID:      collection.ChainId,
+			AsOf:         asOf,
+			SortBy:       sortBy,
+			MetricValue:  metricValue,
+			MetricChange: metricChange,
 		})
 	}
 
-	// 限制返回数量
-	if limit < int64(len(respInfos)) {
-		respInfos = respInfos[:limit]
+	sortRanking(respInfos, sortBy)
+	for i, info := range respInfos {
+		info.Rank = i + 1
 	}
 
 	return respInfos, nil
 }
+
+// rankingMetric 计算排序指标的值以及相对上一窗口的变化百分比
+// floor_change 的指标值为当前地板价, 变化百分比为窗口内地板价的变化
+func rankingMetric(sortBy string, current, prev dao.CollectionTradeStats, floorPrice decimal.Decimal, floorChange float64) (decimal.Decimal, float64) {
+	switch sortBy {
+	case RankingSortSales:
+		return decimal.NewFromInt(current.ItemCount),
+			percentChange(decimal.NewFromInt(current.ItemCount), decimal.NewFromInt(prev.ItemCount))
+	case RankingSortFloorChange:
+		return floorPrice, decimal.NewFromFloat(floorChange).Mul(decimal.NewFromInt(100)).Round(2).InexactFloat64()
+	case RankingSortAvgPrice:
+		currentAvg := averagePrice(current)
+		return currentAvg, percentChange(currentAvg, averagePrice(prev))
+	default:
+		return current.Volume, percentChange(current.Volume, prev.Volume)
+	}
+}
+
+// averagePrice 计算窗口内的平均成交价, 没有成交时为0
+func averagePrice(stats dao.CollectionTradeStats) decimal.Decimal {
+	if stats.ItemCount == 0 {
+		return decimal.Zero
+	}
+	return stats.Volume.Div(decimal.NewFromInt(stats.ItemCount))
+}
+
+// percentChange 计算相对上一窗口的变化百分比, 保留两位小数; 上一窗口为0时无法计算, 返回0
+func percentChange(current, prev decimal.Decimal) float64 {
+	if prev.IsZero() {
+		return 0
+	}
+	return current.Sub(prev).Div(prev).Mul(decimal.NewFromInt(100)).Round(2).InexactFloat64()
+}
+
+// sortRanking 按排序指标降序排列, floor_change 按变化百分比排序, 指标相同时按成交额排序
+func sortRanking(infos []*types.CollectionRankingInfo, sortBy string) {
+	sort.SliceStable(infos, func(i, j int) bool {
+		if sortBy == RankingSortFloorChange {
+			if infos[i].MetricChange != infos[j].MetricChange {
+				return infos[i].MetricChange > infos[j].MetricChange
+			}
+		} else if !infos[i].MetricValue.Equal(infos[j].MetricValue) {
+			return infos[i].MetricValue.GreaterThan(infos[j].MetricValue)
+		}
+		return infos[i].Volume.GreaterThan(infos[j].Volume)
+	})
+}
+
+// MergeRankings 合并多条链的排名, 按排序指标重新排序、截取前 limit 个并重新计算排名位置
+func MergeRankings(rankings [][]*types.CollectionRankingInfo, sortBy string, limit int64) []*types.CollectionRankingInfo {
+	total := 0
+	for _, ranking := range rankings {
+		total += len(ranking)
+	}
+	merged := make([]*types.CollectionRankingInfo, 0, total)
+	for _, ranking := range rankings {
+		merged = append(merged, ranking...)
+	}
+
+	sortRanking(merged, sortBy)
+	if limit >= 0 && limit < int64(len(merged)) {
+		merged = merged[:limit]
+	}
+	for i, info := range merged {
+		info.Rank = i + 1
+	}
+
+	return merged
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/joinmouse/EasySwapBase/logger/xzap"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+
+	"github.com/joinmouse/EasySwapBackend/src/common/tracing"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+	"github.com/joinmouse/EasySwapBackend/src/types/v1"
+)
+
+const (
+	TraitDistributionCacheKey = "cache:es:trait:distribution:%s:%s"
+	TraitDistributionCacheTTL = 10 * 60 // second
+
+	rarityScoreEpsilon = 1e-9
+)
+
+func traitDistributionCacheKey(chain, collectionAddr string) string {
+	return fmt.Sprintf(TraitDistributionCacheKey, strings.ToLower(chain), strings.ToLower(collectionAddr))
+}
+
+// traitKey 生成 Trait匹配键, 忽略大小写和首尾空白,
+// 避免不同来源的 Trait名称/值因格式差异而匹配不上
+func traitKey(trait, traitValue string) string {
+	return strings.ToLower(fmt.Sprintf("%s:%s", strings.TrimSpace(trait), strings.TrimSpace(traitValue)))
+}
+
+// getTraitDistribution 获取合集的 Trait分布
+// 优先读取Redis缓存,缓存不存在或已过期时从数据库重新计算并写回缓存
+func getTraitDistribution(ctx context.Context, svcCtx *svc.ServerCtx, chain, collectionAddr string) (*types.TraitDistribution, error) {
+	cacheKey := traitDistributionCacheKey(chain, collectionAddr)
+	if cached, err := svcCtx.KvStore.Get(cacheKey); err == nil && cached != "" {
+		var dist types.TraitDistribution
+		if err := json.Unmarshal([]byte(cached), &dist); err == nil {
+			return &dist, nil
+		}
+	}
+
+	itemsTraits, err := svcCtx.Dao.QueryCollectionItemsTraits(ctx, chain, collectionAddr)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on query collection items traits")
+	}
+
+	// 1. 统计每个 Trait值的数量
+	counts := make(map[string]int64)
+	tokenTraits := make(map[string][]string)
+	for _, trait := range itemsTraits {
+		key := traitKey(trait.Trait, trait.TraitValue)
+		counts[key]++
+		tokenTraits[trait.TokenId] = append(tokenTraits[trait.TokenId], key)
+	}
+
+	// 2. 计算每个Item的稀有度分数并降序排列
+	total := int64(len(tokenTraits))
+	scores := make([]float64, 0, len(tokenTraits))
+	for _, keys := range tokenTraits {
+		scores = append(scores, rarityScore(keys, counts, total))
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(scores)))
+
+	dist := &types.TraitDistribution{
+		TotalItems: total,
+		Counts:     counts,
+		Scores:     scores,
+	}
+
+	raw, err := json.Marshal(dist)
+	if err == nil {
+		if err := svcCtx.KvStore.Setex(cacheKey, string(raw), TraitDistributionCacheTTL); err != nil {
+			xzap.WithContext(ctx).Warn("failed on cache trait distribution", zap.Error(err))
+		}
+	}
+
+	return dist, nil
+}
+
+// rarityScore 计算稀有度分数: 各 Trait值占比倒数之和
+func rarityScore(keys []string, counts map[string]int64, total int64) float64 {
+	var score float64
+	for _, key := range keys {
+		if count := counts[key]; count > 0 {
+			score += float64(total) / float64(count)
+		}
+	}
+
+	return score
+}
+
+// GetItemRarity 获取NFT Item的 Trait稀有度及其在合集中的稀有度排名
+// 1. 获取合集 Trait分布(带缓存)
+// 2. 计算Item每个 Trait的数量和占比
+// 3. 根据稀有度分数计算排名
+func GetItemRarity(ctx context.Context, svcCtx *svc.ServerCtx, chain, collectionAddr, tokenID string) (*types.ItemRarityInfo, error) {
+	ctx, span := tracing.Start(ctx, "service.GetItemRarity")
+	defer span.End()
+
+	info := &types.ItemRarityInfo{
+		CollectionAddress: collectionAddr,
+		TokenID:           tokenID,
+		Traits:            []types.TraitRarity{},
+	}
+
+	dist, err := getTraitDistribution(ctx, svcCtx, chain, collectionAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	// 合集中没有任何Item时直接返回空结果
+	info.TotalItems = dist.TotalItems
+	if dist.TotalItems == 0 {
+		return info, nil
+	}
+
+	itemTraits, err := svcCtx.Dao.QueryItemTraits(ctx, chain, collectionAddr, tokenID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on query item traits")
+	}
+	if len(itemTraits) == 0 {
+		return info, nil
+	}
+
+	// 查询Item各 Trait的最低挂单价格,失败时不影响稀有度计算
+	traitsPrices := make(map[string]decimal.Decimal)
+	traitsPrice, err := svcCtx.Dao.QueryTraitsPrice(ctx, chain, collectionAddr, []string{tokenID})
+	if err != nil {
+		xzap.WithContext(ctx).Warn("failed on query traits price", zap.Error(err))
+	}
+	for _, traitPrice := range traitsPrice {
+		traitsPrices[traitKey(traitPrice.Trait, traitPrice.TraitValue)] = traitPrice.Price
+	}
+
+	keys := make([]string, 0, len(itemTraits))
+	for _, trait := range itemTraits {
+		key := traitKey(trait.Trait, trait.TraitValue)
+		keys = append(keys, key)
+
+		count := dist.Counts[key]
+		info.Traits = append(info.Traits, types.TraitRarity{
+			TraitPrice: types.TraitPrice{
+				CollectionAddress: collectionAddr,
+				TokenID:           tokenID,
+				Trait:             trait.Trait,
+				TraitValue:        trait.TraitValue,
+				Price:             traitsPrices[key],
+			},
+			Count: count,
+			Percentage: decimal.NewFromInt(count).
+				DivRound(decimal.NewFromInt(dist.TotalItems), 4).
+				Mul(decimal.NewFromInt(100)).
+				InexactFloat64(),
+		})
+	}
+
+	// 排名 = 分数严格大于当前Item的数量 + 1
+	// Trait累加顺序不同会带来浮点误差,比较时留出容差
+	score := rarityScore(keys, dist.Counts, dist.TotalItems)
+	info.Score = decimal.NewFromFloat(score).Round(4).InexactFloat64()
+	higher := sort.Search(len(dist.Scores), func(i int) bool {
+		return dist.Scores[i] <= score+rarityScoreEpsilon
+	})
+	info.Rank = int64(higher) + 1
+
+	return info, nil
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/joinmouse/EasySwapBackend/src/common/tracing"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+	"github.com/joinmouse/EasySwapBackend/src/types/v1"
+)
+
+const (
+	MinSearchKeywordLen = 2  // 搜索关键字的最小长度
+	DefaultSearchLimit  = 10 // 默认返回的搜索结果数量
+	MaxSearchLimit      = 50 // 最多返回的搜索结果数量
+)
+
+// SearchCollections 按名称或符号搜索集合
+// includeHidden 为 false 时不返回被隐藏的集合
+func SearchCollections(ctx context.Context, svcCtx *svc.ServerCtx, chain, keyword string, limit int, includeHidden bool) ([]types.CollectionSearchInfo, error) {
+	ctx, span := tracing.Start(ctx, "service.SearchCollections")
+	defer span.End()
+
+	keyword = strings.TrimSpace(keyword)
+	if len([]rune(keyword)) < MinSearchKeywordLen {
+		return []types.CollectionSearchInfo{}, nil
+	}
+
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	if limit > MaxSearchLimit {
+		limit = MaxSearchLimit
+	}
+
+	var hidden []string
+	if !includeHidden {
+		var err error
+		hidden, err = svcCtx.Dao.QueryHiddenCollections(ctx, chainIDByName(svcCtx, chain))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed on get hidden collections")
+		}
+	}
+
+	collections, err := svcCtx.Dao.SearchCollections(ctx, chain, keyword, limit, hidden)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on search collections")
+	}
+
+	if collections == nil {
+		collections = []types.CollectionSearchInfo{}
+	}
+
+	return collections, nil
+}
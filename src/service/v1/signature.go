@@ -0,0 +1,135 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/joinmouse/EasySwapBase/logger/xzap"
+	"go.uber.org/zap"
+
+	"github.com/joinmouse/EasySwapBackend/src/common/metrics"
+	"github.com/joinmouse/EasySwapBackend/src/service/nodeclient"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+)
+
+const (
+	// rejectedSignatureCacheKey 合约钱包拒绝的签名缓存, 避免同一签名反复发起链上调用
+	rejectedSignatureCacheKey = "cache:es:login:sig:rejected:%s"
+	// RejectedSignatureTTL 合约钱包拒绝结果的缓存时间(秒)
+	RejectedSignatureTTL = 60
+)
+
+var (
+	// eip1271Selector isValidSignature(bytes32,bytes) 的函数选择器
+	eip1271Selector = []byte{0x16, 0x26, 0xba, 0x7e}
+	// eip1271MagicValue 签名有效时合约返回的 bytes4 值, 与函数选择器相同
+	eip1271MagicValue = eip1271Selector
+)
+
+// verifyLoginSignature 校验登录消息的签名
+// 1. 按 EIP-191 (personal_sign) 恢复签名地址, 与登录地址一致时校验通过
+// 2. 不一致时视为合约钱包(Safe、Argent等), 通过 EIP-1271 isValidSignature 在链上校验
+// 3. 合约钱包拒绝的签名短暂缓存, 缓存期内直接返回错误
+func verifyLoginSignature(ctx context.Context, svcCtx *svc.ServerCtx, chainID int, address, message, signature string) error {
+	sig, err := hex.DecodeString(strings.TrimPrefix(strings.TrimPrefix(signature, "0x"), "0X"))
+	if err != nil || len(sig) == 0 {
+		return ErrInvalidSignature
+	}
+	if !common.IsHexAddress(address) {
+		return ErrInvalidSignature
+	}
+	signer := common.HexToAddress(address)
+	hash := accounts.TextHash([]byte(message))
+
+	if recovered, ok := recoverSigner(hash, sig); ok && recovered == signer {
+		return nil
+	}
+
+	return verifyContractSignature(ctx, svcCtx, chainID, signer, hash, sig)
+}
+
+// recoverSigner 从 65 字节的 ECDSA 签名中恢复签名地址, 兼容 v 为 27/28 和 0/1 两种格式
+func recoverSigner(hash []byte, sig []byte) (common.Address, bool) {
+	if len(sig) != crypto.SignatureLength {
+		return common.Address{}, false
+	}
+
+	normalized := make([]byte, len(sig))
+	copy(normalized, sig)
+	if normalized[crypto.RecoveryIDOffset] >= 27 {
+		normalized[crypto.RecoveryIDOffset] -= 27
+	}
+
+	pub, err := crypto.SigToPub(hash, normalized)
+	if err != nil {
+		return common.Address{}, false
+	}
+
+	return crypto.PubkeyToAddress(*pub), true
+}
+
+// verifyContractSignature 调用合约钱包的 isValidSignature 校验签名
+// 地址不是合约、调用回滚或返回值不是 magic value 时视为拒绝, 节点不可用或返回其他错误时返回 ErrUpstreamRPC
+func verifyContractSignature(ctx context.Context, svcCtx *svc.ServerCtx, chainID int, signer common.Address, hash []byte, sig []byte) error {
+	nodeSrv, ok := svcCtx.NodeSrvs[int64(chainID)]
+	if !ok || nodeSrv == nil {
+		return ErrInvalidSignature
+	}
+
+	digest := sha256.Sum256(append(append(signer.Bytes(), hash...), sig...))
+	cacheKey := fmt.Sprintf(rejectedSignatureCacheKey, hex.EncodeToString(digest[:]))
+	rejected, err := svcCtx.KvStore.Exists(cacheKey)
+	if err != nil {
+		xzap.WithContext(ctx).Warn("failed on check rejected signature cache", zap.Error(err))
+	}
+	if rejected {
+		return ErrSignatureRejected
+	}
+
+	result, err := nodeSrv.NodeClient.CallContract(ctx, ethereum.CallMsg{
+		To:   &signer,
+		Data: encodeIsValidSignature(hash, sig),
+	}, nil)
+	metrics.ObserveRPC(nodeSrv.ChainName, "isValidSignature", err)
+	if err != nil && !nodeclient.IsReverted(err) {
+		xzap.WithContext(ctx).Error("failed on call isValidSignature", zap.String("address", signer.Hex()), zap.Error(err))
+		return ErrUpstreamRPC
+	}
+	if err == nil && len(result) >= len(eip1271MagicValue) && bytes.Equal(result[:len(eip1271MagicValue)], eip1271MagicValue) {
+		return nil
+	}
+
+	if err != nil {
+		xzap.WithContext(ctx).Debug("isValidSignature reverted", zap.String("address", signer.Hex()), zap.Error(err))
+	}
+	if err := svcCtx.KvStore.Setex(cacheKey, "1", RejectedSignatureTTL); err != nil {
+		xzap.WithContext(ctx).Warn("failed on cache rejected signature", zap.Error(err))
+	}
+
+	return ErrSignatureRejected
+}
+
+// encodeIsValidSignature 按 ABI 编码 isValidSignature(bytes32 hash, bytes signature) 的调用数据
+func encodeIsValidSignature(hash []byte, sig []byte) []byte {
+	data := make([]byte, 0, 4+32*4+len(sig))
+	data = append(data, eip1271Selector...)
+	data = append(data, common.LeftPadBytes(hash, 32)...)
+	// 动态类型 bytes 的偏移量, 位于两个参数头之后
+	data = append(data, common.LeftPadBytes([]byte{0x40}, 32)...)
+	data = append(data, common.LeftPadBytes(big.NewInt(int64(len(sig))).Bytes(), 32)...)
+	data = append(data, sig...)
+	if pad := len(sig) % 32; pad != 0 {
+		data = append(data, make([]byte, 32-pad)...)
+	}
+
+	return data
+}
@@ -0,0 +1,24 @@
+package service
+
+const (
+	// 集合表 token_standard 字段取值
+	tokenStandardERC721  = 1
+	tokenStandardERC1155 = 2
+
+	TokenStandardERC721  = "erc721"
+	TokenStandardERC1155 = "erc1155"
+	TokenStandardUnknown = "unknown"
+)
+
+// tokenStandardName 将集合表中的合约实现标准转换为接口返回的名称
+// 未记录或无法识别的标准返回 unknown, 由调用方按兼容逻辑处理
+func tokenStandardName(standard int64) string {
+	switch standard {
+	case tokenStandardERC721:
+		return TokenStandardERC721
+	case tokenStandardERC1155:
+		return TokenStandardERC1155
+	default:
+		return TokenStandardUnknown
+	}
+}
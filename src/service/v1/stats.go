@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/joinmouse/EasySwapBase/logger/xzap"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/joinmouse/EasySwapBackend/src/common/tracing"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+	"github.com/joinmouse/EasySwapBackend/src/types/v1"
+)
+
+const (
+	CollectionStatsCacheKey = "cache:es:collection:stats:%s:%s"
+	CollectionStatsCacheTTL = 60 // second
+)
+
+func collectionStatsCacheKey(chain, collectionAddr string) string {
+	return fmt.Sprintf(CollectionStatsCacheKey, strings.ToLower(chain), strings.ToLower(collectionAddr))
+}
+
+// GetCollectionStats 获取集合的聚合统计信息
+// 1. 优先读取Redis缓存
+// 2. 查询总供应量、持有人数量、上架数量、地板价和各时间窗口的成交数据, 地板价优先读取后台任务的缓存
+// 3. 计算上架比例并写回缓存
+// 4. 查询集合的隐藏标记, 标记不缓存, 切换后立即生效
+func GetCollectionStats(ctx context.Context, svcCtx *svc.ServerCtx, chain, collectionAddr string) (*types.CollectionStats, error) {
+	ctx, span := tracing.Start(ctx, "service.GetCollectionStats")
+	defer span.End()
+
+	stats, err := collectionStats(ctx, svcCtx, chain, collectionAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	stats.Flagged, err = svcCtx.Dao.IsCollectionHidden(ctx, chainIDByName(svcCtx, chain), collectionAddr)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get collection flag")
+	}
+
+	return stats, nil
+}
+
+// collectionStats 读取缓存或实时计算集合的聚合统计信息
+func collectionStats(ctx context.Context, svcCtx *svc.ServerCtx, chain, collectionAddr string) (*types.CollectionStats, error) {
+	cacheKey := collectionStatsCacheKey(chain, collectionAddr)
+	if cached, err := svcCtx.KvStore.Get(cacheKey); err == nil && cached != "" {
+		var stats types.CollectionStats
+		if err := json.Unmarshal([]byte(cached), &stats); err == nil {
+			return &stats, nil
+		}
+	}
+
+	if _, err := svcCtx.Dao.QueryCollectionInfo(ctx, chain, collectionAddr); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCollectionNotFound
+		}
+		return nil, errors.Wrap(err, "failed on get collection info")
+	}
+
+	supply, err := svcCtx.Dao.QueryCollectionSupplyStats(ctx, chain, collectionAddr)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get collection supply stats")
+	}
+
+	listed, err := svcCtx.Dao.QueryListedAmount(ctx, chain, collectionAddr)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get listed count")
+	}
+
+	// 优先使用后台任务缓存的地板价, 缓存不可用时实时查询
+	// 数据时间取实时查询的时间和缓存计算时间中较早的一个
+	asOf := time.Now().Unix()
+	var floorPrice decimal.Decimal
+	if market, ok := GetCachedCollectionMarket(svcCtx, chain, collectionAddr); ok {
+		floorPrice = market.FloorPrice
+		if market.UpdatedAt > 0 && market.UpdatedAt < asOf {
+			asOf = market.UpdatedAt
+		}
+	} else {
+		floorPrice, err = svcCtx.Dao.QueryFloorPrice(ctx, chain, collectionAddr)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed on get floor price")
+		}
+	}
+	if clamped, ok := ClampPrice(svcCtx, floorPrice); ok {
+		xzap.WithContext(ctx).Warn("collection floor price out of range",
+			zap.String("collection", collectionAddr), zap.String("floor_price", floorPrice.String()))
+		floorPrice = clamped
+	}
+
+	sales, err := svcCtx.Dao.QueryCollectionSaleStats(ctx, chain, collectionAddr, time.Now())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get collection sale stats")
+	}
+
+	stats := &types.CollectionStats{
+		TotalSupply: supply.TotalSupply,
+		OwnerCount:  supply.OwnerCount,
+		ListedCount: listed,
+		FloorPrice:  floorPrice,
+		Volume24h:   sales.Volume24h,
+		Volume7d:    sales.Volume7d,
+		Volume30d:   sales.Volume30d,
+		Sales24h:    sales.Sales24h,
+		Sales7d:     sales.Sales7d,
+		Sales30d:    sales.Sales30d,
+		AsOf:        asOf,
+	}
+
+	// 空集合没有Item, 上架比例为0, 避免除零
+	if supply.TotalSupply > 0 {
+		stats.ListedPercent = decimal.NewFromInt(listed).
+			DivRound(decimal.NewFromInt(supply.TotalSupply), 4).
+			Mul(decimal.NewFromInt(100)).
+			InexactFloat64()
+	}
+
+	raw, err := json.Marshal(stats)
+	if err == nil {
+		if err := svcCtx.KvStore.Setex(cacheKey, string(raw), CollectionStatsCacheTTL); err != nil {
+			xzap.WithContext(ctx).Warn("failed on cache collection stats", zap.Error(err))
+		}
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/go-redis/redis/v8"
+	logging "github.com/joinmouse/EasySwapBase/logger"
+	"github.com/joinmouse/EasySwapBase/logger/xzap"
+	"github.com/joinmouse/EasySwapBase/stores/xkv"
+	"github.com/zeromicro/go-zero/core/stores/cache"
+	"github.com/zeromicro/go-zero/core/stores/kv"
+	"github.com/zeromicro/go-zero/core/stores/redis"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/joinmouse/EasySwapBackend/src/config"
+	"github.com/joinmouse/EasySwapBackend/src/dao"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+)
+
+func TestMain(m *testing.M) {
+	// 服务层会记录日志, 需要先初始化全局 logger
+	if _, err := xzap.SetUp(logging.LogConf{Mode: "console", Path: os.TempDir(), Level: "error"}); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// stubDB 测试用的数据库驱动, 记录执行的 SQL
+// 设置 results 时查询返回其构造的结果, 否则所有查询都返回空结果
+type stubDB struct {
+	mu      sync.Mutex
+	queries []string
+	results func(query string) *stubRows
+}
+
+// rows 返回查询的结果
+func (s *stubDB) rows(query string) driver.Rows {
+	s.mu.Lock()
+	results := s.results
+	s.mu.Unlock()
+	if results != nil {
+		if rows := results(query); rows != nil {
+			return rows
+		}
+	}
+	return &stubRows{}
+}
+
+func (s *stubDB) record(query string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queries = append(s.queries, query)
+}
+
+func (s *stubDB) Connect(context.Context) (driver.Conn, error) { return &stubConn{db: s}, nil }
+func (s *stubDB) Driver() driver.Driver                        { return nil }
+
+type stubConn struct{ db *stubDB }
+
+func (c *stubConn) Prepare(query string) (driver.Stmt, error) {
+	return &stubStmt{db: c.db, query: query}, nil
+}
+func (c *stubConn) Close() error              { return nil }
+func (c *stubConn) Begin() (driver.Tx, error) { return stubTx{}, nil }
+
+func (c *stubConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	c.db.record(query)
+	return c.db.rows(query), nil
+}
+
+func (c *stubConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.db.record(query)
+	return driver.RowsAffected(0), nil
+}
+
+type stubStmt struct {
+	db    *stubDB
+	query string
+}
+
+func (s *stubStmt) Close() error  { return nil }
+func (s *stubStmt) NumInput() int { return -1 }
+func (s *stubStmt) Exec([]driver.Value) (driver.Result, error) {
+	s.db.record(s.query)
+	return driver.RowsAffected(0), nil
+}
+func (s *stubStmt) Query([]driver.Value) (driver.Rows, error) {
+	s.db.record(s.query)
+	return s.db.rows(s.query), nil
+}
+
+type stubTx struct{}
+
+func (stubTx) Commit() error   { return nil }
+func (stubTx) Rollback() error { return nil }
+
+// stubRows 查询结果, 按顺序返回 values 中的每一行
+type stubRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *stubRows) Columns() []string { return r.columns }
+func (r *stubRows) Close() error      { return nil }
+func (r *stubRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+// newStubServerCtx 创建连接到空数据库和内存 Redis 的服务上下文
+// 发布订阅客户端连接同一个内存 Redis
+func newStubServerCtx(t *testing.T) (*svc.ServerCtx, *stubDB) {
+	t.Helper()
+
+	stub := &stubDB{}
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      sql.OpenDB(stub),
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open stub db: %v", err)
+	}
+
+	mr := miniredis.RunT(t)
+	store := xkv.NewStore(kv.KvConf{cache.NodeConf{
+		RedisConf: redis.RedisConf{Host: mr.Addr(), Type: redis.NodeType},
+		Weight:    1,
+	}})
+
+	pubSub := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { pubSub.Close() })
+
+	return &svc.ServerCtx{
+		C:       &config.Config{},
+		DB:      db,
+		Dao:     dao.New(db, store),
+		KvStore: store,
+		PubSub:  pubSub,
+	}, stub
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+
+	"github.com/joinmouse/EasySwapBackend/src/common"
+	"github.com/joinmouse/EasySwapBackend/src/common/tracing"
+	"github.com/joinmouse/EasySwapBackend/src/dao"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+	"github.com/joinmouse/EasySwapBackend/src/types/v1"
+)
+
+const (
+	DefaultTopTraitLimit = 10  // 默认返回的 Trait数量
+	MaxTopTraitLimit     = 100 // 最多返回的 Trait数量
+
+	MinTraitSampleSize = 5                   // 成交笔数达到该值时估值视为可靠
+	TraitSaleWindow    = 30 * 24 * time.Hour // 统计 Trait成交的时间窗口
+)
+
+// Trait估值置信度
+const (
+	TraitConfidenceHigh   = "high"   // 成交样本充足, 估值为平均成交价
+	TraitConfidenceMedium = "medium" // 有少量成交, 估值结合了成交价和最低挂单价
+	TraitConfidenceLow    = "low"    // 没有成交, 估值来自最低挂单价或合集地板价
+)
+
+// traitEstimator 根据合集内各 Trait的最低挂单价和成交统计估算 Trait价值
+type traitEstimator struct {
+	collectionAddr string
+	floorPrice     decimal.Decimal
+	listingPrices  map[string]decimal.Decimal
+	saleStats      map[string]dao.TraitSaleStats
+}
+
+func newTraitEstimator(ctx context.Context, svcCtx *svc.ServerCtx, chain, collectionAddr string, tokenIDs []string) (*traitEstimator, error) {
+	// 1. 查询Trait对应的最低挂单价格
+	traitsPrice, err := svcCtx.Dao.QueryTraitsPrice(ctx, chain, collectionAddr, tokenIDs)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on query traits price")
+	}
+
+	// 2. 查询统计窗口内各Trait的成交情况
+	saleStats, err := svcCtx.Dao.QueryTraitSaleStats(ctx, chain, collectionAddr, time.Now().Add(-TraitSaleWindow))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on query traits sale stats")
+	}
+
+	// 3. 查询合集地板价, 作为既无成交也无挂单的Trait的兜底估值
+	floorPrice, err := svcCtx.Dao.QueryFloorPrice(ctx, chain, collectionAddr)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on query floor price")
+	}
+
+	estimator := &traitEstimator{
+		collectionAddr: collectionAddr,
+		floorPrice:     floorPrice,
+		listingPrices:  make(map[string]decimal.Decimal, len(traitsPrice)),
+		saleStats:      make(map[string]dao.TraitSaleStats, len(saleStats)),
+	}
+	for _, traitPrice := range traitsPrice {
+		estimator.listingPrices[traitKey(traitPrice.Trait, traitPrice.TraitValue)] = traitPrice.Price
+	}
+	for _, stat := range saleStats {
+		estimator.saleStats[traitKey(stat.Trait, stat.TraitValue)] = stat
+	}
+
+	return estimator, nil
+}
+
+// estimate 估算单个Trait的价值
+//   - 成交笔数 >= MinTraitSampleSize: 取平均成交价, 置信度 high
+//   - 有少量成交: 按成交笔数在平均成交价和最低挂单价之间加权, 置信度 medium
+//   - 没有成交: 取最低挂单价, 无挂单时取合集地板价, 置信度 low
+func (e *traitEstimator) estimate(tokenID, trait, traitValue string) types.TraitPriceEstimate {
+	key := traitKey(trait, traitValue)
+	listingPrice := e.listingPrices[key]
+	stat := e.saleStats[key]
+
+	result := types.TraitPriceEstimate{
+		TraitPrice: types.TraitPrice{
+			CollectionAddress: e.collectionAddr,
+			TokenID:           tokenID,
+			Trait:             trait,
+			TraitValue:        traitValue,
+		},
+		ListingPrice: listingPrice,
+		SampleSize:   stat.SampleSize,
+		IsEstimate:   true,
+	}
+
+	switch {
+	case stat.SampleSize >= MinTraitSampleSize:
+		result.Price = stat.AvgPrice
+		result.IsEstimate = false
+		result.Confidence = TraitConfidenceHigh
+	case stat.SampleSize > 0:
+		result.Price = stat.AvgPrice
+		if !common.IsZeroOrNeg(listingPrice) {
+			weight := decimal.NewFromInt(stat.SampleSize).Div(decimal.NewFromInt(MinTraitSampleSize))
+			result.Price = stat.AvgPrice.Mul(weight).Add(listingPrice.Mul(decimal.NewFromInt(1).Sub(weight)))
+		}
+		result.Confidence = TraitConfidenceMedium
+	case !common.IsZeroOrNeg(listingPrice):
+		result.Price = listingPrice
+		result.Confidence = TraitConfidenceLow
+	default:
+		result.Price = e.floorPrice
+		result.Confidence = TraitConfidenceLow
+	}
+
+	return result
+}
+
+// GetItemTopTraitPrice 获取Trait估值排行
+// 未指定 token ids时返回合集中估值最高的前 limit 个Trait;
+// 指定 token ids时返回每个token估值最高的Trait, 同样按估值排序并截取前 limit 个
+func GetItemTopTraitPrice(ctx context.Context, svcCtx *svc.ServerCtx, chain, collectionAddr string, tokenIDs []string, limit int) (*types.ItemTopTraitResp, error) {
+	ctx, span := tracing.Start(ctx, "service.GetItemTopTraitPrice")
+	defer span.End()
+
+	if limit <= 0 {
+		limit = DefaultTopTraitLimit
+	}
+	if limit > MaxTopTraitLimit {
+		limit = MaxTopTraitLimit
+	}
+
+	estimator, err := newTraitEstimator(ctx, svcCtx, chain, collectionAddr, tokenIDs)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on calc top trait")
+	}
+
+	results := []types.TraitPriceEstimate{}
+	if len(tokenIDs) == 0 {
+		// 合集维度: 对合集中的每个Trait值估值
+		traits, err := svcCtx.Dao.QueryCollectionTraits(ctx, chain, collectionAddr)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed on query collection traits")
+		}
+
+		for _, trait := range traits {
+			results = append(results, estimator.estimate("", trait.Trait, trait.TraitValue))
+		}
+	} else {
+		// token维度: 取每个token估值最高的Trait
+		traits, err := svcCtx.Dao.QueryItemsTraits(ctx, chain, collectionAddr, tokenIDs)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed on query items trait")
+		}
+
+		topTraits := make(map[string]types.TraitPriceEstimate)
+		for _, trait := range traits {
+			estimate := estimator.estimate(trait.TokenId, trait.Trait, trait.TraitValue)
+			if topTrait, ok := topTraits[trait.TokenId]; ok && !traitEstimateLess(topTrait, estimate) {
+				continue
+			}
+			topTraits[trait.TokenId] = estimate
+		}
+
+		for _, topTrait := range topTraits {
+			results = append(results, topTrait)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return traitEstimateLess(results[j], results[i])
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return &types.ItemTopTraitResp{
+		Result: results,
+	}, nil
+}
+
+// traitEstimateLess 比较两个Trait估值: 先比较估值, 估值相同时样本量多的更可信,
+// 最后按 token id、Trait名称和值排序以保证结果稳定
+func traitEstimateLess(a, b types.TraitPriceEstimate) bool {
+	if !a.Price.Equal(b.Price) {
+		return a.Price.LessThan(b.Price)
+	}
+	if a.SampleSize != b.SampleSize {
+		return a.SampleSize < b.SampleSize
+	}
+	if a.TokenID != b.TokenID {
+		return a.TokenID > b.TokenID
+	}
+	if a.Trait != b.Trait {
+		return a.Trait > b.Trait
+	}
+	return a.TraitValue > b.TraitValue
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/joinmouse/EasySwapBase/logger/xzap"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/joinmouse/EasySwapBackend/src/common/tracing"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+	"github.com/joinmouse/EasySwapBackend/src/types/v1"
+)
+
+const (
+	CollectionTraitsCacheKey = "cache:es:collection:traits:%s:%s"
+	CollectionTraitsCacheTTL = 60 * 60 // second, Trait很少变化, 元数据刷新时主动失效
+)
+
+func collectionTraitsCacheKey(chain, collectionAddr string) string {
+	return fmt.Sprintf(CollectionTraitsCacheKey, strings.ToLower(chain), strings.ToLower(collectionAddr))
+}
+
+// GetCollectionTraits 分页获取集合的 Trait目录
+// 1. 完整目录缓存在Redis中, 按 Trait类别名称升序排列, 类别内的值按数量降序排列
+// 2. 大集合的类别可能很多, 按类别分页返回, 同一类别的值总是在同一页中完整返回
+func GetCollectionTraits(ctx context.Context, svcCtx *svc.ServerCtx, chain, collectionAddr string, page, pageSize int) (*types.PageResp, error) {
+	ctx, span := tracing.Start(ctx, "service.GetCollectionTraits")
+	defer span.End()
+
+	catalog, err := getCollectionTraitCatalog(ctx, svcCtx, chain, collectionAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []types.CollectionTraitInfo{}
+	start := (page - 1) * pageSize
+	if start < len(catalog) {
+		end := start + pageSize
+		if end > len(catalog) {
+			end = len(catalog)
+		}
+		items = catalog[start:end]
+	}
+
+	return &types.PageResp{
+		Total:    int64(len(catalog)),
+		Page:     page,
+		PageSize: pageSize,
+		Items:    items,
+	}, nil
+}
+
+// getCollectionTraitCatalog 获取集合完整的 Trait目录
+// 优先读取Redis缓存, 缓存不存在时从数据库统计每个 Trait值的Item数量并写回缓存
+func getCollectionTraitCatalog(ctx context.Context, svcCtx *svc.ServerCtx, chain, collectionAddr string) ([]types.CollectionTraitInfo, error) {
+	cacheKey := collectionTraitsCacheKey(chain, collectionAddr)
+	if cached, err := svcCtx.KvStore.Get(cacheKey); err == nil && cached != "" {
+		var catalog []types.CollectionTraitInfo
+		if err := json.Unmarshal([]byte(cached), &catalog); err == nil {
+			return catalog, nil
+		}
+	}
+
+	if _, err := svcCtx.Dao.QueryCollectionInfo(ctx, chain, collectionAddr); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCollectionNotFound
+		}
+		return nil, errors.Wrap(err, "failed on get collection info")
+	}
+
+	traitCounts, err := svcCtx.Dao.QueryCollectionTraits(ctx, chain, collectionAddr)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get collection traits")
+	}
+
+	index := make(map[string]int)
+	catalog := []types.CollectionTraitInfo{}
+	for _, traitCount := range traitCounts {
+		i, ok := index[traitCount.Trait]
+		if !ok {
+			i = len(catalog)
+			index[traitCount.Trait] = i
+			catalog = append(catalog, types.CollectionTraitInfo{Trait: traitCount.Trait})
+		}
+		catalog[i].Values = append(catalog[i].Values, types.TraitValue{
+			TraitValue:  traitCount.TraitValue,
+			TraitAmount: traitCount.Count,
+		})
+	}
+
+	sort.Slice(catalog, func(i, j int) bool {
+		return catalog[i].Trait < catalog[j].Trait
+	})
+	for _, trait := range catalog {
+		values := trait.Values
+		sort.Slice(values, func(i, j int) bool {
+			if values[i].TraitAmount != values[j].TraitAmount {
+				return values[i].TraitAmount > values[j].TraitAmount
+			}
+			return values[i].TraitValue < values[j].TraitValue
+		})
+	}
+
+	raw, err := json.Marshal(catalog)
+	if err == nil {
+		if err := svcCtx.KvStore.Setex(cacheKey, string(raw), CollectionTraitsCacheTTL); err != nil {
+			xzap.WithContext(ctx).Warn("failed on cache collection traits", zap.Error(err))
+		}
+	}
+
+	return catalog, nil
+}
+
+// invalidateCollectionTraits 删除集合的 Trait目录和 Trait分布缓存
+// 元数据刷新后 Trait可能变化, 删除失败时只记录日志, 缓存过期后自然恢复
+func invalidateCollectionTraits(ctx context.Context, svcCtx *svc.ServerCtx, chain, collectionAddr string) {
+	keys := []string{
+		collectionTraitsCacheKey(chain, collectionAddr),
+		traitDistributionCacheKey(chain, collectionAddr),
+	}
+	if _, err := svcCtx.KvStore.Del(keys...); err != nil {
+		xzap.WithContext(ctx).Warn("failed on invalidate collection traits cache",
+			zap.String("collection_address", collectionAddr), zap.Error(err))
+	}
+}
@@ -14,10 +14,14 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/joinmouse/EasySwapBase/errcode"
+	"github.com/joinmouse/EasySwapBase/logger/xzap"
 	"github.com/joinmouse/EasySwapBase/stores/gdb/orderbookmodel/base"
 	"github.com/pkg/errors"
+	"go.uber.org/zap"
 
 	"github.com/joinmouse/EasySwapBackend/src/api/middleware"
+	"github.com/joinmouse/EasySwapBackend/src/common/tracing"
+	"github.com/joinmouse/EasySwapBackend/src/dao"
 	"github.com/joinmouse/EasySwapBackend/src/service/svc"
 	"github.com/joinmouse/EasySwapBackend/src/types/v1"
 )
@@ -26,38 +30,159 @@ func getUserLoginMsgCacheKey(address string) string {
 	return middleware.CR_LOGIN_MSG_KEY + ":" + strings.ToLower(address)
 }
 
+func getUsedLoginNonceCacheKey(nonce string) string {
+	return middleware.CR_LOGIN_MSG_KEY + ":used:" + nonce
+}
+
 func getUserLoginTokenCacheKey(address string) string {
 	return middleware.CR_LOGIN_KEY + ":" + strings.ToLower(address)
 }
 
-func UserLogin(ctx context.Context, svcCtx *svc.ServerCtx, req types.LoginReq) (*types.UserLoginInfo, error) {
-	// 返回结果
-	res := types.UserLoginInfo{}
+const (
+	DefaultLoginHistoryLimit = 20  // 默认返回的登录记录数量
+	MaxLoginHistoryLimit     = 100 // 最多返回的登录记录数量
+
+	loginAuditTimeout      = 3 * time.Second // 异步写入登录审计记录的超时时间
+	maxLoginAuditUserAgent = 512             // 登录审计记录中 user-agent 的最大长度
+)
+
+// UserLogin 用户登录, 无论成功与否都会异步记录一条登录审计日志
+// ip 和 userAgent 为发起登录请求的客户端信息, 仅用于审计
+func UserLogin(ctx context.Context, svcCtx *svc.ServerCtx, req types.LoginReq, ip, userAgent string) (*types.UserLoginInfo, error) {
+	ctx, span := tracing.Start(ctx, "service.UserLogin")
+	defer span.End()
+
+	res, err := userLogin(ctx, svcCtx, req)
+	recordLoginAttempt(ctx, svcCtx, req, ip, userAgent, err)
+
+	return res, err
+}
+
+// recordLoginAttempt 异步写入登录审计记录, 不阻塞登录响应
+// 写入失败只记录日志, 不影响登录结果
+func recordLoginAttempt(ctx context.Context, svcCtx *svc.ServerCtx, req types.LoginReq, ip, userAgent string, loginErr error) {
+	if len(userAgent) > maxLoginAuditUserAgent {
+		userAgent = strings.ToValidUTF8(userAgent[:maxLoginAuditUserAgent], "")
+	}
+
+	audit := &dao.UserLoginAudit{
+		UserAddress: req.Address,
+		ChainId:     req.ChainID,
+		Success:     loginErr == nil,
+		Reason:      loginFailureReason(loginErr),
+		Ip:          ip,
+		UserAgent:   userAgent,
+		CreateTime:  time.Now().UnixMilli(),
+	}
+
+	// 请求结束后ctx会被取消, 异步写入需要脱离请求的生命周期
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		ctx, cancel := context.WithTimeout(ctx, loginAuditTimeout)
+		defer cancel()
+
+		if err := svcCtx.Dao.InsertUserLoginAudit(ctx, audit); err != nil {
+			xzap.WithContext(ctx).Warn("failed on record user login audit",
+				zap.String("address", audit.UserAddress), zap.Bool("success", audit.Success), zap.Error(err))
+		}
+	}()
+}
+
+// loginFailureReason 返回登录失败原因, 业务错误使用其错误消息,
+// 其他内部错误统一记录为 internal error, 避免把内部细节写入审计记录
+func loginFailureReason(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var e *errcode.Err
+	if errors.As(err, &e) {
+		return e.Error()
+	}
+
+	return "internal error"
+}
+
+// GetLoginHistory 查询用户最近的登录记录
+func GetLoginHistory(ctx context.Context, svcCtx *svc.ServerCtx, address string, limit int) ([]types.LoginHistory, error) {
+	ctx, span := tracing.Start(ctx, "service.GetLoginHistory")
+	defer span.End()
+
+	if limit <= 0 {
+		limit = DefaultLoginHistoryLimit
+	}
+	if limit > MaxLoginHistoryLimit {
+		limit = MaxLoginHistoryLimit
+	}
 
-	//todo: add verify signature
-	//ok := verifySignature(req.Message, req.Signature, req.PublicKey)
-	//if !ok {
-	//	return nil, errors.New("invalid signature")
-	//}
+	audits, err := svcCtx.Dao.QueryUserLoginAudits(ctx, address, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get login history")
+	}
 
-	// 从缓存中获取登录消息UUID
-	cachedUUID, err := svcCtx.KvStore.Get(getUserLoginMsgCacheKey(req.Address))
-	if cachedUUID == "" || err != nil {
-		return nil, errcode.ErrTokenExpire
+	history := make([]types.LoginHistory, 0, len(audits))
+	for _, audit := range audits {
+		history = append(history, types.LoginHistory{
+			ChainID:   audit.ChainId,
+			Success:   audit.Success,
+			Reason:    audit.Reason,
+			IP:        audit.Ip,
+			UserAgent: audit.UserAgent,
+			LoginTime: audit.CreateTime,
+		})
 	}
 
-	// 分割消息获取UUID
+	return history, nil
+}
+
+func userLogin(ctx context.Context, svcCtx *svc.ServerCtx, req types.LoginReq) (*types.UserLoginInfo, error) {
+	// 返回结果
+	res := types.UserLoginInfo{}
+
+	// 从消息中解析nonce
 	splits := strings.Split(req.Message, "Nonce:")
 	if len(splits) != 2 {
-		return nil, errcode.ErrTokenExpire
+		return nil, ErrLoginNonceInvalid
+	}
+	loginNonce := strings.TrimSpace(splits[1])
+
+	// 已经使用过的nonce不允许再次登录, 防止签名被重放
+	used, err := svcCtx.KvStore.Exists(getUsedLoginNonceCacheKey(loginNonce))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on check login nonce status")
+	}
+	if used {
+		return nil, ErrLoginNonceUsed
 	}
 
-	// 获取登录UUID并验证
-	loginUUID := strings.Trim(splits[1], "\n")
-	if loginUUID != cachedUUID {
-		return nil, errcode.ErrTokenExpire
+	// 从缓存中获取登录消息nonce, 不存在说明已过期
+	cachedNonce, err := svcCtx.KvStore.Get(getUserLoginMsgCacheKey(req.Address))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get login nonce")
+	}
+	if cachedNonce == "" {
+		return nil, ErrLoginNonceExpired
+	}
+	if loginNonce != cachedNonce {
+		return nil, ErrLoginNonceInvalid
 	}
 
+	// 校验签名, 支持EOA和EIP-1271合约钱包, 校验通过后才消费nonce
+	if err := verifyLoginSignature(ctx, svcCtx, req.ChainID, req.Address, req.Message, req.Signature); err != nil {
+		return nil, err
+	}
+
+	// 删除nonce, 保证每条登录消息只能使用一次
+	// 并发请求中只有成功删除的一方可以继续登录
+	deleted, err := svcCtx.KvStore.Del(getUserLoginMsgCacheKey(req.Address))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on consume login nonce")
+	}
+	if deleted == 0 {
+		return nil, ErrLoginNonceUsed
+	}
+	_ = svcCtx.KvStore.Setex(getUsedLoginNonceCacheKey(loginNonce), req.Address, LoginNonceTTL)
+
 	// 查询用户信息
 	var user base.User
 	db := svcCtx.DB.WithContext(ctx).Table(base.UserTableName()).
@@ -134,14 +259,24 @@ func PKCS7Padding(ciphertext []byte, blocksize int) []byte {
 	return append(ciphertext, padtext...)
 }
 
-func genLoginTemplate(nonce string) string {
-	return fmt.Sprintf("Welcome to EasySwap!\nNonce:%s", nonce)
+// LoginNonceTTL 登录消息的有效期(秒), 过期后需要重新获取
+const LoginNonceTTL = 5 * 60
+
+// genLoginTemplate 生成登录签名消息, 包含链ID、签发时间和随机nonce
+// nonce 必须位于最后一行, UserLogin 通过 "Nonce:" 解析
+func genLoginTemplate(chainID int, issuedAt time.Time, nonce string) string {
+	return fmt.Sprintf("Welcome to EasySwap!\nChain ID:%d\nIssued At:%s\nNonce:%s",
+		chainID, issuedAt.UTC().Format(time.RFC3339), nonce)
 }
 
-func GetUserLoginMsg(ctx context.Context, svcCtx *svc.ServerCtx, address string) (*types.UserLoginMsgResp, error) {
-	uuid := uuid.NewString()
-	loginMsg := genLoginTemplate(uuid)
-	if err := svcCtx.KvStore.Setex(getUserLoginMsgCacheKey(address), uuid, 72*60*60); err != nil {
+func GetUserLoginMsg(ctx context.Context, svcCtx *svc.ServerCtx, chainID int, address string) (*types.UserLoginMsgResp, error) {
+	ctx, span := tracing.Start(ctx, "service.GetUserLoginMsg")
+	defer span.End()
+
+	nonce := uuid.NewString()
+	loginMsg := genLoginTemplate(chainID, time.Now(), nonce)
+	// 同一地址重新获取消息会覆盖之前的nonce
+	if err := svcCtx.KvStore.Setex(getUserLoginMsgCacheKey(address), nonce, LoginNonceTTL); err != nil {
 		return nil, errors.Wrap(err, "failed on generate login msg")
 	}
 
@@ -149,6 +284,9 @@ func GetUserLoginMsg(ctx context.Context, svcCtx *svc.ServerCtx, address string)
 }
 
 func GetSigStatusMsg(ctx context.Context, svcCtx *svc.ServerCtx, userAddr string) (*types.UserSignStatusResp, error) {
+	ctx, span := tracing.Start(ctx, "service.GetSigStatusMsg")
+	defer span.End()
+
 	isSigned, err := svcCtx.Dao.GetUserSigStatus(ctx, userAddr)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed on get user sign status")
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/joinmouse/EasySwapBase/logger/xzap"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/joinmouse/EasySwapBackend/src/common/tracing"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+	"github.com/joinmouse/EasySwapBackend/src/types/v1"
+)
+
+// MaxWatchlistSize 每个用户最多关注的集合数量
+const MaxWatchlistSize = 500
+
+// floorChangePeriod 关注列表中地板价变化的统计周期(秒)
+const floorChangePeriod = 24 * 60 * 60
+
+// AddToWatchlist 关注集合
+// 已关注的集合直接返回成功, 未关注时检查集合是否存在以及关注数量上限
+// 数量上限在插入语句中检查, 并发添加时不会超过 MaxWatchlistSize
+func AddToWatchlist(ctx context.Context, svcCtx *svc.ServerCtx, userAddr string, chainID int, chain, collectionAddr string) error {
+	ctx, span := tracing.Start(ctx, "service.AddToWatchlist")
+	defer span.End()
+
+	watched, err := svcCtx.Dao.IsCollectionWatched(ctx, userAddr, chainID, collectionAddr)
+	if err != nil {
+		return errors.Wrap(err, "failed on check watchlist")
+	}
+	if watched {
+		return nil
+	}
+
+	if _, err := svcCtx.Dao.QueryCollectionInfo(ctx, chain, collectionAddr); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCollectionNotFound
+		}
+		return errors.Wrap(err, "failed on get collection info")
+	}
+
+	added, err := svcCtx.Dao.AddUserWatchlist(ctx, userAddr, chainID, collectionAddr, MaxWatchlistSize)
+	if err != nil {
+		return err
+	}
+	if added {
+		return nil
+	}
+
+	// 没有新增记录时, 可能是并发请求已关注了该集合, 否则为达到上限
+	watched, err = svcCtx.Dao.IsCollectionWatched(ctx, userAddr, chainID, collectionAddr)
+	if err != nil {
+		return errors.Wrap(err, "failed on check watchlist")
+	}
+	if !watched {
+		return ErrWatchlistFull
+	}
+
+	return nil
+}
+
+// RemoveFromWatchlist 取消关注集合
+func RemoveFromWatchlist(ctx context.Context, svcCtx *svc.ServerCtx, userAddr string, chainID int, collectionAddr string) error {
+	ctx, span := tracing.Start(ctx, "service.RemoveFromWatchlist")
+	defer span.End()
+
+	return svcCtx.Dao.DeleteUserWatchlist(ctx, userAddr, chainID, collectionAddr)
+}
+
+// GetWatchlist 获取用户关注的集合列表
+// 1. 查询用户关注的集合
+// 2. 按链分组批量查询集合信息和24小时地板价变化
+// 3. 已不在支持链上的集合会被忽略
+func GetWatchlist(ctx context.Context, svcCtx *svc.ServerCtx, userAddr string) ([]types.WatchlistItem, error) {
+	ctx, span := tracing.Start(ctx, "service.GetWatchlist")
+	defer span.End()
+
+	watchlist, err := svcCtx.Dao.QueryUserWatchlist(ctx, userAddr)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed on get watchlist")
+	}
+
+	result := make([]types.WatchlistItem, 0, len(watchlist))
+	if len(watchlist) == 0 {
+		return result, nil
+	}
+
+	chainAddrs := make(map[int][]string)
+	for _, watch := range watchlist {
+		chainAddrs[watch.ChainId] = append(chainAddrs[watch.ChainId], watch.CollectionAddress)
+	}
+
+	type collectionKey struct {
+		chainID int
+		address string
+	}
+	collections := make(map[collectionKey]types.WatchlistItem)
+	for chainID, addrs := range chainAddrs {
+		chain := chainNameByID(svcCtx, chainID)
+		if chain == "" {
+			continue
+		}
+
+		infos, err := svcCtx.Dao.QueryCollectionsInfo(ctx, chain, addrs)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed on get collections info")
+		}
+
+		// 地板价变化查询失败时不影响关注列表返回
+		floorChange, err := svcCtx.Dao.QueryCollectionFloorChange(ctx, chain, floorChangePeriod)
+		if err != nil {
+			xzap.WithContext(ctx).Warn("failed on get collection floor change", zap.Error(err))
+		}
+
+		for _, info := range infos {
+			collections[collectionKey{chainID, strings.ToLower(info.Address)}] = types.WatchlistItem{
+				ChainID:           chainID,
+				CollectionAddress: info.Address,
+				Name:              info.Name,
+				ImageURI:          info.ImageUri,
+				FloorPrice:        info.FloorPrice,
+				FloorChange24h:    floorChange[info.Address],
+			}
+		}
+	}
+
+	for _, watch := range watchlist {
+		item, ok := collections[collectionKey{watch.ChainId, strings.ToLower(watch.CollectionAddress)}]
+		if !ok {
+			continue
+		}
+		item.AddedAt = watch.CreateTime
+		result = append(result, item)
+	}
+
+	return result, nil
+}
+
+// chainNameByID 根据链ID获取配置中的链名称, 不支持的链返回空字符串
+func chainNameByID(svcCtx *svc.ServerCtx, chainID int) string {
+	for _, c := range svcCtx.C.ChainSupported {
+		if c != nil && c.ChainID == chainID {
+			return c.Name
+		}
+	}
+
+	return ""
+}
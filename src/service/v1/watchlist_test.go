@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/joinmouse/EasySwapBase/stores/gdb/orderbookmodel/multi"
+)
+
+func TestAddToWatchlistFull(t *testing.T) {
+	svcCtx, stub := newStubServerCtx(t)
+	stub.results = func(query string) *stubRows {
+		if strings.Contains(query, multi.CollectionTableName("eth")) {
+			return &stubRows{
+				columns: []string{"address"},
+				values:  [][]driver.Value{{testCollection}},
+			}
+		}
+		return nil
+	}
+
+	// 插入语句没有新增记录且集合未被关注时, 说明已达到关注数量上限
+	if err := AddToWatchlist(context.Background(), svcCtx, testUser, 1, "eth", testCollection); err != ErrWatchlistFull {
+		t.Errorf("AddToWatchlist() error = %v, want %v", err, ErrWatchlistFull)
+	}
+}
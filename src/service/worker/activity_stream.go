@@ -0,0 +1,90 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/joinmouse/EasySwapBase/logger/xzap"
+	"go.uber.org/zap"
+
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+	"github.com/joinmouse/EasySwapBackend/src/service/v1"
+)
+
+// activityStreamWorkerRunning 保证每个进程只运行一个交易活动推送任务
+var activityStreamWorkerRunning int32
+
+// ActivityStreamWorker 交易活动实时推送任务
+// 交易活动由同步服务写入活动表, 该任务定期读取每条链上新写入的活动, 发布到集合的实时推送频道
+// 启动时从活动表当前最大的ID开始, 不补发启动前写入的活动
+type ActivityStreamWorker struct {
+	loop
+
+	svcCtx   *svc.ServerCtx
+	interval time.Duration
+
+	cursors map[string]int64 // chain -> 已读取的最大活动ID
+}
+
+// NewActivityStreamWorker 创建交易活动推送任务
+// 配置的轮询间隔为负数或没有发布订阅客户端时返回 nil, 表示不启用
+func NewActivityStreamWorker(svcCtx *svc.ServerCtx) *ActivityStreamWorker {
+	if svcCtx == nil || svcCtx.PubSub == nil || svcCtx.Dao == nil {
+		return nil
+	}
+
+	interval := service.DefaultActivityStreamInterval
+	if svcCtx.C != nil {
+		if svcCtx.C.Worker.ActivityStreamInterval < 0 {
+			return nil
+		}
+		if svcCtx.C.Worker.ActivityStreamInterval > 0 {
+			interval = svcCtx.C.Worker.ActivityStreamInterval
+		}
+	}
+
+	return &ActivityStreamWorker{
+		loop:     loop{running: &activityStreamWorkerRunning},
+		svcCtx:   svcCtx,
+		interval: time.Duration(interval) * time.Second,
+		cursors:  make(map[string]int64),
+	}
+}
+
+// Start 在后台协程中启动推送任务
+func (w *ActivityStreamWorker) Start() error {
+	return w.start("activity stream", w.interval, w.poll)
+}
+
+// poll 依次处理所有支持的链, 单条链失败只记录日志, 下次轮询从原位置重试
+func (w *ActivityStreamWorker) poll(ctx context.Context) {
+	for _, chain := range w.svcCtx.C.ChainSupported {
+		if chain == nil || ctx.Err() != nil {
+			continue
+		}
+
+		cursor, ok := w.cursors[chain.Name]
+		if !ok {
+			latest, err := service.LatestActivityID(ctx, w.svcCtx, chain.Name)
+			if err != nil {
+				xzap.WithContext(ctx).Error("failed on init activity stream cursor",
+					zap.String("chain", chain.Name), zap.Error(err))
+				continue
+			}
+			w.cursors[chain.Name] = latest
+			continue
+		}
+
+		lastID, published, err := service.PublishNewActivities(ctx, w.svcCtx, chain.ChainID, chain.Name, cursor)
+		if err != nil {
+			xzap.WithContext(ctx).Error("failed on publish new activities",
+				zap.String("chain", chain.Name), zap.Int64("after_id", cursor), zap.Error(err))
+			continue
+		}
+		w.cursors[chain.Name] = lastID
+		if published > 0 {
+			xzap.WithContext(ctx).Debug("activities published",
+				zap.String("chain", chain.Name), zap.Int("count", published), zap.Int64("last_id", lastID))
+		}
+	}
+}
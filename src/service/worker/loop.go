@@ -0,0 +1,68 @@
+package worker
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/joinmouse/EasySwapBase/logger/xzap"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// ErrAlreadyRunning 同一进程内已经有一个实例在运行
+var ErrAlreadyRunning = errors.New("worker is already running")
+
+// loop 定时执行的后台任务的运行状态, 由各任务嵌入
+// running 指向任务的包级运行标志, 保证每个进程只运行一个同类任务
+type loop struct {
+	running *int32
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// start 在后台协程中每隔 interval 执行一次 fn, 启动后立即执行一次
+// 同类任务已在运行时返回 ErrAlreadyRunning
+func (l *loop) start(name string, interval time.Duration, fn func(ctx context.Context)) error {
+	if !atomic.CompareAndSwapInt32(l.running, 0, 1) {
+		return ErrAlreadyRunning
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	l.cancel = cancel
+	l.done = make(chan struct{})
+	go l.run(ctx, interval, fn)
+
+	xzap.WithContext(ctx).Info(name+" worker started", zap.Duration("interval", interval))
+	return nil
+}
+
+// Stop 停止任务并等待正在进行的一次执行结束
+func (l *loop) Stop() {
+	if l.cancel == nil {
+		return
+	}
+
+	l.cancel()
+	<-l.done
+	l.cancel = nil
+	atomic.StoreInt32(l.running, 0)
+}
+
+func (l *loop) run(ctx context.Context, interval time.Duration, fn func(ctx context.Context)) {
+	defer close(l.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		fn(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package worker
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	logging "github.com/joinmouse/EasySwapBase/logger"
+	"github.com/joinmouse/EasySwapBase/logger/xzap"
+)
+
+func TestMain(m *testing.M) {
+	if _, err := xzap.SetUp(logging.LogConf{Mode: "console", Path: os.TempDir(), Level: "error"}); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+func TestLoopSingleInstance(t *testing.T) {
+	var running int32
+	var calls int32
+	fn := func(context.Context) { atomic.AddInt32(&calls, 1) }
+
+	first := &loop{running: &running}
+	if err := first.start("test", time.Hour, fn); err != nil {
+		t.Fatalf("start() error: %v", err)
+	}
+	second := &loop{running: &running}
+	if err := second.start("test", time.Hour, fn); err != ErrAlreadyRunning {
+		t.Errorf("second start() error = %v, want %v", err, ErrAlreadyRunning)
+	}
+
+	first.Stop()
+	// 启动后立即执行一次
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("calls = %d, want 1", got)
+	}
+	// 停止后可以重新启动
+	if err := second.start("test", time.Hour, fn); err != nil {
+		t.Errorf("start() after Stop() error: %v", err)
+	}
+	second.Stop()
+}
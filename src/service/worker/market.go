@@ -0,0 +1,93 @@
+// Package worker 定义了后端服务在进程内运行的后台任务
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/joinmouse/EasySwapBase/logger/xzap"
+	"go.uber.org/zap"
+
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+	"github.com/joinmouse/EasySwapBackend/src/service/v1"
+)
+
+// marketWorkerRunning 保证每个进程只运行一个集合行情刷新任务
+var marketWorkerRunning int32
+
+// MarketWorker 集合行情刷新任务
+// 定期重新计算每条链上所有集合的地板价、24小时成交数据和已上架Item并写入Redis, 读接口直接读取缓存
+type MarketWorker struct {
+	loop
+
+	svcCtx   *svc.ServerCtx
+	interval time.Duration
+}
+
+// NewMarketWorker 创建集合行情刷新任务
+// 配置的刷新间隔为负数时返回 nil, 表示不启用
+func NewMarketWorker(svcCtx *svc.ServerCtx) *MarketWorker {
+	interval := service.DefaultMarketRefreshInterval
+	if svcCtx.C != nil {
+		if svcCtx.C.Worker.MarketRefreshInterval < 0 {
+			return nil
+		}
+		if svcCtx.C.Worker.MarketRefreshInterval > 0 {
+			interval = svcCtx.C.Worker.MarketRefreshInterval
+		}
+	}
+
+	return &MarketWorker{
+		loop:     loop{running: &marketWorkerRunning},
+		svcCtx:   svcCtx,
+		interval: time.Duration(interval) * time.Second,
+	}
+}
+
+// Start 在后台协程中启动刷新任务, 启动后立即执行一次刷新
+func (w *MarketWorker) Start() error {
+	return w.start("collection market", w.interval, w.refresh)
+}
+
+// refresh 依次刷新所有支持的链, 单条链失败只记录日志, 不影响其他链和后续的刷新
+func (w *MarketWorker) refresh(ctx context.Context) {
+	for _, chain := range w.svcCtx.C.ChainSupported {
+		if chain == nil || ctx.Err() != nil {
+			continue
+		}
+
+		w.refreshChain(ctx, chain.Name, "collection market", service.RefreshCollectionMarket)
+		w.refreshChain(ctx, chain.Name, "listed items", service.RefreshListedItems)
+	}
+}
+
+// refreshChain 执行单条链的一项刷新并记录结果, 失败只记录日志
+func (w *MarketWorker) refreshChain(ctx context.Context, chain, name string, fn refreshFunc) {
+	start := time.Now()
+	count, err := w.safeRefresh(ctx, chain, fn)
+	if err != nil {
+		xzap.WithContext(ctx).Error("failed on refresh "+name,
+			zap.String("chain", chain), zap.Error(err))
+		return
+	}
+	xzap.WithContext(ctx).Debug(name+" refreshed",
+		zap.String("chain", chain), zap.Int("collections", count), zap.Duration("took", time.Since(start)))
+}
+
+// refreshFunc 刷新单条链的数据, 返回刷新的集合数量
+type refreshFunc func(ctx context.Context, svcCtx *svc.ServerCtx, chain string) (int, error)
+
+// safeRefresh 单次刷新的耗时不超过刷新间隔, 并将 panic 转为错误避免任务退出
+func (w *MarketWorker) safeRefresh(ctx context.Context, chain string, fn refreshFunc) (count int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, w.interval)
+	defer cancel()
+
+	return fn(ctx, w.svcCtx, chain)
+}
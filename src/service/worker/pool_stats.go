@@ -0,0 +1,68 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/joinmouse/EasySwapBase/logger/xzap"
+	"go.uber.org/zap"
+
+	"github.com/joinmouse/EasySwapBackend/src/common/metrics"
+	"github.com/joinmouse/EasySwapBackend/src/service/svc"
+)
+
+// DefaultPoolStatsInterval 连接池监控指标的默认采集间隔（秒）
+const DefaultPoolStatsInterval = 15
+
+// poolStatsWorkerRunning 保证每个进程只运行一个连接池监控任务
+var poolStatsWorkerRunning int32
+
+// PoolStatsWorker 连接池监控任务
+// 定期把数据库连接池的连接数和等待情况写入监控指标, 便于在连接池耗尽导致请求超时之前告警
+// Redis 客户端由 go-zero 管理, 没有对外暴露连接池状态, 这里不采集
+type PoolStatsWorker struct {
+	loop
+
+	svcCtx   *svc.ServerCtx
+	interval time.Duration
+}
+
+// NewPoolStatsWorker 创建连接池监控任务
+// 配置的采集间隔为负数或没有数据库连接时返回 nil, 表示不启用
+func NewPoolStatsWorker(svcCtx *svc.ServerCtx) *PoolStatsWorker {
+	if svcCtx == nil || svcCtx.DB == nil {
+		return nil
+	}
+
+	interval := DefaultPoolStatsInterval
+	if svcCtx.C != nil {
+		if svcCtx.C.Worker.PoolStatsInterval < 0 {
+			return nil
+		}
+		if svcCtx.C.Worker.PoolStatsInterval > 0 {
+			interval = svcCtx.C.Worker.PoolStatsInterval
+		}
+	}
+
+	return &PoolStatsWorker{
+		loop:     loop{running: &poolStatsWorkerRunning},
+		svcCtx:   svcCtx,
+		interval: time.Duration(interval) * time.Second,
+	}
+}
+
+// Start 在后台协程中启动监控任务, 启动后立即采集一次
+func (w *PoolStatsWorker) Start() error {
+	return w.start("pool stats", w.interval, w.collect)
+}
+
+// collect 采集一次数据库连接池状态, 获取底层连接失败只记录日志
+func (w *PoolStatsWorker) collect(ctx context.Context) {
+	sqlDB, err := w.svcCtx.DB.DB()
+	if err != nil {
+		xzap.WithContext(ctx).Warn("failed on get sql db for pool stats", zap.Error(err))
+		return
+	}
+
+	metrics.ObserveDBPool(sqlDB.Stats())
+}
@@ -4,15 +4,26 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+// TimeRange 按事件时间过滤的区间, 单位为 Unix 秒
+type TimeRange struct {
+	Since int64 `json:"since"`
+	Until int64 `json:"until"`
+}
+
 type ActivityMultiChainFilterParams struct {
 	ChainID             []int    `json:"filter_ids"`
 	CollectionAddresses []string `json:"collection_addresses"`
 	TokenID             string   `json:"token_id"`
 	UserAddresses       []string `json:"user_addresses"`
+	Maker               string   `json:"maker"`
+	Taker               string   `json:"taker"`
 	EventTypes          []string `json:"event_types"`
 
-	Page     int `json:"page"`
-	PageSize int `json:"page_size"`
+	Page     int    `json:"page"`
+	PageSize int    `json:"page_size"`
+	Cursor   string `json:"cursor"` // 上一页返回的 next_cursor, 不为空时忽略 page
+
+	TimeRange *TimeRange `json:"-"` // query 参数 since/until 指定的时间区间, 为空时不限制
 }
 
 type ActivityInfo struct {
@@ -34,6 +45,20 @@ type ActivityInfo struct {
 }
 
 type ActivityResp struct {
-	Result interface{} `json:"result"`
-	Count  int64       `json:"count"`
+	Result     interface{} `json:"result"`
+	Count      int64       `json:"count"`
+	NextCursor string      `json:"next_cursor"` // 获取下一页时传入的游标, 为空表示没有更多数据
+}
+
+const (
+	ActivityStreamSnapshot = "snapshot" // 连接建立时推送的最近活动快照
+	ActivityStreamEvent    = "activity" // 实时推送的新活动
+)
+
+// ActivityStreamMsg WebSocket推送的交易活动消息
+// 集合实时推送频道的消息体为单条 ActivityInfo 的 JSON, 与快照中的活动格式相同
+// 频道名称为 es:activity:stream:<chain>:<collection_address>
+type ActivityStreamMsg struct {
+	Type string      `json:"type"` // 消息类型: snapshot 或 activity
+	Data interface{} `json:"data"` // snapshot 时为 ActivityInfo 列表, activity 时为单条 ActivityInfo
 }
@@ -0,0 +1,34 @@
+package types
+
+import "github.com/joinmouse/EasySwapBackend/src/common/buildinfo"
+
+// ReadOnlyReq 定义了切换只读模式的请求参数
+type ReadOnlyReq struct {
+	ReadOnly *bool `json:"read_only" binding:"required"` // 是否开启只读模式
+}
+
+// ReadOnlyResp 定义了只读模式状态的响应数据结构
+type ReadOnlyResp struct {
+	ReadOnly bool `json:"read_only"` // 当前是否处于只读模式
+}
+
+// CollectionHiddenReq 定义了设置集合隐藏标记的请求参数
+type CollectionHiddenReq struct {
+	ChainID int    `json:"chain_id" binding:"required"`        // 链ID
+	Hidden  *bool  `json:"hidden" binding:"required"`          // 是否隐藏集合
+	Reason  string `json:"reason" binding:"omitempty,max=255"` // 隐藏原因
+}
+
+// CollectionHiddenResp 定义了集合隐藏标记的响应数据结构
+type CollectionHiddenResp struct {
+	ChainID           int    `json:"chain_id"`           // 链ID
+	CollectionAddress string `json:"collection_address"` // 集合地址
+	Hidden            bool   `json:"hidden"`             // 当前是否隐藏
+	Reason            string `json:"reason"`             // 隐藏原因
+}
+
+// AdminConfigResp 定义了运行配置查询的响应数据结构
+type AdminConfigResp struct {
+	Config interface{}    `json:"config"` // 生效的配置, 密码、密钥等敏感字段已脱敏
+	Build  buildinfo.Info `json:"build"`  // 构建信息和启动时间
+}
@@ -16,3 +16,53 @@ type ItemBid struct {
 	Bidder            string          `json:"bidder"`
 	OrderType         int64           `json:"order_type"`
 }
+
+// TraitItemBids 集合中拥有某个 Trait值的 Item 上的有效Item出价汇总
+// 订单簿中没有针对 Trait 的出价, 这里是Item出价按 Trait值分组的结果, BidType 固定为 item
+// Price 为这些Item出价中的最高价格, TokenID 为空
+type TraitItemBids struct {
+	TraitPrice
+	BidType   string `json:"bid_type"`   // 出价类型, 固定为 item
+	TotalSize int64  `json:"total_size"` // 这些Item出价剩余未成交的数量之和
+	Bids      int64  `json:"bids"`       // 这些Item出价的数量
+}
+
+// BidPriceLevel Collection Bid 深度图中的一个价位
+type BidPriceLevel struct {
+	Price              decimal.Decimal `json:"price"`
+	BidSize            int64           `json:"bid_size"`            // 该价位所有出价的原始数量之和
+	BidUnfilled        int64           `json:"bid_unfilled"`        // 该价位所有出价剩余未成交的数量之和
+	Total              decimal.Decimal `json:"total"`               // 剩余未成交数量对应的总价值
+	Makers             int64           `json:"makers"`              // 该价位不同出价人的数量
+	CumulativeUnfilled int64           `json:"cumulative_unfilled"` // 从最高价到该价位累计的剩余未成交数量
+}
+
+// ItemOffer NFT Item 的一条有效出价
+// 包括针对该 Item 的出价和适用于整个集合的出价
+type ItemOffer struct {
+	OrderID         string          `json:"order_id"`
+	MarketplaceId   int             `json:"marketplace_id"`
+	BidType         string          `json:"bid_type"` // item: Item出价 collection: 集合出价
+	Bidder          string          `json:"bidder"`
+	CurrencyAddress string          `json:"currency_address"`
+	Price           decimal.Decimal `json:"price"`           // 出价的单价
+	EffectivePrice  decimal.Decimal `json:"effective_price"` // 扣除市场手续费和版税后卖家实际到手的金额
+	BidSize         int64           `json:"bid_size"`        // 出价的原始数量
+	BidUnfilled     int64           `json:"bid_unfilled"`    // 剩余可成交的数量
+	EventTime       int64           `json:"event_time"`
+	ExpireTime      int64           `json:"expire_time"` // in seconds
+}
+
+// AcceptOfferPreview 持有人接受当前最优出价的预估结果
+// 没有可接受的出价时 has_offer 为 false, offer 和 fees 为空, 金额为0
+type AcceptOfferPreview struct {
+	CollectionAddress string          `json:"collection_address"`
+	TokenID           string          `json:"token_id"`
+	Owner             string          `json:"owner"`        // 当前持有人, 持有人自己的出价不参与计算
+	HasOffer          bool            `json:"has_offer"`    // 是否存在可接受的出价
+	Offer             *ItemOffer      `json:"offer"`        // 实际到手金额最高的出价
+	Currency          string          `json:"currency"`     // 出价的支付代币
+	Price             decimal.Decimal `json:"price"`        // 出价的单价
+	Fees              *ListingFees    `json:"fees"`         // 卖家需要支付的市场手续费和版税明细
+	NetProceeds       decimal.Decimal `json:"net_proceeds"` // 扣除费用后卖家实际到手的金额
+}
@@ -0,0 +1,38 @@
+package types
+
+// SupportedChain 定义了后端支持的区块链信息
+// 只包含前端需要的公开信息，不包含 RPC 端点等内部配置
+type SupportedChain struct {
+	Name                string `json:"name"`                 // 区块链名称
+	ChainID             int    `json:"chain_id"`             // 区块链 ID
+	NativeSymbol        string `json:"native_symbol"`        // 原生代币符号
+	MarketplaceContract string `json:"marketplace_contract"` // EasySwap 订单簿合约地址
+}
+
+// ChainFees 定义了链上交易的手续费信息, 费率单位为基点(1 基点 = 0.01%)
+type ChainFees struct {
+	ChainID           int                 `json:"chain_id"`
+	MarketplaceBps    int                 `json:"marketplace_bps"`     // 市场手续费基点
+	FeeRecipient      string              `json:"fee_recipient"`       // 市场手续费接收地址
+	DefaultRoyaltyBps int                 `json:"default_royalty_bps"` // 默认版税基点
+	RoyaltyOverrides  []CollectionRoyalty `json:"royalty_overrides"`   // 单独配置了版税的集合
+}
+
+// CollectionRoyalty 集合单独配置的版税
+type CollectionRoyalty struct {
+	CollectionAddress string `json:"collection_address"`
+	RoyaltyBps        int    `json:"royalty_bps"`       // 版税基点
+	RoyaltyRecipient  string `json:"royalty_recipient"` // 版税接收地址
+}
+
+// ChainSyncStatus 索引服务在某条链上的同步状态
+type ChainSyncStatus struct {
+	ChainID      int   `json:"chain_id"`
+	IndexedBlock int64 `json:"indexed_block"` // 数据已同步到的区块高度, 取各类索引任务中最慢的一个
+	IndexedTime  int64 `json:"indexed_time"`  // 同步到该区块的时间
+	HeadBlock    int64 `json:"head_block"`    // 链上最新区块高度
+	LagBlocks    int64 `json:"lag_blocks"`    // 落后的区块数
+	LagSeconds   int64 `json:"lag_seconds"`   // 按平均出块时间估算的落后秒数
+	MaxSyncLag   int64 `json:"max_sync_lag"`  // 视为健康的最大落后区块数
+	Healthy      bool  `json:"healthy"`       // 落后区块数是否在 MaxSyncLag 以内
+}
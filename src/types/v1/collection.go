@@ -13,12 +13,21 @@ type CollectionItemFilterParams struct {
 	ChainID     int    `json:"chain_id"`
 	Page        int    `json:"page"`
 	PageSize    int    `json:"page_size"`
+
+	// Traits 特征过滤, key 为特征名称, value 为可选的特征值
+	// 不同特征之间为 AND, 同一特征的多个值之间为 OR
+	Traits     map[string][]string `json:"traits"`
+	MinPrice   *Price              `json:"min_price"`   // 价格下限, 必须以字符串形式传入; 只查询有报价的Item时作用于最高出价, 否则作用于最低挂单价格
+	MaxPrice   *Price              `json:"max_price"`   // 价格上限, 必须以字符串形式传入, 作用的价格与 MinPrice 相同
+	ListedOnly bool                `json:"listed_only"` // 只返回已上架的Item
 }
 
 type CollectionBidFilterParams struct {
-	ChainID  int `json:"chain_id"`
-	Page     int `json:"page"`
-	PageSize int `json:"page_size"`
+	ChainID    int  `json:"chain_id"`
+	Page       int  `json:"page"`
+	PageSize   int  `json:"page_size"`
+	ResolveEns bool `json:"resolve_ens"` // 是否将出价人地址解析为 ENS 名称
+	RefreshEns bool `json:"refresh_ens"` // 是否忽略 ENS 负缓存(未设置名称或解析失败), 重新解析
 }
 
 type CollectionBids struct {
@@ -26,11 +35,22 @@ type CollectionBids struct {
 	Size    int             `json:"size"`
 	Total   decimal.Decimal `json:"total"`
 	Bidders int             `json:"bidders"`
+	Makers  []BidMaker      `json:"makers" gorm:"-"` // 该价位的出价人, 最多返回 MaxBidLevelMakers 个
+}
+
+// BidMaker 出价人信息
+// 同时返回原始地址和解析到的 ENS 名称, 未请求解析或地址没有 ENS 名称时 EnsName 为空
+type BidMaker struct {
+	Address string `json:"address"`
+	EnsName string `json:"ens_name,omitempty"`
 }
 
 type CollectionBidsResp struct {
-	Result interface{} `json:"result"`
-	Count  int64       `json:"count"`
+	Result   interface{} `json:"result"`
+	Count    int64       `json:"count"`
+	Page     int         `json:"page"`      // 当前页码
+	PageSize int         `json:"page_size"` // 每页数量
+	HasMore  bool        `json:"has_more"`  // 是否还有下一页
 }
 
 type HistorySalesPriceInfo struct {
@@ -39,6 +59,48 @@ type HistorySalesPriceInfo struct {
 	TimeStamp int64           `json:"time_stamp"`
 }
 
+// OwnerBucket 按持有数量划分的持有人区间
+type OwnerBucket struct {
+	Range   string `json:"range"`   // 持有数量区间, 如 "1"、"2-5"、"6-20"、"20+"
+	Holders int64  `json:"holders"` // 该区间的持有人数量
+}
+
+// CollectionOwnerDistribution 集合持有人分布概览
+type CollectionOwnerDistribution struct {
+	Holders      int64         `json:"holders"`        // 持有人数量
+	TotalHeld    int64         `json:"total_held"`     // 所有持有人的持有数量之和
+	Buckets      []OwnerBucket `json:"buckets"`        // 按持有数量分桶的持有人数量
+	TopHolders   int           `json:"top_holders"`    // 统计集中度时使用的头部持有人数量
+	TopHeld      int64         `json:"top_held"`       // 头部持有人的持有数量之和
+	TopHeldShare float64       `json:"top_held_share"` // 头部持有人的持有占比(百分比)
+}
+
+// CollectionOwnersResp 集合持有人列表, 包含分页的持有人及分布概览
+type CollectionOwnersResp struct {
+	PageResp
+	Distribution *CollectionOwnerDistribution `json:"distribution"`
+}
+
+// HistorySalesFilter 集合成交历史的过滤条件
+type HistorySalesFilter struct {
+	Since    int64            // 成交时间下限(Unix 秒), 为 0 时不限制
+	Until    int64            // 成交时间上限(Unix 秒), 为 0 时为当前时间
+	MinPrice *decimal.Decimal // 成交价格下限, 为空时不限制
+	MaxPrice *decimal.Decimal // 成交价格上限, 为空时不限制
+}
+
+// ItemSaleInfo 单个NFT Item的一次成交记录
+type ItemSaleInfo struct {
+	Price           decimal.Decimal `json:"price"`
+	Currency        string          `json:"currency"`
+	CurrencyAddress string          `json:"currency_address,omitempty"`
+	Seller          string          `json:"seller"`
+	Buyer           string          `json:"buyer"`
+	MarketplaceID   int             `json:"marketplace_id"`
+	TxHash          string          `json:"tx_hash"`
+	TimeStamp       int64           `json:"time_stamp"`
+}
+
 type TopTraitFilterParams struct {
 	TokenIds []string `json:"token_ids"`
 	ChainID  int      `json:"chain_id"`
@@ -65,6 +127,8 @@ type NFTListingInfo struct {
 	ListExpireTime int64           `json:"list_expire_time"`
 	ListSalt       int64           `json:"list_salt"`
 	ListMaker      string          `json:"list_maker"`
+	ListTotalPrice decimal.Decimal `json:"list_total_price"`    // 买家需要支付的总价：挂单价格 + 市场手续费 + 版税，未上架时为0
+	ListFees       *ListingFees    `json:"list_fees,omitempty"` // 总价中的费用明细，未上架时不返回
 
 	BidOrderID    string          `json:"bid_order_id"`
 	BidTime       int64           `json:"bid_time"`
@@ -100,6 +164,12 @@ type CollectionRankingInfo struct {
 	ItemSold    int64           `json:"item_sold"`
 	ListAmount  int             `json:"list_amount"`
 	ChainID     int             `json:"chain_id"`
+	AsOf        int64           `json:"as_of"` // 排名数据的计算时间（秒），地板价来自后台任务缓存时为缓存的计算时间
+
+	Rank         int             `json:"rank"`          // 排名位置，从 1 开始
+	SortBy       string          `json:"sort_by"`       // 排序指标（volume/sales/floor_change/avg_price）
+	MetricValue  decimal.Decimal `json:"metric_value"`  // 排序指标在当前窗口的值，floor_change 时为当前地板价
+	MetricChange float64         `json:"metric_change"` // 排序指标相对上一个等长窗口的变化百分比
 }
 
 type CollectionRankingResp struct {
@@ -120,6 +190,7 @@ type CollectionDetail struct {
 	TotalSupply    int64           `json:"total_supply"`
 	OwnerAmount    int64           `json:"owner_amount"`
 	RoyaltyFeeRate string          `json:"royalty_fee_rate"`
+	Flagged        bool            `json:"flagged"` // 集合是否被举报隐藏, 被隐藏的集合不出现在搜索和排名中
 }
 
 type CollectionDetailResp struct {
@@ -130,13 +201,111 @@ type CommonResp struct {
 	Result interface{} `json:"result"`
 }
 
+// PageResp 通用分页响应结构
+type PageResp struct {
+	Total    int64       `json:"total"`     // 总记录数
+	Page     int         `json:"page"`      // 当前页码
+	PageSize int         `json:"page_size"` // 每页数量
+	Items    interface{} `json:"items"`     // 当前页数据
+}
+
 type RefreshItem struct {
 	ChainID        int64  `json:"chain_id"`
 	CollectionAddr string `json:"collection_addr"`
 	TokenID        string `json:"token_id"`
 }
 
+// IndexCollection 集合收录队列中的任务, 由索引服务消费
+type IndexCollection struct {
+	ChainID        int64  `json:"chain_id"`
+	CollectionAddr string `json:"collection_addr"`
+	TokenStandard  string `json:"token_standard"` // erc721 / erc1155
+	JobID          string `json:"job_id"`         // 收录任务ID, 索引服务据此更新任务状态
+}
+
+// CollectionIndexReq 提交集合收录的请求体
+type CollectionIndexReq struct {
+	ChainID int    `json:"chain_id" binding:"required"`
+	Address string `json:"address" binding:"required"` // 集合合约地址
+}
+
+// CollectionIndexResp 提交集合收录的响应
+type CollectionIndexResp struct {
+	ChainID           int      `json:"chain_id"`
+	CollectionAddress string   `json:"collection_address"`
+	TokenStandard     string   `json:"token_standard"` // erc721 / erc1155
+	Indexed           bool     `json:"indexed"`        // 集合是否已经收录, 为 true 时不会创建任务
+	Job               *JobInfo `json:"job,omitempty"`  // 收录任务, 进度通过 GET /jobs/:id 查询
+}
+
+// ItemMetadataRefreshResp 刷新 item 元数据的响应
+type ItemMetadataRefreshResp struct {
+	Result      string `json:"result"`       // 刷新结果描述
+	RefreshedAt int64  `json:"refreshed_at"` // 最近一次入队刷新的时间(Unix 秒)
+	Cached      bool   `json:"cached"`       // 是否命中冷却期，为 true 时本次请求未重新入队
+}
+
 type CollectionListed struct {
 	CollectionAddr string `json:"collection_address"`
 	Count          int    `json:"count"`
 }
+
+// FloorPricePoint 地板价历史中的一个时间点
+type FloorPricePoint struct {
+	Timestamp  int64           `json:"timestamp"`   // 分桶起始时间(Unix 秒)
+	FloorPrice decimal.Decimal `json:"floor_price"` // 分桶内的地板价, 无挂单记录时沿用上一个分桶的地板价
+}
+
+// CollectionSearchInfo 集合搜索结果
+type CollectionSearchInfo struct {
+	Address    string          `json:"address"`
+	Name       string          `json:"name"`
+	Symbol     string          `json:"symbol"`
+	ImageURI   string          `json:"image_uri" gorm:"column:image_uri"`
+	FloorPrice decimal.Decimal `json:"floor_price"`
+	Volume24h  decimal.Decimal `json:"volume_24h" gorm:"column:volume_24h"`
+}
+
+// CollectionStats 集合的聚合统计信息
+type CollectionStats struct {
+	TotalSupply   int64           `json:"total_supply"`
+	OwnerCount    int64           `json:"owner_count"`
+	ListedCount   int64           `json:"listed_count"`
+	ListedPercent float64         `json:"listed_percent"`
+	FloorPrice    decimal.Decimal `json:"floor_price"`
+	Volume24h     decimal.Decimal `json:"volume_24h"`
+	Volume7d      decimal.Decimal `json:"volume_7d"`
+	Volume30d     decimal.Decimal `json:"volume_30d"`
+	Sales24h      int64           `json:"sales_24h"`
+	Sales7d       int64           `json:"sales_7d"`
+	Sales30d      int64           `json:"sales_30d"`
+	AsOf          int64           `json:"as_of"`   // 统计数据的计算时间（秒），地板价来自后台任务缓存时为缓存的计算时间
+	Flagged       bool            `json:"flagged"` // 集合是否被举报隐藏, 不写入缓存
+}
+
+// CollectionCompareItem 集合对比结果中的一个集合
+type CollectionCompareItem struct {
+	CollectionAddress string `json:"collection_address"`
+	Name              string `json:"name"`
+	CollectionStats
+}
+
+// CollectionCompareSkipped 未参与对比的集合及原因
+type CollectionCompareSkipped struct {
+	CollectionAddress string `json:"collection_address"`
+	Reason            string `json:"reason"`
+}
+
+// CollectionCompareResp 集合对比结果, collections 按请求中的地址顺序排列
+type CollectionCompareResp struct {
+	Collections []CollectionCompareItem    `json:"collections"`
+	Skipped     []CollectionCompareSkipped `json:"skipped"`
+}
+
+// CollectionMarketSnapshot 后台任务定期计算并缓存的集合地板价和24小时成交数据
+type CollectionMarketSnapshot struct {
+	FloorPrice decimal.Decimal `json:"floor_price"`
+	Volume24h  decimal.Decimal `json:"volume_24h"`
+	Sales24h   int64           `json:"sales_24h"`
+	UpdatedAt  int64           `json:"updated_at"` // 计算时间(秒)
+}
@@ -0,0 +1,22 @@
+package types
+
+const (
+	HealthStatusOK           = "ok"            // 依赖服务正常
+	HealthStatusUnavailable  = "unavailable"   // 依赖服务不可用
+	HealthStatusShuttingDown = "shutting_down" // 服务正在优雅关闭
+	HealthStatusDegraded     = "degraded"      // 部分区块链初始化失败，其余功能正常
+)
+
+// DependencyStatus 定义了单个依赖服务的检查结果
+type DependencyStatus struct {
+	Name   string `json:"name"`            // 依赖名称，如 db、redis、chain:sepolia
+	Status string `json:"status"`          // 检查结果，ok、unavailable 或 degraded（区块链初始化失败）
+	Error  string `json:"error,omitempty"` // 检查失败时的错误信息
+}
+
+// ReadinessResp 定义了就绪检查的响应数据结构
+type ReadinessResp struct {
+	Status       string             `json:"status"`       // 整体状态，所有依赖正常时为 ok，仅有降级的区块链时为 degraded，开始优雅关闭后为 shutting_down
+	Dependencies []DependencyStatus `json:"dependencies"` // 各依赖服务的检查结果，优雅关闭期间不再检查，为空数组
+	InFlight     int64              `json:"in_flight"`    // 正在处理中的请求数（包含本次就绪检查）
+}
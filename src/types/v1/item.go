@@ -16,15 +16,25 @@ type ItemPriceInfo struct {
 	TokenID           string          `json:"token_id"`           // NFT Token ID
 	Maker             string          `json:"maker"`              // 挂单制作者的地址
 	Price             decimal.Decimal `json:"price"`              // 挂单价格（使用高精度十进制）
-	OrderStatus       int             `json:"order_status"`       // 订单状态（0=有效, 1=已取消, 2=已成交）
+	OrderStatus       int             `json:"order_status"`       // 订单状态，取值见 OrderStatusActive 等常量
 }
 
 // ItemOwner 定义了 NFT 物品的所有权信息
 // 用于记录 NFT 的当前持有者
+// ERC-721 只返回 Owner, ERC-1155 一个 Token 可以有多个持有者, 通过 Owners 返回每个持有者的数量
 type ItemOwner struct {
-	CollectionAddress string `json:"collection_address"` // NFT 合约地址
-	TokenID           string `json:"token_id"`           // NFT Token ID
-	Owner             string `json:"owner"`              // NFT 当前持有者的地址
+	CollectionAddress string             `json:"collection_address"` // NFT 合约地址
+	TokenID           string             `json:"token_id"`           // NFT Token ID
+	TokenStandard     string             `json:"token_standard"`     // 合约实现标准（erc721、erc1155、unknown）
+	Owner             string             `json:"owner,omitempty"`    // NFT 当前持有者的地址（ERC-721）
+	Owners            []ItemOwnerBalance `json:"owners,omitempty"`   // NFT 持有者列表（ERC-1155）
+}
+
+// ItemOwnerBalance 定义了单个持有者信息
+// 单个 ERC-1155 Token 的持有数量从链上读取, 数量为0时也返回 balance
+type ItemOwnerBalance struct {
+	Owner   string `json:"owner"`   // 持有者地址
+	Balance int64  `json:"balance"` // 持有数量
 }
 
 // ItemImage 定义了 NFT 物品的图片信息
@@ -45,6 +55,7 @@ type ItemDetailInfo struct {
 	CollectionName     string `json:"collection_name"`     // NFT 所属集合名称
 	CollectionImageURI string `json:"collection_image_uri"` // 集合头像 URI
 	TokenID            string `json:"token_id"`            // NFT Token ID
+	TokenStandard      string `json:"token_standard"`      // 合约实现标准（erc721、erc1155、unknown）
 	
 	// 媒体信息
 	ImageURI  string `json:"image_uri"`  // NFT 图片 URI
@@ -57,9 +68,14 @@ type ItemDetailInfo struct {
 	
 	// 所有权和市场信息
 	OwnerAddress  string `json:"owner_address"`  // 当前持有者地址
-	MarketplaceID int    `json:"marketplace_id"` // 交易市场 ID
+	MarketplaceID int    `json:"marketplace_id"` // 最低价挂单所在的交易市场 ID（Deprecated: 请使用 best_listing）
+
+	// 各市场的挂单, 按价格升序排列, 没有挂单时为空数组
+	Listings    []ListingInfo `json:"listings"`
+	BestListing *ListingInfo  `json:"best_listing"` // 价格最低的挂单, 没有挂单时为 null
 
 	// 挂单信息（卖单）
+	// Deprecated: 兼容旧版客户端, 取值与 best_listing 一致, 新客户端请使用 listings 和 best_listing
 	ListOrderID    string          `json:"list_order_id"`    // 挂单订单 ID
 	ListTime       int64           `json:"list_time"`        // 挂单时间戳
 	ListPrice      decimal.Decimal `json:"list_price"`       // 挂单价格
@@ -77,6 +93,12 @@ type ItemDetailInfo struct {
 	BidType       int64           `json:"bid_type"`        // 出价类型（0=单个 NFT, 1=集合出价）
 	BidSize       int64           `json:"bid_size"`        // 出价数量
 	BidUnfilled   int64           `json:"bid_unfilled"`    // 未填充的出价数量
+	BidCurrency        string `json:"bid_currency"`                   // 出价币种符号（如 "WETH"）
+	BidCurrencyAddress string `json:"bid_currency_address,omitempty"` // 出价使用的 ERC-20 代币地址，原生代币时为空
+
+	// 数据新鲜度
+	IndexedAt int64 `json:"indexed_at"` // 索引服务最后一次更新该 NFT 记录的时间（秒）
+	AsOf      int64 `json:"as_of"`      // 响应数据的生成时间（秒），缓存的响应为写入缓存的时间
 }
 
 // ItemDetailInfoResp 定义了 NFT 物品详细信息的 API 响应结构
@@ -84,11 +106,37 @@ type ItemDetailInfoResp struct {
 	Result interface{} `json:"result"` // 返回结果，通常是 ItemDetailInfo 或错误信息
 }
 
+// ItemDetailBatchReq 定义了批量查询 NFT 物品详情的请求结构
+type ItemDetailBatchReq struct {
+	TokenIDs []string `json:"token_ids"` // 要查询的 Token ID 列表，最多 50 个
+}
+
+// ItemDetailBatchResp 定义了批量查询 NFT 物品详情的响应结构
+type ItemDetailBatchResp struct {
+	Result map[string]ItemDetailInfo `json:"result"` // Token ID 到物品详情的映射，不存在的 Token ID 不会出现
+}
+
 // ListingInfo 定义了 NFT 的挂单信息
 // 用于表示在特定市场上的挂单价格
 type ListingInfo struct {
 	MarketplaceId int32           `json:"marketplace_id"` // 交易市场 ID
 	Price         decimal.Decimal `json:"price"`          // 挂单价格
+	Currency        string `json:"currency" gorm:"-"`                   // 支付币种符号（如 "ETH"、"WETH"）
+	CurrencyAddress string `json:"currency_address,omitempty"`          // 挂单使用的支付代币地址
+	Count           int64  `json:"count,omitempty" gorm:"-"`            // 按价位聚合时该价位的挂单数量
+	Quantity        int64  `json:"quantity,omitempty" gorm:"-"`         // 按价位聚合时该价位剩余可成交的数量之和
+	Sellers         int64  `json:"sellers,omitempty" gorm:"-"`          // 按价位聚合时该价位不同卖家的数量
+	TotalPrice      *decimal.Decimal `json:"total_price,omitempty" gorm:"-"` // 买家需要支付的总价：挂单价格 + 市场手续费 + 版税，按价位聚合时不返回
+	Fees            *ListingFees     `json:"fees,omitempty" gorm:"-"`        // 总价中的费用明细，按价位聚合时不返回
+}
+
+// ListingFees 定义了购买挂单时在挂单价格之外需要支付的费用明细
+// 费率单位为基点（1 基点 = 0.01%），费用按支付代币的精度取整
+type ListingFees struct {
+	MarketplaceBps int             `json:"marketplace_bps"` // 市场手续费基点
+	MarketplaceFee decimal.Decimal `json:"marketplace_fee"` // 市场手续费
+	RoyaltyBps     int             `json:"royalty_bps"`     // 版税基点，集合单独配置了版税时使用集合版税
+	RoyaltyFee     decimal.Decimal `json:"royalty_fee"`     // 版税
 }
 
 // TraitPrice 定义了 NFT 特征的价格信息
@@ -101,8 +149,18 @@ type TraitPrice struct {
 	Price             decimal.Decimal `json:"price"`              // 具有该特征的 NFT 的价格
 }
 
+// TraitPriceEstimate 定义了 NFT 特征的估值信息
+// 在 TraitPrice 的基础上增加估值依据, Price 为该特征的估值
+type TraitPriceEstimate struct {
+	TraitPrice
+	ListingPrice decimal.Decimal `json:"listing_price"` // 具有该特征的 NFT 当前最低挂单价格，无挂单时为 0
+	SampleSize   int64           `json:"sample_size"`   // 估值所依据的成交笔数
+	IsEstimate   bool            `json:"is_estimate"`   // 成交样本不足时为 true，此时 Price 为推算值
+	Confidence   string          `json:"confidence"`    // 估值置信度: high / medium / low
+}
+
 // ItemTopTraitResp 定义了 NFT 顶级特征信息的 API 响应结构
 // 用于返回最有价值或最稀有的 NFT 特征信息
 type ItemTopTraitResp struct {
-	Result interface{} `json:"result"` // 返回结果，通常是 TraitPrice 数组或错误信息
+	Result interface{} `json:"result"` // 返回结果，通常是 TraitPriceEstimate 数组或错误信息
 }
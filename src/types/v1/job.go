@@ -0,0 +1,15 @@
+package types
+
+// JobInfo 后台任务的状态和进度
+type JobInfo struct {
+	JobID     string `json:"job_id"`
+	Type      string `json:"type"`            // 任务类型, 如 collection_metadata_refresh
+	Status    string `json:"status"`          // pending / running / completed / failed
+	Total     int64  `json:"total"`           // 需要处理的总数
+	Succeeded int64  `json:"succeeded"`       // 处理成功的数量
+	Skipped   int64  `json:"skipped"`         // 跳过的数量, 如元数据刷新命中冷却期
+	Failed    int64  `json:"failed"`          // 处理失败的数量
+	Error     string `json:"error,omitempty"` // 任务失败原因
+	CreatedAt int64  `json:"created_at"`      // 创建时间(Unix 秒)
+	UpdatedAt int64  `json:"updated_at"`      // 最近一次更新进度的时间(Unix 秒)
+}
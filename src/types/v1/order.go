@@ -1,8 +1,142 @@
 package types
 
+import (
+	"github.com/joinmouse/EasySwapBase/stores/gdb/orderbookmodel/multi"
+	"github.com/shopspring/decimal"
+)
+
+// 订单状态, 与订单表 order_status 字段取值一致
+const (
+	OrderStatusActive    = multi.OrderStatusActive    // 有效
+	OrderStatusInactive  = multi.OrderStatusInactive  // 失效, 如挂单人已不再持有Item
+	OrderStatusExpired   = multi.OrderStatusExpired   // 已过期
+	OrderStatusCancelled = multi.OrderStatusCancelled // 已取消
+	OrderStatusFilled    = multi.OrderStatusFilled    // 已完全成交
+	OrderStatusNeedSign  = multi.OrderStatusNeedSign  // 等待签名
+)
+
+// orderStatusLabels 订单状态的可读名称
+var orderStatusLabels = map[int]string{
+	OrderStatusActive:    "Active",
+	OrderStatusInactive:  "Inactive",
+	OrderStatusExpired:   "Expired",
+	OrderStatusCancelled: "Cancelled",
+	OrderStatusFilled:    "Filled",
+	OrderStatusNeedSign:  "Awaiting signature",
+}
+
+// OrderStatusLabel 返回订单状态的可读名称, 未知状态返回 Unknown
+func OrderStatusLabel(status int) string {
+	if label, ok := orderStatusLabels[status]; ok {
+		return label
+	}
+	return "Unknown"
+}
+
+// 订单状态变化事件
+const (
+	OrderEventCreated         = "created"
+	OrderEventPartiallyFilled = "partially_filled"
+	OrderEventFilled          = "filled"
+	OrderEventCancelled       = "cancelled"
+	OrderEventExpired         = "expired"
+)
+
 type OrderInfosParam struct {
 	ChainID           int      `json:"chain_id"`
 	UserAddress       string   `json:"user_address"`
 	CollectionAddress string   `json:"collection_address"`
 	TokenIds          []string `json:"token_ids"`
 }
+
+// OrderDetailsReq 批量查询订单详情的请求参数
+type OrderDetailsReq struct {
+	OrderIDs []string `json:"order_ids"`
+	ChainID  int      `json:"chain_id"` // 可选, 不传时在所有支持的链上查询
+}
+
+// OrderDetail 订单详情
+type OrderDetail struct {
+	ChainID           int             `json:"chain_id"`
+	OrderID           string          `json:"order_id"`
+	OrderType         int64           `json:"order_type"`
+	OrderStatus       int             `json:"order_status"`
+	MarketplaceID     int             `json:"marketplace_id"`
+	CollectionAddress string          `json:"collection_address"`
+	TokenID           string          `json:"token_id"`
+	Maker             string          `json:"maker"`
+	Taker             string          `json:"taker"`
+	Price             decimal.Decimal `json:"price"`
+	Currency          string          `json:"currency"`
+	CurrencyAddress   string          `json:"currency_address,omitempty"`
+	Size              int64           `json:"size"`
+	QuantityRemaining int64           `json:"quantity_remaining"`
+	FilledAmount      int64           `json:"filled_amount"` // 已成交数量
+	Salt              int64           `json:"salt"`
+	EventTime         int64           `json:"event_time"`
+	ExpireTime        int64           `json:"expire_time"` // in seconds
+}
+
+// OrderDetailsResp 批量查询订单详情的返回结果
+// NotFound 为所有链上都不存在的订单ID
+type OrderDetailsResp struct {
+	Orders   []OrderDetail `json:"orders"`
+	NotFound []string      `json:"not_found"`
+}
+
+// 订单校验结果级别
+const (
+	OrderCheckOK      = "ok"      // 校验通过
+	OrderCheckWarning = "warning" // 可以签名, 但需要提示用户
+	OrderCheckError   = "error"   // 订单不合法, 不应签名
+)
+
+// OrderValidateReq 签名前校验订单的请求参数, 字段与待签名的订单一致
+type OrderValidateReq struct {
+	ChainID           int    `json:"chain_id"`
+	OrderType         int64  `json:"order_type"` // 1 listing, 3 collection bid, 4 item bid
+	CollectionAddress string `json:"collection_address"`
+	TokenID           string `json:"token_id"` // 集合出价不需要传
+	Maker             string `json:"maker"`
+	Price             *Price `json:"price"`            // 单价, 必须以字符串形式传入
+	CurrencyAddress   string `json:"currency_address"` // 支付代币地址, 为空或零地址表示原生代币
+	Size              int64  `json:"size"`             // 数量, 不传时为1
+	ExpireTime        int64  `json:"expire_time"`      // in seconds
+}
+
+// OrderCheck 单个字段的校验结果
+type OrderCheck struct {
+	Field   string `json:"field"`   // 请求中的字段名, 如 price、expire_time
+	Level   string `json:"level"`   // ok、warning 或 error
+	Code    string `json:"code"`    // 机器可读的结果标识, 如 expired、price_too_high
+	Message string `json:"message"` // 面向用户的说明
+}
+
+// OrderValidateResp 订单校验结果
+// Status 为所有字段中最严重的级别, 存在 error 时 Valid 为 false
+type OrderValidateResp struct {
+	Valid   bool         `json:"valid"`
+	Status  string       `json:"status"`
+	Results []OrderCheck `json:"results"`
+}
+
+// OrderStatusEvent 订单状态变化时间线中的一个事件
+type OrderStatusEvent struct {
+	Event     string `json:"event"`             // created / partially_filled / filled / cancelled / expired
+	Timestamp int64  `json:"timestamp"`         // 事件发生的时间(Unix 秒)
+	TxHash    string `json:"tx_hash,omitempty"` // 链上交易哈希, 过期等没有对应交易的事件为空
+	Filled    int64  `json:"filled,omitempty"`  // 成交事件发生后的累计成交数量
+}
+
+// OrderStatusResp 订单当前状态及状态变化时间线
+type OrderStatusResp struct {
+	ChainID           int                `json:"chain_id"`
+	OrderID           string             `json:"order_id"`
+	OrderType         int64              `json:"order_type"`
+	Status            int                `json:"status"`       // 当前状态, 已过期但尚未被索引服务更新的有效订单返回已过期
+	StatusLabel       string             `json:"status_label"` // 当前状态的可读名称
+	Size              int64              `json:"size"`
+	QuantityRemaining int64              `json:"quantity_remaining"`
+	FilledAmount      int64              `json:"filled_amount"`
+	Timeline          []OrderStatusEvent `json:"timeline"` // 按时间升序排列
+}
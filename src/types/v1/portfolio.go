@@ -6,6 +6,8 @@ import (
 
 type UserCollectionsParams struct {
 	UserAddresses []string `json:"user_addresses"`
+	SortBy        string   `json:"sort_by"`   // 排序方式: value 持仓估值(默认), count 持有数量, name 集合名称
+	MinItems      int64    `json:"min_items"` // 只返回持有数量不少于该值的集合
 }
 
 type UserCollections struct {
@@ -28,6 +30,8 @@ type CollectionInfo struct {
 	ListAmount int             `json:"list_amount"`
 	ItemAmount int64           `json:"item_amount"`
 	FloorPrice decimal.Decimal `json:"floor_price"`
+	// 按地板价估算的持仓价值, 地板价异常时为0
+	EstimatedValue decimal.Decimal `json:"estimated_value"`
 }
 
 type ChainInfo struct {
@@ -107,8 +111,9 @@ type PortfolioItemInfo struct {
 }
 
 type UserItemsResp struct {
-	Result interface{} `json:"result"`
-	Count  int64       `json:"count"`
+	Result       interface{} `json:"result"`
+	Count        int64       `json:"count"`
+	FailedChains []string    `json:"failed_chains,omitempty"` // 查询失败或超时的链, 这些链上Item只返回基本信息, 出价、Collection、挂单和图片信息缺失
 }
 
 type UserListingsResp struct {
@@ -178,3 +183,38 @@ type MultichainCollection struct {
 	CollectionAddress string `json:"collection_address"`
 	Chain             string `json:"chain"`
 }
+
+// WatchlistItem 用户关注的集合信息
+type WatchlistItem struct {
+	ChainID           int             `json:"chain_id"`
+	CollectionAddress string          `json:"collection_address"`
+	Name              string          `json:"name"`
+	ImageURI          string          `json:"image_uri"`
+	FloorPrice        decimal.Decimal `json:"floor_price"`
+	FloorChange24h    float64         `json:"floor_change_24h"`
+	AddedAt           int64           `json:"added_at"`
+}
+
+// PortfolioValueResp 用户持仓的估算价值
+type PortfolioValueResp struct {
+	ChainID     int                        `json:"chain_id"`
+	Currency    string                     `json:"currency"`     // 估值使用的币种, 为链的原生代币
+	TotalValue  decimal.Decimal            `json:"total_value"`  // 所有有估值的持仓之和
+	ItemCount   int64                      `json:"item_count"`   // 持有的 Item 数量, ERC-1155 按链上持有份数计算
+	PricedCount int64                      `json:"priced_count"` // 有估值的数量, 没有出价和地板价的持仓不计入总价值
+	Collections []PortfolioCollectionValue `json:"collections"`  // 按集合价值降序排列
+	UpdatedAt   int64                      `json:"updated_at"`   // 估值的计算时间(Unix 秒), 结果会短暂缓存
+}
+
+// PortfolioCollectionValue 用户在单个集合中持仓的估算价值
+type PortfolioCollectionValue struct {
+	CollectionAddress string          `json:"collection_address"`
+	Name              string          `json:"name"`
+	ImageURI          string          `json:"image_uri"`
+	TokenStandard     string          `json:"token_standard"`
+	ItemCount         int64           `json:"item_count"`
+	PricedCount       int64           `json:"priced_count"`
+	FloorPrice        decimal.Decimal `json:"floor_price"`
+	BestBid           decimal.Decimal `json:"best_bid"` // 集合出价的最高价, 单个Item的出价更高时该Item按Item出价估值
+	Value             decimal.Decimal `json:"value"`
+}
@@ -0,0 +1,39 @@
+package types
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrPriceNotString 请求中的价格不是 JSON 字符串
+var ErrPriceNotString = errors.New("price must be a JSON string")
+
+// Price 请求中的价格, JSON 中必须以字符串形式传入, 例如 "0.3"
+// 数字形式的价格在客户端往往已经过浮点运算(如 0.1 + 0.2 得到 0.30000000000000004),
+// 为避免精度问题直接拒绝, 而不是按数字解析
+type Price struct {
+	decimal.Decimal
+}
+
+// UnmarshalJSON 只接受字符串形式的价格, null 表示未传入
+func (p *Price) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if len(data) < 2 || data[0] != '"' || data[len(data)-1] != '"' {
+		return ErrPriceNotString
+	}
+
+	return p.Decimal.UnmarshalJSON(data)
+}
+
+// DecimalPtr 返回价格对应的 decimal, p 为 nil 时返回 nil
+func (p *Price) DecimalPtr() *decimal.Decimal {
+	if p == nil {
+		return nil
+	}
+
+	return &p.Decimal
+}
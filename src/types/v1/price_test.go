@@ -0,0 +1,78 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestPriceUnmarshalString(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`"0.3"`, "0.3"},
+		// 0.1 + 0.2 在客户端按浮点计算的结果, 以字符串传入时按原样保留, 不做舍入
+		{`"0.30000000000000004"`, "0.30000000000000004"},
+		{`"0.000000000000000001"`, "0.000000000000000001"},
+		{`"123456789012345678901234567890.123456789"`, "123456789012345678901234567890.123456789"},
+		{`"0"`, "0"},
+		{`"-1.5"`, "-1.5"},
+	}
+
+	for _, tt := range tests {
+		var req struct {
+			Price Price `json:"price"`
+		}
+		if err := json.Unmarshal([]byte(`{"price":`+tt.in+`}`), &req); err != nil {
+			t.Errorf("unmarshal %s: %v", tt.in, err)
+			continue
+		}
+		if got := req.Price.String(); got != tt.want {
+			t.Errorf("unmarshal %s = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPriceUnmarshalRejectsNumber(t *testing.T) {
+	for _, in := range []string{`0.3`, `0.30000000000000004`, `1`, `1e18`, `-0.1`, `true`, `{}`, `[]`} {
+		var req struct {
+			Price Price `json:"price"`
+		}
+		err := json.Unmarshal([]byte(`{"price":`+in+`}`), &req)
+		if !errors.Is(err, ErrPriceNotString) {
+			t.Errorf("unmarshal %s error = %v, want %v", in, err, ErrPriceNotString)
+		}
+	}
+}
+
+func TestPriceUnmarshalInvalidString(t *testing.T) {
+	for _, in := range []string{`""`, `"abc"`, `"0.1+0.2"`, `"1,5"`} {
+		var req struct {
+			Price Price `json:"price"`
+		}
+		if err := json.Unmarshal([]byte(`{"price":`+in+`}`), &req); err == nil {
+			t.Errorf("unmarshal %s: want error, got %s", in, req.Price.String())
+		}
+	}
+}
+
+func TestPriceUnmarshalNull(t *testing.T) {
+	var req struct {
+		Price    *Price `json:"price"`
+		MinPrice *Price `json:"min_price"`
+	}
+	if err := json.Unmarshal([]byte(`{"price":null}`), &req); err != nil {
+		t.Fatalf("unmarshal null: %v", err)
+	}
+	if req.Price.DecimalPtr() != nil || req.MinPrice.DecimalPtr() != nil {
+		t.Error("null or missing price should be nil")
+	}
+
+	if err := json.Unmarshal([]byte(`{"price":"0.3"}`), &req); err != nil {
+		t.Fatalf("unmarshal price: %v", err)
+	}
+	if d := req.Price.DecimalPtr(); d == nil || d.String() != "0.3" {
+		t.Errorf("DecimalPtr() = %v, want 0.3", d)
+	}
+}
@@ -18,12 +18,43 @@ type TraitInfo struct {
 	TraitPercent float64 `json:"trait_percent"`
 }
 
+// TraitValue 集合中一个 Trait值及拥有该值的Item数量
 type TraitValue struct {
 	TraitValue  string `json:"trait_value"`
 	TraitAmount int64  `json:"trait_amount"`
 }
 
+// CollectionTraitInfo 集合的一个 Trait类别及其所有可能的值
 type CollectionTraitInfo struct {
 	Trait  string       `json:"trait"`
-	Values []TraitValue `json:"values"`
+	Values []TraitValue `json:"values"` // 按Item数量降序排列
+}
+
+// TraitRarity 单个 Trait的稀有度信息
+// 在 TraitPrice 的基础上增加该 Trait值在合集中的数量和占比, Price 为该 Trait的最低挂单价格
+type TraitRarity struct {
+	TraitPrice
+	Count      int64   `json:"count"`      // 合集中拥有该 Trait值的Item数量
+	Percentage float64 `json:"percentage"` // 合集中拥有该 Trait值的Item占比(百分比)
+}
+
+// ItemRarityInfo NFT Item的稀有度信息
+type ItemRarityInfo struct {
+	CollectionAddress string        `json:"collection_address"`
+	TokenID           string        `json:"token_id"`
+	Score             float64       `json:"score"`       // 稀有度分数,各 Trait占比倒数之和,越大越稀有
+	Rank              int64         `json:"rank"`        // 在合集中的稀有度排名,从1开始,0表示无法计算
+	TotalItems        int64         `json:"total_items"` // 参与排名的Item数量
+	Traits            []TraitRarity `json:"traits"`
+}
+
+type ItemRarityResp struct {
+	Result interface{} `json:"result"`
+}
+
+// TraitDistribution 合集的 Trait分布,缓存在Redis中
+type TraitDistribution struct {
+	TotalItems int64            `json:"total_items"` // 拥有 Trait的Item数量
+	Counts     map[string]int64 `json:"counts"`      // trait:trait_value -> 数量
+	Scores     []float64        `json:"scores"`      // 所有Item的稀有度分数,降序排列
 }
@@ -36,3 +36,19 @@ type UserLoginMsgResp struct {
 type UserSignStatusResp struct {
 	IsSigned bool `json:"is_signed"` // 用户是否已经完成签名认证
 }
+
+// LoginHistory 定义了一条用户登录记录
+// 登录成功和失败的尝试都会被记录，用于用户自查账户安全
+type LoginHistory struct {
+	ChainID   int    `json:"chain_id"`   // 登录时使用的链 ID
+	Success   bool   `json:"success"`    // 本次登录是否成功
+	Reason    string `json:"reason"`     // 登录失败原因，成功时为空
+	IP        string `json:"ip"`         // 发起登录的客户端 IP
+	UserAgent string `json:"user_agent"` // 发起登录的客户端 User-Agent
+	LoginTime int64  `json:"login_time"` // 登录时间（毫秒时间戳）
+}
+
+// LoginHistoryResp 定义了用户登录记录的响应数据结构
+type LoginHistoryResp struct {
+	Result []LoginHistory `json:"result"` // 按时间倒序排列的登录记录
+}